@@ -0,0 +1,124 @@
+// Package birchtest provides assertions and mock matchers for tests
+// that inspect birch documents.
+//
+// Paths are dot-separated sequences of keys, as in "metrics.ops.2",
+// where numeric components index into arrays; keys that themselves
+// contain dots cannot be addressed. Every failure message includes
+// the full document being inspected, so that the context of a failure
+// is visible without further debugging.
+package birchtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Lookup returns the value at the path in the document, or an error
+// if the path does not exist.
+func Lookup(d *birch.Document, path string) (*birch.Value, error) {
+	return d.RecursiveLookupErr(strings.Split(path, ".")...)
+}
+
+// AssertHasPath reports a test failure, and returns false, if the
+// document does not have a value at the path.
+func AssertHasPath(t testing.TB, d *birch.Document, path string) bool {
+	t.Helper()
+
+	if _, err := Lookup(d, path); err != nil {
+		t.Errorf("document does not have path '%s': %v\ndocument: %s", path, err, d)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotHasPath reports a test failure, and returns false, if the
+// document has a value at the path.
+func AssertNotHasPath(t testing.TB, d *birch.Document, path string) bool {
+	t.Helper()
+
+	if val, err := Lookup(d, path); err == nil {
+		t.Errorf("document has unexpected path '%s' with value %v\ndocument: %s", path, val.Interface(), d)
+		return false
+	}
+
+	return true
+}
+
+// AssertValueType reports a test failure, and returns false, if the
+// document does not have a value of the given type at the path.
+func AssertValueType(t testing.TB, d *birch.Document, path string, expected bsontype.Type) bool {
+	t.Helper()
+
+	val, err := Lookup(d, path)
+	if err != nil {
+		t.Errorf("document does not have path '%s': %v\ndocument: %s", path, err, d)
+		return false
+	}
+
+	if val.Type() != expected {
+		t.Errorf("value at path '%s' has type %s, expected %s\ndocument: %s", path, val.Type(), expected, d)
+		return false
+	}
+
+	return true
+}
+
+// AssertValueEqual reports a test failure, and returns false, if the
+// value at the path in the document is not equal to expected.
+//
+// The expected value may be a *birch.Value or any Go value that
+// birch.MarshalValue can convert. Values are compared with
+// Value.Compare, so numeric values are equal when they represent the
+// same number regardless of their BSON types (e.g. an int in the test
+// matches an int64 in the document), and documents and arrays are
+// compared element by element.
+func AssertValueEqual(t testing.TB, d *birch.Document, path string, expected interface{}) bool {
+	t.Helper()
+
+	val, err := Lookup(d, path)
+	if err != nil {
+		t.Errorf("document does not have path '%s': %v\ndocument: %s", path, err, d)
+		return false
+	}
+
+	want, ok := expected.(*birch.Value)
+	if !ok {
+		want, err = birch.MarshalValue(expected)
+		if err != nil {
+			t.Errorf("cannot convert expected value %v (%T) for path '%s': %v", expected, expected, path, err)
+			return false
+		}
+	}
+
+	if val.Compare(want) != 0 {
+		t.Errorf("value at path '%s' is not equal\nexpected: %v (%s)\n  actual: %v (%s)\ndocument: %s",
+			path, want.Interface(), want.Type(), val.Interface(), val.Type(), d)
+		return false
+	}
+
+	return true
+}
+
+// RequireHasPath is the same as AssertHasPath, but stops the test
+// on failure.
+func RequireHasPath(t testing.TB, d *birch.Document, path string) {
+	t.Helper()
+
+	if !AssertHasPath(t, d, path) {
+		t.FailNow()
+	}
+}
+
+// RequireValueEqual is the same as AssertValueEqual, but stops the
+// test on failure.
+func RequireValueEqual(t testing.TB, d *birch.Document, path string, expected interface{}) {
+	t.Helper()
+
+	if !AssertValueEqual(t, d, path, expected) {
+		t.FailNow()
+	}
+}
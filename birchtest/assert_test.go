@@ -0,0 +1,87 @@
+package birchtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+type mockT struct {
+	testing.TB
+	messages []string
+	stopped  bool
+}
+
+func (m *mockT) Helper()  {}
+func (m *mockT) FailNow() { m.stopped = true }
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.messages = append(m.messages, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	doc := birch.DC.Elements(
+		birch.EC.String("name", "test"),
+		birch.EC.Int64("count", 42),
+		birch.EC.SubDocumentFromElements("meta",
+			birch.EC.Boolean("ok", true),
+			birch.EC.ArrayFromElements("tags", birch.VC.String("a"), birch.VC.String("b")),
+		),
+	)
+
+	t.Run("Passing", func(t *testing.T) {
+		mt := &mockT{TB: t}
+
+		assert.True(t, AssertHasPath(mt, doc, "name"))
+		assert.True(t, AssertHasPath(mt, doc, "meta.tags.1"))
+		assert.True(t, AssertNotHasPath(mt, doc, "meta.missing"))
+		assert.True(t, AssertNotHasPath(mt, doc, "name.nested"))
+		assert.True(t, AssertValueType(mt, doc, "count", bsontype.Int64))
+		assert.True(t, AssertValueEqual(mt, doc, "count", 42))
+		assert.True(t, AssertValueEqual(mt, doc, "count", 42.0))
+		assert.True(t, AssertValueEqual(mt, doc, "meta.ok", true))
+		assert.True(t, AssertValueEqual(mt, doc, "meta.tags", []string{"a", "b"}))
+		assert.True(t, AssertValueEqual(mt, doc, "meta.tags.0", birch.VC.String("a")))
+		RequireHasPath(mt, doc, "meta.ok")
+		RequireValueEqual(mt, doc, "name", "test")
+
+		assert.Empty(t, mt.messages)
+		assert.False(t, mt.stopped)
+	})
+	t.Run("Failing", func(t *testing.T) {
+		for name, check := range map[string]func(testing.TB) bool{
+			"HasPath":      func(mt testing.TB) bool { return AssertHasPath(mt, doc, "meta.missing") },
+			"NotHasPath":   func(mt testing.TB) bool { return AssertNotHasPath(mt, doc, "meta.ok") },
+			"TypeMissing":  func(mt testing.TB) bool { return AssertValueType(mt, doc, "missing", bsontype.Int64) },
+			"Type":         func(mt testing.TB) bool { return AssertValueType(mt, doc, "count", bsontype.Int32) },
+			"EqualMissing": func(mt testing.TB) bool { return AssertValueEqual(mt, doc, "missing", 42) },
+			"Equal":        func(mt testing.TB) bool { return AssertValueEqual(mt, doc, "count", 43) },
+			"EqualType":    func(mt testing.TB) bool { return AssertValueEqual(mt, doc, "name", 42) },
+		} {
+			t.Run(name, func(t *testing.T) {
+				mt := &mockT{TB: t}
+
+				assert.False(t, check(mt))
+				if assert.Len(t, mt.messages, 1) {
+					assert.Contains(t, mt.messages[0], doc.String())
+				}
+			})
+		}
+		t.Run("Unconvertible", func(t *testing.T) {
+			mt := &mockT{TB: t}
+			assert.False(t, AssertValueEqual(mt, doc, "count", make(chan int)))
+			assert.Len(t, mt.messages, 1)
+		})
+		t.Run("Require", func(t *testing.T) {
+			mt := &mockT{TB: t}
+			RequireHasPath(mt, doc, "missing")
+			assert.True(t, mt.stopped)
+
+			mt = &mockT{TB: t}
+			RequireValueEqual(mt, doc, "count", 0)
+			assert.True(t, mt.stopped)
+		})
+	})
+}
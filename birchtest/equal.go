@@ -0,0 +1,200 @@
+package birchtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Matcher matches arguments that are equal to a document, for use
+// with mocks. It implements the Matcher and GotFormatter interfaces
+// of github.com/golang/mock/gomock without depending on gomock, so
+// that a mismatch prints the differences between the documents. With
+// testify's mock package, use mock.MatchedBy(matcher.Matches).
+type Matcher struct {
+	expected *birch.Document
+}
+
+// DocumentMatcher returns a Matcher for documents that are equal to
+// expected.
+func DocumentMatcher(expected *birch.Document) *Matcher {
+	return &Matcher{expected: expected}
+}
+
+// Matches reports whether x is equal to the expected document, as
+// DocumentsEqual reports. x may be a *birch.Document, or a
+// birch.Reader or []byte with the BSON representation of a document.
+func (m *Matcher) Matches(x interface{}) bool {
+	doc, ok := asDocument(x)
+	return ok && DocumentsEqual(m.expected, doc)
+}
+
+// String describes the expected document.
+func (m *Matcher) String() string {
+	return fmt.Sprintf("is equal to document %s", m.expected)
+}
+
+// Got describes an argument that did not match, with its differences
+// from the expected document.
+func (m *Matcher) Got(x interface{}) string {
+	doc, ok := asDocument(x)
+	if !ok {
+		return fmt.Sprintf("%v (%T), which is not a document", x, x)
+	}
+
+	return fmt.Sprintf("document %s\ndifferences:\n%s", doc, Diff(m.expected, doc))
+}
+
+func asDocument(x interface{}) (*birch.Document, bool) {
+	switch val := x.(type) {
+	case *birch.Document:
+		return val, true
+	case birch.Reader:
+		doc, err := birch.ReadDocument(val)
+		return doc, err == nil
+	case []byte:
+		doc, err := birch.ReadDocument(val)
+		return doc, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// DocumentsEqual reports whether the documents have the same elements
+// in the same order, as Document.EqualExcept does without ignored
+// paths. Use it as the comparison function for assertions and mocks
+// that take one.
+func DocumentsEqual(expected, actual *birch.Document) bool {
+	return expected.EqualExcept(actual)
+}
+
+// Diff describes the differences between two documents, with one
+// line for each path that is missing from actual, unexpected in
+// actual, or has a different value, and returns an empty string if
+// the documents are equal. Arrays are compared by position, and the
+// paths of their elements use the index as the key.
+func Diff(expected, actual *birch.Document) string {
+	if expected == nil || actual == nil {
+		if expected == actual {
+			return ""
+		}
+		return fmt.Sprintf("expected: %s\n  actual: %s", expected, actual)
+	}
+
+	var lines []string
+	diffElements(&lines, "", expected.Elements(), actual.Elements(), false)
+	if len(lines) == 0 && !DocumentsEqual(expected, actual) {
+		// only the values of repeated keys differ.
+		lines = append(lines, "documents differ in the values of duplicate keys")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func diffElements(lines *[]string, prefix string, expected, actual []*birch.Element, array bool) {
+	key := func(idx int, elem *birch.Element) string {
+		if array {
+			return prefix + strconv.Itoa(idx)
+		}
+		return prefix + elem.Key()
+	}
+
+	index := make(map[string]*birch.Element, len(actual))
+	for idx, elem := range actual {
+		if _, ok := index[key(idx, elem)]; !ok {
+			index[key(idx, elem)] = elem
+		}
+	}
+
+	var expectedKeys, actualKeys []string
+	seen := make(map[string]bool, len(expected))
+	for idx, elem := range expected {
+		path := key(idx, elem)
+		expectedKeys = append(expectedKeys, path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		match, ok := index[path]
+		if !ok {
+			*lines = append(*lines, fmt.Sprintf("missing '%s': expected %s", path, describe(elem.Value())))
+			continue
+		}
+
+		diffValues(lines, path, elem.Value(), match.Value())
+	}
+
+	for idx, elem := range actual {
+		path := key(idx, elem)
+		actualKeys = append(actualKeys, path)
+		if !seen[path] {
+			*lines = append(*lines, fmt.Sprintf("unexpected '%s': %s", path, describe(elem.Value())))
+		}
+	}
+
+	if !array && len(expectedKeys) == len(actualKeys) && strings.Join(expectedKeys, "\x00") != strings.Join(actualKeys, "\x00") {
+		container := "the document"
+		if prefix != "" {
+			container = "'" + strings.TrimSuffix(prefix, ".") + "'"
+		}
+		*lines = append(*lines, fmt.Sprintf("keys of %s are in a different order: expected [%s], actual [%s]",
+			container, strings.Join(expectedKeys, ", "), strings.Join(actualKeys, ", ")))
+	}
+}
+
+func diffValues(lines *[]string, path string, expected, actual *birch.Value) {
+	switch {
+	case expected.Type() != actual.Type():
+	case expected.Type() == bsontype.EmbeddedDocument:
+		diffElements(lines, path+".", expected.MutableDocument().Elements(), actual.MutableDocument().Elements(), false)
+		return
+	case expected.Type() == bsontype.Array:
+		diffElements(lines, path+".", arrayElements(expected.MutableArray()), arrayElements(actual.MutableArray()), true)
+		return
+	case expected.Equal(actual):
+		return
+	}
+
+	*lines = append(*lines, fmt.Sprintf("'%s': expected %s, actual %s", path, describe(expected), describe(actual)))
+}
+
+func arrayElements(a *birch.Array) []*birch.Element {
+	out := make([]*birch.Element, a.Len())
+	for idx := range out {
+		out[idx] = birch.EC.Value(strconv.Itoa(idx), a.Lookup(uint(idx)))
+	}
+	return out
+}
+
+func describe(v *birch.Value) string {
+	return fmt.Sprintf("%v (%s)", v.Interface(), v.Type())
+}
+
+// AssertDocumentEqual reports a test failure, with the differences
+// between the documents, and returns false, if the documents are not
+// equal as DocumentsEqual reports.
+func AssertDocumentEqual(t testing.TB, expected, actual *birch.Document) bool {
+	t.Helper()
+
+	if !DocumentsEqual(expected, actual) {
+		t.Errorf("documents are not equal\ndifferences:\n%s\nexpected: %s\n  actual: %s", Diff(expected, actual), expected, actual)
+		return false
+	}
+
+	return true
+}
+
+// RequireDocumentEqual is the same as AssertDocumentEqual, but stops
+// the test on failure.
+func RequireDocumentEqual(t testing.TB, expected, actual *birch.Document) {
+	t.Helper()
+
+	if !AssertDocumentEqual(t, expected, actual) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,78 @@
+package birchtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestDocumentEqual(t *testing.T) {
+	makeDoc := func(count int64, tags ...string) *birch.Document {
+		vals := make([]*birch.Value, len(tags))
+		for idx, tag := range tags {
+			vals[idx] = birch.VC.String(tag)
+		}
+		return birch.DC.Elements(
+			birch.EC.String("name", "test"),
+			birch.EC.SubDocumentFromElements("meta",
+				birch.EC.Int64("count", count),
+				birch.EC.ArrayFromElements("tags", vals...),
+			),
+		)
+	}
+	doc := makeDoc(1, "a", "b")
+
+	t.Run("Diff", func(t *testing.T) {
+		assert.Empty(t, Diff(doc, makeDoc(1, "a", "b")))
+		assert.Empty(t, Diff(nil, nil))
+		assert.NotEmpty(t, Diff(doc, nil))
+
+		assert.Equal(t, "'meta.count': expected 1 (64-bit integer), actual 2 (64-bit integer)", Diff(doc, makeDoc(2, "a", "b")))
+		assert.Equal(t, "'meta.tags.1': expected b (string), actual c (string)\nunexpected 'meta.tags.2': d (string)",
+			Diff(doc, makeDoc(1, "a", "c", "d")))
+		assert.Equal(t, "missing 'meta.tags.1': expected b (string)", Diff(doc, makeDoc(1, "a")))
+
+		other := makeDoc(1, "a", "b")
+		other.Set(birch.EC.Int32("name", 1))
+		other.Append(birch.EC.Boolean("extra", true))
+		assert.Equal(t, "'name': expected test (string), actual 1 (32-bit integer)\nunexpected 'extra': true (boolean)", Diff(doc, other))
+
+		reordered := birch.DC.Elements(doc.ElementAt(1), doc.ElementAt(0))
+		assert.Equal(t, "keys of the document are in a different order: expected [name, meta], actual [meta, name]", Diff(doc, reordered))
+	})
+	t.Run("Matcher", func(t *testing.T) {
+		matcher := DocumentMatcher(doc)
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+
+		assert.True(t, matcher.Matches(makeDoc(1, "a", "b")))
+		assert.True(t, matcher.Matches(data))
+		assert.True(t, matcher.Matches(birch.Reader(data)))
+		assert.False(t, matcher.Matches(makeDoc(2, "a", "b")))
+		assert.False(t, matcher.Matches(data[:5]))
+		assert.False(t, matcher.Matches("name"))
+		assert.False(t, matcher.Matches(nil))
+
+		assert.Contains(t, matcher.String(), `"name": "test"`)
+		assert.Contains(t, matcher.Got(makeDoc(2, "a", "b")), "'meta.count': expected 1 (64-bit integer), actual 2 (64-bit integer)")
+		assert.Contains(t, matcher.Got(42), "not a document")
+	})
+	t.Run("Assertions", func(t *testing.T) {
+		mt := &mockT{TB: t}
+		assert.True(t, AssertDocumentEqual(mt, doc, makeDoc(1, "a", "b")))
+		RequireDocumentEqual(mt, doc, makeDoc(1, "a", "b"))
+		assert.Empty(t, mt.messages)
+		assert.False(t, mt.stopped)
+
+		assert.False(t, AssertDocumentEqual(mt, doc, makeDoc(1, "b")))
+		require.Len(t, mt.messages, 1)
+		assert.Contains(t, mt.messages[0], "'meta.tags.0': expected a (string), actual b (string)")
+		assert.False(t, mt.stopped)
+
+		RequireDocumentEqual(mt, doc, nil)
+		assert.Len(t, mt.messages, 2)
+		assert.True(t, mt.stopped)
+	})
+}
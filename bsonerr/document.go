@@ -6,17 +6,17 @@ import "github.com/pkg/errors"
 var NilReader = errors.New("nil reader")
 
 // InvalidReadOnlyDocument indicates that the underlying bytes of a bson.Reader are invalid.
-var InvalidReadOnlyDocument = errors.New("invalid read-only document")
+var InvalidReadOnlyDocument = newKindError(CorruptDocument, "invalid read-only document")
 
 // InvalidKey indicates that the BSON representation of a key is missing a null terminator.
-var InvalidKey = errors.New("invalid document key")
+var InvalidKey = newKindError(CorruptDocument, "invalid document key")
 
 // InvalidArrayKey indicates that a key that isn't a positive integer was used to lookup an
 // element in an array.
 var InvalidArrayKey = errors.New("invalid array key")
 
 // InvalidLength indicates that a length in a binary representation of a BSON document is invalid.
-var InvalidLength = errors.New("document length is invalid")
+var InvalidLength = newKindError(CorruptDocument, "document length is invalid")
 
 // EmptyKey indicates that no key was provided to a Lookup method.
 var EmptyKey = errors.New("empty key provided")
@@ -29,16 +29,24 @@ var NilDocument = errors.New("document is nil")
 
 // InvalidDocumentType indicates that a type which doesn't represent a BSON document was
 // was provided when a document was expected.
-var InvalidDocumentType = errors.New("invalid document type")
+var InvalidDocumentType = newKindError(InvalidType, "invalid document type")
 
 // InvalidDepthTraversal indicates that a provided path of keys to a nested value in a document
 // does not exist.
 //
 // Please fix.
-var InvalidDepthTraversal = errors.New("invalid depth traversal for key path")
+var InvalidDepthTraversal = newKindError(KeyNotFound, "invalid depth traversal for key path")
 
 // ElementNotFound indicates that an Element matching a certain condition does not exist.
-var ElementNotFound = errors.New("element not found")
+var ElementNotFound = newKindError(KeyNotFound, "element not found")
 
 // OutOfBounds indicates that an index provided to access something was invalid.
 var OutOfBounds = errors.New("out of bounds")
+
+// LimitExceeded indicates that a value in a BSON document exceeded a
+// limit set when reading the document.
+var LimitExceeded = errors.New("read limit exceeded")
+
+// DuplicateKey indicates that a document has more than one element
+// with the same key.
+var DuplicateKey = errors.New("duplicate key")
@@ -12,20 +12,20 @@ var UninitializedElement = errors.New("bson/ast/compact: Method call on uninitia
 var InvalidWriter = errors.New("bson: invalid writer provided")
 
 // InvalidString indicates that a BSON string value had an incorrect length.
-var InvalidString = errors.New("invalid string value")
+var InvalidString = newKindError(CorruptDocument, "invalid string value")
 
 // InvalidBinarySubtype indicates that a BSON binary value had an undefined subtype.
-var InvalidBinarySubtype = errors.New("invalid BSON binary Subtype")
+var InvalidBinarySubtype = newKindError(CorruptDocument, "invalid BSON binary Subtype")
 
 // InvalidBooleanType indicates that a BSON boolean value had an incorrect byte.
-var InvalidBooleanType = errors.New("invalid value for BSON Boolean Type")
+var InvalidBooleanType = newKindError(CorruptDocument, "invalid value for BSON Boolean Type")
 
 // StringLargerThanContainer indicates that the code portion of a BSON JavaScript code with scope
 // value is larger than the specified length of the entire value.
-var StringLargerThanContainer = errors.New("string size is larger than the JavaScript code with scope container")
+var StringLargerThanContainer = newKindError(CorruptDocument, "string size is larger than the JavaScript code with scope container")
 
 // InvalidElement indicates that a bson.Element had invalid underlying BSON.
-var InvalidElement = errors.New("invalid Element")
+var InvalidElement = newKindError(CorruptDocument, "invalid Element")
 
 // ElementType specifies that a method to obtain a BSON value an incorrect type was called on a bson.Value.
 type ElementType struct {
@@ -44,3 +44,7 @@ func NewElementTypeError(method string, t bsontype.Type) error {
 func (ete ElementType) Error() string {
 	return "Call of " + ete.Method + " on " + ete.Type.String() + " type"
 }
+
+// Is reports whether the target is InvalidType, so that errors.Is
+// classifies type errors with the other errors of that kind.
+func (ete ElementType) Is(target error) bool { return target == InvalidType }
@@ -0,0 +1,47 @@
+package bsonerr
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The following errors classify the more specific errors in this
+// package, and in the packages that use it, by their cause. Use
+// errors.Is to check whether an error, or an error that wraps it,
+// belongs to one of these kinds; the specific errors still compare
+// equal to themselves.
+var (
+	// CorruptDocument indicates that the binary representation of
+	// a document or value is not valid BSON.
+	CorruptDocument = errors.New("corrupt document")
+	// UnexpectedEOF indicates that BSON data ended before the end
+	// of a document or value. It is io.ErrUnexpectedEOF, which
+	// the methods that read from an io.Reader also return.
+	UnexpectedEOF = io.ErrUnexpectedEOF
+	// InvalidType indicates that a value or document had a
+	// different type than an operation required.
+	InvalidType = errors.New("invalid type")
+	// KeyNotFound indicates that a document does not have an
+	// element with a key, or a path of keys, that was looked up.
+	KeyNotFound = errors.New("key not found")
+)
+
+// TooSmall indicates that a slice of bytes is too small to hold the
+// document or value that it should contain.
+var TooSmall = newKindError(UnexpectedEOF, "error: too small")
+
+// kindError is a specific error that errors.Is also matches against
+// its kind.
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func newKindError(kind error, msg string) error { return &kindError{msg: msg, kind: kind} }
+
+// Error implements the error interface.
+func (e *kindError) Error() string { return e.msg }
+
+// Is reports whether the target is the kind of the error.
+func (e *kindError) Is(target error) bool { return target == e.kind }
@@ -378,7 +378,9 @@ func (ElementConstructor) BinaryWithSubtype(key string, b []byte, btype byte) *E
 	return elem
 }
 
-// Undefined creates a undefined element with the given key.
+// Undefined creates a undefined element with the given key. Undefined
+// is a deprecated BSON type, and is supported for compatibility with
+// legacy data.
 func (ElementConstructor) Undefined(key string) *Element {
 	size := 1 + uint32(len(key)) + 1
 	b := make([]byte, size)
@@ -477,6 +479,8 @@ func (ElementConstructor) Regex(key string, pattern, options string) *Element {
 }
 
 // DBPointer creates a dbpointer element with the given key and value.
+// DBPointer is a deprecated BSON type, and is supported for
+// compatibility with legacy data.
 func (ElementConstructor) DBPointer(key string, ns string, oid types.ObjectID) *Element {
 	size := uint32(1 + len(key) + 1 + 4 + len(ns) + 1 + 12)
 	elem := newElement(0, uint32(1+len(key)+1))
@@ -548,6 +552,12 @@ func (ElementConstructor) CodeWithScope(key string, code string, scope *Document
 	return elem
 }
 
+// JavaScriptWithScope creates a JavaScript code with scope element
+// with the given key and value, and is equivalent to CodeWithScope.
+func (ElementConstructor) JavaScriptWithScope(key string, code string, scope *Document) *Element {
+	return EC.CodeWithScope(key, code, scope)
+}
+
 // Int32 creates a int32 element with the given key and value.
 func (ElementConstructor) Int32(key string, i int32) *Element {
 	size := uint32(1 + len(key) + 1 + 4)
@@ -703,7 +713,8 @@ func (ValueConstructor) BinaryWithSubtype(b []byte, btype byte) *Value {
 	return EC.BinaryWithSubtype("", b, btype).value
 }
 
-// Undefined creates a undefined element.
+// Undefined creates a undefined element. Undefined is a deprecated
+// BSON type.
 func (ValueConstructor) Undefined() *Value {
 	return EC.Undefined("").value
 }
@@ -738,7 +749,8 @@ func (ValueConstructor) Regex(pattern, options string) *Value {
 	return EC.Regex("", pattern, options).value
 }
 
-// DBPointer creates a dbpointer value from the arguments.
+// DBPointer creates a dbpointer value from the arguments. DBPointer is
+// a deprecated BSON type.
 func (ValueConstructor) DBPointer(ns string, oid types.ObjectID) *Value {
 	return EC.DBPointer("", ns, oid).value
 }
@@ -758,6 +770,12 @@ func (ValueConstructor) CodeWithScope(code string, scope *Document) *Value {
 	return EC.CodeWithScope("", code, scope).value
 }
 
+// JavaScriptWithScope creates a JavaScript code with scope value
+// from the arguments, and is equivalent to CodeWithScope.
+func (ValueConstructor) JavaScriptWithScope(code string, scope *Document) *Value {
+	return EC.CodeWithScope("", code, scope).value
+}
+
 // Int32 creates a int32 value from the argument.
 func (ValueConstructor) Int32(i int32) *Value {
 	return EC.Int32("", i).value
@@ -1285,4 +1285,133 @@ func TestDocumentConstructor(t *testing.T) {
 			})
 		}
 	})
+	t.Run("Pairs", func(t *testing.T) {
+		doc, err := DC.Pairs(
+			KV{"z", 1},
+			KV{"a", "two"},
+			KV{"m", []int64{3, 4}},
+			KV{"n", nil},
+			KV{"s", struct {
+				Name string `bson:"name"`
+			}{Name: "five"}},
+			KV{"d", DC.Elements(EC.Boolean("six", true))},
+		)
+		require.NoError(t, err)
+		require.Equal(t, 6, doc.Len())
+
+		keys := []string{}
+		for _, elem := range doc.Elements() {
+			keys = append(keys, elem.Key())
+		}
+		assert.Equal(t, []string{"z", "a", "m", "n", "s", "d"}, keys)
+
+		assert.Equal(t, 1, doc.Lookup("z").Int())
+		assert.Equal(t, "two", doc.Lookup("a").StringValue())
+		assert.Equal(t, int64(4), doc.Lookup("m").MutableArray().Lookup(1).Int64())
+		assert.Equal(t, bsontype.Null, doc.Lookup("n").Type())
+		assert.Equal(t, "five", doc.RecursiveLookup("s", "name").StringValue())
+		assert.True(t, doc.RecursiveLookup("d", "six").Boolean())
+
+		t.Run("Empty", func(t *testing.T) {
+			doc, err := DC.Pairs()
+			require.NoError(t, err)
+			assert.Equal(t, 0, doc.Len())
+		})
+		t.Run("Error", func(t *testing.T) {
+			doc, err := DC.Pairs(KV{"ok", 1}, KV{"bad", make(chan int)})
+			require.Error(t, err)
+			assert.Nil(t, doc)
+			assert.Contains(t, err.Error(), "'bad'")
+		})
+	})
+	t.Run("OrderedMap", func(t *testing.T) {
+		elem, err := EC.OrderedMap("config", []KV{
+			{"z", 1},
+			{"a", "two"},
+			{"nested", []KV{{"y", true}, {"b", []int64{3}}}},
+			{"n", nil},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "config", elem.Key())
+		require.Equal(t, bsontype.EmbeddedDocument, elem.Value().Type())
+
+		doc := elem.Value().MutableDocument()
+		assert.Equal(t, []string{"z", "a", "nested", "n"}, doc.KeyNames())
+		assert.Equal(t, []string{"y", "b"}, doc.Lookup("nested").MutableDocument().KeyNames())
+		assert.Equal(t, 1, doc.Lookup("z").Int())
+		assert.True(t, doc.RecursiveLookup("nested", "y").Boolean())
+		assert.Equal(t, int64(3), doc.RecursiveLookup("nested", "b").MutableArray().Lookup(0).Int64())
+		assert.Equal(t, bsontype.Null, doc.Lookup("n").Type())
+
+		data, err := DC.Elements(elem).MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"z", "a", "nested", "n"}, out.Lookup("config").MutableDocument().KeyNames())
+
+		t.Run("Empty", func(t *testing.T) {
+			elem, err := EC.OrderedMap("empty", nil)
+			require.NoError(t, err)
+			assert.Equal(t, 0, elem.Value().MutableDocument().Len())
+		})
+		t.Run("Error", func(t *testing.T) {
+			elem, err := EC.OrderedMap("config", []KV{{"ok", 1}, {"bad", make(chan int)}})
+			require.Error(t, err)
+			assert.Nil(t, elem)
+			assert.Contains(t, err.Error(), "'config.bad'")
+
+			elem, err = EC.OrderedMap("config", []KV{{"section", []KV{{"bad", make(chan int)}}}})
+			require.Error(t, err)
+			assert.Nil(t, elem)
+			assert.Contains(t, err.Error(), "'config.section.bad'")
+		})
+	})
+}
+
+func TestArrayConstructor(t *testing.T) {
+	t.Run("SubDocuments", func(t *testing.T) {
+		hosts := []*Document{
+			DC.Elements(EC.String("host", "a"), EC.Int64("ops", 10)),
+			DC.Elements(EC.String("host", "b"), EC.SubDocumentFromElements("mem", EC.Int64("resident", 20))),
+		}
+
+		arr := AC.SubDocuments(hosts...)
+		require.Equal(t, 2, arr.Len())
+		assert.Equal(t, "a", arr.Lookup(0).MutableDocument().Lookup("host").StringValue())
+		assert.Equal(t, int64(20), arr.Lookup(1).MutableDocument().RecursiveLookup("mem", "resident").Int64())
+
+		t.Run("DeepCopy", func(t *testing.T) {
+			hosts[0].Set(EC.String("host", "changed"))
+			hosts[0].Append(EC.Boolean("extra", true))
+			hosts[1].Lookup("mem").MutableDocument().Set(EC.Int64("resident", 0))
+
+			assert.Equal(t, "a", arr.Lookup(0).MutableDocument().Lookup("host").StringValue())
+			assert.Equal(t, 2, arr.Lookup(0).MutableDocument().Len())
+			assert.Equal(t, int64(20), arr.Lookup(1).MutableDocument().RecursiveLookup("mem", "resident").Int64())
+		})
+		t.Run("Nil", func(t *testing.T) {
+			arr := AC.SubDocuments(nil, DC.New())
+			require.Equal(t, 2, arr.Len())
+			assert.Equal(t, bsontype.Null, arr.Lookup(0).Type())
+			assert.Equal(t, bsontype.EmbeddedDocument, arr.Lookup(1).Type())
+		})
+		t.Run("Empty", func(t *testing.T) {
+			assert.Equal(t, 0, AC.SubDocuments().Len())
+		})
+	})
+	t.Run("ArrayFromDocuments", func(t *testing.T) {
+		doc := DC.Elements(EC.ArrayFromDocuments("hosts",
+			DC.Elements(EC.String("host", "a")),
+			DC.Elements(EC.String("host", "b")),
+		))
+
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		hosts := parsed.Lookup("hosts").MutableArray()
+		require.Equal(t, 2, hosts.Len())
+		assert.Equal(t, "b", hosts.Lookup(1).MutableDocument().Lookup("host").StringValue())
+	})
 }
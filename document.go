@@ -8,6 +8,7 @@ package birch
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"sort"
@@ -25,6 +26,7 @@ type Document struct {
 	IgnoreNilInsert bool
 	elems           []*Element
 	index           []uint32
+	onChange        func(Op, string, *Value)
 }
 
 // NewDocument creates an empty Document. The numberOfElems parameter will
@@ -158,6 +160,8 @@ func (d *Document) Append(elems ...*Element) *Document {
 		} else {
 			d.index = append(d.index, uint32(len(d.elems)-1))
 		}
+
+		d.changed(OpAppend, elem)
 	}
 
 	return d
@@ -232,15 +236,25 @@ func (d *Document) Prepend(elems ...*Element) *Document {
 		}
 	}
 
+	if d.onChange != nil {
+		for _, elem := range elems {
+			if elem != nil {
+				d.changed(OpInsert, elem)
+			}
+		}
+	}
+
 	return d
 }
 
 // Set replaces an element of a document. If an element with a matching key is
-// found, the element will be replaced with the one provided. If the document
-// does not have an element with that key, the element is appended to the
-// document instead. If a nil element is passed as a parameter this method will
-// panic. To change this behavior to silently ignore a nil element, set
-// IgnoreNilInsert to true on the Document.
+// found, the element will be replaced with the one provided, in the same
+// position. If the document does not have an element with that key, the element
+// is appended to the document instead. When the document has more than one
+// element with the key, Set replaces the first, which is the element that
+// Lookup returns, and leaves the others unchanged. If a nil element is passed
+// as a parameter this method will panic. To change this behavior to silently
+// ignore a nil element, set IgnoreNilInsert to true on the Document.
 //
 // If a nil element is inserted and this method panics, it does not remove the
 // previously added elements.
@@ -257,7 +271,17 @@ func (d *Document) Set(elem *Element) *Document {
 	i := sort.Search(len(d.index), func(i int) bool { return bytes.Compare(d.keyFromIndex(i), []byte(key)) >= 0 })
 
 	if i < len(d.index) && bytes.Equal(d.keyFromIndex(i), []byte(key)) {
-		d.elems[d.index[i]] = elem
+		// the index orders elements with the same key
+		// arbitrarily, so replace the first in the document.
+		first := d.index[i]
+		for j := i + 1; j < len(d.index) && bytes.Equal(d.keyFromIndex(j), []byte(key)); j++ {
+			if d.index[j] < first {
+				first = d.index[j]
+			}
+		}
+
+		d.elems[first] = elem
+		d.changed(OpSet, elem)
 		return d
 	}
 
@@ -272,6 +296,8 @@ func (d *Document) Set(elem *Element) *Document {
 		d.index = append(d.index, position)
 	}
 
+	d.changed(OpAppend, elem)
+
 	return d
 }
 
@@ -408,6 +434,8 @@ func (d *Document) Delete(key ...string) *Element {
 				}
 			}
 
+			d.changed(OpDelete, elem)
+
 			return elem
 		}
 
@@ -476,20 +504,37 @@ func (d *Document) ExtendInterface(in interface{}) *Document {
 }
 
 // Reset clears a document so it can be reused. This method clears references
-// to the underlying pointers to elements so they can be garbage collected.
+// to the underlying pointers to elements so they can be garbage collected,
+// but retains the capacity of the underlying storage, so that appending to
+// the document afterwards does not need to reallocate.
 func (d *Document) Reset() {
 	if d == nil {
 		panic(bsonerr.NilDocument)
 	}
 
+	var removed []*Element
+	if d.onChange != nil {
+		removed = append(removed, d.elems...)
+	}
+
 	for idx := range d.elems {
 		d.elems[idx] = nil
 	}
 
 	d.elems = d.elems[:0]
 	d.index = d.index[:0]
+
+	for _, elem := range removed {
+		d.changed(OpDelete, elem)
+	}
 }
 
+// Clear empties the document while keeping the capacity of its
+// underlying storage for reuse, and is equivalent to Reset. Use Clear
+// (or Reset) when returning a document to a pool, and DC.New or
+// DC.Make when an independent document is needed.
+func (d *Document) Clear() { d.Reset() }
+
 // Validate validates the document and returns its total size.
 func (d *Document) Validate() (uint32, error) {
 	if d == nil {
@@ -511,23 +556,117 @@ func (d *Document) Validate() (uint32, error) {
 	return size, nil
 }
 
-// WriteTo implements the io.WriterTo interface.
+// WriteTo implements the io.WriterTo interface, writing the BSON
+// representation of the document to w and returning the number of
+// bytes written.
+//
+// WriteTo does not marshal the document into an intermediate buffer:
+// elements read from existing BSON are written directly from their
+// underlying bytes, with adjacent elements coalesced into a single
+// write, and only elements constructed or modified in memory are
+// marshaled individually. Wrap w in a bufio.Writer to reduce the
+// number of writes for documents with many such elements.
 //
-// TODO(skriptble): We can optimize this by having creating implementations of
-// writeByteSlice that write directly to an io.Writer instead.
+// If a write fails, WriteTo returns the number of bytes written so far
+// along with the writer's error, or io.ErrShortWrite if the writer
+// reported a short write without an error.
 func (d *Document) WriteTo(w io.Writer) (int64, error) {
 	if d == nil {
 		return 0, bsonerr.NilDocument
 	}
 
-	b, err := d.MarshalBSON()
-	if err != nil {
-		return 0, err
+	// validate each element once, keeping its size so that the
+	// elements do not need to be validated again while writing.
+	sizes := make([]uint32, len(d.elems))
+	var size uint32 = 4 + 1
+
+	for idx, elem := range d.elems {
+		n, err := elem.Validate()
+		if err != nil {
+			return 0, err
+		}
+
+		sizes[idx] = n
+		size += n
+	}
+
+	var (
+		total   int64
+		pending []byte
+		err     error
+	)
+
+	write := func(b []byte) error {
+		n, err := w.Write(b)
+		total += int64(n)
+
+		if err == nil && n < len(b) {
+			err = io.ErrShortWrite
+		}
+
+		return err
+	}
+
+	header := make([]byte, 4)
+	binary.LittleEndian.PutUint32(header, size)
+
+	if err = write(header); err != nil {
+		return total, err
 	}
 
-	n, err := w.Write(b)
+	for idx, elem := range d.elems {
+		n := sizes[idx]
+
+		if elem.value.d != nil {
+			if len(pending) > 0 {
+				if err = write(pending); err != nil {
+					return total, err
+				}
+
+				pending = nil
+			}
 
-	return int64(n), err
+			b := make([]byte, n)
+			if _, err = elem.writeByteSlice(true, 0, n, b); err != nil {
+				return total, err
+			}
+
+			if err = write(b); err != nil {
+				return total, err
+			}
+
+			continue
+		}
+
+		raw := elem.value.data[elem.value.start : elem.value.start+n]
+
+		// extend the pending span when this element directly
+		// follows it in the same underlying buffer.
+		if len(pending) > 0 && cap(pending) > len(pending) && &pending[:len(pending)+1][len(pending)] == &raw[0] {
+			pending = pending[:len(pending)+len(raw)]
+			continue
+		}
+
+		if len(pending) > 0 {
+			if err = write(pending); err != nil {
+				return total, err
+			}
+		}
+
+		pending = raw
+	}
+
+	if len(pending) > 0 {
+		if err = write(pending); err != nil {
+			return total, err
+		}
+	}
+
+	if err = write([]byte{0x00}); err != nil {
+		return total, err
+	}
+
+	return total, nil
 }
 
 // WriteDocument will serialize this document to the provided writer beginning
@@ -624,6 +763,37 @@ func (d *Document) MarshalBSON() ([]byte, error) {
 	return b, nil
 }
 
+// MarshalTo appends the BSON representation of the document to buf,
+// growing it as needed and leaving its existing contents intact. This
+// allows callers that encode many documents to reuse buffers, for
+// instance from a sync.Pool, rather than allocating a slice for each
+// document as MarshalBSON does. If the document is not valid, buf is
+// not modified.
+func (d *Document) MarshalTo(buf *bytes.Buffer) error {
+	if d == nil {
+		return bsonerr.NilDocument
+	}
+
+	size, err := d.Validate()
+	if err != nil {
+		return err
+	}
+
+	// encode the document into the spare capacity that Grow
+	// guarantees, and then extend the buffer over it.
+	buf.Grow(int(size))
+	start := buf.Len()
+	b := buf.Bytes()[:start+int(size)]
+
+	if _, err = d.writeByteSlice(uint(start), size, b); err != nil {
+		return err
+	}
+
+	_, _ = buf.Write(b[start:])
+
+	return nil
+}
+
 // UnmarshalBSON implements the Unmarshaler interface.
 func (d *Document) UnmarshalBSON(b []byte) error {
 	if d == nil {
@@ -648,13 +818,23 @@ func (d *Document) UnmarshalBSON(b []byte) error {
 		} else {
 			d.index = append(d.index, uint32(len(d.elems)-1))
 		}
+		d.changed(OpAppend, elem)
 		return nil
 	})
 
 	return err
 }
 
-// ReadFrom will read one BSON document from the given io.Reader.
+// ReadFrom implements the io.ReaderFrom interface. It reads one BSON
+// document from the given io.Reader, replacing the contents of the
+// document with its elements, and returns the number of bytes read.
+//
+// ReadFrom reads exactly the number of bytes given by the document's
+// length prefix, and never reads past the end of the document. If the
+// reader is exhausted before any bytes are read, ReadFrom returns
+// io.EOF, which indicates a clean end of a stream of documents. If the
+// reader is exhausted part way through a document, ReadFrom returns
+// io.ErrUnexpectedEOF.
 func (d *Document) ReadFrom(r io.Reader) (int64, error) {
 	if d == nil {
 		return 0, bsonerr.NilDocument
@@ -671,15 +851,25 @@ func (d *Document) ReadFrom(r io.Reader) (int64, error) {
 	}
 
 	givenLength := readi32(sizeBuf)
+	if givenLength < 5 {
+		return total, bsonerr.InvalidLength
+	}
+
 	b := make([]byte, givenLength)
 	copy(b[0:4], sizeBuf)
 	n, err = io.ReadFull(r, b[4:])
 	total += int64(n)
 
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
 	if err != nil {
 		return total, err
 	}
 
+	d.Reset()
+
 	return total, d.UnmarshalBSON(b)
 }
 
@@ -12,9 +12,13 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -432,6 +436,12 @@ func TestDocument(t *testing.T) {
 				EC.Int32("d", 5),
 				NewDocument(EC.Int32("b", 1), EC.Int32("a", 2), EC.Int32("d", 5), EC.Int32("c", 4)),
 			},
+			{
+				"update-first-duplicate",
+				NewDocument(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("a", 3), EC.Int32("a", 4)),
+				EC.Int32("a", 5),
+				NewDocument(EC.Int32("a", 5), EC.Int32("b", 2), EC.Int32("a", 3), EC.Int32("a", 4)),
+			},
 		}
 
 		for _, tc := range testCases {
@@ -444,6 +454,17 @@ func TestDocument(t *testing.T) {
 				// }
 			})
 		}
+		t.Run("DuplicatesFromBSON", func(t *testing.T) {
+			data, err := NewDocument(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("a", 3)).MarshalBSON()
+			require.NoError(t, err)
+			doc, err := ReadDocument(data)
+			require.NoError(t, err)
+
+			doc.Set(EC.Int32("a", 5))
+			require.Equal(t, []string{"a", "b", "a"}, doc.KeyNames())
+			require.Equal(t, int32(5), doc.Lookup("a").Int32())
+			require.Equal(t, int32(3), doc.ElementAt(2).Value().Int32())
+		})
 	})
 	t.Run("RecursiveLookup", func(t *testing.T) {
 		t.Run("empty key", func(t *testing.T) {
@@ -617,6 +638,18 @@ func TestDocument(t *testing.T) {
 			t.Errorf("Expected length of index slice to be 0. got %d; want %d", len(d.elems), 0)
 		}
 	})
+	t.Run("Clear", func(t *testing.T) {
+		d := NewDocument(EC.Null("a"), EC.Null("b"), EC.Null("c"))
+		capacity := cap(d.elems)
+		d.Clear()
+		require.Equal(t, 0, d.Len())
+		require.Equal(t, capacity, cap(d.elems))
+
+		d.Append(EC.Null("d"))
+		require.Equal(t, 1, d.Len())
+		require.Equal(t, capacity, cap(d.elems))
+		require.NotNil(t, d.Lookup("d"))
+	})
 	t.Run("WriteTo", func(t *testing.T) {
 		testCases := []struct {
 			name string
@@ -645,6 +678,68 @@ func TestDocument(t *testing.T) {
 				// }
 			})
 		}
+		makeDoc := func() *Document {
+			return NewDocument(
+				EC.String("a", "hello"),
+				EC.Int64("b", 42),
+				EC.SubDocumentFromElements("c", EC.Boolean("d", true)),
+				EC.ArrayFromElements("e", VC.Int32(1), VC.Int32(2)),
+			)
+		}
+		t.Run("MatchesMarshalBSON", func(t *testing.T) {
+			for _, doc := range []*Document{makeDoc(), makeDoc().Copy()} {
+				want, err := doc.MarshalBSON()
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				n, err := doc.WriteTo(&buf)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(want)), n)
+				assert.Equal(t, want, buf.Bytes())
+			}
+		})
+		t.Run("CoalescesReadElements", func(t *testing.T) {
+			want, err := makeDoc().MarshalBSON()
+			require.NoError(t, err)
+			doc, err := ReadDocument(want)
+			require.NoError(t, err)
+
+			out := &countingWriter{}
+			n, err := doc.WriteTo(out)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(want)), n)
+			assert.Equal(t, want, out.Bytes())
+			assert.Equal(t, 3, out.writes)
+
+			doc.Set(EC.Int64("b", 43))
+			out = &countingWriter{}
+			_, err = doc.WriteTo(out)
+			require.NoError(t, err)
+			assert.Equal(t, 5, out.writes)
+
+			rt, err := ReadDocument(out.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, int64(43), rt.Lookup("b").Int64())
+			assert.Equal(t, "hello", rt.Lookup("a").StringValue())
+		})
+		t.Run("PartialWrite", func(t *testing.T) {
+			doc := makeDoc()
+			size, err := doc.Validate()
+			require.NoError(t, err)
+
+			for _, limit := range []int{0, 2, 4, 10, int(size) - 1} {
+				out := &limitWriter{limit: limit, err: errors.New("connection reset")}
+				n, err := doc.WriteTo(out)
+				assert.Equal(t, out.err, err)
+				assert.Equal(t, int64(limit), n)
+				assert.Equal(t, limit, out.Len())
+			}
+
+			out := &limitWriter{limit: 10}
+			n, err := doc.WriteTo(out)
+			assert.Equal(t, io.ErrShortWrite, err)
+			assert.Equal(t, int64(10), n)
+		})
 	})
 	t.Run("WriteDocument", func(t *testing.T) {
 		t.Run("invalid-document", func(t *testing.T) {
@@ -734,6 +829,46 @@ func TestDocument(t *testing.T) {
 
 		}
 	})
+	t.Run("Legacy", func(t *testing.T) {
+		oid := types.ObjectID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C}
+		b := []byte{
+			'\x1F', '\x00', '\x00', '\x00',
+			'\x06', 'u', '\x00',
+			'\x0C', 'p', '\x00', '\x04', '\x00', '\x00', '\x00', 'a', '.', 'b', '\x00',
+			'\x01', '\x02', '\x03', '\x04', '\x05', '\x06', '\x07', '\x08', '\x09', '\x0A', '\x0B', '\x0C',
+			'\x00',
+		}
+
+		d, err := ReadDocument(b)
+		require.NoError(t, err)
+		require.Equal(t, 2, d.Len())
+		assert.True(t, d.Lookup("u").IsUndefined())
+		assert.False(t, d.Lookup("p").IsUndefined())
+
+		ns, id, ok := d.Lookup("p").DBPointerOK()
+		require.True(t, ok)
+		assert.Equal(t, "a.b", ns)
+		assert.Equal(t, oid, id)
+
+		out, err := d.MarshalBSON()
+		require.NoError(t, err)
+		assert.Equal(t, b, out)
+
+		t.Run("ExtendedJSON", func(t *testing.T) {
+			js, err := d.MarshalJSON()
+			require.NoError(t, err)
+
+			rt := DC.New()
+			require.NoError(t, rt.UnmarshalJSON(js))
+			assert.True(t, rt.Lookup("u").IsUndefined())
+			assert.True(t, rt.Lookup("p").Equal(d.Lookup("p")))
+		})
+		t.Run("CanonicalExtendedJSON", func(t *testing.T) {
+			rt := DC.New()
+			require.NoError(t, rt.UnmarshalJSON([]byte(`{"p":{"$dbPointer":{"$ref":"a.b","$id":{"$oid":"0102030405060708090a0b0c"}}}}`)))
+			assert.True(t, rt.Lookup("p").Equal(d.Lookup("p")))
+		})
+	})
 	t.Run("ReadFrom", func(t *testing.T) {
 		t.Run("[]byte-too-small", func(t *testing.T) {
 			var buf bytes.Buffer
@@ -749,10 +884,52 @@ func TestDocument(t *testing.T) {
 				t.Errorf("Unexepected error while writing length: %s", err)
 			}
 			_, err = NewDocument().ReadFrom(&buf)
-			if err != io.EOF {
-				t.Errorf("Expected error not returned. got %s; want %s", err, io.EOF)
+			if err != io.ErrUnexpectedEOF {
+				t.Errorf("Expected error not returned. got %s; want %s", err, io.ErrUnexpectedEOF)
 			}
 		})
+		t.Run("truncated-length", func(t *testing.T) {
+			n, err := NewDocument().ReadFrom(bytes.NewBuffer([]byte{'\x05', '\x00'}))
+			assert.Equal(t, io.ErrUnexpectedEOF, err)
+			assert.Equal(t, int64(2), n)
+		})
+		t.Run("truncated-document", func(t *testing.T) {
+			b, err := NewDocument(EC.String("hello", "world")).MarshalBSON()
+			require.NoError(t, err)
+
+			n, err := NewDocument().ReadFrom(bytes.NewBuffer(b[:len(b)-3]))
+			assert.Equal(t, io.ErrUnexpectedEOF, err)
+			assert.Equal(t, int64(len(b)-3), n)
+		})
+		t.Run("invalid-length", func(t *testing.T) {
+			_, err := NewDocument().ReadFrom(bytes.NewBuffer([]byte{'\xFF', '\xFF', '\xFF', '\xFF', '\x00'}))
+			assert.Equal(t, bsonerr.InvalidLength, err)
+		})
+		t.Run("stream", func(t *testing.T) {
+			var buf bytes.Buffer
+			for i := 0; i < 3; i++ {
+				_, err := NewDocument(EC.Int("idx", i), EC.String("pad", strings.Repeat("x", i))).WriteTo(&buf)
+				require.NoError(t, err)
+			}
+			trailing := []byte{'\x01', '\x00', '\x00', '\x00'}
+			buf.Write(trailing)
+
+			doc := NewDocument()
+			for i := 0; i < 3; i++ {
+				n, err := doc.ReadFrom(&buf)
+				require.NoError(t, err)
+				assert.Equal(t, int64(24+i), n)
+				require.Equal(t, 2, doc.Len())
+				assert.Equal(t, i, doc.Lookup("idx").Int())
+			}
+			assert.Equal(t, trailing, buf.Bytes())
+
+			_, err := doc.ReadFrom(&buf)
+			assert.Equal(t, bsonerr.InvalidLength, err)
+
+			_, err = doc.ReadFrom(&buf)
+			assert.Equal(t, io.EOF, err)
+		})
 		t.Run("invalid-document", func(t *testing.T) {
 			var buf bytes.Buffer
 			_, err := (&buf).Write([]byte{'\x07', '\x00', '\x00', '\x00', '\x01', '\x00', '\x00'})
@@ -987,6 +1164,131 @@ func BenchmarkDocument(b *testing.B) {
 	}
 }
 
+func TestDocumentMarshalTo(t *testing.T) {
+	docs := []*Document{
+		DC.Elements(EC.String("a", "b"), EC.SubDocumentFromElements("c", EC.Int32("d", 1))),
+		DC.New(),
+		DC.Elements(EC.ArrayFromElements("e", VC.Int64(1), VC.String("f"))),
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		buf := bytes.NewBufferString("prefix")
+		expected := []byte("prefix")
+
+		for _, doc := range docs {
+			require.NoError(t, doc.MarshalTo(buf))
+
+			data, err := doc.MarshalBSON()
+			require.NoError(t, err)
+			expected = append(expected, data...)
+		}
+
+		assert.Equal(t, expected, buf.Bytes())
+	})
+	t.Run("Reuse", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		for _, doc := range docs {
+			buf.Reset()
+			require.NoError(t, doc.MarshalTo(buf))
+
+			data, err := doc.MarshalBSON()
+			require.NoError(t, err)
+			assert.Equal(t, data, buf.Bytes())
+		}
+	})
+	t.Run("PartiallyRead", func(t *testing.T) {
+		buf := bytes.NewBufferString("prefix")
+		_, err := buf.ReadByte()
+		require.NoError(t, err)
+
+		require.NoError(t, docs[0].MarshalTo(buf))
+		data, err := docs[0].MarshalBSON()
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte("refix"), data...), buf.Bytes())
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		buf := bytes.NewBufferString("prefix")
+
+		var doc *Document
+		assert.Equal(t, bsonerr.NilDocument, doc.MarshalTo(buf))
+		assert.Error(t, DC.Elements(&Element{&Value{}}).MarshalTo(buf))
+		assert.Equal(t, "prefix", buf.String())
+	})
+}
+
+func BenchmarkDocumentMarshalTo(b *testing.B) {
+	doc := DC.Elements(
+		EC.SubDocumentFromElements("driver",
+			EC.String("name", "mongo-go-driver"),
+			EC.String("version", "1234567"),
+		),
+		EC.ArrayFromElements("values", VC.Int64(1), VC.Int64(2), VC.Int64(3)),
+		EC.String("platform", "go1.9.2"),
+	)
+
+	b.Run("MarshalBSON", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := doc.MarshalBSON(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ReusedBuffer", func(b *testing.B) {
+		b.ReportAllocs()
+
+		buf := &bytes.Buffer{}
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := doc.MarshalTo(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		b.ReportAllocs()
+
+		pool := &sync.Pool{New: func() interface{} { return &bytes.Buffer{} }}
+		for i := 0; i < b.N; i++ {
+			buf := pool.Get().(*bytes.Buffer)
+			buf.Reset()
+			if err := doc.MarshalTo(buf); err != nil {
+				b.Fatal(err)
+			}
+			pool.Put(buf)
+		}
+	})
+}
+
+func BenchmarkDocumentReuse(b *testing.B) {
+	elems := []*Element{
+		EC.String("name", "mongo-go-driver"),
+		EC.String("platform", "go1.9.2"),
+		EC.Int64("count", 42),
+		EC.Boolean("ok", true),
+	}
+
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			doc := DC.New()
+			doc.Append(elems...)
+		}
+	})
+	b.Run("Clear", func(b *testing.B) {
+		b.ReportAllocs()
+
+		doc := DC.Make(len(elems))
+
+		for i := 0; i < b.N; i++ {
+			doc.Clear()
+			doc.Append(elems...)
+		}
+	})
+}
+
 func valueEqual(v1, v2 *Value) bool {
 	if v1 == nil && v2 == nil {
 		return true
@@ -1007,6 +1309,33 @@ func valueEqual(v1, v2 *Value) bool {
 	return true
 }
 
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(b)
+}
+
+// limitWriter accepts at most limit bytes, and then returns err,
+// which may be nil to simulate a short write.
+type limitWriter struct {
+	bytes.Buffer
+	limit int
+	err   error
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if remaining := w.limit - w.Len(); len(b) > remaining {
+		n, _ := w.Buffer.Write(b[:remaining])
+		return n, w.err
+	}
+
+	return w.Buffer.Write(b)
+}
+
 func elementEqual(e1, e2 *Element) bool {
 	if e1 == nil && e2 == nil {
 		return true
@@ -16,6 +16,7 @@ import (
 	"github.com/tychoish/birch/bsontype"
 	"github.com/tychoish/birch/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestElement(t *testing.T) {
@@ -2632,3 +2633,140 @@ func testValidateValue(t *testing.T) {
 		}
 	})
 }
+
+func TestReadElement(t *testing.T) {
+	oid := types.NewObjectID()
+	now := time.Now().Truncate(time.Millisecond)
+	scope := DC.Elements(EC.Int32("x", 1))
+
+	elems := []*Element{
+		EC.Double("double", 3.14),
+		EC.String("string", "hello"),
+		EC.SubDocumentFromElements("document", EC.Int32("a", 1), EC.ArrayFromElements("b", VC.String("c"))),
+		EC.ArrayFromElements("array", VC.Int32(1), VC.DocumentFromElements(EC.Boolean("d", true))),
+		EC.Binary("binary", []byte("data")),
+		EC.BinaryWithSubtype("binaryOld", []byte("data"), 0x02),
+		EC.Undefined("undefined"),
+		EC.ObjectID("objectid", oid),
+		EC.Boolean("boolean", true),
+		EC.Time("datetime", now),
+		EC.Null("null"),
+		EC.Regex("regex", "^a", "i"),
+		EC.DBPointer("dbpointer", "db.coll", oid),
+		EC.JavaScript("javascript", "function() {}"),
+		EC.Symbol("symbol", "sym"),
+		EC.CodeWithScope("codewithscope", "function() {}", scope),
+		EC.Int32("int32", 42),
+		EC.Timestamp("timestamp", 10, 20),
+		EC.Int64("int64", 42),
+		EC.Decimal128("decimal", types.NewDecimal128(1, 2)),
+		EC.MinKey("minkey"),
+		EC.MaxKey("maxkey"),
+		EC.Int32("", 0),
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, elem := range elems {
+			t.Run(elem.Value().Type().String(), func(t *testing.T) {
+				data, err := elem.MarshalBSON()
+				require.NoError(t, err)
+
+				out, n, err := ReadElement(data)
+				require.NoError(t, err)
+				assert.Equal(t, len(data), n)
+				assert.Equal(t, elem.Key(), out.Key())
+				assert.True(t, elem.Equal(out))
+
+				again, err := out.MarshalBSON()
+				require.NoError(t, err)
+				assert.Equal(t, data, again)
+			})
+		}
+	})
+	t.Run("Stream", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		for _, elem := range elems {
+			data, err := elem.MarshalBSON()
+			require.NoError(t, err)
+			buf.Write(data)
+		}
+
+		data := buf.Bytes()
+		for _, elem := range elems {
+			out, n, err := ReadElement(data)
+			require.NoError(t, err)
+			assert.True(t, elem.Equal(out), elem.Key())
+			data = data[n:]
+		}
+		assert.Empty(t, data)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		data, err := EC.SubDocumentFromElements("doc", EC.String("a", "b")).MarshalBSON()
+		require.NoError(t, err)
+
+		for name, invalid := range map[string][]byte{
+			"Empty":          nil,
+			"TypeOnly":       {byte(bsontype.Int32)},
+			"MissingKeyNull": {byte(bsontype.Int32), 'a', 'b'},
+			"UnknownType":    {0x42, 'a', 0x00, 0x01},
+			"Truncated":      data[:len(data)-3],
+			"Int32Short":     {byte(bsontype.Int32), 'a', 0x00, 0x01, 0x00},
+		} {
+			t.Run(name, func(t *testing.T) {
+				elem, n, err := ReadElement(invalid)
+				assert.Error(t, err)
+				assert.Nil(t, elem)
+				assert.Zero(t, n)
+			})
+		}
+
+		// the embedded document is missing its terminator
+		corrupt := append([]byte{}, data...)
+		corrupt[len(corrupt)-1] = 0x01
+		_, _, err = ReadElement(corrupt)
+		assert.Error(t, err)
+	})
+}
+
+func TestElementType(t *testing.T) {
+	t.Run("Constructed", func(t *testing.T) {
+		assert.Equal(t, bsontype.Double, EC.Double("a", 1.5).Type())
+		assert.Equal(t, bsontype.String, EC.String("b", "c").Type())
+		assert.Equal(t, bsontype.EmbeddedDocument, EC.SubDocumentFromElements("d", EC.Int32("e", 1)).Type())
+		assert.Equal(t, bsontype.Array, EC.ArrayFromElements("f", VC.Int64(1)).Type())
+		assert.Equal(t, bsontype.Null, EC.Null("g").Type())
+	})
+	t.Run("Reader", func(t *testing.T) {
+		doc := DC.Elements(
+			EC.Int32("a", 1),
+			EC.Boolean("b", true),
+			EC.SubDocumentFromElements("c", EC.String("d", "e")),
+			EC.Time("f", time.Now()),
+		)
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+
+		iter, err := Reader(data).Iterator()
+		require.NoError(t, err)
+
+		types := []bsontype.Type{}
+		for iter.Next() {
+			types = append(types, iter.Element().Type())
+		}
+		require.NoError(t, iter.Err())
+		assert.Equal(t, []bsontype.Type{bsontype.Int32, bsontype.Boolean, bsontype.EmbeddedDocument, bsontype.DateTime}, types)
+	})
+	t.Run("ReadElement", func(t *testing.T) {
+		data, err := EC.Timestamp("ts", 1, 2).MarshalBSON()
+		require.NoError(t, err)
+
+		elem, _, err := ReadElement(data)
+		require.NoError(t, err)
+		assert.Equal(t, bsontype.Timestamp, elem.Type())
+	})
+	t.Run("Uninitialized", func(t *testing.T) {
+		var nilElem *Element
+		assert.PanicsWithValue(t, bsonerr.UninitializedElement, func() { nilElem.Type() })
+		assert.PanicsWithValue(t, bsonerr.UninitializedElement, func() { (&Element{}).Type() })
+	})
+}
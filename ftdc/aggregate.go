@@ -0,0 +1,242 @@
+package ftdc
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Reducer identifies how GroupAggregate combines the values of a field
+// within a group.
+type Reducer int
+
+const (
+	// ReduceSum adds the values.
+	ReduceSum Reducer = iota
+	// ReduceMin keeps the smallest value.
+	ReduceMin
+	// ReduceMax keeps the largest value.
+	ReduceMax
+	// ReduceMean averages the values.
+	ReduceMean
+	// ReduceLast keeps the value from the last document.
+	ReduceLast
+)
+
+func (r Reducer) String() string {
+	switch r {
+	case ReduceSum:
+		return "sum"
+	case ReduceMin:
+		return "min"
+	case ReduceMax:
+		return "max"
+	case ReduceMean:
+		return "mean"
+	case ReduceLast:
+		return "last"
+	default:
+		return fmt.Sprintf("Reducer(%d)", int(r))
+	}
+}
+
+// GroupAggregate reads documents from the channel until it is closed,
+// groups them by the value at groupKey, and combines the values of
+// the fields in agg within each group with the field's reducer, for
+// example to roll up per-host samples into per-datacenter summaries.
+// Memory use is proportional to the number of groups, not to the
+// number of documents. The group key and the fields are dot-separated
+// paths, which may address fields in nested documents but do not
+// descend into arrays.
+//
+// The result maps the value at groupKey, which is the string for
+// string values and the extended JSON form for other values, to a
+// document with an element for each field that appears in the
+// group's documents, in order of the field paths, keyed by the path.
+// Fields must be int32, int64, or double values. Sums, minimums, and
+// maximums are int64 values when all of the values are integers, and
+// doubles otherwise; means are doubles; and ReduceLast keeps the value
+// of the last document, with its type.
+//
+// GroupAggregate returns an error for documents that are nil or lack
+// the group key, for field values that are not numeric, and for
+// unknown reducers. After an error it continues to read, and discard,
+// the remaining documents, so that the sender is not blocked.
+func GroupAggregate(in <-chan *birch.Document, groupKey string, agg map[string]Reducer) (map[string]*birch.Document, error) {
+	var err error
+
+	fields := make([]string, 0, len(agg))
+	for field, reducer := range agg {
+		if reducer < ReduceSum || reducer > ReduceLast {
+			err = errors.Errorf("unknown reducer %s for '%s'", reducer, field)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	groups := map[string][]fieldAggregate{}
+	count := 0
+	for doc := range in {
+		count++
+		if err != nil {
+			continue
+		}
+
+		if doc == nil {
+			err = errors.Errorf("document %d is nil", count)
+			continue
+		}
+
+		group := lookupPath(doc, groupKey)
+		if group == nil {
+			err = errors.Errorf("document %d has no '%s'", count, groupKey)
+			continue
+		}
+
+		name, gerr := groupName(group)
+		if gerr != nil {
+			err = errors.Wrapf(gerr, "document %d", count)
+			continue
+		}
+
+		aggregates, ok := groups[name]
+		if !ok {
+			aggregates = make([]fieldAggregate, len(fields))
+			groups[name] = aggregates
+		}
+
+		for idx, field := range fields {
+			value := lookupPath(doc, field)
+			if value == nil {
+				continue
+			}
+
+			if err = aggregates[idx].add(value); err != nil {
+				err = errors.Wrapf(err, "document %d field '%s'", count, field)
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*birch.Document, len(groups))
+	for name, aggregates := range groups {
+		doc := birch.DC.Make(len(fields))
+		for idx, field := range fields {
+			if aggregates[idx].count > 0 {
+				doc.Append(birch.EC.Value(field, aggregates[idx].result(agg[field])))
+			}
+		}
+		out[name] = doc
+	}
+
+	return out, nil
+}
+
+func lookupPath(doc *birch.Document, path string) *birch.Value {
+	return doc.RecursiveLookup(strings.Split(path, ".")...)
+}
+
+func groupName(v *birch.Value) (string, error) {
+	if str, ok := v.StringValueOK(); ok {
+		return str, nil
+	}
+
+	out, err := v.MarshalJSON()
+	if err != nil {
+		return "", errors.Wrap(err, "problem converting group key")
+	}
+
+	return string(out), nil
+}
+
+// fieldAggregate holds the state of every reducer for one field of
+// one group, which is small enough that tracking all of them is
+// simpler than tracking only the one in use.
+type fieldAggregate struct {
+	count    int
+	floats   bool
+	intSum   int64
+	intMin   int64
+	intMax   int64
+	floatSum float64
+	floatMin float64
+	floatMax float64
+	last     *birch.Value
+}
+
+func (a *fieldAggregate) add(v *birch.Value) error {
+	var (
+		i       int64
+		f       float64
+		integer = true
+	)
+
+	switch v.Type() {
+	case bsontype.Int32:
+		i = int64(v.Int32())
+		f = float64(i)
+	case bsontype.Int64:
+		i = v.Int64()
+		f = float64(i)
+	case bsontype.Double:
+		f = v.Double()
+		integer = false
+	default:
+		return errors.Errorf("value of type %s is not numeric", v.Type())
+	}
+
+	if a.count == 0 {
+		a.intMin, a.intMax = i, i
+		a.floatMin, a.floatMax = f, f
+	}
+
+	a.count++
+	a.floats = a.floats || !integer
+	a.last = v.Clone()
+
+	a.intSum += i
+	a.floatSum += f
+	if i < a.intMin {
+		a.intMin = i
+	}
+	if i > a.intMax {
+		a.intMax = i
+	}
+	a.floatMin = math.Min(a.floatMin, f)
+	a.floatMax = math.Max(a.floatMax, f)
+
+	return nil
+}
+
+func (a *fieldAggregate) result(r Reducer) *birch.Value {
+	switch r {
+	case ReduceSum:
+		if a.floats {
+			return birch.VC.Double(a.floatSum)
+		}
+		return birch.VC.Int64(a.intSum)
+	case ReduceMin:
+		if a.floats {
+			return birch.VC.Double(a.floatMin)
+		}
+		return birch.VC.Int64(a.intMin)
+	case ReduceMax:
+		if a.floats {
+			return birch.VC.Double(a.floatMax)
+		}
+		return birch.VC.Int64(a.intMax)
+	case ReduceMean:
+		return birch.VC.Double(a.floatSum / float64(a.count))
+	default:
+		return a.last
+	}
+}
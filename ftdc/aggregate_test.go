@@ -0,0 +1,116 @@
+package ftdc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestGroupAggregate(t *testing.T) {
+	stream := func(docs ...*birch.Document) <-chan *birch.Document {
+		out := make(chan *birch.Document)
+		go func() {
+			defer close(out)
+			for _, doc := range docs {
+				out <- doc
+			}
+		}()
+		return out
+	}
+
+	sample := func(dc, host string, ops int64, latency float64, resident int32) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.String("host", host),
+			birch.EC.SubDocumentFromElements("location", birch.EC.String("dc", dc)),
+			birch.EC.Int64("ops", ops),
+			birch.EC.Double("latency", latency),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Int32("resident", resident)),
+		)
+	}
+
+	docs := []*birch.Document{
+		sample("east", "a", 10, 1.5, 100),
+		sample("west", "b", 5, 4.0, 50),
+		sample("east", "c", 30, 0.5, 300),
+		sample("west", "d", 7, 2.0, 70),
+		sample("east", "e", 20, 1.0, 200),
+	}
+
+	t.Run("MultipleGroups", func(t *testing.T) {
+		out, err := GroupAggregate(stream(docs...), "location.dc", map[string]Reducer{
+			"ops":          ReduceSum,
+			"latency":      ReduceMean,
+			"mem.resident": ReduceMax,
+		})
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+
+		east := out["east"]
+		require.NotNil(t, east)
+		assert.Equal(t, []string{"latency", "mem.resident", "ops"}, east.KeyNames())
+		assert.Equal(t, int64(60), east.Lookup("ops").Int64())
+		assert.Equal(t, 1.0, east.Lookup("latency").Double())
+		assert.Equal(t, int64(300), east.Lookup("mem.resident").Int64())
+
+		west := out["west"]
+		require.NotNil(t, west)
+		assert.Equal(t, int64(12), west.Lookup("ops").Int64())
+		assert.Equal(t, 3.0, west.Lookup("latency").Double())
+		assert.Equal(t, int64(70), west.Lookup("mem.resident").Int64())
+	})
+	t.Run("Reducers", func(t *testing.T) {
+		for _, test := range []struct {
+			reducer  Reducer
+			field    string
+			expected interface{}
+		}{
+			{reducer: ReduceSum, field: "ops", expected: int64(60)},
+			{reducer: ReduceMin, field: "ops", expected: int64(10)},
+			{reducer: ReduceMax, field: "ops", expected: int64(30)},
+			{reducer: ReduceMean, field: "ops", expected: 20.0},
+			{reducer: ReduceLast, field: "ops", expected: int64(20)},
+			{reducer: ReduceSum, field: "latency", expected: 3.0},
+			{reducer: ReduceMin, field: "latency", expected: 0.5},
+			{reducer: ReduceMax, field: "latency", expected: 1.5},
+			{reducer: ReduceLast, field: "mem.resident", expected: int32(200)},
+		} {
+			t.Run(test.reducer.String()+"/"+test.field, func(t *testing.T) {
+				out, err := GroupAggregate(stream(docs...), "location.dc", map[string]Reducer{test.field: test.reducer})
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, out["east"].Lookup(test.field).Interface())
+			})
+		}
+	})
+	t.Run("MixedTypes", func(t *testing.T) {
+		out, err := GroupAggregate(stream(
+			birch.DC.Elements(birch.EC.Int32("g", 1), birch.EC.Int32("v", 2)),
+			birch.DC.Elements(birch.EC.Int32("g", 1), birch.EC.Double("v", 0.5)),
+			birch.DC.Elements(birch.EC.Int32("g", 1)),
+			birch.DC.Elements(birch.EC.Int32("g", 2)),
+		), "g", map[string]Reducer{"v": ReduceSum})
+		require.NoError(t, err)
+		assert.Equal(t, 2.5, out["1"].Lookup("v").Double())
+		assert.Equal(t, 0, out["2"].Len(), "fields missing from a group are omitted")
+	})
+	t.Run("Errors", func(t *testing.T) {
+		for name, test := range map[string]struct {
+			docs []*birch.Document
+			agg  map[string]Reducer
+		}{
+			"NilDocument":    {docs: []*birch.Document{docs[0], nil, docs[1]}},
+			"MissingGroup":   {docs: []*birch.Document{docs[0], birch.DC.Elements(birch.EC.Int64("ops", 1)), docs[1]}},
+			"NonNumeric":     {docs: docs, agg: map[string]Reducer{"host": ReduceSum}},
+			"UnknownReducer": {docs: docs, agg: map[string]Reducer{"ops": Reducer(42)}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// the whole stream is read after an error, so
+				// this does not block.
+				out, err := GroupAggregate(stream(test.docs...), "location.dc", test.agg)
+				assert.Error(t, err)
+				assert.Nil(t, out)
+			})
+		}
+	})
+}
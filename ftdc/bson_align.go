@@ -0,0 +1,54 @@
+package ftdc
+
+import "github.com/tychoish/birch"
+
+// AlignSamples extracts the metrics from a sequence of sample
+// documents that may not share the same structure, and returns the
+// union of the metric paths along with one series for each path.
+//
+// The paths are ordered by first appearance: all paths from the first
+// sample in document order, followed by any paths that first appear
+// in the second sample, and so on. Paths are fully qualified and dot
+// separated, as reported by Metric.Key. The returned series have the
+// same order as the paths, and each series has one value per sample;
+// where a sample does not include a metric the series holds the fill
+// value.
+//
+// Values use the FTDC encoding of metrics: booleans are 0 or 1,
+// floating point values are stored as their IEEE 754 bit patterns,
+// date times as milliseconds since the epoch, and timestamps as two
+// metrics. Values that FTDC does not record as metrics (e.g. strings)
+// are not included. Nil samples contribute only fill values.
+func AlignSamples(samples []*birch.Document, fill int64) ([]string, [][]int64) {
+	keys := []string{}
+	positions := map[string]int{}
+	series := [][]int64{}
+
+	for idx, sample := range samples {
+		if sample == nil {
+			continue
+		}
+
+		for _, metric := range metricForDocument([]string{}, sample) {
+			key := metric.Key()
+
+			pos, ok := positions[key]
+			if !ok {
+				pos = len(keys)
+				positions[key] = pos
+				keys = append(keys, key)
+
+				values := make([]int64, len(samples))
+				for i := range values {
+					values[i] = fill
+				}
+
+				series = append(series, values)
+			}
+
+			series[pos][idx] = metric.startingValue
+		}
+	}
+
+	return keys, series
+}
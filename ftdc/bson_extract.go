@@ -117,10 +117,15 @@ func extractMetricsFromValue(val *birch.Value) (extractedMetrics, error) {
 	return metrics, err
 }
 
+// extractDelta returns the value to store for a sample of a metric.
+// Doubles are stored as the bit patterns of each sample, rather than
+// as the difference from the previous sample, which floating point
+// subtraction cannot represent exactly. Readers restore them with
+// undeltaFloats.
 func extractDelta(current *birch.Value, previous *birch.Value) (int64, error) {
 	switch current.Type() {
 	case bsontype.Double:
-		return normalizeFloat(current.Double() - previous.Double()), nil
+		return normalizeFloat(current.Double()), nil
 	case bsontype.Int64:
 		return current.Int64() - previous.Int64(), nil
 	default:
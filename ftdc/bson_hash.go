@@ -1,16 +1,25 @@
 package ftdc
 
 import (
+	"encoding/binary"
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"sort"
+	"strconv"
 
 	"github.com/tychoish/birch"
 	"github.com/tychoish/birch/bsontype"
 )
 
+// metricKeyHash returns a hash of the paths and types of the values
+// in the document that the collectors extract as metrics, and the
+// number of metrics. Values that are not metrics, such as strings and
+// nulls, do not affect the hash, so they do not split chunks, but a
+// metric that changes type does, because the collectors cannot store
+// samples of different types in one chunk.
 func metricKeyHash(doc *birch.Document) (string, int) {
-	checksum := fnv.New64()
+	checksum := fnv.New64a()
 	seen := metricKeyHashDocument(checksum, "", doc)
 	return fmt.Sprintf("%x", checksum.Sum(nil)), seen
 }
@@ -20,49 +29,124 @@ func metricKeyHashDocument(checksum hash.Hash, key string, doc *birch.Document)
 	seen := 0
 	for iter.Next() {
 		elem := iter.Element()
-		seen += metricKeyHashValue(checksum, fmt.Sprintf("%s.%s", key, elem.Key()), elem.Value())
+		seen += metricKeyHashValue(checksum, key+"."+elem.Key(), elem.Value())
 	}
 
 	return seen
 }
 
 func metricKeyHashArray(checksum hash.Hash, key string, array *birch.Array) int {
-	seen := 0
 	iter := array.Iterator()
-	idx := 0
-	for iter.Next() {
-		seen += metricKeyHashValue(checksum, fmt.Sprintf("%s.%d", key, idx), iter.Value())
-		idx++
+	seen := 0
+	for idx := 0; iter.Next(); idx++ {
+		seen += metricKeyHashValue(checksum, key+"."+strconv.Itoa(idx), iter.Value())
 	}
 
 	return seen
 }
 
 func metricKeyHashValue(checksum hash.Hash, key string, value *birch.Value) int {
+	seen := 0
+
 	switch value.Type() {
 	case bsontype.Array:
 		return metricKeyHashArray(checksum, key, value.MutableArray())
 	case bsontype.EmbeddedDocument:
 		return metricKeyHashDocument(checksum, key, value.MutableDocument())
-	case bsontype.Boolean:
-		_, _ = checksum.Write([]byte(key))
-		return 1
-	case bsontype.Double:
-		_, _ = checksum.Write([]byte(key))
-		return 1
-	case bsontype.Int32:
-		_, _ = checksum.Write([]byte(key))
-		return 1
-	case bsontype.Int64:
-		_, _ = checksum.Write([]byte(key))
-		return 1
-	case bsontype.DateTime:
-		_, _ = checksum.Write([]byte(key))
-		return 1
+	case bsontype.Boolean, bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.DateTime:
+		seen = 1
 	case bsontype.Timestamp:
-		_, _ = checksum.Write([]byte(key))
-		return 2
+		seen = 2
 	default:
 		return 0
 	}
+
+	_, _ = checksum.Write([]byte(key))
+	_, _ = checksum.Write([]byte{0, byte(value.Type())})
+
+	return seen
+}
+
+// SchemaSignatureOptions controls how SchemaSignature treats the
+// structure of a document.
+type SchemaSignatureOptions struct {
+	// IgnoreOrder produces the same signature for documents that
+	// have the same keys and types in a different order. FTDC chunks
+	// store metrics by position, so the chunk writer always treats
+	// reordered documents as having a different schema.
+	IgnoreOrder bool
+}
+
+// SchemaSignature returns a stable hash of the structure of a
+// document: the path and type of every value, including values in
+// nested documents and arrays, but not the values themselves. Two
+// documents with the same keys, in the same order, with values of the
+// same types have the same signature.
+//
+// The signature is useful for grouping heterogeneous documents by
+// shape. The collectors and the ChunkWriter detect schema changes
+// with a narrower hash that only covers the values they store as
+// metrics.
+func SchemaSignature(doc *birch.Document) uint64 {
+	return SchemaSignatureWithOptions(doc, SchemaSignatureOptions{})
+}
+
+// SchemaSignatureWithOptions is the same as SchemaSignature, but
+// allows callers to control how the structure is compared.
+func SchemaSignatureWithOptions(doc *birch.Document, opts SchemaSignatureOptions) uint64 {
+	return opts.document(doc)
+}
+
+func (opts SchemaSignatureOptions) document(doc *birch.Document) uint64 {
+	sigs := make([]uint64, 0, doc.Len())
+
+	iter := doc.Iterator()
+	for iter.Next() {
+		elem := iter.Element()
+		sigs = append(sigs, opts.value(elem.Key(), elem.Value()))
+	}
+
+	if opts.IgnoreOrder {
+		sort.Slice(sigs, func(i, j int) bool { return sigs[i] < sigs[j] })
+	}
+
+	return combineSignatures(bsontype.EmbeddedDocument, sigs)
+}
+
+func (opts SchemaSignatureOptions) value(key string, value *birch.Value) uint64 {
+	checksum := fnv.New64a()
+	_, _ = checksum.Write([]byte(key))
+	_, _ = checksum.Write([]byte{0, byte(value.Type())})
+
+	switch value.Type() {
+	case bsontype.EmbeddedDocument:
+		writeSignature(checksum, opts.document(value.MutableDocument()))
+	case bsontype.Array:
+		sigs := []uint64{}
+		iter := value.MutableArray().Iterator()
+		for iter.Next() {
+			sigs = append(sigs, opts.value("", iter.Value()))
+		}
+
+		writeSignature(checksum, combineSignatures(bsontype.Array, sigs))
+	}
+
+	return checksum.Sum64()
+}
+
+func combineSignatures(t bsontype.Type, sigs []uint64) uint64 {
+	checksum := fnv.New64a()
+	_, _ = checksum.Write([]byte{byte(t)})
+
+	for _, sig := range sigs {
+		writeSignature(checksum, sig)
+	}
+
+	return checksum.Sum64()
+}
+
+func writeSignature(checksum hash.Hash64, sig uint64) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, sig)
+	_, _ = checksum.Write(buf)
 }
@@ -1,8 +1,12 @@
 package ftdc
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"hash/fnv"
+	"io"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -1092,3 +1096,145 @@ func TestIsOneChecker(t *testing.T) {
 	assert.True(t, isNum(1, birch.VC.Int64(1)))
 	assert.True(t, isNum(1, birch.VC.Double(1.0)))
 }
+
+func TestSchemaSignature(t *testing.T) {
+	doc := birch.DC.Elements(
+		birch.EC.Int64("a", 1),
+		birch.EC.String("b", "one"),
+		birch.EC.SubDocumentFromElements("c",
+			birch.EC.Double("d", 1.5),
+			birch.EC.ArrayFromElements("e", birch.VC.Int32(1), birch.VC.Boolean(true)),
+		),
+	)
+	sig := SchemaSignature(doc)
+
+	t.Run("Stable", func(t *testing.T) {
+		assert.Equal(t, sig, SchemaSignature(doc))
+		assert.Equal(t, sig, SchemaSignature(doc.Copy()))
+	})
+	t.Run("DifferentValues", func(t *testing.T) {
+		other := birch.DC.Elements(
+			birch.EC.Int64("a", 42),
+			birch.EC.String("b", "two"),
+			birch.EC.SubDocumentFromElements("c",
+				birch.EC.Double("d", -100),
+				birch.EC.ArrayFromElements("e", birch.VC.Int32(7), birch.VC.Boolean(false)),
+			),
+		)
+		assert.Equal(t, sig, SchemaSignature(other))
+	})
+	t.Run("DifferentStructure", func(t *testing.T) {
+		for name, other := range map[string]*birch.Document{
+			"Type":        birch.DC.Elements(birch.EC.Int32("a", 1), birch.EC.String("b", "one"), doc.LookupElement("c")),
+			"Key":         birch.DC.Elements(birch.EC.Int64("z", 1), birch.EC.String("b", "one"), doc.LookupElement("c")),
+			"Missing":     birch.DC.Elements(birch.EC.Int64("a", 1), doc.LookupElement("c")),
+			"Extra":       birch.DC.Elements(birch.EC.Int64("a", 1), birch.EC.String("b", "one"), doc.LookupElement("c"), birch.EC.Null("f")),
+			"NestedType":  birch.DC.Elements(birch.EC.Int64("a", 1), birch.EC.String("b", "one"), birch.EC.SubDocumentFromElements("c", birch.EC.Int64("d", 1), birch.EC.ArrayFromElements("e", birch.VC.Int32(1), birch.VC.Boolean(true)))),
+			"ArrayLength": birch.DC.Elements(birch.EC.Int64("a", 1), birch.EC.String("b", "one"), birch.EC.SubDocumentFromElements("c", birch.EC.Double("d", 1.5), birch.EC.ArrayFromElements("e", birch.VC.Int32(1)))),
+			"Flattened":   birch.DC.Elements(birch.EC.Int64("a", 1), birch.EC.String("b", "one"), birch.EC.Double("c.d", 1.5)),
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.NotEqual(t, sig, SchemaSignature(other))
+				assert.NotEqual(t, SchemaSignatureWithOptions(doc, SchemaSignatureOptions{IgnoreOrder: true}),
+					SchemaSignatureWithOptions(other, SchemaSignatureOptions{IgnoreOrder: true}))
+			})
+		}
+	})
+	t.Run("Reordered", func(t *testing.T) {
+		reordered := birch.DC.Elements(
+			birch.EC.SubDocumentFromElements("c",
+				birch.EC.ArrayFromElements("e", birch.VC.Int32(1), birch.VC.Boolean(true)),
+				birch.EC.Double("d", 1.5),
+			),
+			birch.EC.String("b", "one"),
+			birch.EC.Int64("a", 1),
+		)
+
+		assert.NotEqual(t, sig, SchemaSignature(reordered))
+		assert.NotEqual(t, sig, SchemaSignatureWithOptions(reordered, SchemaSignatureOptions{}))
+
+		unordered := SchemaSignatureOptions{IgnoreOrder: true}
+		assert.Equal(t, SchemaSignatureWithOptions(doc, unordered), SchemaSignatureWithOptions(reordered, unordered))
+
+		t.Run("ArrayOrderIsSignificant", func(t *testing.T) {
+			one := birch.DC.Elements(birch.EC.ArrayFromElements("e", birch.VC.Int32(1), birch.VC.Boolean(true)))
+			two := birch.DC.Elements(birch.EC.ArrayFromElements("e", birch.VC.Boolean(true), birch.VC.Int32(1)))
+			assert.NotEqual(t, SchemaSignatureWithOptions(one, unordered), SchemaSignatureWithOptions(two, unordered))
+		})
+	})
+}
+
+func TestAlignSamples(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		keys, series := AlignSamples(nil, 0)
+		assert.Len(t, keys, 0)
+		assert.Len(t, series, 0)
+	})
+	t.Run("Uniform", func(t *testing.T) {
+		samples := []*birch.Document{}
+		for i := int64(0); i < 3; i++ {
+			samples = append(samples, birch.DC.Elements(
+				birch.EC.Int64("a", i),
+				birch.EC.String("name", "ignored"),
+				birch.EC.SubDocumentFromElements("b",
+					birch.EC.Int32("c", int32(i*2)),
+					birch.EC.SubDocumentFromElements("d", birch.EC.Boolean("e", i%2 == 0)),
+				),
+			))
+		}
+
+		keys, series := AlignSamples(samples, -1)
+		assert.Equal(t, []string{"a", "b.c", "b.d.e"}, keys)
+		assert.Equal(t, [][]int64{{0, 1, 2}, {0, 2, 4}, {1, 0, 1}}, series)
+	})
+	t.Run("Divergent", func(t *testing.T) {
+		samples := []*birch.Document{
+			birch.DC.Elements(birch.EC.Int64("a", 1), birch.EC.Int64("b", 2)),
+			birch.DC.Elements(birch.EC.Int64("b", 3), birch.EC.Int64("c", 4)),
+			nil,
+			birch.DC.Elements(birch.EC.Double("a", 1.5), birch.EC.Time("ts", time.Unix(1, 0))),
+		}
+
+		keys, series := AlignSamples(samples, -1)
+		assert.Equal(t, []string{"a", "b", "c", "ts"}, keys)
+		require.Len(t, series, 4)
+		assert.Equal(t, []int64{1, -1, -1, normalizeFloat(1.5)}, series[0])
+		assert.Equal(t, []int64{2, 3, -1, -1}, series[1])
+		assert.Equal(t, []int64{-1, 4, -1, -1}, series[2])
+		assert.Equal(t, []int64{-1, -1, -1, 1000}, series[3])
+	})
+}
+
+func TestDoublePrecision(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// doubles are stored as their bit patterns rather than
+	// converted to integers, so fractional values are not lost.
+	values := []float64{0.25, 1.0001, -3.5, 1e-9, 12345.678901, math.MaxFloat64, math.SmallestNonzeroFloat64, 0}
+
+	collector := NewBaseCollector(len(values))
+	for _, val := range values {
+		require.NoError(t, collector.Add(birch.DC.Elements(birch.EC.Double("latency", val))))
+	}
+	data, err := collector.Resolve()
+	require.NoError(t, err)
+
+	for name, read := range map[string]func(context.Context, io.Reader) Iterator{
+		"Flattened":  ReadMetrics,
+		"Structured": ReadStructuredMetrics,
+	} {
+		t.Run(name, func(t *testing.T) {
+			iter := read(ctx, bytes.NewReader(data))
+			var out []float64
+			for iter.Next() {
+				elem := iter.Document().LookupElement("latency")
+				require.NotNil(t, elem)
+				require.Equal(t, bsontype.Double, elem.Value().Type())
+				out = append(out, elem.Value().Double())
+			}
+			require.NoError(t, iter.Err())
+			assert.Equal(t, values, out)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package ftdc
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/pkg/errors"
+)
+
+// hllPrecision is the number of hash bits that select a register in
+// the HyperLogLog sketch that Cardinality uses once a metric has more
+// distinct values than it counts exactly. 2^14 one-byte registers
+// keep the sketch at 16KB with a standard error of about 0.8%.
+const hllPrecision = 14
+
+// Cardinality reads all chunks from the iterator and counts the
+// distinct values of one metric, for example to decide whether a
+// metric is constant, takes a few values, or takes many, in a single
+// pass over the data. The key is the key of the metric as Chunk.Keys
+// returns it.
+//
+// The count is exact while the metric has at most maxExact distinct
+// values. Beyond that Cardinality discards the values that it has
+// seen and estimates the count with a HyperLogLog sketch of fixed
+// size, so that memory use stays bounded, and the boolean return
+// value is true. Values are compared in their encoded form, so
+// floating point values compare by their bits.
+//
+// Cardinality returns an error if maxExact is negative, if no chunk
+// has the metric, or if the iterator encounters an error.
+func Cardinality(iter *ChunkIterator, key string, maxExact int) (int, bool, error) {
+	if maxExact < 0 {
+		return 0, false, errors.Errorf("maximum exact count must not be negative, not %d", maxExact)
+	}
+
+	exact := map[int64]struct{}{}
+	var sketch *hyperLogLog
+	found := false
+	for iter.Next() {
+		chunk := iter.Chunk()
+		keys := chunk.Keys()
+		for idx := range chunk.Metrics {
+			if keys[idx] != key {
+				continue
+			}
+
+			found = true
+			for _, v := range chunk.Metrics[idx].Values {
+				if sketch != nil {
+					sketch.add(v)
+					continue
+				}
+
+				exact[v] = struct{}{}
+				if len(exact) > maxExact {
+					sketch = &hyperLogLog{}
+					for seen := range exact {
+						sketch.add(seen)
+					}
+					exact = nil
+				}
+			}
+			break
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return 0, false, errors.Wrap(err, "problem reading chunks")
+	}
+
+	if !found {
+		return 0, false, errors.Errorf("metric '%s' not found", key)
+	}
+
+	if sketch != nil {
+		return sketch.estimate(), true, nil
+	}
+
+	return len(exact), false, nil
+}
+
+// hyperLogLog records, for each register, the longest run of leading
+// zeros plus one in the hashes that the register selects.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hyperLogLog) add(v int64) {
+	hash := mix64(uint64(v))
+	idx := hash >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hyperLogLog) estimate() int {
+	const m = float64(1 << hllPrecision)
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	est := (0.7213 / (1 + 1.079/m)) * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		// linear counting is more accurate for small counts.
+		est = m * math.Log(m/float64(zeros))
+	}
+
+	return int(math.Round(est))
+}
+
+// mix64 is the splitmix64 finalizer, which spreads sequential metric
+// values, such as counters, across the whole hash space.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
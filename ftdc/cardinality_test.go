@@ -0,0 +1,66 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestCardinality(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const samples = 20000
+
+	data := produceMockChunks(samples, 1000, func(idx int) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Int64("count", int64(idx)),
+			birch.EC.Int32("constant", 42),
+			birch.EC.SubDocumentFromElements("op", birch.EC.Double("state", float64(idx%5))),
+		)
+	})
+
+	cardinality := func(key string, maxExact int) (int, bool, error) {
+		return Cardinality(ReadChunks(ctx, bytes.NewReader(data)), key, maxExact)
+	}
+
+	t.Run("Constant", func(t *testing.T) {
+		n, estimated, err := cardinality("constant", 10)
+		require.NoError(t, err)
+		assert.False(t, estimated)
+		assert.Equal(t, 1, n)
+	})
+	t.Run("LowCardinality", func(t *testing.T) {
+		n, estimated, err := cardinality("op.state", 5)
+		require.NoError(t, err)
+		assert.False(t, estimated)
+		assert.Equal(t, 5, n)
+	})
+	t.Run("SwitchesToEstimate", func(t *testing.T) {
+		n, estimated, err := cardinality("op.state", 4)
+		require.NoError(t, err)
+		assert.True(t, estimated)
+		assert.Equal(t, 5, n)
+	})
+	t.Run("HighCardinality", func(t *testing.T) {
+		n, estimated, err := cardinality("count", 100)
+		require.NoError(t, err)
+		assert.True(t, estimated)
+		assert.InEpsilon(t, samples, n, 0.03)
+
+		n, estimated, err = cardinality("count", samples)
+		require.NoError(t, err)
+		assert.False(t, estimated)
+		assert.Equal(t, samples, n)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, _, err := cardinality("count", -1)
+		assert.Error(t, err)
+		_, _, err = cardinality("missing", 10)
+		assert.Error(t, err)
+	})
+}
@@ -3,6 +3,8 @@ package ftdc
 import (
 	"context"
 	"testing"
+
+	"github.com/tychoish/birch"
 )
 
 func BenchmarkCollectorInterface(b *testing.B) {
@@ -39,3 +41,49 @@ func BenchmarkCollectorInterface(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkEncodingCompression(b *testing.B) {
+	// monotonic counters: one with a steady rate, and two whose
+	// rates grow steadily, for which every delta is different but
+	// the deltas of deltas are constant.
+	docs := make([]*birch.Document, 300)
+	for i := range docs {
+		n := int64(i)
+		docs[i] = birch.DC.Elements(
+			birch.EC.Int64("uptime", n*1000),
+			birch.EC.Int64("ops", n*(n+1)/2*1500),
+			birch.EC.Int64("bytes", n*n*(1<<20)),
+		)
+	}
+
+	for _, scheme := range []EncodingScheme{EncodingDelta, EncodingDeltaOfDelta, EncodingPerMetric} {
+		b.Run(scheme.String(), func(b *testing.B) {
+			var size int
+			for n := 0; n < b.N; n++ {
+				collector, err := NewBaseCollectorWithOptions(len(docs), BaseCollectorOptions{Encoding: scheme})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, doc := range docs {
+					if err = collector.Add(doc); err != nil {
+						b.Fatal(err)
+					}
+				}
+				data, err := collector.Resolve()
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+
+			raw := 0
+			for _, doc := range docs {
+				n, _ := doc.Validate()
+				raw += int(n)
+			}
+
+			b.ReportMetric(float64(size), "bytes/chunk")
+			b.ReportMetric(float64(raw)/float64(size), "ratio")
+		})
+	}
+}
@@ -16,6 +16,7 @@ type betterCollector struct {
 	deltas     []int64
 	numSamples int
 	maxDeltas  int
+	encoding   EncodingScheme
 }
 
 // NewBasicCollector provides a basic FTDC data collector that mirrors
@@ -28,6 +29,31 @@ func NewBaseCollector(maxSize int) Collector {
 	}
 }
 
+// BaseCollectorOptions configures the collector returned by
+// NewBaseCollectorWithOptions.
+type BaseCollectorOptions struct {
+	// Encoding selects the encoding of the samples in each chunk.
+	// Chunks with an encoding other than the default EncodingDelta
+	// record it in the chunk document, and readers in this package
+	// detect it, but other FTDC readers cannot read them. With
+	// EncodingPerMetric, the collector selects the encoding of each
+	// metric in each chunk.
+	Encoding EncodingScheme
+}
+
+// NewBaseCollectorWithOptions is the same as NewBaseCollector, but
+// encodes chunks as described by the options.
+func NewBaseCollectorWithOptions(maxSize int, opts BaseCollectorOptions) (Collector, error) {
+	if err := opts.Encoding.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &betterCollector{
+		maxDeltas: maxSize,
+		encoding:  opts.Encoding,
+	}, nil
+}
+
 func (c *betterCollector) SetMetadata(in interface{}) error {
 	doc, err := readDocument(in)
 	if err != nil {
@@ -136,10 +162,15 @@ func (c *betterCollector) Resolve() ([]byte, error) {
 		}
 	}
 
-	_, err = birch.NewDocument(
+	chunk := birch.NewDocument(
 		birch.EC.Time("_id", c.startedAt),
 		birch.EC.Int32("type", 1),
-		birch.EC.Binary("data", data)).WriteTo(buf)
+		birch.EC.Binary("data", data))
+	if c.encoding != EncodingDelta {
+		chunk.Append(birch.EC.Int32(chunkEncodingKey, int32(c.encoding)))
+	}
+
+	_, err = chunk.WriteTo(buf)
 	if err != nil {
 		return nil, errors.Wrap(err, "problem writing metric chunk document")
 	}
@@ -155,28 +186,37 @@ func (c *betterCollector) getPayload() ([]byte, error) {
 
 	payload.Write(encodeSizeValue(uint32(len(c.lastSample.values))))
 	payload.Write(encodeSizeValue(uint32(c.numSamples)))
-	zeroCount := int64(0)
-	for i := 0; i < len(c.lastSample.values); i++ {
-		for j := 0; j < c.numSamples; j++ {
-			delta := c.deltas[getOffset(c.maxDeltas, j, i)]
-
-			if delta == 0 {
-				zeroCount++
-				continue
-			}
 
-			if zeroCount > 0 {
-				payload.Write(encodeValue(0))
-				payload.Write(encodeValue(zeroCount - 1))
-				zeroCount = 0
+	if c.encoding == EncodingPerMetric {
+		c.writeMetricSeries(payload)
+	} else {
+		zeroCount := int64(0)
+		series := make([]int64, c.numSamples)
+		for i := 0; i < len(c.lastSample.values); i++ {
+			for j := range series {
+				series[j] = c.deltas[getOffset(c.maxDeltas, j, i)]
 			}
+			c.encoding.encode(series)
 
-			payload.Write(encodeValue(delta))
+			for _, delta := range series {
+				if delta == 0 {
+					zeroCount++
+					continue
+				}
+
+				if zeroCount > 0 {
+					payload.Write(encodeValue(0))
+					payload.Write(encodeValue(zeroCount - 1))
+					zeroCount = 0
+				}
+
+				payload.Write(encodeValue(delta))
+			}
+		}
+		if zeroCount > 0 {
+			payload.Write(encodeValue(0))
+			payload.Write(encodeValue(zeroCount - 1))
 		}
-	}
-	if zeroCount > 0 {
-		payload.Write(encodeValue(0))
-		payload.Write(encodeValue(zeroCount - 1))
 	}
 
 	data, err := compressBuffer(payload.Bytes())
@@ -186,3 +226,24 @@ func (c *betterCollector) getPayload() ([]byte, error) {
 
 	return data, nil
 }
+
+// writeMetricSeries writes the samples of a chunk encoded with
+// EncodingPerMetric: a header with the scheme of each metric, one byte
+// per metric, followed by the encoded samples of each metric.
+func (c *betterCollector) writeMetricSeries(payload *bytes.Buffer) {
+	series := make([][]int64, len(c.lastSample.values))
+	schemes := make([]byte, len(series))
+
+	for i := range series {
+		series[i] = make([]int64, c.numSamples)
+		for j := range series[i] {
+			series[i][j] = c.deltas[getOffset(c.maxDeltas, j, i)]
+		}
+		schemes[i] = byte(chooseSeriesEncoding(series[i]))
+	}
+
+	payload.Write(schemes)
+	for i := range series {
+		EncodingScheme(schemes[i]).writeSeries(payload, series[i])
+	}
+}
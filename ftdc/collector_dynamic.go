@@ -43,6 +43,7 @@ func (c *dynamicCollector) Info() CollectorInfo {
 func (c *dynamicCollector) Reset() {
 	c.chunks = []*batchCollector{newBatchCollector(c.maxSamples)}
 	c.hash = ""
+	c.currentNum = 0
 }
 
 func (c *dynamicCollector) SetMetadata(in interface{}) error {
@@ -64,13 +65,15 @@ func (c *dynamicCollector) Add(in interface{}) error {
 
 	lastChunk := c.chunks[len(c.chunks)-1]
 
-	docHash, _ := metricKeyHash(doc)
-	if c.hash == docHash {
+	docHash, num := metricKeyHash(doc)
+	if c.hash == docHash && c.currentNum == num {
 		return errors.WithStack(lastChunk.Add(doc))
 	}
 
 	chunk := newBatchCollector(c.maxSamples)
 	c.chunks = append(c.chunks, chunk)
+	c.hash = docHash
+	c.currentNum = num
 
 	return errors.WithStack(chunk.Add(doc))
 }
@@ -20,15 +20,16 @@ type streamingCollector struct {
 // when the collector as collected the "maxSamples" number of
 // samples during the Add operation.
 func NewStreamingCollector(maxSamples int, writer io.Writer) Collector {
-	return newStreamingCollector(maxSamples, writer)
+	return newStreamingCollector(maxSamples, writer, EncodingDelta)
 }
 
-func newStreamingCollector(maxSamples int, writer io.Writer) *streamingCollector {
+func newStreamingCollector(maxSamples int, writer io.Writer, encoding EncodingScheme) *streamingCollector {
 	return &streamingCollector{
 		maxSamples: maxSamples,
 		output:     writer,
 		Collector: &betterCollector{
 			maxDeltas: maxSamples,
+			encoding:  encoding,
 		},
 	}
 }
@@ -86,16 +87,23 @@ type streamingDynamicCollector struct {
 // NewStreamingDynamicCollector has the same semantics as the dynamic
 // collector but wraps the streaming collector rather than the batch
 // collector. Chunks are flushed during the Add() operation when the
-// schema changes or the chunk is full.
+// schema changes or the chunk is full. The schema changes when the
+// keys of a sample, or the types of its values, differ from those of
+// the previous sample, as determined by SchemaSignature. Metadata set
+// on the collector is written with every chunk.
 func NewStreamingDynamicCollector(max int, writer io.Writer) Collector {
+	return newStreamingDynamicCollector(max, writer, EncodingDelta)
+}
+
+func newStreamingDynamicCollector(max int, writer io.Writer, encoding EncodingScheme) *streamingDynamicCollector {
 	return &streamingDynamicCollector{
 		output:             writer,
-		streamingCollector: newStreamingCollector(max, writer),
+		streamingCollector: newStreamingCollector(max, writer, encoding),
 	}
 }
 
 func (c *streamingDynamicCollector) Reset() {
-	c.streamingCollector = newStreamingCollector(c.streamingCollector.maxSamples, c.output)
+	c.streamingCollector.Reset()
 	c.metricCount = 0
 	c.hash = ""
 }
@@ -107,21 +115,15 @@ func (c *streamingDynamicCollector) Add(in interface{}) error {
 	}
 
 	docHash, num := metricKeyHash(doc)
-	if c.hash == "" {
-		c.hash = docHash
-		c.metricCount = num
+	if c.hash != docHash || c.metricCount != num {
 		if c.streamingCollector.count > 0 {
 			if err := FlushCollector(c, c.output); err != nil {
 				return errors.WithStack(err)
 			}
 		}
-		return errors.WithStack(c.streamingCollector.Add(doc))
-	}
 
-	if c.metricCount != num || c.hash != docHash {
-		if err := FlushCollector(c, c.output); err != nil {
-			return errors.WithStack(err)
-		}
+		c.hash = docHash
+		c.metricCount = num
 	}
 
 	return errors.WithStack(c.streamingCollector.Add(doc))
@@ -311,6 +311,36 @@ func TestStreamingEncoding(t *testing.T) {
 	}
 }
 
+func TestStreamingDynamicCollectorChunks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	collector := NewStreamingDynamicCollector(100, buf)
+	require.NoError(t, collector.SetMetadata(birch.NewDocument(birch.EC.String("host", "localhost"))))
+
+	for i := int64(0); i < 3; i++ {
+		require.NoError(t, collector.Add(birch.NewDocument(birch.EC.Int64("one", i))))
+	}
+	for i := int64(0); i < 2; i++ {
+		require.NoError(t, collector.Add(birch.NewDocument(birch.EC.Int64("one", i), birch.EC.Int64("two", i))))
+	}
+	for i := int64(0); i < 4; i++ {
+		require.NoError(t, collector.Add(birch.NewDocument(birch.EC.Double("one", float64(i)), birch.EC.Int64("two", i))))
+	}
+	require.NoError(t, FlushCollector(collector, buf))
+
+	var sizes []int
+	chunks := ReadChunks(ctx, bytes.NewBuffer(buf.Bytes()))
+	for chunks.Next() {
+		chunk := chunks.Chunk()
+		sizes = append(sizes, chunk.Size())
+		assert.Equal(t, "localhost", chunk.GetMetadata().RecursiveLookup("doc", "host").StringValue())
+	}
+	require.NoError(t, chunks.Err())
+	assert.Equal(t, []int{3, 2, 4}, sizes)
+}
+
 func TestFixedEncoding(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -329,7 +359,7 @@ func TestFixedEncoding(t *testing.T) {
 		},
 		{
 			name:    "Streaming",
-			factory: func() Collector { return newStreamingCollector(20, &bytes.Buffer{}) },
+			factory: func() Collector { return newStreamingCollector(20, &bytes.Buffer{}, EncodingDelta) },
 		},
 	} {
 		t.Run(impl.name, func(t *testing.T) {
@@ -405,6 +435,97 @@ func TestFixedEncoding(t *testing.T) {
 					assert.Error(t, collector.Add(birch.NewDocument(birch.EC.Int64("one", 43))))
 				}
 			})
+			t.Run("TypeMismatch", func(t *testing.T) {
+				collector := impl.factory()
+				assert.NoError(t, collector.Add(birch.NewDocument(birch.EC.Int64("one", 43))))
+				assert.NoError(t, collector.Add(birch.NewDocument(birch.EC.Int64("one", 44))))
+
+				if strings.Contains(impl.name, "Dynamic") {
+					assert.NoError(t, collector.Add(birch.NewDocument(birch.EC.Double("one", 44.5))))
+					assert.Equal(t, 3, collector.Info().SampleCount)
+				} else {
+					assert.Error(t, collector.Add(birch.NewDocument(birch.EC.Double("one", 44.5))))
+				}
+			})
+		})
+	}
+}
+
+func TestDynamicCollectorSchemaChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, impl := range []struct {
+		name    string
+		resolve func(t *testing.T, docs []*birch.Document) []byte
+	}{
+		{
+			name: "Dynamic",
+			resolve: func(t *testing.T, docs []*birch.Document) []byte {
+				collector := NewDynamicCollector(100)
+				for _, doc := range docs {
+					require.NoError(t, collector.Add(doc))
+				}
+				out, err := collector.Resolve()
+				require.NoError(t, err)
+				return out
+			},
+		},
+		{
+			name: "StreamingDynamic",
+			resolve: func(t *testing.T, docs []*birch.Document) []byte {
+				buf := &bytes.Buffer{}
+				collector := NewStreamingDynamicCollector(100, buf)
+				for _, doc := range docs {
+					require.NoError(t, collector.Add(doc))
+				}
+				require.NoError(t, FlushCollector(collector, buf))
+				return buf.Bytes()
+			},
+		},
+	} {
+		t.Run(impl.name, func(t *testing.T) {
+			countChunks := func(t *testing.T, data []byte) int {
+				iter := ReadChunks(ctx, bytes.NewReader(data))
+				defer iter.Close()
+				count := 0
+				for iter.Next() {
+					count++
+				}
+				require.NoError(t, iter.Err())
+				return count
+			}
+
+			t.Run("TypeChangesBack", func(t *testing.T) {
+				docs := []*birch.Document{
+					birch.NewDocument(birch.EC.Int32("ops", 1)),
+					birch.NewDocument(birch.EC.Int64("ops", 2)),
+					birch.NewDocument(birch.EC.Int32("ops", 3)),
+					birch.NewDocument(birch.EC.Int32("ops", 4)),
+				}
+				data := impl.resolve(t, docs)
+				assert.Equal(t, 3, countChunks(t, data))
+
+				iter := ReadMetrics(ctx, bytes.NewReader(data))
+				var out []interface{}
+				for iter.Next() {
+					out = append(out, iter.Document().Lookup("ops").Interface())
+				}
+				require.NoError(t, iter.Err())
+				assert.Equal(t, []interface{}{int32(1), int64(2), int32(3), int32(4)}, out)
+			})
+			t.Run("NonMetricChanges", func(t *testing.T) {
+				docs := make([]*birch.Document, 10)
+				for i := range docs {
+					label := birch.EC.Null("label")
+					if i%2 == 0 {
+						label = birch.EC.String("label", "primary")
+					}
+					docs[i] = birch.NewDocument(birch.EC.Int64("ops", int64(i)), label)
+				}
+
+				assert.Equal(t, 1, countChunks(t, impl.resolve(t, docs)))
+			})
 		})
 	}
 }
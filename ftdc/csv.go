@@ -14,14 +14,6 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (c *Chunk) getFieldNames() []string {
-	fieldNames := make([]string, len(c.Metrics))
-	for idx, m := range c.Metrics {
-		fieldNames[idx] = m.Key()
-	}
-	return fieldNames
-}
-
 func (c *Chunk) getRecord(i int) []string {
 	fields := make([]string, len(c.Metrics))
 	for idx, m := range c.Metrics {
@@ -47,7 +39,7 @@ func WriteCSV(ctx context.Context, iter *ChunkIterator, writer io.Writer) error
 		}
 		chunk := iter.Chunk()
 		if numFields == 0 {
-			fieldNames := chunk.getFieldNames()
+			fieldNames := chunk.Keys()
 			if err := csvw.Write(fieldNames); err != nil {
 				return errors.Wrap(err, "problem writing field names")
 			}
@@ -113,7 +105,7 @@ func DumpCSV(ctx context.Context, iter *ChunkIterator, prefix string) error {
 
 		chunk := iter.Chunk()
 		if numFields == 0 {
-			fieldNames := chunk.getFieldNames()
+			fieldNames := chunk.Keys()
 			if err = csvw.Write(fieldNames); err != nil {
 				return errors.Wrap(err, "problem writing field names")
 			}
@@ -132,7 +124,7 @@ func DumpCSV(ctx context.Context, iter *ChunkIterator, prefix string) error {
 			fileCount++
 
 			// now dump header
-			fieldNames := chunk.getFieldNames()
+			fieldNames := chunk.Keys()
 			if err := csvw.Write(fieldNames); err != nil {
 				return errors.Wrap(err, "problem writing field names")
 			}
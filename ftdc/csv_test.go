@@ -50,6 +50,26 @@ func TestWriteCSVIntegration(t *testing.T) {
 		err := DumpCSV(ctx, iter, filepath.Join(tmp, "dump"))
 		require.NoError(t, err)
 	})
+	t.Run("NestedFieldNames", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		for i := int64(0); i < 3; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(
+				birch.EC.Int64("a", i),
+				birch.EC.SubDocumentFromElements("b",
+					birch.EC.Int64("c", i),
+					birch.EC.SubDocumentFromElements("d", birch.EC.Int64("e", i)),
+				),
+			)))
+		}
+		require.NoError(t, cw.Flush())
+
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteCSV(ctx, ReadChunks(ctx, buf), out))
+
+		lines := strings.Split(out.String(), "\n")
+		assert.Equal(t, "a,b.c,b.d.e", lines[0])
+	})
 	t.Run("WriteWithSchemaChange", func(t *testing.T) {
 		iter := ReadChunks(ctx, bytes.NewBuffer(newMixedChunk(10)))
 		out := &bytes.Buffer{}
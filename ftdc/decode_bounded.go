@@ -0,0 +1,105 @@
+package ftdc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch"
+)
+
+// DecodeBounded reads the chunks from each of the files in turn, and
+// passes each chunk to fn, stopping at the first error that fn
+// returns, so that callers can process and discard the chunks of
+// inputs that are too large to hold in memory. Files may be gzip
+// compressed, as for OpenMaybeCompressed.
+//
+// DecodeBounded reads one document and decodes one chunk at a time,
+// without reading ahead, and does not keep chunks after fn returns.
+// The encoded size of each document, and the size of the decoded
+// samples of each chunk, must be at most maxBytes, so the memory that
+// DecodeBounded uses, apart from the memory that fn retains, is at
+// most a small multiple of maxBytes. Larger documents and chunks are
+// rejected before they are read or decoded.
+//
+// DecodeBounded returns an error if maxBytes is not positive, if a
+// file cannot be read, if a document or chunk is larger than
+// maxBytes, or if the context is canceled. Chunks that cannot be
+// decoded produce a *ChunkError, wrapped with the name of the file.
+func DecodeBounded(ctx context.Context, files []string, maxBytes int64, fn func(*Chunk) error) error {
+	if maxBytes <= 0 {
+		return errors.Errorf("memory limit must be positive, not %d", maxBytes)
+	}
+
+	for _, path := range files {
+		if err := decodeFileBounded(ctx, path, maxBytes, fn); err != nil {
+			return errors.Wrapf(err, "problem decoding '%s'", path)
+		}
+	}
+
+	return nil
+}
+
+func decodeFileBounded(ctx context.Context, path string, maxBytes int64, fn func(*Chunk) error) error {
+	file, err := OpenMaybeCompressed(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewReader(file)
+
+	var (
+		metadata *birch.Document
+		offset   int64
+		index    int
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		header, err := buf.Peek(4)
+		if err == io.EOF && len(header) == 0 {
+			return nil
+		} else if err != nil {
+			return errors.Wrapf(err, "problem reading document at offset %d", offset)
+		}
+
+		if size := int64(int32(binary.LittleEndian.Uint32(header))); size > maxBytes {
+			return errors.Errorf("document of %d bytes at offset %d exceeds the limit of %d bytes", size, offset, maxBytes)
+		}
+
+		doc := &birch.Document{}
+		n, err := doc.ReadFrom(buf)
+		if err != nil {
+			return errors.Wrapf(err, "problem reading document at offset %d", offset)
+		}
+
+		docType := doc.Lookup("type")
+		switch {
+		case isNum(0, docType):
+			metadata = doc
+		case isNum(1, docType):
+			chunk, err := readChunkWithLimit(doc, metadata, maxBytes)
+			if err != nil {
+				return &ChunkError{Index: index, Offset: offset, Err: err}
+			}
+			index++
+			chunk.next = ScanState{
+				Offset:   offset + n,
+				Chunks:   index,
+				Metadata: metadata,
+			}
+
+			if err = fn(chunk); err != nil {
+				return err
+			}
+		}
+
+		offset += n
+	}
+}
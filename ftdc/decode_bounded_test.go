@@ -0,0 +1,135 @@
+package ftdc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestDecodeBounded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "ftdc-decode-bounded")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	write := func(t *testing.T, name string, start, samples int64, compress bool) string {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(5)
+		for i := start; i < start+samples; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Int64("counter", i))))
+		}
+		require.NoError(t, cw.Flush())
+
+		data := buf.Bytes()
+		if compress {
+			out := &bytes.Buffer{}
+			gz := gzip.NewWriter(out)
+			_, err := gz.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, gz.Close())
+			data = out.Bytes()
+		}
+
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, data, 0600))
+		return path
+	}
+
+	files := []string{
+		write(t, "first.ftdc", 0, 20, false),
+		write(t, "second.ftdc.gz", 20, 10, true),
+	}
+
+	t.Run("AllFiles", func(t *testing.T) {
+		var counters []int64
+		err := DecodeBounded(ctx, files, 1<<20, func(c *Chunk) error {
+			assert.Equal(t, 5, c.Size())
+			counters = append(counters, c.Metrics[0].Values...)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, counters, 30)
+		for idx, v := range counters {
+			assert.Equal(t, int64(idx), v)
+		}
+	})
+	t.Run("CallbackError", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		err := DecodeBounded(ctx, files, 1<<20, func(c *Chunk) error {
+			count++
+			if count == 2 {
+				return stop
+			}
+			return nil
+		})
+		assert.Equal(t, stop, errors.Cause(err))
+		assert.Equal(t, 2, count)
+	})
+	t.Run("DocumentTooLarge", func(t *testing.T) {
+		err := DecodeBounded(ctx, files, 16, func(*Chunk) error {
+			assert.Fail(t, "no chunk is within the limit")
+			return nil
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds the limit")
+	})
+	t.Run("ChunkTooLarge", func(t *testing.T) {
+		// constant metrics compress to almost nothing, so the
+		// decoded chunk is far larger than its document.
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(1000)
+		for i := 0; i < 1000; i++ {
+			doc := birch.DC.New()
+			for m := 0; m < 10; m++ {
+				doc.Append(birch.EC.Int64(string(rune('a'+m)), 1))
+			}
+			require.NoError(t, cw.Add(doc))
+		}
+		require.NoError(t, cw.Flush())
+		require.True(t, buf.Len() < 10000)
+
+		path := filepath.Join(dir, "constant.ftdc")
+		require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+
+		err := DecodeBounded(ctx, []string{path}, 10000, func(*Chunk) error { return nil })
+		require.Error(t, err)
+		var chunkErr *ChunkError
+		require.True(t, errors.As(err, &chunkErr))
+		assert.Equal(t, 0, chunkErr.Index)
+		assert.Contains(t, err.Error(), "exceeds the limit")
+
+		require.NoError(t, DecodeBounded(ctx, []string{path}, 80000, func(c *Chunk) error {
+			assert.Equal(t, 1000, c.Size())
+			return nil
+		}))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		noop := func(*Chunk) error { return nil }
+		assert.Error(t, DecodeBounded(ctx, files, 0, noop))
+		assert.Error(t, DecodeBounded(ctx, []string{filepath.Join(dir, "missing")}, 1<<20, noop))
+
+		cctx, ccancel := context.WithCancel(ctx)
+		ccancel()
+		assert.Error(t, DecodeBounded(cctx, files, 1<<20, noop))
+
+		path := filepath.Join(dir, "truncated.ftdc")
+		data, err := ioutil.ReadFile(files[0])
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(path, data[:len(data)-3], 0600))
+		assert.Error(t, DecodeBounded(ctx, []string{path}, 1<<20, noop))
+	})
+}
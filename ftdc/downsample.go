@@ -0,0 +1,120 @@
+package ftdc
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// DownsampleOptions controls how Chunk.DownsampleWithOptions reduces
+// the samples in a chunk.
+type DownsampleOptions struct {
+	// Mean replaces each window of samples with the mean of the
+	// window, rather than with the first sample of the window.
+	Mean bool
+}
+
+// Downsample returns a chunk with every factor-th sample of the chunk,
+// starting with the first, to reduce the size of long series before
+// exporting them. See DownsampleWithOptions.
+func (c *Chunk) Downsample(factor int) (*Chunk, error) {
+	return c.DownsampleWithOptions(factor, DownsampleOptions{})
+}
+
+// DownsampleWithOptions returns a chunk with one sample for each
+// window of factor consecutive samples in the chunk, where the last
+// window may be shorter than the others. By default each window is
+// represented by its first sample. With the Mean option, integer,
+// floating point, and datetime metrics hold the mean of the window,
+// where integers are rounded to the nearest integer, and other
+// metrics, such as booleans and timestamps, hold the value of the
+// first sample.
+//
+// The chunk has the same metrics, metadata, and reference document as
+// the original, so its iterators and other methods produce documents
+// with the same structure. DownsampleWithOptions returns an error if
+// the factor is not positive.
+func (c *Chunk) DownsampleWithOptions(factor int, opts DownsampleOptions) (*Chunk, error) {
+	if factor <= 0 {
+		return nil, errors.Errorf("invalid downsampling factor %d", factor)
+	}
+
+	n := (c.nPoints + factor - 1) / factor
+
+	out := &Chunk{
+		Metrics:   make([]Metric, len(c.Metrics)),
+		nPoints:   n,
+		id:        c.id,
+		metadata:  c.metadata,
+		reference: c.reference,
+		next:      c.next,
+	}
+
+	for idx, m := range c.Metrics {
+		values := make([]int64, n)
+		for i := range values {
+			start := i * factor
+			end := start + factor
+			if end > c.nPoints {
+				end = c.nPoints
+			}
+
+			window := m.Values[start:end]
+			switch {
+			case !opts.Mean:
+				values[i] = window[0]
+			case m.originalType == bsontype.Double:
+				var sum float64
+				for _, v := range window {
+					sum += restoreFloat(v)
+				}
+				values[i] = normalizeFloat(sum / float64(len(window)))
+			case m.originalType == bsontype.Int32, m.originalType == bsontype.Int64, m.originalType == bsontype.DateTime:
+				values[i] = meanInt64(window)
+			default:
+				values[i] = window[0]
+			}
+		}
+
+		m.Values = values
+		if n > 0 {
+			m.startingValue = values[0]
+		}
+		out.Metrics[idx] = m
+	}
+
+	return out, nil
+}
+
+// meanInt64 returns the mean of the values, rounded half away from
+// zero, without overflowing for large values.
+func meanInt64(values []int64) int64 {
+	n := int64(len(values))
+
+	var quotient, remainder int64
+	for _, v := range values {
+		quotient += v / n
+		remainder += v % n
+	}
+
+	quotient += remainder / n
+	remainder %= n
+
+	// give the remainder the sign of the mean before rounding it.
+	switch {
+	case quotient > 0 && remainder < 0:
+		quotient--
+		remainder += n
+	case quotient < 0 && remainder > 0:
+		quotient++
+		remainder -= n
+	}
+
+	switch {
+	case 2*remainder >= n:
+		quotient++
+	case 2*remainder <= -n:
+		quotient--
+	}
+
+	return quotient
+}
@@ -0,0 +1,104 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestChunkDownsample(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Unix(1600000000, 0)
+	data := produceMockChunks(7, 0, func(idx int) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Time("start", start.Add(time.Duration(idx)*time.Second)),
+			birch.EC.Int64("ops", int64(idx*idx)),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Double("ratio", float64(idx)/4)),
+			birch.EC.Boolean("ok", idx%2 == 1),
+		)
+	})
+
+	iter := ReadChunks(ctx, bytes.NewReader(data))
+	require.True(t, iter.Next())
+	chunk := iter.Chunk()
+
+	samples := func(t *testing.T, c *Chunk) []*birch.Document {
+		iter := c.StructuredIterator(ctx)
+		defer iter.Close()
+		var out []*birch.Document
+		for iter.Next() {
+			out = append(out, iter.Document())
+		}
+		return out
+	}
+
+	t.Run("Decimate", func(t *testing.T) {
+		out, err := chunk.Downsample(3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, out.Size())
+		assert.Equal(t, chunk.Keys(), out.Keys())
+
+		docs := samples(t, out)
+		require.Len(t, docs, 3)
+		for idx, doc := range docs {
+			assert.Equal(t, int64(9*idx*idx), doc.Lookup("ops").Int64())
+			assert.Equal(t, float64(3*idx)/4, doc.RecursiveLookup("mem", "ratio").Double())
+			assert.Equal(t, start.Add(time.Duration(3*idx)*time.Second), doc.Lookup("start").Time())
+			assert.Equal(t, idx%2 == 1, doc.Lookup("ok").Boolean())
+		}
+
+		sample, err := out.Sample(2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(36), sample.Lookup("ops").Int64())
+
+		same, err := chunk.Downsample(1)
+		require.NoError(t, err)
+		assert.Equal(t, samples(t, chunk), samples(t, same))
+	})
+	t.Run("Mean", func(t *testing.T) {
+		out, err := chunk.DownsampleWithOptions(3, DownsampleOptions{Mean: true})
+		require.NoError(t, err)
+		assert.Equal(t, 3, out.Size())
+
+		docs := samples(t, out)
+		require.Len(t, docs, 3)
+
+		// windows of 0-2, 3-5, and 6.
+		assert.Equal(t, int64(2), docs[0].Lookup("ops").Int64(), "(0+1+4)/3 rounds to 2")
+		assert.Equal(t, int64(17), docs[1].Lookup("ops").Int64(), "(9+16+25)/3 rounds to 17")
+		assert.Equal(t, int64(36), docs[2].Lookup("ops").Int64())
+		assert.Equal(t, 0.25, docs[0].RecursiveLookup("mem", "ratio").Double())
+		assert.Equal(t, 1.0, docs[1].RecursiveLookup("mem", "ratio").Double())
+		assert.Equal(t, start.Add(time.Second), docs[0].Lookup("start").Time())
+		assert.False(t, docs[0].Lookup("ok").Boolean(), "booleans hold the first sample")
+
+		rates, err := out.Rates()
+		require.NoError(t, err)
+		assert.Equal(t, []float64{5, 9.5}, rates["ops"], "mean times of 1s, 4s, and 6s")
+	})
+	t.Run("Factor", func(t *testing.T) {
+		for _, factor := range []int{0, -1} {
+			_, err := chunk.Downsample(factor)
+			assert.Error(t, err)
+		}
+
+		out, err := chunk.Downsample(100)
+		require.NoError(t, err)
+		assert.Equal(t, 1, out.Size())
+	})
+	t.Run("MeanInt64", func(t *testing.T) {
+		assert.Equal(t, int64(1), meanInt64([]int64{-1, 2}))
+		assert.Equal(t, int64(-1), meanInt64([]int64{1, -2}))
+		assert.Equal(t, int64(-2), meanInt64([]int64{-1, -2, -3}))
+		assert.Equal(t, int64(math.MaxInt64-1), meanInt64([]int64{math.MaxInt64, math.MaxInt64 - 2}))
+		assert.Equal(t, int64(math.MinInt64+1), meanInt64([]int64{math.MinInt64, math.MinInt64 + 2}))
+	})
+}
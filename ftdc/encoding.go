@@ -0,0 +1,273 @@
+package ftdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// EncodingScheme identifies how the samples of the metrics in a chunk
+// are encoded before they are compressed.
+type EncodingScheme int32
+
+const (
+	// EncodingDelta stores the difference between each sample and
+	// the previous sample of a metric. This is the encoding that
+	// MongoDB uses, and is the default; it is the only encoding
+	// that other FTDC readers support.
+	EncodingDelta EncodingScheme = 0
+	// EncodingDeltaOfDelta stores the difference between
+	// consecutive deltas of a metric. Counters that increase at a
+	// steady rate have deltas of deltas that are mostly zero,
+	// which compress much better than their deltas. The
+	// differences are zigzag encoded, as binary.PutVarint does, so
+	// that small negative differences are as small as positive
+	// ones.
+	EncodingDeltaOfDelta EncodingScheme = 1
+	// EncodingRaw stores each sample of a metric as its difference
+	// from the chunk's reference sample, which suits gauges that
+	// move up and down around a level, whose deltas alternate
+	// between positive and (expensive to encode) negative values.
+	// It is only used for individual metrics in chunks encoded
+	// with EncodingPerMetric.
+	EncodingRaw EncodingScheme = 2
+	// EncodingRLE stores the deltas of a metric as pairs of a delta
+	// and the number of times it repeats, which suits metrics that
+	// are constant or change at a constant rate for long
+	// stretches. It is only used for individual metrics in chunks
+	// encoded with EncodingPerMetric.
+	EncodingRLE EncodingScheme = 3
+	// EncodingPerMetric encodes each metric in a chunk with the
+	// scheme that ChooseEncoding selects for it, and records the
+	// scheme of each metric in a header in the chunk.
+	EncodingPerMetric EncodingScheme = 4
+)
+
+// chunkEncodingKey is the field of a chunk document that records the
+// encoding of the chunk. The field is only written for encodings
+// other than EncodingDelta, so that chunks with the default encoding
+// are identical to MongoDB's. The field also versions the layout of
+// the chunk's payload: only chunks encoded with EncodingPerMetric
+// have per-metric headers.
+const chunkEncodingKey = "encoding"
+
+func (e EncodingScheme) String() string {
+	switch e {
+	case EncodingDelta:
+		return "delta"
+	case EncodingDeltaOfDelta:
+		return "delta-of-delta"
+	case EncodingRaw:
+		return "raw"
+	case EncodingRLE:
+		return "rle"
+	case EncodingPerMetric:
+		return "per-metric"
+	default:
+		return "unknown"
+	}
+}
+
+// validate returns an error if the scheme cannot be used to encode a
+// chunk.
+func (e EncodingScheme) validate() error {
+	switch e {
+	case EncodingDelta, EncodingDeltaOfDelta, EncodingPerMetric:
+		return nil
+	default:
+		return errors.Errorf("unsupported encoding scheme %d", e)
+	}
+}
+
+// validateSeries returns an error if the scheme cannot be used to
+// encode a single metric in a chunk encoded with EncodingPerMetric.
+func (e EncodingScheme) validateSeries() error {
+	switch e {
+	case EncodingDelta, EncodingDeltaOfDelta, EncodingRaw, EncodingRLE:
+		return nil
+	default:
+		return errors.Errorf("unsupported metric encoding scheme %d", e)
+	}
+}
+
+// readChunkEncoding returns the encoding recorded in a chunk
+// document.
+func readChunkEncoding(doc *birch.Document) (EncodingScheme, error) {
+	val := doc.Lookup(chunkEncodingKey)
+	if val == nil {
+		return EncodingDelta, nil
+	}
+
+	if val.Type() != bsontype.Int32 {
+		return 0, errors.Errorf("invalid encoding of type %s", val.Type())
+	}
+
+	scheme := EncodingScheme(val.Int32())
+	if err := scheme.validate(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return scheme, nil
+}
+
+// ChooseEncoding returns the scheme that encodes consecutive samples
+// of a metric in the fewest bytes before compression: EncodingDelta,
+// EncodingDeltaOfDelta, EncodingRaw, or EncodingRLE. Ties favor the
+// schemes in that order, and fewer than two values use EncodingDelta.
+// Chunks encoded with EncodingPerMetric use this to select the scheme
+// of each metric.
+func ChooseEncoding(values []int64) EncodingScheme {
+	if len(values) < 2 {
+		return EncodingDelta
+	}
+
+	deltas := make([]int64, len(values)-1)
+	for i := range deltas {
+		deltas[i] = values[i+1] - values[i]
+	}
+
+	return chooseSeriesEncoding(deltas)
+}
+
+func chooseSeriesEncoding(deltas []int64) EncodingScheme {
+	best := EncodingDelta
+	bestSize := -1
+	buf := &bytes.Buffer{}
+
+	for _, scheme := range []EncodingScheme{EncodingDelta, EncodingDeltaOfDelta, EncodingRaw, EncodingRLE} {
+		buf.Reset()
+		scheme.writeSeries(buf, deltas)
+		if bestSize < 0 || buf.Len() < bestSize {
+			best, bestSize = scheme, buf.Len()
+		}
+	}
+
+	return best
+}
+
+// encode converts the deltas of one metric, in place, into the values
+// that are stored for the scheme. EncodingRLE stores the deltas
+// themselves, before run-length encoding them. EncodingDeltaOfDelta
+// keeps the first delta, like EncodingDelta, and zigzag encodes the
+// differences that follow it.
+func (e EncodingScheme) encode(deltas []int64) {
+	switch e {
+	case EncodingDeltaOfDelta:
+		for i := len(deltas) - 1; i > 0; i-- {
+			deltas[i] = zigzag(deltas[i] - deltas[i-1])
+		}
+	case EncodingRaw:
+		for i := 1; i < len(deltas); i++ {
+			deltas[i] += deltas[i-1]
+		}
+	}
+}
+
+// decode converts the stored values of one metric, in place, back
+// into deltas, reversing encode.
+func (e EncodingScheme) decode(values []int64) {
+	switch e {
+	case EncodingDeltaOfDelta:
+		for i := 1; i < len(values); i++ {
+			values[i] = unzigzag(values[i]) + values[i-1]
+		}
+	case EncodingRaw:
+		for i := len(values) - 1; i > 0; i-- {
+			values[i] -= values[i-1]
+		}
+	}
+}
+
+// zigzag maps signed integers to unsigned integers so that values
+// near zero, whether positive or negative, have small encodings:
+// 0, -1, 1, -2, 2 become 0, 1, 2, 3, 4.
+func zigzag(v int64) int64 {
+	return int64(uint64(v<<1) ^ uint64(v>>63))
+}
+
+// unzigzag reverses zigzag.
+func unzigzag(v int64) int64 {
+	return int64(uint64(v)>>1) ^ -(v & 1)
+}
+
+// writeSeries writes the deltas of one metric in a chunk encoded with
+// EncodingPerMetric. Unlike chunks with a single encoding, runs of
+// zeros do not continue from one metric into the next.
+func (e EncodingScheme) writeSeries(buf *bytes.Buffer, deltas []int64) {
+	values := make([]int64, len(deltas))
+	copy(values, deltas)
+	e.encode(values)
+
+	if e == EncodingRLE {
+		for i := 0; i < len(values); {
+			run := 1
+			for i+run < len(values) && values[i+run] == values[i] {
+				run++
+			}
+
+			buf.Write(encodeValue(values[i]))
+			buf.Write(encodeValue(int64(run - 1)))
+			i += run
+		}
+
+		return
+	}
+
+	zeroCount := int64(0)
+	for _, value := range values {
+		if value == 0 {
+			zeroCount++
+			continue
+		}
+
+		if zeroCount > 0 {
+			buf.Write(encodeValue(0))
+			buf.Write(encodeValue(zeroCount - 1))
+			zeroCount = 0
+		}
+
+		buf.Write(encodeValue(value))
+	}
+
+	if zeroCount > 0 {
+		buf.Write(encodeValue(0))
+		buf.Write(encodeValue(zeroCount - 1))
+	}
+}
+
+// readSeries reads the deltas of one metric written by writeSeries.
+func (e EncodingScheme) readSeries(buf io.ByteReader, num int) ([]int64, error) {
+	values := make([]int64, num)
+
+	for i := 0; i < num; {
+		value, err := binary.ReadUvarint(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "reached unexpected end of encoded integer")
+		}
+
+		run := uint64(1)
+		if e == EncodingRLE || value == 0 {
+			extra, err := binary.ReadUvarint(buf)
+			if err != nil {
+				return nil, errors.Wrap(err, "reached unexpected end of encoded run")
+			}
+			run += extra
+		}
+
+		if run > uint64(num-i) {
+			return nil, errors.Errorf("run of %d values exceeds the %d remaining samples", run, num-i)
+		}
+
+		for end := i + int(run); i < end; i++ {
+			values[i] = int64(value)
+		}
+	}
+
+	e.decode(values)
+
+	return values, nil
+}
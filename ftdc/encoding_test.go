@@ -0,0 +1,308 @@
+package ftdc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func makeEncodingSamples(n int) []*birch.Document {
+	docs := make([]*birch.Document, n)
+	gauge := int64(0)
+	start := time.Now().Truncate(time.Millisecond)
+	for i := range docs {
+		gauge += rand.Int63n(21) - 10
+		docs[i] = birch.DC.Elements(
+			birch.EC.Int64("counter", int64(i)*1000),
+			birch.EC.Int64("gauge", gauge),
+			birch.EC.Int64("wrap", math.MaxInt64-int64(i)*math.MaxInt32),
+			birch.EC.Int32("small", int32(i%3)),
+			birch.EC.Double("ratio", float64(i)/3),
+			birch.EC.Boolean("flag", i%2 == 0),
+			birch.EC.Time("ts", start.Add(time.Duration(i)*time.Second)),
+			birch.EC.SubDocumentFromElements("nested", birch.EC.Int64("constant", 42)),
+		)
+	}
+	return docs
+}
+
+func TestEncodingScheme(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("Series", func(t *testing.T) {
+		for name, series := range map[string][]int64{
+			"Empty":    {},
+			"Single":   {7},
+			"Counter":  {10, 10, 10, 10},
+			"Mixed":    {5, -3, 0, 0, 12, math.MinInt64, math.MaxInt64, 1},
+			"Overflow": {math.MaxInt64, math.MinInt64, math.MaxInt64},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for _, scheme := range []EncodingScheme{EncodingDelta, EncodingDeltaOfDelta} {
+					values := append([]int64{}, series...)
+					scheme.encode(values)
+					scheme.decode(values)
+					assert.Equal(t, series, values)
+				}
+			})
+		}
+
+		counter := []int64{10, 10, 10, 10}
+		EncodingDeltaOfDelta.encode(counter)
+		assert.Equal(t, []int64{10, 0, 0, 0}, counter)
+
+		// negative differences are zigzag encoded.
+		jitter := []int64{10, 9, 10, 8}
+		EncodingDeltaOfDelta.encode(jitter)
+		assert.Equal(t, []int64{10, 1, 2, 3}, jitter)
+	})
+	t.Run("Zigzag", func(t *testing.T) {
+		for _, v := range []int64{0, 1, -1, 2, -2, 1 << 40, -(1 << 40), math.MaxInt64, math.MinInt64} {
+			assert.Equal(t, v, unzigzag(zigzag(v)))
+			assert.Len(t, encodeValue(zigzag(v)), binary.PutVarint(make([]byte, binary.MaxVarintLen64), v))
+		}
+		assert.Equal(t, []int64{0, 1, 2, 3, 4}, []int64{zigzag(0), zigzag(-1), zigzag(1), zigzag(-2), zigzag(2)})
+	})
+	t.Run("WriteSeries", func(t *testing.T) {
+		for name, series := range map[string][]int64{
+			"Empty":    {},
+			"Single":   {7},
+			"Zeros":    {0, 0, 0, 0, 0},
+			"Counter":  {10, 10, 10, 10},
+			"Mixed":    {5, -3, 0, 0, 12, math.MinInt64, math.MaxInt64, 1},
+			"Overflow": {math.MaxInt64, math.MinInt64, math.MaxInt64},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for _, scheme := range []EncodingScheme{EncodingDelta, EncodingDeltaOfDelta, EncodingRaw, EncodingRLE} {
+					buf := &bytes.Buffer{}
+					scheme.writeSeries(buf, series)
+					values, err := scheme.readSeries(buf, len(series))
+					require.NoError(t, err, scheme.String())
+					assert.Equal(t, series, values, scheme.String())
+					assert.Zero(t, buf.Len(), scheme.String())
+				}
+			})
+		}
+		t.Run("Truncated", func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			EncodingRLE.writeSeries(buf, []int64{1, 2, 3})
+			_, err := EncodingRLE.readSeries(bytes.NewBuffer(buf.Bytes()[:buf.Len()-1]), 3)
+			assert.Error(t, err)
+		})
+		t.Run("LongRun", func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			EncodingRLE.writeSeries(buf, []int64{1, 1, 1, 1})
+			_, err := EncodingRLE.readSeries(buf, 2)
+			assert.Error(t, err)
+		})
+	})
+	t.Run("ChooseEncoding", func(t *testing.T) {
+		growing := make([]int64, 100)
+		oscillating := make([]int64, 100)
+		constant := make([]int64, 100)
+		steady := make([]int64, 100)
+		for i := range growing {
+			n := int64(i)
+			growing[i] = n * (n + 1) / 2 * 1500
+			oscillating[i] = 1 << 40
+			if i%2 == 1 {
+				oscillating[i] += 1 << 20
+			}
+			constant[i] = 42
+			steady[i] = n * 1000
+		}
+
+		assert.Equal(t, EncodingDelta, ChooseEncoding(nil))
+		assert.Equal(t, EncodingDelta, ChooseEncoding([]int64{1}))
+		assert.Equal(t, EncodingDelta, ChooseEncoding(constant))
+		assert.Equal(t, EncodingDeltaOfDelta, ChooseEncoding(growing))
+		assert.Equal(t, EncodingRaw, ChooseEncoding(oscillating))
+		assert.Equal(t, EncodingRLE, ChooseEncoding(steady))
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		docs := makeEncodingSamples(250)
+
+		write := func(t *testing.T, scheme EncodingScheme) []byte {
+			buf := &bytes.Buffer{}
+			cw := NewChunkWriter(buf)
+			cw.SetMaxSamples(100)
+			require.NoError(t, cw.SetEncoding(scheme))
+			for _, doc := range docs {
+				require.NoError(t, cw.Add(doc))
+			}
+			require.NoError(t, cw.Close())
+			return buf.Bytes()
+		}
+		read := func(t *testing.T, data []byte) []string {
+			var out []string
+			iter := ReadStructuredMetrics(ctx, bytes.NewReader(data))
+			defer iter.Close()
+			for iter.Next() {
+				out = append(out, iter.Document().String())
+			}
+			require.NoError(t, iter.Err())
+			return out
+		}
+
+		delta := write(t, EncodingDelta)
+		dod := write(t, EncodingDeltaOfDelta)
+
+		expected := read(t, delta)
+		require.Len(t, expected, len(docs))
+		assert.Equal(t, expected, read(t, dod))
+		assert.NotEqual(t, delta, dod)
+
+		for data, encoding := range map[*[]byte]*birch.Value{&delta: nil, &dod: birch.VC.Int32(1)} {
+			buf := bytes.NewReader(*data)
+			for {
+				doc := &birch.Document{}
+				if _, err := doc.ReadFrom(buf); err == io.EOF {
+					break
+				} else {
+					require.NoError(t, err)
+				}
+				if encoding == nil {
+					assert.Nil(t, doc.Lookup(chunkEncodingKey))
+				} else {
+					assert.True(t, encoding.Equal(doc.Lookup(chunkEncodingKey)))
+				}
+			}
+		}
+	})
+	t.Run("PerMetric", func(t *testing.T) {
+		docs := makeEncodingSamples(250)
+		read := func(t *testing.T, scheme EncodingScheme) []string {
+			buf := &bytes.Buffer{}
+			cw := NewChunkWriter(buf)
+			cw.SetMaxSamples(100)
+			require.NoError(t, cw.SetEncoding(scheme))
+			for _, doc := range docs {
+				require.NoError(t, cw.Add(doc))
+			}
+			require.NoError(t, cw.Close())
+
+			var out []string
+			iter := ReadStructuredMetrics(ctx, bytes.NewReader(buf.Bytes()))
+			defer iter.Close()
+			for iter.Next() {
+				out = append(out, iter.Document().String())
+			}
+			require.NoError(t, iter.Err())
+			return out
+		}
+
+		expected := read(t, EncodingDelta)
+		require.Len(t, expected, len(docs))
+		assert.Equal(t, expected, read(t, EncodingPerMetric))
+	})
+	t.Run("PerMetricCorruptHeader", func(t *testing.T) {
+		collector, err := NewBaseCollectorWithOptions(100, BaseCollectorOptions{Encoding: EncodingPerMetric})
+		require.NoError(t, err)
+		for _, doc := range makeEncodingSamples(10) {
+			require.NoError(t, collector.Add(doc))
+		}
+		data, err := collector.Resolve()
+		require.NoError(t, err)
+
+		doc, err := birch.ReadDocument(data)
+		require.NoError(t, err)
+		_, payload := doc.Lookup("data").Binary()
+		z, err := zlib.NewReader(bytes.NewReader(payload[4:]))
+		require.NoError(t, err)
+		raw, err := ioutil.ReadAll(z)
+		require.NoError(t, err)
+
+		// the header follows the reference document and the
+		// number of metrics and samples.
+		ref, err := birch.ReadDocument(raw)
+		require.NoError(t, err)
+		size, err := ref.Validate()
+		require.NoError(t, err)
+		raw[int(size)+8] = 42
+
+		payload, err = compressBuffer(raw)
+		require.NoError(t, err)
+		doc.Set(birch.EC.Binary("data", payload))
+		data, err = doc.MarshalBSON()
+		require.NoError(t, err)
+
+		iter := ReadChunks(ctx, bytes.NewReader(data))
+		assert.False(t, iter.Next())
+		require.Error(t, iter.Err())
+		assert.Contains(t, iter.Err().Error(), "unsupported metric encoding")
+	})
+	t.Run("SwitchEncoding", func(t *testing.T) {
+		docs := makeEncodingSamples(10)
+
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		for _, doc := range docs[:4] {
+			require.NoError(t, cw.Add(doc))
+		}
+		require.NoError(t, cw.SetEncoding(EncodingDeltaOfDelta))
+		for _, doc := range docs[4:] {
+			require.NoError(t, cw.Add(doc))
+		}
+		require.NoError(t, cw.Flush())
+
+		var sizes []int
+		iter := ReadChunks(ctx, buf)
+		for iter.Next() {
+			sizes = append(sizes, iter.Chunk().Size())
+		}
+		require.NoError(t, iter.Err())
+		assert.Equal(t, []int{4, 6}, sizes)
+	})
+	t.Run("Collector", func(t *testing.T) {
+		collector, err := NewBaseCollectorWithOptions(100, BaseCollectorOptions{Encoding: EncodingDeltaOfDelta})
+		require.NoError(t, err)
+		for _, doc := range makeEncodingSamples(50) {
+			require.NoError(t, collector.Add(doc))
+		}
+		data, err := collector.Resolve()
+		require.NoError(t, err)
+
+		iter := ReadMetrics(ctx, bytes.NewReader(data))
+		count := 0
+		for iter.Next() {
+			assert.Equal(t, int64(count)*1000, iter.Document().Lookup("counter").Int64())
+			count++
+		}
+		require.NoError(t, iter.Err())
+		assert.Equal(t, 50, count)
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := NewBaseCollectorWithOptions(100, BaseCollectorOptions{Encoding: 42})
+		assert.Error(t, err)
+		assert.Error(t, NewChunkWriter(&bytes.Buffer{}).SetEncoding(-1))
+
+		collector, err := NewBaseCollectorWithOptions(100, BaseCollectorOptions{})
+		require.NoError(t, err)
+		require.NoError(t, collector.Add(birch.DC.Elements(birch.EC.Int64("a", 1))))
+		data, err := collector.Resolve()
+		require.NoError(t, err)
+
+		doc, err := birch.ReadDocument(data)
+		require.NoError(t, err)
+		doc.Append(birch.EC.Int32(chunkEncodingKey, 42))
+		data, err = doc.MarshalBSON()
+		require.NoError(t, err)
+
+		iter := ReadChunks(ctx, bytes.NewReader(data))
+		assert.False(t, iter.Next())
+		require.Error(t, iter.Err())
+		assert.Contains(t, iter.Err().Error(), "unsupported encoding")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/tychoish/birch"
 	"github.com/tychoish/birch/bsontype"
 )
@@ -16,12 +17,25 @@ type Chunk struct {
 	id        time.Time
 	metadata  *birch.Document
 	reference *birch.Document
+	next      ScanState
 }
 
 func (c *Chunk) GetMetadata() *birch.Document { return c.metadata }
 func (c *Chunk) Size() int                    { return c.nPoints }
 func (c *Chunk) Len() int                     { return len(c.Metrics) }
 
+// Keys returns the dot-separated key of each metric in the chunk, in
+// the order of the chunk's metrics, without expanding the samples.
+// Timestamps produce two metrics, the second of which has an ".inc"
+// suffix.
+func (c *Chunk) Keys() []string {
+	keys := make([]string, len(c.Metrics))
+	for idx, m := range c.Metrics {
+		keys[idx] = m.Key()
+	}
+	return keys
+}
+
 // Iterator returns an iterator that you can use to read documents for
 // each sample period in the chunk. Documents are returned in collection
 // order, with keys flattened and dot-separated fully qualified
@@ -29,12 +43,7 @@ func (c *Chunk) Len() int                     { return len(c.Metrics) }
 //
 // The documents are constructed from the metrics data lazily.
 func (c *Chunk) Iterator(ctx context.Context) Iterator {
-	sctx, cancel := context.WithCancel(ctx)
-	return &sampleIterator{
-		closer:   cancel,
-		stream:   c.streamFlattenedDocuments(sctx),
-		metadata: c.GetMetadata(),
-	}
+	return c.IteratorWithOptions(ctx, IteratorOptions{})
 }
 
 // StructuredIterator returns the contents of the chunk as a sequence
@@ -42,12 +51,25 @@ func (c *Chunk) Iterator(ctx context.Context) Iterator {
 // (with the non-metrics fields omitted.) The output documents mirror
 // the structure of the input documents.
 func (c *Chunk) StructuredIterator(ctx context.Context) Iterator {
-	sctx, cancel := context.WithCancel(ctx)
-	return &sampleIterator{
-		closer:   cancel,
-		stream:   c.streamDocuments(sctx),
-		metadata: c.GetMetadata(),
+	return c.IteratorWithOptions(ctx, IteratorOptions{Structured: true})
+}
+
+// Sample returns the i-th sample of the chunk, counting from 0, as a
+// document with the structure and types of the chunk's reference
+// document, as the StructuredIterator returns it. Sample returns an
+// error if i is out of range.
+func (c *Chunk) Sample(i int) (*birch.Document, error) {
+	if i < 0 || i >= c.nPoints {
+		return nil, errors.Errorf("sample %d is out of range for chunk with %d samples", i, c.nPoints)
+	}
+
+	if c.reference == nil {
+		return nil, errors.New("chunk has no reference document")
 	}
+
+	doc, _ := restoreDocument(c.reference, i, c.Metrics, 0)
+
+	return doc, nil
 }
 
 // Metric represents an item in a chunk.
@@ -276,6 +276,126 @@ func TestReadPathIntegration(t *testing.T) {
 	}
 }
 
+func TestReadMatrixNestedKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	for i := int64(0); i < 3; i++ {
+		require.NoError(t, cw.Add(birch.DC.Elements(
+			birch.EC.SubDocumentFromElements("b",
+				birch.EC.Int64("c", i),
+				birch.EC.SubDocumentFromElements("d", birch.EC.Int64("e", i)),
+			),
+		)))
+	}
+	require.NoError(t, cw.Flush())
+
+	iter := ReadMatrix(ctx, buf)
+	require.True(t, iter.Next())
+
+	keys := []string{}
+	for _, elem := range iter.Document().Elements() {
+		keys = append(keys, elem.Key())
+	}
+	require.NoError(t, iter.Err())
+	assert.ElementsMatch(t, []string{"b.c", "b.d.e"}, keys)
+}
+
+func TestChunkKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	for i := int64(0); i < 3; i++ {
+		require.NoError(t, cw.Add(birch.DC.Elements(
+			birch.EC.Int64("z", i),
+			birch.EC.String("name", "ignored"),
+			birch.EC.SubDocumentFromElements("b",
+				birch.EC.Boolean("ok", true),
+				birch.EC.SubDocumentFromElements("d", birch.EC.Double("e", 1.5)),
+			),
+			birch.EC.Timestamp("ts", uint32(i), 1),
+			birch.EC.Int32("a", 1),
+		)))
+	}
+	require.NoError(t, cw.Flush())
+
+	iter := ReadChunks(ctx, buf)
+	require.True(t, iter.Next())
+	chunk := iter.Chunk()
+
+	keys := chunk.Keys()
+	assert.Equal(t, []string{"z", "b.ok", "b.d.e", "ts", "ts.inc", "a"}, keys)
+	assert.Len(t, keys, chunk.Len())
+	for idx, key := range keys {
+		assert.Equal(t, chunk.Metrics[idx].Key(), key)
+	}
+
+	assert.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+	assert.Empty(t, (&Chunk{}).Keys())
+}
+
+func TestChunkSample(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	for i := int64(0); i < 4; i++ {
+		require.NoError(t, cw.Add(birch.DC.Elements(
+			birch.EC.Int64("counter", i*10),
+			birch.EC.String("name", "ignored"),
+			birch.EC.SubDocumentFromElements("mem",
+				birch.EC.Int32("resident", int32(i)),
+				birch.EC.Double("ratio", float64(i)/4),
+			),
+			birch.EC.ArrayFromElements("load", birch.VC.Int64(i), birch.VC.Boolean(i%2 == 0)),
+		)))
+	}
+	require.NoError(t, cw.Flush())
+
+	iter := ReadChunks(ctx, buf)
+	require.True(t, iter.Next())
+	chunk := iter.Chunk()
+	require.Equal(t, 4, chunk.Size())
+
+	structured := chunk.StructuredIterator(ctx)
+	defer structured.Close()
+	for i := 0; i < chunk.Size(); i++ {
+		require.True(t, structured.Next())
+
+		sample, err := chunk.Sample(i)
+		require.NoError(t, err)
+		expected, err := structured.Document().MarshalBSON()
+		require.NoError(t, err)
+		actual, err := sample.MarshalBSON()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+
+		assert.Equal(t, int64(i*10), sample.Lookup("counter").Int64())
+		assert.Nil(t, sample.Lookup("name"))
+		assert.Equal(t, int32(i), sample.RecursiveLookup("mem", "resident").Int32())
+		assert.Equal(t, float64(i)/4, sample.RecursiveLookup("mem", "ratio").Double())
+		load := sample.Lookup("load").MutableArray()
+		require.Equal(t, 2, load.Len())
+		assert.Equal(t, int64(i), load.Lookup(0).Int64())
+		assert.Equal(t, i%2 == 0, load.Lookup(1).Boolean())
+	}
+
+	for _, i := range []int{-1, 4} {
+		sample, err := chunk.Sample(i)
+		assert.Error(t, err)
+		assert.Nil(t, sample)
+	}
+
+	_, err := (&Chunk{nPoints: 1}).Sample(0)
+	assert.Error(t, err)
+}
+
 func TestRoundTrip(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -0,0 +1,80 @@
+package ftdc
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Histogram reads all chunks from the iterator and counts the values
+// of one integer, floating point, or datetime metric in the buckets
+// that the boundaries define, for example to examine the distribution
+// of a latency without holding every sample in memory. The key is the
+// key of the metric as Chunk.Keys returns it.
+//
+// The boundaries must be in increasing order. For n boundaries,
+// Histogram returns n+1 counts: the first counts the values below the
+// first boundary, the last counts the values at or above the last
+// boundary, and the count at position i counts the values from
+// boundary i-1, inclusive, up to boundary i. NaN values are not
+// counted. Chunks without the metric do not contribute to the counts.
+//
+// Histogram returns an error if the boundaries are empty or out of
+// order, if no chunk has the metric, if the metric is not numeric, or
+// if the iterator encounters an error.
+func Histogram(iter *ChunkIterator, key string, buckets []float64) ([]uint64, error) {
+	if len(buckets) == 0 {
+		return nil, errors.New("must specify at least one bucket boundary")
+	}
+	for idx, b := range buckets {
+		if math.IsNaN(b) || (idx > 0 && b <= buckets[idx-1]) {
+			return nil, errors.Errorf("bucket boundaries must be increasing, not %v", buckets)
+		}
+	}
+
+	counts := make([]uint64, len(buckets)+1)
+	found := false
+	for iter.Next() {
+		chunk := iter.Chunk()
+		keys := chunk.Keys()
+		for idx := range chunk.Metrics {
+			if keys[idx] != key {
+				continue
+			}
+
+			m := &chunk.Metrics[idx]
+
+			var value func(int64) float64
+			switch m.originalType {
+			case bsontype.Int32, bsontype.Int64, bsontype.DateTime:
+				value = func(v int64) float64 { return float64(v) }
+			case bsontype.Double:
+				value = restoreFloat
+			default:
+				return nil, errors.Errorf("metric '%s' has non-numeric type %s", key, m.originalType)
+			}
+
+			found = true
+			for _, v := range m.Values {
+				f := value(v)
+				if math.IsNaN(f) {
+					continue
+				}
+				counts[sort.Search(len(buckets), func(i int) bool { return buckets[i] > f })]++
+			}
+			break
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "problem reading chunks")
+	}
+
+	if !found {
+		return nil, errors.Errorf("metric '%s' not found", key)
+	}
+
+	return counts, nil
+}
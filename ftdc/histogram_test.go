@@ -0,0 +1,64 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestHistogram(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the samples span two chunks.
+	data := produceMockChunks(10, 5, func(idx int) *birch.Document {
+		latency := float64(idx * 10)
+		if idx == 9 {
+			latency = math.NaN()
+		}
+		return birch.DC.Elements(
+			birch.EC.Int64("count", int64(idx)),
+			birch.EC.SubDocumentFromElements("op", birch.EC.Double("latency", latency)),
+			birch.EC.Boolean("ok", idx%2 == 0),
+		)
+	})
+
+	histogram := func(key string, buckets ...float64) ([]uint64, error) {
+		return Histogram(ReadChunks(ctx, bytes.NewReader(data)), key, buckets)
+	}
+
+	t.Run("Integers", func(t *testing.T) {
+		counts, err := histogram("count", 2, 5, 8)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 3, 3, 2}, counts)
+	})
+	t.Run("Doubles", func(t *testing.T) {
+		counts, err := histogram("op.latency", 15, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 3, 4}, counts, "NaN values are not counted")
+	})
+	t.Run("SingleBoundary", func(t *testing.T) {
+		counts, err := histogram("count", 100)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{10, 0}, counts)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := histogram("count")
+		assert.Error(t, err)
+		_, err = histogram("count", 5, 2)
+		assert.Error(t, err)
+		_, err = histogram("count", 1, 1)
+		assert.Error(t, err)
+		_, err = histogram("count", math.NaN())
+		assert.Error(t, err)
+		_, err = histogram("missing", 1)
+		assert.Error(t, err)
+		_, err = histogram("ok", 1)
+		assert.Error(t, err)
+	})
+}
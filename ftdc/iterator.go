@@ -19,28 +19,25 @@ type Iterator interface {
 // ReadMetrics returns a standard document iterator that reads FTDC
 // chunks. The Documents returned by the iterator are flattened.
 func ReadMetrics(ctx context.Context, r io.Reader) Iterator {
-	iterctx, cancel := context.WithCancel(ctx)
-	iter := &combinedIterator{
-		closer:  cancel,
-		chunks:  ReadChunks(iterctx, r),
-		flatten: true,
-		pipe:    make(chan *birch.Document, 100),
-		catcher: grip.NewCatcher(),
-	}
-
-	go iter.worker(iterctx)
-	return iter
+	return ReadMetricsWithOptions(ctx, r, IteratorOptions{})
 }
 
 // ReadStructuredMetrics returns a standard document iterator that reads FTDC
 // chunks. The Documents returned by the iterator retain the structure
 // of the input documents.
 func ReadStructuredMetrics(ctx context.Context, r io.Reader) Iterator {
+	return ReadMetricsWithOptions(ctx, r, IteratorOptions{Structured: true})
+}
+
+// ReadMetricsWithOptions returns a standard document iterator that
+// reads FTDC chunks, producing the documents that
+// Chunk.IteratorWithOptions produces for each chunk.
+func ReadMetricsWithOptions(ctx context.Context, r io.Reader, opts IteratorOptions) Iterator {
 	iterctx, cancel := context.WithCancel(ctx)
 	iter := &combinedIterator{
 		closer:  cancel,
 		chunks:  ReadChunks(iterctx, r),
-		flatten: false,
+		opts:    opts,
 		pipe:    make(chan *birch.Document, 100),
 		catcher: grip.NewCatcher(),
 	}
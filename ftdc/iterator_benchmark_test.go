@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
 )
 
 func BenchmarkIterator(b *testing.B) {
@@ -147,3 +148,40 @@ func BenchmarkIterator(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkChunkFlattenedDocuments(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a chunk shaped like server status samples, with nested
+	// sections of metrics whose keys repeat in every sample.
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	cw.SetMaxSamples(300)
+	for i := int64(0); i < 300; i++ {
+		doc := birch.DC.Make(10)
+		for section := 0; section < 10; section++ {
+			metrics := birch.DC.Make(20)
+			for metric := 0; metric < 20; metric++ {
+				metrics.Append(birch.EC.Int64(fmt.Sprintf("metricName%d", metric), i*int64(metric)))
+			}
+			doc.Append(birch.EC.SubDocument(fmt.Sprintf("section%d", section), metrics))
+		}
+		require.NoError(b, cw.Add(doc))
+	}
+	require.NoError(b, cw.Flush())
+
+	chunks := ReadChunks(ctx, buf)
+	require.True(b, chunks.Next())
+	chunk := chunks.Chunk()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		iter := chunk.Iterator(ctx)
+		for iter.Next() {
+			require.NotNil(b, iter.Document())
+		}
+		iter.Close()
+	}
+}
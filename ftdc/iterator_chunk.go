@@ -3,8 +3,10 @@ package ftdc
 import (
 	"context"
 	"io"
+	"sync"
 
 	"github.com/cdr/grip"
+	"github.com/pkg/errors"
 	"github.com/tychoish/birch"
 )
 
@@ -33,30 +35,118 @@ import (
 // You shoule check the Err() method when iterator is complete to see
 // if there were any issues encountered when decoding chunks.
 type ChunkIterator struct {
-	pipe    chan *Chunk
-	next    *Chunk
-	cancel  context.CancelFunc
-	closed  bool
-	catcher grip.Catcher
+	pipe      chan *Chunk
+	next      *Chunk
+	cancel    context.CancelFunc
+	closed    bool
+	catcher   grip.Catcher
+	mu        sync.Mutex
+	chunkErrs []*ChunkError
+	state     ScanState
+}
+
+// ScanState records a position between two chunks in an FTDC data
+// source, from which ResumeChunks can continue reading.
+type ScanState struct {
+	// Offset is the number of bytes in the source before the
+	// next document to read.
+	Offset int64
+	// Chunks is the number of metrics chunks before Offset,
+	// including chunks that could not be decoded. It is the index
+	// of the next chunk.
+	Chunks int
+	// Metadata is the most recent metadata document before
+	// Offset, which is attached to the chunks that follow it.
+	Metadata *birch.Document
+}
+
+// ReadChunksOptions controls how ReadChunksWithOptions handles chunks
+// that cannot be decoded.
+type ReadChunksOptions struct {
+	// SkipCorrupt continues reading past chunks that cannot be
+	// decoded. The errors for the skipped chunks are available
+	// from the iterator's ChunkErrors method, and are not
+	// reported by Err. By default the iterator stops at the
+	// first chunk that cannot be decoded.
+	SkipCorrupt bool
 }
 
 // ReadChunks creates a ChunkIterator from an underlying FTDC data
-// source.
+// source. The iterator stops at the first chunk that cannot be
+// decoded; use ReadChunksWithOptions to skip corrupt chunks.
 func ReadChunks(ctx context.Context, r io.Reader) *ChunkIterator {
+	return ReadChunksWithOptions(ctx, r, ReadChunksOptions{})
+}
+
+// ReadChunksWithOptions creates a ChunkIterator from an underlying
+// FTDC data source, handling chunks that cannot be decoded as
+// described by the options.
+func ReadChunksWithOptions(ctx context.Context, r io.Reader, opts ReadChunksOptions) *ChunkIterator {
+	return readChunksFrom(ctx, r, ScanState{}, opts)
+}
+
+// ResumeChunks creates a ChunkIterator that continues reading an FTDC
+// data source from a state returned by the Checkpoint method of an
+// earlier iterator over the same source. The iterator returns the
+// same chunks, with the same metadata, that the earlier iterator
+// would have returned after the checkpoint.
+func ResumeChunks(ctx context.Context, r io.ReadSeeker, state ScanState) (*ChunkIterator, error) {
+	return ResumeChunksWithOptions(ctx, r, state, ReadChunksOptions{})
+}
+
+// ResumeChunksWithOptions is the same as ResumeChunks, but handles
+// chunks that cannot be decoded as described by the options.
+func ResumeChunksWithOptions(ctx context.Context, r io.ReadSeeker, state ScanState, opts ReadChunksOptions) (*ChunkIterator, error) {
+	if state.Offset < 0 || state.Chunks < 0 {
+		return nil, errors.Errorf("invalid scan state at offset %d after %d chunks", state.Offset, state.Chunks)
+	}
+
+	if _, err := r.Seek(state.Offset, io.SeekStart); err != nil {
+		return nil, errors.Wrapf(err, "problem seeking to offset %d", state.Offset)
+	}
+
+	return readChunksFrom(ctx, r, state, opts), nil
+}
+
+func readChunksFrom(ctx context.Context, r io.Reader, state ScanState, opts ReadChunksOptions) *ChunkIterator {
 	iter := &ChunkIterator{
 		catcher: grip.NewCatcher(),
 		pipe:    make(chan *Chunk, 2),
+		state:   state,
 	}
 
-	ipc := make(chan *birch.Document)
+	ipc := make(chan diagnosticDocument)
 	ctx, iter.cancel = context.WithCancel(ctx)
 
+	diagnosticDone := make(chan struct{})
 	go func() {
-		iter.catcher.Add(readDiagnostic(ctx, r, ipc))
+		defer close(diagnosticDone)
+		iter.catcher.Add(readDiagnostic(ctx, r, ipc, state.Offset))
 	}()
 
 	go func() {
-		iter.catcher.Add(readChunks(ctx, ipc, iter.pipe))
+		// close the pipe only after both readers have
+		// recorded their errors, so that Err is complete
+		// once Next returns false.
+		defer close(iter.pipe)
+
+		err := readChunks(ctx, ipc, iter.pipe, state, func(err *ChunkError) error {
+			iter.mu.Lock()
+			iter.chunkErrs = append(iter.chunkErrs, err)
+			iter.mu.Unlock()
+
+			if opts.SkipCorrupt {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			iter.catcher.Add(err)
+			// stop reading the underlying source, which
+			// would otherwise block on the unread documents.
+			iter.cancel()
+		}
+		<-diagnosticDone
 	}()
 
 	return iter
@@ -72,6 +162,7 @@ func (iter *ChunkIterator) Next() bool {
 	}
 
 	iter.next = next
+	iter.state = next.next
 	return true
 }
 
@@ -91,3 +182,23 @@ func (iter *ChunkIterator) Close() { iter.cancel(); iter.closed = true }
 // Err returns a non-nil error if the iterator encountered any errors
 // during iteration.
 func (iter *ChunkIterator) Err() error { return iter.catcher.Resolve() }
+
+// Checkpoint returns the position in the source after the chunk most
+// recently returned by the iterator. Pass the state to ResumeChunks to
+// continue reading from that chunk boundary, for instance after the
+// process reading a large file was interrupted.
+func (iter *ChunkIterator) Checkpoint() ScanState { return iter.state }
+
+// ChunkErrors returns an error for each chunk that the iterator could
+// not decode, in the order that the chunks appear in the source. The
+// errors identify the position of each chunk in the source, which
+// allows callers to detect and handle corrupt chunks. Call
+// ChunkErrors after Next returns false.
+func (iter *ChunkIterator) ChunkErrors() []*ChunkError {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	out := make([]*ChunkError, len(iter.chunkErrs))
+	copy(out, iter.chunkErrs)
+	return out
+}
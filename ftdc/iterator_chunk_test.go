@@ -0,0 +1,358 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestChunkIteratorErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// writeCorruptStream writes four chunks of five samples each,
+	// replacing the data of the second chunk, and returns the
+	// stream and the offset of the corrupt chunk.
+	writeCorruptStream := func(t *testing.T, data []byte) ([]byte, int64) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(5)
+		cw.SetMetadata(birch.DC.Elements(birch.EC.String("host", "localhost")))
+		for i := int64(0); i < 20; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Int64("counter", i))))
+		}
+		require.NoError(t, cw.Flush())
+
+		out := &bytes.Buffer{}
+		var (
+			offset int64
+			chunks int
+		)
+		for {
+			doc := &birch.Document{}
+			_, err := doc.ReadFrom(buf)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			if isNum(1, doc.Lookup("type")) {
+				if chunks == 1 {
+					offset = int64(out.Len())
+					doc.Set(birch.EC.Binary("data", data))
+				}
+				chunks++
+			}
+			_, err = doc.WriteTo(out)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 4, chunks)
+
+		return out.Bytes(), offset
+	}
+
+	for name, data := range map[string][]byte{
+		"NotCompressed": {0, 0, 0, 0, 'n', 'o', 't', ' ', 'z', 'l', 'i', 'b'},
+		"Truncated":     {0, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stream, offset := writeCorruptStream(t, data)
+
+			t.Run("StopsAtFirstError", func(t *testing.T) {
+				iter := ReadChunks(ctx, bytes.NewReader(stream))
+				defer iter.Close()
+
+				count := 0
+				for iter.Next() {
+					count++
+				}
+				assert.Equal(t, 1, count)
+				assert.Error(t, iter.Err())
+
+				chunkErrs := iter.ChunkErrors()
+				require.Len(t, chunkErrs, 1)
+				assert.Equal(t, 1, chunkErrs[0].Index)
+				assert.Equal(t, offset, chunkErrs[0].Offset)
+				assert.Error(t, chunkErrs[0].Err)
+				assert.True(t, errors.Is(chunkErrs[0], chunkErrs[0].Err))
+				assert.Contains(t, iter.Err().Error(), chunkErrs[0].Error())
+			})
+			t.Run("SkipCorrupt", func(t *testing.T) {
+				iter := ReadChunksWithOptions(ctx, bytes.NewReader(stream), ReadChunksOptions{SkipCorrupt: true})
+				defer iter.Close()
+
+				var first []int64
+				for iter.Next() {
+					first = append(first, iter.Chunk().Metrics[0].Values[0])
+				}
+				assert.NoError(t, iter.Err())
+				assert.Equal(t, []int64{0, 10, 15}, first)
+
+				chunkErrs := iter.ChunkErrors()
+				require.Len(t, chunkErrs, 1)
+				assert.Equal(t, 1, chunkErrs[0].Index)
+				assert.Equal(t, offset, chunkErrs[0].Offset)
+			})
+		})
+	}
+	t.Run("Valid", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		for i := int64(0); i < 10; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Int64("counter", i))))
+		}
+		require.NoError(t, cw.Flush())
+
+		iter := ReadChunks(ctx, buf)
+		defer iter.Close()
+		for iter.Next() {
+		}
+		assert.NoError(t, iter.Err())
+		assert.Empty(t, iter.ChunkErrors())
+	})
+}
+
+func TestChunkIteratorCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	cw.SetMaxSamples(5)
+	cw.SetMetadata(birch.DC.Elements(birch.EC.String("host", "localhost")))
+	for i := int64(0); i < 23; i++ {
+		require.NoError(t, cw.Add(birch.DC.Elements(
+			birch.EC.Int64("counter", i),
+			birch.EC.SubDocumentFromElements("nested", birch.EC.Int64("gauge", i%3)),
+		)))
+	}
+	require.NoError(t, cw.Flush())
+	stream := buf.Bytes()
+
+	// samples renders each sample of each chunk, along with its
+	// metadata, so that scans can be compared.
+	samples := func(t *testing.T, chunk *Chunk) []string {
+		var out []string
+		iter := chunk.Iterator(ctx)
+		defer iter.Close()
+		for iter.Next() {
+			out = append(out, chunk.GetMetadata().String()+iter.Document().String())
+		}
+		require.NoError(t, iter.Err())
+		return out
+	}
+
+	var (
+		expected [][]string
+		states   []ScanState
+	)
+	iter := ReadChunks(ctx, bytes.NewReader(stream))
+	states = append(states, iter.Checkpoint())
+	for iter.Next() {
+		expected = append(expected, samples(t, iter.Chunk()))
+		states = append(states, iter.Checkpoint())
+	}
+	require.NoError(t, iter.Err())
+	require.Len(t, expected, 5)
+	assert.Equal(t, ScanState{}, states[0])
+	assert.Equal(t, int64(len(stream)), states[len(states)-1].Offset)
+
+	for idx, state := range states {
+		state := state
+		assert.Equal(t, idx, state.Chunks)
+
+		t.Run(fmt.Sprintf("AfterChunk%d", idx), func(t *testing.T) {
+			resumed, err := ResumeChunks(ctx, bytes.NewReader(stream), state)
+			require.NoError(t, err)
+			defer resumed.Close()
+
+			assert.Equal(t, state, resumed.Checkpoint())
+
+			var rest [][]string
+			for resumed.Next() {
+				rest = append(rest, samples(t, resumed.Chunk()))
+				assert.Equal(t, states[idx+len(rest)], resumed.Checkpoint())
+			}
+			require.NoError(t, resumed.Err())
+			assert.Equal(t, expected[idx:], append([][]string{}, rest...))
+		})
+	}
+	t.Run("MappedFile", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ftdc-mapped")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+		path := filepath.Join(dir, "metrics.ftdc")
+		require.NoError(t, ioutil.WriteFile(path, stream, 0600))
+
+		mapped, err := birch.OpenMapped(path)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mapped.Close()) }()
+
+		resumed, err := ResumeChunks(ctx, mapped.NewReader(), states[3])
+		require.NoError(t, err)
+		defer resumed.Close()
+
+		var rest [][]string
+		for resumed.Next() {
+			rest = append(rest, samples(t, resumed.Chunk()))
+		}
+		require.NoError(t, resumed.Err())
+		assert.Equal(t, expected[3:], rest)
+	})
+	t.Run("InvalidState", func(t *testing.T) {
+		_, err := ResumeChunks(ctx, bytes.NewReader(stream), ScanState{Offset: -1})
+		assert.Error(t, err)
+	})
+	t.Run("Interrupted", func(t *testing.T) {
+		first := ReadChunks(ctx, bytes.NewReader(stream))
+		require.True(t, first.Next())
+		require.True(t, first.Next())
+		state := first.Checkpoint()
+		first.Close()
+
+		resumed, err := ResumeChunks(ctx, bytes.NewReader(stream), state)
+		require.NoError(t, err)
+		defer resumed.Close()
+
+		count := 0
+		for resumed.Next() {
+			count += resumed.Chunk().Size()
+		}
+		require.NoError(t, resumed.Err())
+		assert.Equal(t, 13, count)
+	})
+}
+
+func TestMergeChunkStreams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now().Truncate(time.Second)
+
+	// writeStream writes a chunk of two samples for each offset,
+	// recording the source in every sample, and sets the start time
+	// of each chunk to its offset, in seconds, from start.
+	writeStream := func(t *testing.T, source int64, offsets ...int) io.Reader {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(2)
+		for range offsets {
+			for i := int64(0); i < 2; i++ {
+				require.NoError(t, cw.Add(birch.DC.Elements(
+					birch.EC.Int64("source", source),
+					birch.EC.Int64("counter", i),
+				)))
+			}
+		}
+		require.NoError(t, cw.Close())
+
+		out := &bytes.Buffer{}
+		chunks := 0
+		for {
+			doc := &birch.Document{}
+			_, err := doc.ReadFrom(buf)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			if isNum(1, doc.Lookup("type")) {
+				doc.Set(birch.EC.Time("_id", start.Add(time.Duration(offsets[chunks])*time.Second)))
+				chunks++
+			}
+			_, err = doc.WriteTo(out)
+			require.NoError(t, err)
+		}
+		require.Equal(t, len(offsets), chunks)
+
+		return out
+	}
+
+	type chunkID struct {
+		source int64
+		offset int
+	}
+	collect := func(t *testing.T, iter *ChunkIterator) []chunkID {
+		var out []chunkID
+		for iter.Next() {
+			chunk := iter.Chunk()
+			id := chunkID{offset: int(chunk.id.Sub(start) / time.Second)}
+			for _, metric := range chunk.Metrics {
+				if metric.Key() == "source" {
+					id.source = metric.Values[0]
+				}
+			}
+			out = append(out, id)
+		}
+		return out
+	}
+
+	t.Run("Interleaved", func(t *testing.T) {
+		iter := MergeChunkStreams(ctx, []io.Reader{
+			writeStream(t, 1, 0, 2, 4, 6),
+			writeStream(t, 2, 1, 3, 4),
+			writeStream(t, 3, 4, 5),
+		})
+		defer iter.Close()
+
+		assert.Equal(t, []chunkID{
+			{1, 0}, {2, 1}, {1, 2}, {2, 3},
+			// ties keep the order of the sources
+			{1, 4}, {2, 4}, {3, 4},
+			{3, 5}, {1, 6},
+		}, collect(t, iter))
+		assert.NoError(t, iter.Err())
+		assert.Empty(t, iter.ChunkErrors())
+	})
+	t.Run("OutOfOrderSource", func(t *testing.T) {
+		iter := MergeChunkStreams(ctx, []io.Reader{
+			writeStream(t, 1, 5, 1),
+			writeStream(t, 2, 3),
+		})
+		defer iter.Close()
+
+		assert.Equal(t, []chunkID{{2, 3}, {1, 5}, {1, 1}}, collect(t, iter))
+		assert.NoError(t, iter.Err())
+	})
+	t.Run("NoSources", func(t *testing.T) {
+		iter := MergeChunkStreams(ctx, nil)
+		defer iter.Close()
+
+		assert.False(t, iter.Next())
+		assert.NoError(t, iter.Err())
+	})
+	t.Run("CorruptSource", func(t *testing.T) {
+		iter := MergeChunkStreams(ctx, []io.Reader{
+			writeStream(t, 1, 0, 2),
+			bytes.NewReader([]byte("not bson")),
+			writeStream(t, 3, 1),
+		})
+		defer iter.Close()
+
+		assert.Equal(t, []chunkID{{1, 0}, {3, 1}, {1, 2}}, collect(t, iter))
+		assert.Error(t, iter.Err())
+	})
+	t.Run("Close", func(t *testing.T) {
+		iter := MergeChunkStreams(ctx, []io.Reader{
+			writeStream(t, 1, 0, 2, 4, 6, 8, 10),
+			writeStream(t, 2, 1, 3, 5, 7, 9, 11),
+		})
+		require.True(t, iter.Next())
+		iter.Close()
+
+		for iter.Next() {
+		}
+	})
+}
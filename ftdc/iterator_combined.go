@@ -16,7 +16,7 @@ type combinedIterator struct {
 	document *birch.Document
 	pipe     chan *birch.Document
 	catcher  grip.Catcher
-	flatten  bool
+	opts     IteratorOptions
 }
 
 func (iter *combinedIterator) Close() {
@@ -51,11 +51,7 @@ func (iter *combinedIterator) worker(ctx context.Context) {
 	for iter.chunks.Next() {
 		chunk := iter.chunks.Chunk()
 
-		if iter.flatten {
-			iter.sample, ok = chunk.Iterator(ctx).(*sampleIterator)
-		} else {
-			iter.sample, ok = chunk.StructuredIterator(ctx).(*sampleIterator)
-		}
+		iter.sample, ok = chunk.IteratorWithOptions(ctx, iter.opts).(*sampleIterator)
 		if !ok {
 			iter.catcher.Add(errors.New("programmer error"))
 			return
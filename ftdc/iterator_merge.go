@@ -0,0 +1,87 @@
+package ftdc
+
+import (
+	"context"
+	"io"
+
+	"github.com/cdr/grip"
+)
+
+// MergeChunkStreams creates a ChunkIterator that interleaves the
+// chunks of several FTDC data sources, such as the diagnostic files
+// that a process rotates through, in order of the start times of the
+// chunks. Each source is read lazily, as with ReadChunks, and the
+// merge holds at most one decoded chunk from each source.
+//
+// The merge assumes that the chunks of each source are in time order,
+// and always preserves the order of the chunks within a source. When
+// the next chunks of several sources have the same start time, the
+// chunk from the source that appears first in readers is returned
+// first, so overlapping sources merge deterministically.
+//
+// A source that cannot be read stops contributing chunks without
+// stopping the merge. The errors from all sources are available from
+// Err and ChunkErrors once Next returns false. The Checkpoint method
+// of the iterator does not apply to merged sources.
+func MergeChunkStreams(ctx context.Context, readers []io.Reader) *ChunkIterator {
+	iter := &ChunkIterator{
+		catcher: grip.NewCatcher(),
+		pipe:    make(chan *Chunk, 2),
+	}
+	ctx, iter.cancel = context.WithCancel(ctx)
+
+	sources := make([]*ChunkIterator, len(readers))
+	for idx := range readers {
+		sources[idx] = ReadChunks(ctx, readers[idx])
+	}
+
+	go func() {
+		defer close(iter.pipe)
+		defer func() {
+			for _, source := range sources {
+				source.Close()
+				iter.catcher.Add(source.Err())
+
+				iter.mu.Lock()
+				iter.chunkErrs = append(iter.chunkErrs, source.ChunkErrors()...)
+				iter.mu.Unlock()
+			}
+		}()
+
+		heads := make([]*Chunk, len(sources))
+		advance := func(idx int) {
+			heads[idx] = nil
+			if sources[idx].Next() {
+				heads[idx] = sources[idx].Chunk()
+			}
+		}
+
+		for idx := range sources {
+			advance(idx)
+		}
+
+		for {
+			// the number of sources is small, so a linear
+			// scan for the earliest chunk is sufficient.
+			next := -1
+			for idx, chunk := range heads {
+				if chunk != nil && (next < 0 || chunk.id.Before(heads[next].id)) {
+					next = idx
+				}
+			}
+
+			if next < 0 {
+				return
+			}
+
+			select {
+			case iter.pipe <- heads[next]:
+				advance(next)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return iter
+}
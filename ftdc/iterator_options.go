@@ -0,0 +1,96 @@
+package ftdc
+
+import (
+	"context"
+	"time"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// IteratorOptions controls the documents that Chunk.IteratorWithOptions
+// and ReadMetricsWithOptions produce.
+type IteratorOptions struct {
+	// Structured produces documents with the structure of the
+	// source documents, as StructuredIterator does, rather than
+	// with flattened keys.
+	Structured bool
+
+	// Timestamp adds a DateTime element, named "ts", with the time
+	// of each sample to each document, for example to import
+	// samples into a MongoDB time series collection. If the
+	// document already has a "ts" element, the element is
+	// replaced.
+	//
+	// The time of each sample is the value of the time metric for
+	// that sample. When the chunk has no time metric, the time of
+	// the i-th sample is the chunk's _id, which FTDC writers set to
+	// the time that they received the first sample, plus i times
+	// Interval; when Interval is not set or the chunk has no _id,
+	// the documents of that chunk have no "ts" element.
+	Timestamp bool
+
+	// TimeKey is the key of the DateTime metric that records the
+	// time of each sample. When empty, the key is "start", as in
+	// MongoDB diagnostic data.
+	TimeKey string
+
+	// Interval is the time between consecutive samples of chunks
+	// that have no time metric.
+	Interval time.Duration
+}
+
+// IteratorWithOptions returns an iterator that reads documents for
+// each sample in the chunk, as Iterator, or StructuredIterator with
+// the Structured option, does, with the changes that the options
+// specify.
+func (c *Chunk) IteratorWithOptions(ctx context.Context, opts IteratorOptions) Iterator {
+	var times []int64
+	if opts.Timestamp {
+		times = c.sampleTimes(opts)
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	iter := &sampleIterator{
+		closer:   cancel,
+		metadata: c.GetMetadata(),
+	}
+
+	if opts.Structured {
+		iter.stream = c.streamDocuments(sctx, times)
+	} else {
+		iter.stream = c.streamFlattenedDocuments(sctx, times)
+	}
+
+	return iter
+}
+
+// sampleTimes returns the time of each sample in the chunk, in
+// milliseconds since the epoch, or nil if the chunk has no time
+// metric and either no interval or no _id to count it from. The
+// result may share memory with the chunk and must not be modified.
+func (c *Chunk) sampleTimes(opts IteratorOptions) []int64 {
+	key := opts.TimeKey
+	if key == "" {
+		key = "start"
+	}
+
+	for idx := range c.Metrics {
+		m := &c.Metrics[idx]
+		if m.originalType == bsontype.DateTime && m.Key() == key && len(m.Values) == c.nPoints {
+			return m.Values
+		}
+	}
+
+	interval := opts.Interval.Milliseconds()
+	if interval == 0 || c.id.IsZero() {
+		return nil
+	}
+
+	first := epochMs(c.id)
+	times := make([]int64, c.nPoints)
+	for i := range times {
+		times[i] = first + int64(i)*interval
+	}
+
+	return times
+}
@@ -0,0 +1,113 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestIteratorOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	base := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	// the samples are roughly one second apart.
+	offsets := []time.Duration{0, 1000 * time.Millisecond, 2100 * time.Millisecond, 3000 * time.Millisecond}
+
+	chunk := func(t *testing.T, key string) *Chunk {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		for idx, offset := range offsets {
+			require.NoError(t, cw.Add(birch.DC.Elements(
+				birch.EC.Time(key, base.Add(offset)),
+				birch.EC.SubDocumentFromElements("op", birch.EC.Int64("count", int64(idx))),
+			)))
+		}
+		require.NoError(t, cw.Flush())
+
+		iter := ReadChunks(ctx, bytes.NewReader(buf.Bytes()))
+		defer iter.Close()
+		require.True(t, iter.Next())
+		return iter.Chunk()
+	}
+
+	times := func(t *testing.T, iter Iterator) []time.Time {
+		defer iter.Close()
+		var out []time.Time
+		for iter.Next() {
+			elem := iter.Document().Lookup("ts")
+			if elem == nil {
+				out = append(out, time.Time{})
+				continue
+			}
+			out = append(out, elem.Time().UTC())
+		}
+		require.NoError(t, iter.Err())
+		return out
+	}
+
+	expected := make([]time.Time, len(offsets))
+	for i, offset := range offsets {
+		expected[i] = base.Add(offset)
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		c := chunk(t, "start")
+		assert.Equal(t, make([]time.Time, len(offsets)), times(t, c.Iterator(ctx)))
+		assert.Equal(t, make([]time.Time, len(offsets)), times(t, c.StructuredIterator(ctx)))
+	})
+	t.Run("TimeMetric", func(t *testing.T) {
+		c := chunk(t, "start")
+		assert.Equal(t, expected, times(t, c.IteratorWithOptions(ctx, IteratorOptions{Timestamp: true})))
+		assert.Equal(t, expected, times(t, c.IteratorWithOptions(ctx, IteratorOptions{Timestamp: true, Structured: true})))
+	})
+	t.Run("IntervalWithTimeMetric", func(t *testing.T) {
+		// the interval does not override the time metric.
+		c := chunk(t, "start")
+		opts := IteratorOptions{Timestamp: true, Interval: 500 * time.Millisecond}
+		assert.Equal(t, expected, times(t, c.IteratorWithOptions(ctx, opts)))
+	})
+	t.Run("TimeKey", func(t *testing.T) {
+		c := chunk(t, "when")
+		opts := IteratorOptions{Timestamp: true, TimeKey: "when"}
+		assert.Equal(t, expected, times(t, c.IteratorWithOptions(ctx, opts)))
+	})
+	t.Run("MissingTimeMetric", func(t *testing.T) {
+		c := chunk(t, "when")
+
+		// without an interval, there is no ts element.
+		assert.Equal(t, make([]time.Time, len(offsets)), times(t, c.IteratorWithOptions(ctx, IteratorOptions{Timestamp: true})))
+
+		// with an interval, the times count from the chunk's _id.
+		opts := IteratorOptions{Timestamp: true, Interval: 2 * time.Second}
+		out := times(t, c.IteratorWithOptions(ctx, opts))
+		require.Len(t, out, len(offsets))
+		for i, ts := range out {
+			assert.Equal(t, c.id.Add(time.Duration(i)*2*time.Second).Truncate(time.Millisecond).UTC(), ts)
+		}
+	})
+	t.Run("ReplacesExisting", func(t *testing.T) {
+		c := chunk(t, "ts")
+		opts := IteratorOptions{Timestamp: true, TimeKey: "ts"}
+		iter := c.IteratorWithOptions(ctx, opts)
+		defer iter.Close()
+		require.True(t, iter.Next())
+		assert.Equal(t, 2, iter.Document().Len())
+	})
+	t.Run("ReadMetricsWithOptions", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		for _, offset := range offsets {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Time("start", base.Add(offset)))))
+		}
+		require.NoError(t, cw.Flush())
+
+		iter := ReadMetricsWithOptions(ctx, bytes.NewReader(buf.Bytes()), IteratorOptions{Timestamp: true})
+		assert.Equal(t, expected, times(t, iter))
+	})
+}
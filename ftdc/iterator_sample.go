@@ -15,22 +15,32 @@ type sampleIterator struct {
 	metadata *birch.Document
 }
 
-func (c *Chunk) streamFlattenedDocuments(ctx context.Context) <-chan *birch.Document {
+// streamFlattenedDocuments sends a flattened document for each sample
+// to the channel, adding a "ts" element with the time of each sample
+// when times is not nil.
+func (c *Chunk) streamFlattenedDocuments(ctx context.Context, times []int64) <-chan *birch.Document {
 	out := make(chan *birch.Document, 100)
 
 	go func() {
 		defer close(out)
-		for i := 0; i < c.nPoints; i++ {
 
+		// every sample has the same keys, so build them once
+		// rather than joining the path of each metric per sample.
+		keys := c.Keys()
+
+		for i := 0; i < c.nPoints; i++ {
 			doc := birch.DC.Make(len(c.Metrics))
-			for _, m := range c.Metrics {
-				elem, ok := restoreFlat(m.originalType, m.Key(), m.Values[i])
+			for idx, m := range c.Metrics {
+				elem, ok := restoreFlat(m.originalType, keys[idx], m.Values[i])
 				if !ok {
 					continue
 				}
 
 				doc.Append(elem)
 			}
+			if times != nil {
+				doc.Set(birch.EC.DateTime("ts", times[i]))
+			}
 
 			select {
 			case out <- doc:
@@ -44,7 +54,9 @@ func (c *Chunk) streamFlattenedDocuments(ctx context.Context) <-chan *birch.Docu
 	return out
 }
 
-func (c *Chunk) streamDocuments(ctx context.Context) <-chan *birch.Document {
+// streamDocuments sends a structured document for each sample to the
+// channel, as streamFlattenedDocuments does.
+func (c *Chunk) streamDocuments(ctx context.Context, times []int64) <-chan *birch.Document {
 	out := make(chan *birch.Document, 100)
 
 	go func() {
@@ -52,6 +64,9 @@ func (c *Chunk) streamDocuments(ctx context.Context) <-chan *birch.Document {
 
 		for i := 0; i < c.nPoints; i++ {
 			doc, _ := restoreDocument(c.reference, i, c.Metrics, 0)
+			if times != nil {
+				doc.Set(birch.EC.DateTime("ts", times[i]))
+			}
 			select {
 			case <-ctx.Done():
 				return
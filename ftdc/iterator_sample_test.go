@@ -14,7 +14,7 @@ func TestSampleIterator(t *testing.T) {
 		chunk := &Chunk{
 			nPoints: 2,
 		}
-		out := chunk.streamDocuments(ctx)
+		out := chunk.streamDocuments(ctx, nil)
 		assert.NotNil(t, out)
 		for {
 			doc, ok := <-out
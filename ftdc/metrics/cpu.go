@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/process"
+	"github.com/tychoish/birch"
+)
+
+// ProcessCPU collects the CPU times and utilization of a process, to
+// record alongside the memory metrics of the runtime collector.
+type ProcessCPU struct {
+	mu   sync.Mutex
+	proc *process.Process
+}
+
+// NewProcessCPU returns a ProcessCPU for the process with the given
+// PID, or for the current process if pid is 0. It returns an error
+// if the process does not exist.
+func NewProcessCPU(pid int32) (*ProcessCPU, error) {
+	proc, err := findProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcessCPU{proc: proc}, nil
+}
+
+// findProcess returns the process with the given PID, or the current
+// process if pid is 0.
+func findProcess(pid int32) (*process.Process, error) {
+	if pid == 0 {
+		pid = int32(os.Getpid())
+	}
+
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding process %d", pid)
+	}
+
+	return proc, nil
+}
+
+// Collect returns a document with the PID of the process, the user,
+// system, and iowait times of the process in seconds, and its CPU
+// utilization as a percentage, where 100 is one CPU.
+//
+// The utilization covers the time since the previous call to
+// Collect, so it is always 0 for the first call; discard the first
+// sample, or call Collect once before collecting samples, if the
+// utilization of every sample matters.
+func (c *ProcessCPU) Collect(ctx context.Context) (*birch.Document, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	percent, err := c.proc.PercentWithContext(ctx, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem collecting cpu percent for process %d", c.proc.Pid)
+	}
+
+	times, err := c.proc.TimesWithContext(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem collecting cpu times for process %d", c.proc.Pid)
+	}
+
+	return marshalProcessCPU(c.proc.Pid, times, percent), nil
+}
+
+// Collector returns a CustomCollector, named "cpu", that adds the
+// document that Collect returns to each sample of CollectRuntime.
+// Since every sample must have the same metrics, the document holds
+// zeros for the times and utilization when collection fails, for
+// example after the process exits.
+func (c *ProcessCPU) Collector() CustomCollector {
+	return CustomCollector{
+		Name: "cpu",
+		Operation: func(ctx context.Context) *birch.Document {
+			doc, err := c.Collect(ctx)
+			if err != nil {
+				return marshalProcessCPU(c.proc.Pid, &cpu.TimesStat{}, 0)
+			}
+			return doc
+		},
+	}
+}
+
+func marshalProcessCPU(pid int32, times *cpu.TimesStat, percent float64) *birch.Document {
+	return birch.DC.Elements(
+		birch.EC.Int32("pid", pid),
+		birch.EC.Double("user", times.User),
+		birch.EC.Double("system", times.System),
+		birch.EC.Double("iowait", times.Iowait),
+		birch.EC.Double("percent", percent))
+}
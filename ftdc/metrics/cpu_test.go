@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestProcessCPU(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("Collect", func(t *testing.T) {
+		collector, err := NewProcessCPU(0)
+		require.NoError(t, err)
+
+		doc, err := collector.Collect(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pid", "user", "system", "iowait", "percent"}, doc.KeyNames())
+		assert.Equal(t, int32(os.Getpid()), doc.Lookup("pid").Int32())
+		assert.Equal(t, 0.0, doc.Lookup("percent").Double(), "the first sample has no utilization")
+		for _, key := range []string{"user", "system", "iowait"} {
+			assert.Equal(t, bsontype.Double, doc.Lookup(key).Type())
+		}
+
+		// spend some cpu time so that the next sample has
+		// measurable utilization.
+		sum := 0.0
+		for i := 0; i < 1e7; i++ {
+			sum += math.Sqrt(float64(i))
+		}
+		require.True(t, sum > 0)
+
+		next, err := collector.Collect(ctx)
+		require.NoError(t, err)
+		assert.True(t, next.Lookup("user").Double()+next.Lookup("system").Double() >= doc.Lookup("user").Double()+doc.Lookup("system").Double())
+		assert.True(t, next.Lookup("percent").Double() >= 0)
+	})
+	t.Run("Collector", func(t *testing.T) {
+		collector, err := NewProcessCPU(int32(os.Getpid()))
+		require.NoError(t, err)
+
+		opts := CollectOptions{SkipSystem: true, SkipProcess: true, Collectors: Collectors{collector.Collector()}}
+		doc := opts.generate(ctx, 1)
+		cpu := doc.Lookup("cpu")
+		require.NotNil(t, cpu)
+		assert.Equal(t, 5, cpu.MutableDocument().Len())
+	})
+	t.Run("MissingProcess", func(t *testing.T) {
+		_, err := NewProcessCPU(math.MaxInt32)
+		assert.Error(t, err)
+	})
+}
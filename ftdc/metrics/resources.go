@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/process"
+	"github.com/tychoish/birch"
+)
+
+// ProcessResources collects counts of the resources that a process
+// holds, which grow when the process leaks them: open file
+// descriptors and threads, along with its context switches.
+type ProcessResources struct {
+	proc *process.Process
+}
+
+// NewProcessResources returns a ProcessResources for the process with
+// the given PID, or for the current process if pid is 0. It returns
+// an error if the process does not exist.
+func NewProcessResources(pid int32) (*ProcessResources, error) {
+	proc, err := findProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcessResources{proc: proc}, nil
+}
+
+// Collect returns a document with the PID of the process, the number
+// of its open file descriptors ("fds") and threads, and the number of
+// voluntary and involuntary context switches of the process.
+//
+// Metrics that the platform does not provide, or that cannot be
+// collected, such as the descriptors of another user's process, are
+// omitted from the document.
+func (c *ProcessResources) Collect(ctx context.Context) *birch.Document {
+	doc := birch.DC.Elements(birch.EC.Int32("pid", c.proc.Pid))
+
+	if fds, err := c.proc.NumFDsWithContext(ctx); err == nil {
+		doc.Append(birch.EC.Int32("fds", fds))
+	}
+
+	if threads, err := c.proc.NumThreadsWithContext(ctx); err == nil {
+		doc.Append(birch.EC.Int32("threads", threads))
+	}
+
+	if switches, err := c.proc.NumCtxSwitchesWithContext(ctx); err == nil && switches != nil {
+		doc.Append(birch.EC.SubDocumentFromElements("ctxSwitches",
+			birch.EC.Int64("voluntary", switches.Voluntary),
+			birch.EC.Int64("involuntary", switches.Involuntary)))
+	}
+
+	return doc
+}
+
+// Collector returns a CustomCollector, named "resources", that adds
+// the document that Collect returns to each sample of CollectRuntime.
+func (c *ProcessResources) Collector() CustomCollector {
+	return CustomCollector{Name: "resources", Operation: c.Collect}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessResources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("Collect", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("file descriptor counts require linux")
+		}
+
+		collector, err := NewProcessResources(0)
+		require.NoError(t, err)
+
+		doc := collector.Collect(ctx)
+		assert.Equal(t, []string{"pid", "fds", "threads", "ctxSwitches"}, doc.KeyNames())
+		assert.Equal(t, int32(os.Getpid()), doc.Lookup("pid").Int32())
+		assert.True(t, doc.Lookup("fds").Int32() > 0)
+		assert.True(t, doc.Lookup("threads").Int32() > 0)
+		assert.True(t, doc.RecursiveLookup("ctxSwitches", "voluntary").Int64() >= 0)
+	})
+	t.Run("Collector", func(t *testing.T) {
+		collector, err := NewProcessResources(int32(os.Getpid()))
+		require.NoError(t, err)
+
+		opts := CollectOptions{SkipSystem: true, SkipProcess: true, Collectors: Collectors{collector.Collector()}}
+		doc := opts.generate(ctx, 1)
+		resources := doc.Lookup("resources")
+		require.NotNil(t, resources)
+		assert.Equal(t, int32(os.Getpid()), resources.MutableDocument().Lookup("pid").Int32())
+	})
+	t.Run("MissingProcess", func(t *testing.T) {
+		_, err := NewProcessResources(math.MaxInt32)
+		assert.Error(t, err)
+	})
+}
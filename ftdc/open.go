@@ -0,0 +1,79 @@
+package ftdc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// the gzip magic number followed by the deflate method, the
+	// only one that gzip defines: the magic number alone also
+	// begins uncompressed files whose first document is 35,615
+	// bytes long.
+	gzipMagic = []byte{0x1f, 0x8b, 0x08}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// OpenMaybeCompressed opens the file at path for reading, and
+// decompresses its contents if the file is gzip compressed, as
+// identified by the first bytes of the file rather than by its name,
+// so that ReadChunks and the other readers can read compressed
+// diagnostic data directly. Files that are not compressed are read as
+// they are. Closing the reader closes the file.
+//
+// OpenMaybeCompressed returns an error if the file cannot be opened,
+// if the gzip header is invalid, or if the file is zstd compressed,
+// which is not supported.
+func OpenMaybeCompressed(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening '%s'", path)
+	}
+
+	buf := bufio.NewReader(file)
+
+	// a short file is not compressed, and produces an error when
+	// read, if it is not valid.
+	magic, _ := buf.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buf)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "problem reading gzip header of '%s'", path)
+		}
+		return &compressedFile{Reader: gz, decompressor: gz, file: file}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		_ = file.Close()
+		return nil, errors.Errorf("'%s' is zstd compressed, which is not supported", path)
+	default:
+		return &compressedFile{Reader: buf, file: file}, nil
+	}
+}
+
+// compressedFile reads a file through a buffer and, if the file is
+// compressed, a decompressor, and closes both.
+type compressedFile struct {
+	io.Reader
+	decompressor io.Closer
+	file         *os.File
+}
+
+func (f *compressedFile) Close() error {
+	var err error
+	if f.decompressor != nil {
+		err = f.decompressor.Close()
+	}
+
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,97 @@
+package ftdc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestOpenMaybeCompressed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "ftdc-open")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	cw.SetMaxSamples(5)
+	for i := int64(0); i < 20; i++ {
+		require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Int64("counter", i))))
+	}
+	require.NoError(t, cw.Flush())
+	data := buf.Bytes()
+
+	write := func(t *testing.T, name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, content, 0600))
+		return path
+	}
+
+	gzipped := &bytes.Buffer{}
+	gz := gzip.NewWriter(gzipped)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	for name, path := range map[string]string{
+		"Plain": write(t, "metrics.ftdc", data),
+		"Gzip":  write(t, "metrics.ftdc.gz", gzipped.Bytes()),
+		// detection does not depend on the file name.
+		"GzipWithoutExtension": write(t, "gzipped", gzipped.Bytes()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := OpenMaybeCompressed(path)
+			require.NoError(t, err)
+
+			iter := ReadChunks(ctx, r)
+			chunks := 0
+			for iter.Next() {
+				assert.Equal(t, 5, iter.Chunk().Size())
+				chunks++
+			}
+			require.NoError(t, iter.Err())
+			assert.Equal(t, 4, chunks)
+			assert.NoError(t, r.Close())
+		})
+	}
+	t.Run("ShortFile", func(t *testing.T) {
+		r, err := OpenMaybeCompressed(write(t, "short", []byte{0x1f}))
+		require.NoError(t, err)
+		out, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x1f}, out)
+		assert.NoError(t, r.Close())
+	})
+	t.Run("Zstd", func(t *testing.T) {
+		_, err := OpenMaybeCompressed(write(t, "metrics.zst", []byte{0x28, 0xb5, 0x2f, 0xfd, 0, 0}))
+		assert.Error(t, err)
+	})
+	t.Run("GzipMagicNumberOnly", func(t *testing.T) {
+		// without the deflate method, the file is not compressed.
+		content := []byte{0x1f, 0x8b, 0, 0, 0}
+		r, err := OpenMaybeCompressed(write(t, "plain", content))
+		require.NoError(t, err)
+		out, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, content, out)
+		assert.NoError(t, r.Close())
+	})
+	t.Run("InvalidGzip", func(t *testing.T) {
+		_, err := OpenMaybeCompressed(write(t, "bad.gz", []byte{0x1f, 0x8b, 0x08, 0}))
+		assert.Error(t, err)
+	})
+	t.Run("Missing", func(t *testing.T) {
+		_, err := OpenMaybeCompressed(filepath.Join(dir, "missing"))
+		assert.Error(t, err)
+	})
+}
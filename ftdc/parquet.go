@@ -0,0 +1,434 @@
+package ftdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// WriteParquet writes a sequence of metric documents, such as the
+// samples that ReadMetrics returns, to w as a Parquet file with one
+// row per document and one column per metric. The columns are the
+// union of the metrics of all documents, in order of first
+// appearance, and are optional, so that a row holds a null for each
+// metric that is missing from its document.
+//
+// Documents are flattened as they are for FTDC chunks: columns are
+// named by the dot-separated path to each metric, and strings, object
+// IDs and the other values that are not metrics are ignored. Metrics
+// map to Parquet types as follows:
+//
+//   - Boolean: BOOLEAN.
+//   - Int32 and Int64: INT64.
+//   - Double: DOUBLE. Columns that mix doubles with other types are
+//     DOUBLE, and hold the other values converted to doubles.
+//   - DateTime: INT64 with the TIMESTAMP_MILLIS converted type.
+//   - Timestamp: two INT64 columns, the seconds in milliseconds and
+//     the increment, which has an ".inc" suffix.
+//
+// Columns that mix other types are INT64, and hold booleans as 0 and
+// 1 and datetimes as milliseconds since the epoch.
+//
+// The file has a single row group of uncompressed, PLAIN encoded data
+// pages. WriteParquet returns an error if the documents have no
+// metrics.
+func WriteParquet(w io.Writer, docs []*birch.Document) error {
+	var (
+		columns []*parquetColumn
+		index   = map[string]*parquetColumn{}
+	)
+
+	for row, doc := range docs {
+		if doc == nil {
+			return errors.Errorf("document %d is nil", row)
+		}
+
+		for _, metric := range metricForDocument([]string{}, doc) {
+			key := metric.Key()
+			col, ok := index[key]
+			if !ok {
+				col = &parquetColumn{name: key}
+				index[key] = col
+				columns = append(columns, col)
+			}
+
+			col.set(row, metric.startingValue, metric.originalType)
+		}
+	}
+
+	if len(columns) == 0 {
+		return errors.New("documents have no metrics to export")
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("PAR1")
+
+	rowGroup := writeParquetRowGroup(out, 0, columns, len(docs))
+	writeParquetFooter(out, columns, []interface{}{rowGroup}, int64(len(docs)))
+
+	_, err := w.Write(out.Bytes())
+
+	return errors.Wrap(err, "problem writing parquet data")
+}
+
+// writeParquetRowGroup writes a data page for each column to out, and
+// returns the metadata for the row group. The offset is the position
+// in the file of the start of out.
+func writeParquetRowGroup(out *bytes.Buffer, offset int64, columns []*parquetColumn, rows int) thriftStruct {
+	chunks := make([]interface{}, len(columns))
+	var totalSize int64
+	for idx, col := range columns {
+		col.pad(rows)
+
+		start := offset + int64(out.Len())
+		col.writePage(out)
+		size := offset + int64(out.Len()) - start
+		totalSize += size
+
+		chunks[idx] = thriftStruct{
+			{2, start},
+			{3, thriftStruct{
+				{1, col.physicalType()},
+				{2, thriftList{thriftI32, []interface{}{parquetEncodingPlain, parquetEncodingRLE}}},
+				{3, thriftList{thriftBinary, []interface{}{col.name}}},
+				{4, int32(0)},
+				{5, int64(rows)},
+				{6, size},
+				{7, size},
+				{9, start},
+			}},
+		}
+	}
+
+	return thriftStruct{
+		{1, thriftList{thriftStructType, chunks}},
+		{2, totalSize},
+		{3, int64(rows)},
+	}
+}
+
+// writeParquetFooter writes the file metadata, its length and the
+// closing magic number to out.
+func writeParquetFooter(out *bytes.Buffer, columns []*parquetColumn, rowGroups []interface{}, rows int64) {
+	schema := make([]interface{}, 0, len(columns)+1)
+	schema = append(schema, thriftStruct{{4, "schema"}, {5, int32(len(columns))}})
+	for _, col := range columns {
+		elem := thriftStruct{{1, col.physicalType()}, {3, parquetOptional}, {4, col.name}}
+		if col.kind == parquetTimestamp {
+			elem = append(elem, thriftField{6, parquetTimestampMillis})
+		}
+		schema = append(schema, elem)
+	}
+
+	footer := &bytes.Buffer{}
+	writeThriftStruct(footer, thriftStruct{
+		{1, int32(1)},
+		{2, thriftList{thriftStructType, schema}},
+		{3, rows},
+		{4, thriftList{thriftStructType, rowGroups}},
+		{6, "birch ftdc"},
+	})
+
+	out.Write(footer.Bytes())
+
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(footer.Len()))
+	out.Write(size[:])
+	out.WriteString("PAR1")
+}
+
+const (
+	parquetBoolean int32 = 0
+	parquetInt64   int32 = 2
+	parquetDouble  int32 = 5
+
+	parquetOptional        int32 = 1
+	parquetTimestampMillis int32 = 9
+	parquetEncodingPlain   int32 = 0
+	parquetEncodingRLE     int32 = 3
+)
+
+type parquetKind int
+
+const (
+	parquetUnknown parquetKind = iota
+	parquetBool
+	parquetInt
+	parquetTimestamp
+	parquetFloat
+)
+
+func parquetKindOf(t bsontype.Type) parquetKind {
+	switch t {
+	case bsontype.Boolean:
+		return parquetBool
+	case bsontype.DateTime:
+		return parquetTimestamp
+	case bsontype.Double:
+		return parquetFloat
+	default:
+		return parquetInt
+	}
+}
+
+// merge returns the kind of a column that holds values of both kinds.
+func (k parquetKind) merge(other parquetKind) parquetKind {
+	switch {
+	case k == parquetUnknown, k == other:
+		return other
+	case k == parquetFloat || other == parquetFloat:
+		return parquetFloat
+	default:
+		return parquetInt
+	}
+}
+
+func (k parquetKind) String() string {
+	switch k {
+	case parquetBool:
+		return "boolean"
+	case parquetInt:
+		return "int64"
+	case parquetTimestamp:
+		return "timestamp"
+	case parquetFloat:
+		return "double"
+	default:
+		return "unknown"
+	}
+}
+
+type parquetColumn struct {
+	name    string
+	kind    parquetKind
+	values  []int64
+	types   []bsontype.Type
+	present []bool
+
+	// fixed is set once a row group has been written, after
+	// which the type of the column cannot change.
+	fixed bool
+}
+
+func (c *parquetColumn) set(row int, value int64, t bsontype.Type) {
+	c.pad(row)
+	if len(c.present) > row {
+		// keep the first of duplicate keys.
+		return
+	}
+
+	c.values = append(c.values, value)
+	c.types = append(c.types, t)
+	c.present = append(c.present, true)
+
+	if c.fixed {
+		return
+	}
+
+	c.kind = c.kind.merge(parquetKindOf(t))
+}
+
+// accepts reports whether the column can hold a value of the type
+// without changing its type, which is fixed once a row group has been
+// written.
+func (c *parquetColumn) accepts(t bsontype.Type) bool {
+	return !c.fixed || c.kind.merge(parquetKindOf(t)) == c.kind
+}
+
+// pad adds nulls to the column for rows without the metric.
+func (c *parquetColumn) pad(rows int) {
+	for len(c.present) < rows {
+		c.values = append(c.values, 0)
+		c.types = append(c.types, bsontype.Null)
+		c.present = append(c.present, false)
+	}
+}
+
+// reset removes the values of the column, after writing a row group,
+// and fixes its type for the following row groups.
+func (c *parquetColumn) reset() {
+	c.values = c.values[:0]
+	c.types = c.types[:0]
+	c.present = c.present[:0]
+	c.fixed = true
+}
+
+func (c *parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case parquetBool:
+		return parquetBoolean
+	case parquetFloat:
+		return parquetDouble
+	default:
+		return parquetInt64
+	}
+}
+
+// writePage writes the column as a single data page: the definition
+// levels, which are 1 for values and 0 for nulls, then the values.
+func (c *parquetColumn) writePage(out *bytes.Buffer) {
+	levels := &bytes.Buffer{}
+	for start := 0; start < len(c.present); {
+		end := start
+		for end < len(c.present) && c.present[end] == c.present[start] {
+			end++
+		}
+
+		putUvarint(levels, uint64(end-start)<<1)
+		if c.present[start] {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		start = end
+	}
+
+	data := &bytes.Buffer{}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(levels.Len()))
+	data.Write(size[:])
+	data.Write(levels.Bytes())
+
+	var bits, nbits byte
+	var value [8]byte
+	for idx, ok := range c.present {
+		if !ok {
+			continue
+		}
+
+		switch c.kind {
+		case parquetBool:
+			if c.values[idx] != 0 {
+				bits |= 1 << nbits
+			}
+			nbits++
+			if nbits == 8 {
+				data.WriteByte(bits)
+				bits, nbits = 0, 0
+			}
+		case parquetFloat:
+			f := float64(c.values[idx])
+			if c.types[idx] == bsontype.Double {
+				f = restoreFloat(c.values[idx])
+			}
+			binary.LittleEndian.PutUint64(value[:], math.Float64bits(f))
+			data.Write(value[:])
+		default:
+			binary.LittleEndian.PutUint64(value[:], uint64(c.values[idx]))
+			data.Write(value[:])
+		}
+	}
+	if nbits > 0 {
+		data.WriteByte(bits)
+	}
+
+	writeThriftStruct(out, thriftStruct{
+		{1, int32(0)},
+		{2, int32(data.Len())},
+		{3, int32(data.Len())},
+		{5, thriftStruct{
+			{1, int32(len(c.present))},
+			{2, parquetEncodingPlain},
+			{3, parquetEncodingRLE},
+			{4, parquetEncodingRLE},
+		}},
+	})
+	out.Write(data.Bytes())
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Parquet metadata uses the thrift compact protocol. These types
+// represent the subset of thrift that the metadata needs.
+
+const (
+	thriftI32        byte = 5
+	thriftI64        byte = 6
+	thriftBinary     byte = 8
+	thriftListType   byte = 9
+	thriftStructType byte = 12
+)
+
+type thriftField struct {
+	id    int16
+	value interface{}
+}
+
+// thriftStruct is a struct with its fields in order of their ids.
+type thriftStruct []thriftField
+
+type thriftList struct {
+	elemType byte
+	items    []interface{}
+}
+
+func writeThriftStruct(buf *bytes.Buffer, fields thriftStruct) {
+	var last int16
+	for _, field := range fields {
+		fieldType := thriftType(field.value)
+		if delta := field.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | fieldType)
+		} else {
+			buf.WriteByte(fieldType)
+			putVarint(buf, int64(field.id))
+		}
+		last = field.id
+
+		writeThriftValue(buf, field.value)
+	}
+	buf.WriteByte(0)
+}
+
+func thriftType(value interface{}) byte {
+	switch value.(type) {
+	case int32:
+		return thriftI32
+	case int64:
+		return thriftI64
+	case string:
+		return thriftBinary
+	case thriftList:
+		return thriftListType
+	default:
+		return thriftStructType
+	}
+}
+
+func writeThriftValue(buf *bytes.Buffer, value interface{}) {
+	switch val := value.(type) {
+	case int32:
+		putVarint(buf, int64(val))
+	case int64:
+		putVarint(buf, val)
+	case string:
+		putUvarint(buf, uint64(len(val)))
+		buf.WriteString(val)
+	case thriftList:
+		if len(val.items) < 15 {
+			buf.WriteByte(byte(len(val.items))<<4 | val.elemType)
+		} else {
+			buf.WriteByte(0xf0 | val.elemType)
+			putUvarint(buf, uint64(len(val.items)))
+		}
+		for _, item := range val.items {
+			writeThriftValue(buf, item)
+		}
+	case thriftStruct:
+		writeThriftStruct(buf, val)
+	}
+}
+
+func putVarint(buf *bytes.Buffer, n int64) {
+	var out [binary.MaxVarintLen64]byte
+	buf.Write(out[:binary.PutVarint(out[:], n)])
+}
+
+func putUvarint(buf *bytes.Buffer, n uint64) {
+	var out [binary.MaxVarintLen64]byte
+	buf.Write(out[:binary.PutUvarint(out[:], n)])
+}
@@ -0,0 +1,225 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParquetExportOptions controls the samples and metrics that
+// ExportParquet writes.
+type ParquetExportOptions struct {
+	// Start and End limit the export to the samples taken at or
+	// after Start and before End. The time of each sample is
+	// computed as for the Timestamp option of IteratorOptions,
+	// using TimeKey and Interval, and when either limit is set,
+	// samples without a time are not exported. The zero value of
+	// each disables that limit.
+	Start time.Time
+	End   time.Time
+
+	// TimeKey and Interval have the same meaning as in
+	// IteratorOptions, and are only used with Start or End.
+	TimeKey  string
+	Interval time.Duration
+
+	// Include limits the export to the metrics whose keys are, or
+	// are beneath, one of these keys; for example, "mem" includes
+	// "mem.resident". When empty, all metrics are exported.
+	Include []string
+
+	// RowGroupSize is the number of rows in each row group, which
+	// bounds the memory that ExportParquet uses. When zero, row
+	// groups have 10,000 rows.
+	RowGroupSize int
+
+	// DropSchemaChanges writes nulls for the values that the schema
+	// of the file, which the first row group fixes, cannot hold:
+	// metrics that first appear after the first row group, and
+	// values whose type does not fit their column. By default,
+	// ExportParquet returns an error for these values rather than
+	// lose data.
+	DropSchemaChanges bool
+}
+
+func (opts ParquetExportOptions) includes(key string) bool {
+	if len(opts.Include) == 0 {
+		return true
+	}
+
+	for _, prefix := range opts.Include {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (opts ParquetExportOptions) inRange(ts int64) bool {
+	if !opts.Start.IsZero() && ts < epochMs(opts.Start) {
+		return false
+	}
+
+	return opts.End.IsZero() || ts < epochMs(opts.End)
+}
+
+// ExportParquet reads all chunks from the iterator and writes their
+// samples to w as a Parquet file with one row per sample, as
+// WriteParquet does, without holding more than one row group of
+// samples in memory, so that it can convert inputs of any size.
+//
+// The columns of the file are the metrics of the samples in the first
+// row group, named by their keys as Chunk.Keys returns them, in order
+// of first appearance, with the types that WriteParquet uses. A row
+// holds a null for each metric that its chunk does not have. Because
+// the schema is fixed by the first row group, later samples may have
+// metrics that have no column, or values that their column cannot
+// hold without loss, such as doubles in an int64 column. Integers are
+// converted to doubles for double columns; for the other cases,
+// ExportParquet returns an error naming the metric, unless
+// DropSchemaChanges is set.
+//
+// ExportParquet returns an error if no samples or metrics match the
+// options, if the schema changes, if the context is canceled, or if
+// the iterator encounters an error. Because the file is written as it
+// is read, w may hold a partial file after an error.
+func ExportParquet(ctx context.Context, iter *ChunkIterator, w io.Writer, opts ParquetExportOptions) error {
+	if opts.RowGroupSize < 0 {
+		return errors.Errorf("row group size must not be negative, not %d", opts.RowGroupSize)
+	}
+	if opts.RowGroupSize == 0 {
+		opts.RowGroupSize = 10000
+	}
+	filterTime := !opts.Start.IsZero() || !opts.End.IsZero()
+
+	var (
+		columns   []*parquetColumn
+		index     = map[string]*parquetColumn{}
+		rowGroups []interface{}
+		offset    int64
+		total     int64
+		rows      int
+	)
+
+	out := &bytes.Buffer{}
+	write := func() error {
+		n, err := w.Write(out.Bytes())
+		offset += int64(n)
+		out.Reset()
+		return errors.Wrap(err, "problem writing parquet data")
+	}
+
+	flush := func() error {
+		if rows == 0 {
+			return nil
+		}
+
+		rowGroups = append(rowGroups, writeParquetRowGroup(out, offset, columns, rows))
+		for _, col := range columns {
+			col.reset()
+		}
+		total += int64(rows)
+		rows = 0
+
+		return write()
+	}
+
+	out.WriteString("PAR1")
+	if err := write(); err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "operation aborted")
+		}
+
+		chunk := iter.Chunk()
+
+		var times []int64
+		if filterTime {
+			times = chunk.sampleTimes(IteratorOptions{TimeKey: opts.TimeKey, Interval: opts.Interval})
+			if times == nil {
+				continue
+			}
+		}
+
+		keys := chunk.Keys()
+		chunkColumns := make([]*parquetColumn, len(chunk.Metrics))
+		late := -1
+		for idx, key := range keys {
+			if !opts.includes(key) {
+				continue
+			}
+
+			col, ok := index[key]
+			if !ok {
+				if rowGroups != nil {
+					if late < 0 {
+						late = idx
+					}
+					continue
+				}
+
+				col = &parquetColumn{name: key}
+				index[key] = col
+				columns = append(columns, col)
+			}
+			chunkColumns[idx] = col
+		}
+
+		for i := 0; i < chunk.nPoints; i++ {
+			if filterTime && !opts.inRange(times[i]) {
+				continue
+			}
+
+			if late >= 0 && !opts.DropSchemaChanges {
+				return errors.Errorf("metric '%s' first appears after the first row group, at row %d", keys[late], total+int64(rows))
+			}
+
+			for idx, col := range chunkColumns {
+				if col == nil {
+					continue
+				}
+
+				metric := chunk.Metrics[idx]
+				if !col.accepts(metric.originalType) {
+					if opts.DropSchemaChanges {
+						continue
+					}
+					return errors.Errorf("metric '%s' has a %s value at row %d, which its %s column cannot hold",
+						col.name, metric.originalType, total+int64(rows), col.kind)
+				}
+				col.set(rows, metric.Values[i], metric.originalType)
+			}
+
+			rows++
+			if rows == opts.RowGroupSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return errors.Wrap(err, "problem reading chunks")
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if len(columns) == 0 || total == 0 {
+		return errors.New("no samples or metrics to export")
+	}
+
+	writeParquetFooter(out, columns, rowGroups, total)
+
+	return write()
+}
@@ -0,0 +1,169 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestExportParquet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Unix(1600000000, 0)
+
+	data := produceMockChunks(25, 8, func(idx int) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Time("start", start.Add(time.Duration(idx)*time.Second)),
+			birch.EC.Int64("ops", int64(idx)),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Int32("resident", int32(idx*2))),
+			birch.EC.Boolean("ok", idx%2 == 0),
+		)
+	})
+
+	export := func(opts ParquetExportOptions) ([]byte, error) {
+		out := &bytes.Buffer{}
+		err := ExportParquet(ctx, ReadChunks(ctx, bytes.NewReader(data)), out, opts)
+		return out.Bytes(), err
+	}
+
+	rowGroups := func(t *testing.T, data []byte) int {
+		size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+		footer := readThrift(t, bytes.NewReader(data[len(data)-8-size:len(data)-8]))
+		return len(footer[4].([]interface{}))
+	}
+
+	column := func(columns []parquetTestColumn, name string) []interface{} {
+		for _, col := range columns {
+			if col.name == name {
+				return col.values
+			}
+		}
+		return nil
+	}
+
+	t.Run("All", func(t *testing.T) {
+		out, err := export(ParquetExportOptions{RowGroupSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 3, rowGroups(t, out))
+
+		rows, columns := readParquet(t, out)
+		assert.Equal(t, int64(25), rows)
+		require.Len(t, columns, 4)
+		assert.Equal(t, "start", columns[0].name)
+		assert.Equal(t, int64(parquetTimestampMillis), columns[0].convertedType)
+
+		ops := column(columns, "ops")
+		resident := column(columns, "mem.resident")
+		ok := column(columns, "ok")
+		require.Len(t, ops, 25)
+		for idx := range ops {
+			assert.Equal(t, int64(idx), ops[idx])
+			assert.Equal(t, int64(idx*2), resident[idx])
+			assert.Equal(t, idx%2 == 0, ok[idx])
+			assert.Equal(t, epochMs(start)+int64(idx)*1000, columns[0].values[idx])
+		}
+	})
+	t.Run("DefaultRowGroupSize", func(t *testing.T) {
+		out, err := export(ParquetExportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, rowGroups(t, out))
+	})
+	t.Run("Include", func(t *testing.T) {
+		out, err := export(ParquetExportOptions{Include: []string{"mem", "ok"}})
+		require.NoError(t, err)
+
+		_, columns := readParquet(t, out)
+		require.Len(t, columns, 2)
+		assert.Equal(t, "mem.resident", columns[0].name)
+		assert.Equal(t, "ok", columns[1].name)
+	})
+	t.Run("TimeRange", func(t *testing.T) {
+		out, err := export(ParquetExportOptions{
+			Start:        start.Add(5 * time.Second),
+			End:          start.Add(15 * time.Second),
+			RowGroupSize: 4,
+		})
+		require.NoError(t, err)
+
+		rows, columns := readParquet(t, out)
+		assert.Equal(t, int64(10), rows)
+		ops := column(columns, "ops")
+		assert.Equal(t, int64(5), ops[0])
+		assert.Equal(t, int64(14), ops[len(ops)-1])
+
+		out, err = export(ParquetExportOptions{Start: start.Add(20 * time.Second)})
+		require.NoError(t, err)
+		rows, _ = readParquet(t, out)
+		assert.Equal(t, int64(5), rows)
+	})
+	t.Run("ChangingMetrics", func(t *testing.T) {
+		data := produceMockChunks(6, 3, func(idx int) *birch.Document {
+			doc := birch.DC.Elements(birch.EC.Int64("a", int64(idx)))
+			if idx < 3 {
+				return doc.Append(birch.EC.Double("b", float64(idx)+0.5))
+			}
+			return doc.Append(birch.EC.Int64("c", 1))
+		})
+
+		err := ExportParquet(ctx, ReadChunks(ctx, bytes.NewReader(data)), &bytes.Buffer{}, ParquetExportOptions{RowGroupSize: 3})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "'c'")
+
+		out := &bytes.Buffer{}
+		require.NoError(t, ExportParquet(ctx, ReadChunks(ctx, bytes.NewReader(data)), out, ParquetExportOptions{RowGroupSize: 3, DropSchemaChanges: true}))
+
+		rows, columns := readParquet(t, out.Bytes())
+		assert.Equal(t, int64(6), rows)
+		require.Len(t, columns, 2, "metrics after the first row group are dropped")
+		assert.Equal(t, []interface{}{int64(0), int64(1), int64(2), int64(3), int64(4), int64(5)}, columns[0].values)
+		assert.Equal(t, "b", columns[1].name)
+		assert.Equal(t, []interface{}{0.5, 1.5, 2.5, nil, nil, nil}, columns[1].values)
+	})
+	t.Run("ChangingTypes", func(t *testing.T) {
+		data := produceMockChunks(4, 0, func(idx int) *birch.Document {
+			if idx < 2 {
+				return birch.DC.Elements(
+					birch.EC.Double("latency", float64(idx)+0.5),
+					birch.EC.Int64("ops", int64(idx)))
+			}
+			return birch.DC.Elements(
+				birch.EC.Int64("latency", int64(idx)),
+				birch.EC.Double("ops", float64(idx)+0.25))
+		})
+
+		err := ExportParquet(ctx, ReadChunks(ctx, bytes.NewReader(data)), &bytes.Buffer{}, ParquetExportOptions{RowGroupSize: 2})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "'ops'")
+
+		out := &bytes.Buffer{}
+		require.NoError(t, ExportParquet(ctx, ReadChunks(ctx, bytes.NewReader(data)), out, ParquetExportOptions{RowGroupSize: 2, DropSchemaChanges: true}))
+
+		rows, columns := readParquet(t, out.Bytes())
+		assert.Equal(t, int64(4), rows)
+		require.Len(t, columns, 2)
+		// integers fit in a double column without loss of type.
+		assert.Equal(t, "latency", columns[0].name)
+		assert.Equal(t, []interface{}{0.5, 1.5, 2.0, 3.0}, columns[0].values)
+		assert.Equal(t, "ops", columns[1].name)
+		assert.Equal(t, []interface{}{int64(0), int64(1), nil, nil}, columns[1].values)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := export(ParquetExportOptions{RowGroupSize: -1})
+		assert.Error(t, err)
+		_, err = export(ParquetExportOptions{Include: []string{"missing"}})
+		assert.Error(t, err)
+		_, err = export(ParquetExportOptions{End: start})
+		assert.Error(t, err)
+
+		cctx, ccancel := context.WithCancel(ctx)
+		ccancel()
+		assert.Error(t, ExportParquet(cctx, ReadChunks(ctx, bytes.NewReader(data)), &bytes.Buffer{}, ParquetExportOptions{}))
+	})
+}
@@ -0,0 +1,248 @@
+package ftdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+// readThrift decodes a thrift compact protocol struct into a map of
+// field ids to values, which are int64s, strings, []interface{}s and
+// maps for nested structs.
+func readThrift(t *testing.T, buf *bytes.Reader) map[int16]interface{} {
+	out := map[int16]interface{}{}
+	var last int16
+	for {
+		header, err := buf.ReadByte()
+		require.NoError(t, err)
+		if header == 0 {
+			return out
+		}
+
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			n, err := binary.ReadVarint(buf)
+			require.NoError(t, err)
+			id = int16(n)
+		}
+		last = id
+
+		out[id] = readThriftValue(t, buf, header&0x0f)
+	}
+}
+
+func readThriftValue(t *testing.T, buf *bytes.Reader, kind byte) interface{} {
+	switch kind {
+	case thriftI32, thriftI64:
+		n, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		return n
+	case thriftBinary:
+		n, err := binary.ReadUvarint(buf)
+		require.NoError(t, err)
+		out := make([]byte, n)
+		_, err = buf.Read(out)
+		require.NoError(t, err)
+		return string(out)
+	case thriftListType:
+		header, err := buf.ReadByte()
+		require.NoError(t, err)
+		size := uint64(header >> 4)
+		if size == 15 {
+			size, err = binary.ReadUvarint(buf)
+			require.NoError(t, err)
+		}
+		items := make([]interface{}, size)
+		for idx := range items {
+			items[idx] = readThriftValue(t, buf, header&0x0f)
+		}
+		return items
+	case thriftStructType:
+		return readThrift(t, buf)
+	default:
+		require.FailNow(t, "unexpected thrift type", "%d", kind)
+		return nil
+	}
+}
+
+type parquetTestColumn struct {
+	name          string
+	physicalType  int64
+	convertedType interface{}
+	values        []interface{}
+}
+
+// readParquet decodes the columns of a file written by WriteParquet or
+// ExportParquet, across all row groups, with nil for null values.
+func readParquet(t *testing.T, data []byte) (int64, []parquetTestColumn) {
+	require.True(t, len(data) > 12)
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := readThrift(t, bytes.NewReader(data[len(data)-8-footerSize:len(data)-8]))
+	assert.Equal(t, int64(1), footer[1])
+	numRows := footer[3].(int64)
+
+	schema := footer[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	assert.Equal(t, "schema", root[4])
+	require.Equal(t, int64(len(schema)-1), root[5])
+
+	columns := make([]parquetTestColumn, len(schema)-1)
+	for idx := range columns {
+		elem := schema[idx+1].(map[int16]interface{})
+		assert.Equal(t, int64(parquetOptional), elem[3])
+		columns[idx] = parquetTestColumn{name: elem[4].(string), physicalType: elem[1].(int64), convertedType: elem[6]}
+	}
+
+	var totalRows int64
+	for _, group := range footer[4].([]interface{}) {
+		rowGroup := group.(map[int16]interface{})
+		groupRows := rowGroup[3].(int64)
+		totalRows += groupRows
+		chunks := rowGroup[1].([]interface{})
+		require.Len(t, chunks, len(columns))
+
+		for idx := range chunks {
+			col := &columns[idx]
+			meta := chunks[idx].(map[int16]interface{})[3].(map[int16]interface{})
+			assert.Equal(t, col.physicalType, meta[1])
+			assert.Equal(t, []interface{}{col.name}, meta[3])
+			assert.Equal(t, groupRows, meta[5])
+
+			page := bytes.NewReader(data[meta[9].(int64):])
+			header := readThrift(t, page)
+			assert.Equal(t, int64(0), header[1])
+			assert.Equal(t, header[2], header[3])
+			require.Equal(t, groupRows, header[5].(map[int16]interface{})[1])
+
+			var size [4]byte
+			_, err := page.Read(size[:])
+			require.NoError(t, err)
+			levelData := make([]byte, binary.LittleEndian.Uint32(size[:]))
+			_, err = page.Read(levelData)
+			require.NoError(t, err)
+			levels := bytes.NewReader(levelData)
+
+			var defined []bool
+			for levels.Len() > 0 {
+				run, err := binary.ReadUvarint(levels)
+				require.NoError(t, err)
+				require.Zero(t, run&1, "levels must be run length encoded")
+				value, err := levels.ReadByte()
+				require.NoError(t, err)
+				for i := uint64(0); i < run>>1; i++ {
+					defined = append(defined, value == 1)
+				}
+			}
+			require.Len(t, defined, int(groupRows))
+
+			var bit uint
+			var bits byte
+			for _, ok := range defined {
+				if !ok {
+					col.values = append(col.values, nil)
+					continue
+				}
+
+				switch col.physicalType {
+				case int64(parquetBoolean):
+					if bit%8 == 0 {
+						bits, err = page.ReadByte()
+						require.NoError(t, err)
+					}
+					col.values = append(col.values, bits&(1<<(bit%8)) != 0)
+					bit++
+				case int64(parquetDouble):
+					var value [8]byte
+					_, err = page.Read(value[:])
+					require.NoError(t, err)
+					col.values = append(col.values, math.Float64frombits(binary.LittleEndian.Uint64(value[:])))
+				default:
+					var value [8]byte
+					_, err = page.Read(value[:])
+					require.NoError(t, err)
+					col.values = append(col.values, int64(binary.LittleEndian.Uint64(value[:])))
+				}
+			}
+		}
+	}
+	assert.Equal(t, numRows, totalRows)
+
+	return numRows, columns
+}
+
+func TestWriteParquet(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	docs := []*birch.Document{
+		birch.DC.Elements(
+			birch.EC.String("host", "ignored"),
+			birch.EC.Int64("ops", 10),
+			birch.EC.Double("ratio", 0.25),
+			birch.EC.Boolean("ok", true),
+			birch.EC.Time("ts", now),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Int32("resident", 4)),
+		),
+		birch.DC.Elements(
+			birch.EC.Int64("ops", 20),
+			birch.EC.Int32("ratio", 1),
+			birch.EC.Boolean("ok", false),
+			birch.EC.Timestamp("at", 5, 2),
+		),
+		birch.DC.Elements(
+			birch.EC.Int32("ops", 30),
+			birch.EC.Boolean("ok", true),
+			birch.EC.Time("ts", now.Add(time.Second)),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Int32("resident", 6)),
+		),
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteParquet(buf, docs))
+
+	rows, columns := readParquet(t, buf.Bytes())
+	assert.Equal(t, int64(3), rows)
+
+	expected := []parquetTestColumn{
+		{name: "ops", physicalType: int64(parquetInt64), values: []interface{}{int64(10), int64(20), int64(30)}},
+		{name: "ratio", physicalType: int64(parquetDouble), values: []interface{}{0.25, 1.0, nil}},
+		{name: "ok", physicalType: int64(parquetBoolean), values: []interface{}{true, false, true}},
+		{
+			name:          "ts",
+			physicalType:  int64(parquetInt64),
+			convertedType: int64(parquetTimestampMillis),
+			values:        []interface{}{epochMs(now), nil, epochMs(now) + 1000},
+		},
+		{name: "mem.resident", physicalType: int64(parquetInt64), values: []interface{}{int64(4), nil, int64(6)}},
+		{name: "at", physicalType: int64(parquetInt64), values: []interface{}{nil, int64(5000), nil}},
+		{name: "at.inc", physicalType: int64(parquetInt64), values: []interface{}{nil, int64(2), nil}},
+	}
+	assert.Equal(t, expected, columns)
+
+	t.Run("ManyBooleans", func(t *testing.T) {
+		docs := make([]*birch.Document, 20)
+		for idx := range docs {
+			docs[idx] = birch.DC.Elements(birch.EC.Boolean("flag", idx%3 == 0))
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteParquet(buf, docs))
+		_, columns := readParquet(t, buf.Bytes())
+		require.Len(t, columns, 1)
+		for idx, val := range columns[0].values {
+			assert.Equal(t, idx%3 == 0, val)
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		assert.Error(t, WriteParquet(&bytes.Buffer{}, nil))
+		assert.Error(t, WriteParquet(&bytes.Buffer{}, []*birch.Document{birch.DC.Elements(birch.EC.String("a", "b"))}))
+		assert.Error(t, WriteParquet(&bytes.Buffer{}, []*birch.Document{nil}))
+	})
+}
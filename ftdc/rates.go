@@ -0,0 +1,98 @@
+package ftdc
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// RateOptions controls how Chunk.RatesWithOptions computes rates.
+type RateOptions struct {
+	// TimeKey is the key of the DateTime metric that records the
+	// time of each sample, such as "start" in MongoDB diagnostic
+	// data. When empty, the first DateTime metric in the chunk is
+	// used.
+	TimeKey string
+
+	// ResetAsNaN reports decreases in a metric, such as counters
+	// that reset when a process restarts, as NaN rather than 0.
+	ResetAsNaN bool
+}
+
+// Rates returns, for each integer and floating point metric in the
+// chunk, the rate of change per second between consecutive samples,
+// using the first DateTime metric in the chunk as the time of each
+// sample. See RatesWithOptions.
+func (c *Chunk) Rates() (map[string][]float64, error) {
+	return c.RatesWithOptions(RateOptions{})
+}
+
+// RatesWithOptions returns, for each integer and floating point
+// metric in the chunk, keyed as by Keys, the change in the metric
+// between each pair of consecutive samples divided by the seconds
+// between them, so each series has one fewer value than the chunk has
+// samples. Decreases, which for counters mean a reset, are 0, or NaN
+// when ResetAsNaN is set, and rates between samples with the same
+// time are NaN. Booleans and time metrics have no rates.
+//
+// RatesWithOptions returns an error if the chunk has no metric for
+// the time of each sample.
+func (c *Chunk) RatesWithOptions(opts RateOptions) (map[string][]float64, error) {
+	var times []int64
+	for idx := range c.Metrics {
+		m := &c.Metrics[idx]
+		if m.originalType == bsontype.DateTime && (opts.TimeKey == "" || opts.TimeKey == m.Key()) {
+			times = m.Values
+			break
+		}
+	}
+
+	if times == nil {
+		if opts.TimeKey != "" {
+			return nil, errors.Errorf("chunk has no time metric '%s'", opts.TimeKey)
+		}
+		return nil, errors.New("chunk has no time metric")
+	}
+
+	n := c.nPoints - 1
+	if n < 0 {
+		n = 0
+	}
+
+	out := make(map[string][]float64, len(c.Metrics))
+	for idx := range c.Metrics {
+		m := &c.Metrics[idx]
+
+		var value func(int) float64
+		switch m.originalType {
+		case bsontype.Int32, bsontype.Int64:
+			value = func(i int) float64 { return float64(m.Values[i]) }
+		case bsontype.Double:
+			value = func(i int) float64 { return restoreFloat(m.Values[i]) }
+		default:
+			continue
+		}
+
+		rates := make([]float64, n)
+		for i := range rates {
+			seconds := float64(times[i+1]-times[i]) / 1000
+			delta := value(i+1) - value(i)
+
+			switch {
+			case seconds <= 0:
+				rates[i] = math.NaN()
+			case delta < 0 && opts.ResetAsNaN:
+				rates[i] = math.NaN()
+			case delta < 0:
+				rates[i] = 0
+			default:
+				rates[i] = delta / seconds
+			}
+		}
+
+		out[m.Key()] = rates
+	}
+
+	return out, nil
+}
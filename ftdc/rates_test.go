@@ -0,0 +1,91 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestChunkRates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Unix(1600000000, 0)
+	offsets := []time.Duration{0, 2 * time.Second, 3 * time.Second, 3 * time.Second, 5 * time.Second}
+	counters := []int64{0, 100, 150, 200, 10}
+
+	data := produceMockChunks(len(offsets), 0, func(idx int) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Time("start", start.Add(offsets[idx])),
+			birch.EC.Int64("ops", counters[idx]),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Double("ratio", float64(idx)/2)),
+			birch.EC.Boolean("ok", true),
+			birch.EC.Time("other", start.Add(time.Duration(idx)*time.Minute)),
+		)
+	})
+
+	iter := ReadChunks(ctx, bytes.NewReader(data))
+	require.True(t, iter.Next())
+	chunk := iter.Chunk()
+
+	t.Run("Default", func(t *testing.T) {
+		rates, err := chunk.Rates()
+		require.NoError(t, err)
+		assert.Len(t, rates, 2)
+
+		ops := rates["ops"]
+		require.Len(t, ops, 4)
+		assert.Equal(t, 50.0, ops[0])
+		assert.Equal(t, 50.0, ops[1])
+		assert.True(t, math.IsNaN(ops[2]), "samples at the same time")
+		assert.Equal(t, 0.0, ops[3], "counter reset")
+
+		ratio := rates["mem.ratio"]
+		require.Len(t, ratio, 4)
+		assert.Equal(t, 0.25, ratio[0])
+		assert.Equal(t, 0.5, ratio[1])
+	})
+	t.Run("ResetAsNaN", func(t *testing.T) {
+		rates, err := chunk.RatesWithOptions(RateOptions{ResetAsNaN: true})
+		require.NoError(t, err)
+		assert.True(t, math.IsNaN(rates["ops"][3]))
+		assert.Equal(t, 50.0, rates["ops"][0])
+	})
+	t.Run("TimeKey", func(t *testing.T) {
+		rates, err := chunk.RatesWithOptions(RateOptions{TimeKey: "other"})
+		require.NoError(t, err)
+		assert.InDelta(t, 100.0/60, rates["ops"][0], 1e-9)
+
+		_, err = chunk.RatesWithOptions(RateOptions{TimeKey: "missing"})
+		assert.Error(t, err)
+		_, err = chunk.RatesWithOptions(RateOptions{TimeKey: "ops"})
+		assert.Error(t, err)
+	})
+	t.Run("NoTime", func(t *testing.T) {
+		data := produceMockChunks(1, 0, func(int) *birch.Document {
+			return birch.DC.Elements(birch.EC.Int64("ops", 1))
+		})
+
+		iter := ReadChunks(ctx, bytes.NewReader(data))
+		require.True(t, iter.Next())
+		_, err := iter.Chunk().Rates()
+		assert.Error(t, err)
+	})
+	t.Run("SingleSample", func(t *testing.T) {
+		data := produceMockChunks(1, 0, func(int) *birch.Document {
+			return birch.DC.Elements(birch.EC.Time("start", start), birch.EC.Int64("ops", 1))
+		})
+
+		iter := ReadChunks(ctx, bytes.NewReader(data))
+		require.True(t, iter.Next())
+		rates, err := iter.Chunk().Rates()
+		require.NoError(t, err)
+		assert.Empty(t, rates["ops"])
+	})
+}
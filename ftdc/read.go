@@ -6,6 +6,7 @@ import (
 	"compress/zlib"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"io"
 
 	"github.com/tychoish/birch"
@@ -13,11 +14,44 @@ import (
 	"github.com/pkg/errors"
 )
 
-func readDiagnostic(ctx context.Context, f io.Reader, ch chan<- *birch.Document) error {
+// ChunkError describes a chunk in an FTDC stream that could not be
+// decoded, and identifies where in the stream the chunk begins.
+type ChunkError struct {
+	// Index is the position of the chunk among the metrics chunks
+	// in the stream, starting at zero. Metadata documents are not
+	// counted.
+	Index int
+	// Offset is the number of bytes in the stream before the
+	// document that holds the chunk.
+	Offset int64
+	// Err is the underlying decoding error.
+	Err error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("problem decoding chunk %d at offset %d: %s", e.Index, e.Offset, e.Err)
+}
+
+// Cause returns the underlying decoding error.
+func (e *ChunkError) Cause() error { return e.Err }
+
+// Unwrap returns the underlying decoding error.
+func (e *ChunkError) Unwrap() error { return e.Err }
+
+type diagnosticDocument struct {
+	doc    *birch.Document
+	offset int64
+	size   int64
+}
+
+// readDiagnostic reads documents from the source, which begins offset
+// bytes into the stream, and sends them to the output channel.
+func readDiagnostic(ctx context.Context, f io.Reader, ch chan<- diagnosticDocument, offset int64) error {
 	defer close(ch)
 	buf := bufio.NewReader(f)
 	for {
-		doc, err := readBufBSON(buf)
+		doc := &birch.Document{}
+		n, err := doc.ReadFrom(buf)
 		if err != nil {
 			if err == io.EOF {
 				err = nil
@@ -25,7 +59,8 @@ func readDiagnostic(ctx context.Context, f io.Reader, ch chan<- *birch.Document)
 			return err
 		}
 		select {
-		case ch <- doc:
+		case ch <- diagnosticDocument{doc: doc, offset: offset, size: n}:
+			offset += n
 			continue
 		case <-ctx.Done():
 			return nil
@@ -33,12 +68,16 @@ func readDiagnostic(ctx context.Context, f io.Reader, ch chan<- *birch.Document)
 	}
 }
 
-func readChunks(ctx context.Context, ch <-chan *birch.Document, o chan<- *Chunk) error {
-	defer close(o)
-
-	var metadata *birch.Document
+// readChunks decodes the chunks in the documents from the input
+// channel, continuing from the scan state. Every chunk that cannot be
+// decoded is passed to the handler: if the handler returns an error,
+// reading stops, and otherwise the chunk is skipped.
+func readChunks(ctx context.Context, ch <-chan diagnosticDocument, o chan<- *Chunk, state ScanState, handler func(*ChunkError) error) error {
+	metadata := state.Metadata
+	index := state.Chunks
 
-	for doc := range ch {
+	for in := range ch {
+		doc := in.doc
 		// the FTDC streams typically have onetime-per-file
 		// metadata that includes information that doesn't
 		// change (like process parameters, and machine
@@ -52,52 +91,105 @@ func readChunks(ctx context.Context, ch <-chan *birch.Document, o chan<- *Chunk)
 			continue
 		}
 
-		id, _ := doc.Lookup("_id").TimeOK()
-
-		// get the data field which holds the metrics chunk
-		zelem := doc.LookupElement("data")
-		if zelem == nil {
-			return errors.New("data is not populated")
-		}
-		_, zBytes := zelem.Value().Binary()
-
-		// the metrics chunk, after the first 4 bytes, is zlib
-		// compressed, so we make a reader for that. data
-		z, err := zlib.NewReader(bytes.NewBuffer(zBytes[4:]))
+		chunk, err := readChunk(doc, metadata)
 		if err != nil {
-			return errors.Wrap(err, "problem building zlib reader")
+			if err = handler(&ChunkError{Index: index, Offset: in.offset, Err: err}); err != nil {
+				return err
+			}
+			index++
+			continue
 		}
-		buf := bufio.NewReader(z)
-
-		// the metrics chunk, which is *not* bson, first
-		// contains a bson document which begins the
-		// sample. This has the field and we use use it to
-		// create a slice of Metrics for each series. The
-		// deltas are not populated.
-		refDoc, metrics, err := readBufMetrics(buf)
-		if err != nil {
-			return errors.Wrap(err, "problem reading metrics")
+		index++
+		chunk.next = ScanState{
+			Offset:   in.offset + in.size,
+			Chunks:   index,
+			Metadata: metadata,
 		}
 
-		// now go back and read the first few bytes
-		// (uncompressed) which tell us how many metrics are
-		// in each sample (e.g. the fields in the document)
-		// and how many events are collected in each series.
-		bl := make([]byte, 8)
-		_, err = io.ReadAtLeast(buf, bl, 8)
-		if err != nil {
-			return err
+		select {
+		case o <- chunk:
+		case <-ctx.Done():
+			return nil
 		}
-		nmetrics := int(binary.LittleEndian.Uint32(bl[:4]))
-		ndeltas := int(binary.LittleEndian.Uint32(bl[4:]))
-
-		// if the number of metrics that we see from the
-		// source document (metrics) and the number the file
-		// reports don't equal, it's probably corrupt.
-		if nmetrics != len(metrics) {
-			return errors.Errorf("metrics mismatch, file likely corrupt Expected %d, got %d", nmetrics, len(metrics))
+	}
+	return nil
+}
+
+func readChunk(doc *birch.Document, metadata *birch.Document) (*Chunk, error) {
+	return readChunkWithLimit(doc, metadata, 0)
+}
+
+// readChunkWithLimit decodes a chunk, as readChunk does, but returns an
+// error, before decoding the samples, if they would take more than
+// limit bytes. A limit of zero does not limit the size of the chunk.
+func readChunkWithLimit(doc *birch.Document, metadata *birch.Document, limit int64) (*Chunk, error) {
+	id, _ := doc.Lookup("_id").TimeOK()
+
+	// get the data field which holds the metrics chunk
+	zelem := doc.LookupElement("data")
+	if zelem == nil {
+		return nil, errors.New("data is not populated")
+	}
+	encoding, err := readChunkEncoding(doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	_, zBytes, ok := zelem.Value().BinaryOK()
+	if !ok || len(zBytes) < 4 {
+		return nil, errors.New("data is not a valid metrics chunk")
+	}
+
+	// the metrics chunk, after the first 4 bytes, is zlib
+	// compressed, so we make a reader for that. data
+	z, err := zlib.NewReader(bytes.NewBuffer(zBytes[4:]))
+	if err != nil {
+		return nil, errors.Wrap(err, "problem building zlib reader")
+	}
+	buf := bufio.NewReader(z)
+
+	// the metrics chunk, which is *not* bson, first
+	// contains a bson document which begins the
+	// sample. This has the field and we use use it to
+	// create a slice of Metrics for each series. The
+	// deltas are not populated.
+	refDoc, metrics, err := readBufMetrics(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading metrics")
+	}
+
+	// now go back and read the first few bytes
+	// (uncompressed) which tell us how many metrics are
+	// in each sample (e.g. the fields in the document)
+	// and how many events are collected in each series.
+	bl := make([]byte, 8)
+	_, err = io.ReadAtLeast(buf, bl, 8)
+	if err != nil {
+		return nil, err
+	}
+	nmetrics := int(binary.LittleEndian.Uint32(bl[:4]))
+	ndeltas := int(binary.LittleEndian.Uint32(bl[4:]))
+
+	// if the number of metrics that we see from the
+	// source document (metrics) and the number the file
+	// reports don't equal, it's probably corrupt.
+	if nmetrics != len(metrics) {
+		return nil, errors.Errorf("metrics mismatch, file likely corrupt Expected %d, got %d", nmetrics, len(metrics))
+	}
+
+	if size := int64(nmetrics) * int64(ndeltas+1) * 8; limit > 0 && size > limit {
+		return nil, errors.Errorf("decoded chunk of %d bytes exceeds the limit of %d bytes", size, limit)
+	}
+
+	if encoding == EncodingPerMetric {
+		if err = readMetricSeries(buf, metrics, ndeltas); err != nil {
+			return nil, err
 		}
 
+		for i, v := range metrics {
+			metrics[i].Values = undeltaMetric(v)
+		}
+	} else {
 		// now go back and populate the delta numbers
 		var nzeroes uint64
 		for i, v := range metrics {
@@ -112,39 +204,66 @@ func readChunks(ctx context.Context, ch <-chan *birch.Document, o chan<- *Chunk)
 				} else {
 					delta, err = binary.ReadUvarint(buf)
 					if err != nil {
-						return errors.Wrap(err, "reached unexpected end of encoded integer")
+						return nil, errors.Wrap(err, "reached unexpected end of encoded integer")
 					}
 					if delta == 0 {
 						nzeroes, err = binary.ReadUvarint(buf)
 						if err != nil {
-							return err
+							return nil, err
 						}
 					}
 				}
 				metrics[i].Values[j] = int64(delta)
 			}
-			if metrics[i].originalType == bsontype.Double {
-				metrics[i].Values = undeltaFloats(v.startingValue, metrics[i].Values)
-			} else {
-				metrics[i].Values = undelta(v.startingValue, metrics[i].Values)
-			}
+			encoding.decode(metrics[i].Values)
 
+			metrics[i].Values = undeltaMetric(metrics[i])
 		}
-		select {
-		case o <- &Chunk{
-			Metrics:   metrics,
-			nPoints:   ndeltas + 1, // this accounts for the reference document
-			id:        id,
-			metadata:  metadata,
-			reference: refDoc,
-		}:
-		case <-ctx.Done():
-			return nil
+	}
+
+	return &Chunk{
+		Metrics:   metrics,
+		nPoints:   ndeltas + 1, // this accounts for the reference document
+		id:        id,
+		metadata:  metadata,
+		reference: refDoc,
+	}, nil
+}
+
+// readMetricSeries reads the samples of a chunk encoded with
+// EncodingPerMetric, starting with the header that records the scheme
+// of each metric.
+func readMetricSeries(buf *bufio.Reader, metrics []Metric, ndeltas int) error {
+	schemes := make([]byte, len(metrics))
+	if _, err := io.ReadFull(buf, schemes); err != nil {
+		return errors.Wrap(err, "reached unexpected end of metric encodings")
+	}
+
+	for i := range metrics {
+		scheme := EncodingScheme(schemes[i])
+		if err := scheme.validateSeries(); err != nil {
+			return errors.Wrapf(err, "metric '%s'", metrics[i].Key())
 		}
+
+		values, err := scheme.readSeries(buf, ndeltas)
+		if err != nil {
+			return errors.Wrapf(err, "metric '%s'", metrics[i].Key())
+		}
+		metrics[i].Values = values
 	}
+
 	return nil
 }
 
+// undeltaMetric converts the deltas of a metric back into its samples.
+func undeltaMetric(m Metric) []int64 {
+	if m.originalType == bsontype.Double {
+		return undeltaFloats(m.startingValue, m.Values)
+	}
+
+	return undelta(m.startingValue, m.Values)
+}
+
 func readBufBSON(buf *bufio.Reader) (*birch.Document, error) {
 	doc := &birch.Document{}
 
@@ -0,0 +1,124 @@
+package ftdc
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch"
+)
+
+// chunkJob is a chunk that a ChunksParallel worker decodes.
+type chunkJob struct {
+	in       diagnosticDocument
+	metadata *birch.Document
+	index    int
+	chunk    *Chunk
+	err      error
+	done     chan struct{}
+}
+
+// ChunksParallel reads the documents of an FTDC data source in order,
+// decodes the chunks in them with the given number of workers, and
+// sends the chunks to the output channel in the order of the source,
+// as ReadChunks does, so that reading a large source is not limited
+// by the speed of decoding on one CPU. ChunksParallel closes the
+// output channel when it returns.
+//
+// At most workers chunks are decoded ahead of the chunk that the
+// output channel is waiting to accept, so a slow consumer limits the
+// memory that decoded chunks use.
+//
+// ChunksParallel returns nil once it has sent every chunk. It returns
+// a *ChunkError for the first chunk that cannot be decoded, an error
+// if the source cannot be read, the context's error if the context is
+// canceled, and an error if workers is not positive.
+func ChunksParallel(ctx context.Context, r io.Reader, workers int, out chan<- *Chunk) error {
+	defer close(out)
+
+	if workers <= 0 {
+		return errors.Errorf("invalid number of workers %d", workers)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	docs := make(chan diagnosticDocument)
+	readErr := make(chan error, 1)
+	go func() { readErr <- readDiagnostic(ctx, r, docs, 0) }()
+
+	// queue holds the jobs in the order of the source, and limits
+	// the number of jobs that are decoded ahead of the output.
+	queue := make(chan *chunkJob, workers)
+	jobs := make(chan *chunkJob)
+	go func() {
+		defer close(queue)
+		defer close(jobs)
+
+		var metadata *birch.Document
+		index := 0
+		for in := range docs {
+			docType := in.doc.Lookup("type")
+			if isNum(0, docType) {
+				metadata = in.doc
+				continue
+			} else if !isNum(1, docType) {
+				continue
+			}
+
+			job := &chunkJob{in: in, metadata: metadata, index: index, done: make(chan struct{})}
+			index++
+
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.chunk, job.err = readChunk(job.in.doc, job.metadata)
+				close(job.done)
+			}
+		}()
+	}
+
+	for job := range queue {
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if job.err != nil {
+			return &ChunkError{Index: job.index, Offset: job.in.offset, Err: job.err}
+		}
+
+		job.chunk.next = ScanState{
+			Offset:   job.in.offset + job.in.size,
+			Chunks:   job.index + 1,
+			Metadata: job.metadata,
+		}
+
+		select {
+		case out <- job.chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return errors.Wrap(<-readErr, "problem reading source")
+}
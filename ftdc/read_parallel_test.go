@@ -0,0 +1,175 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+// writeParallelStream writes the given number of chunks, with metadata
+// and ten samples of several metrics in each.
+func writeParallelStream(t testing.TB, chunks int) []byte {
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	cw.SetMaxSamples(10)
+	cw.SetMetadata(birch.DC.Elements(birch.EC.String("host", "localhost")))
+	for i := 0; i < chunks*10; i++ {
+		doc := birch.DC.Make(20)
+		for j := 0; j < 20; j++ {
+			doc.Append(birch.EC.Int64("metric"+strconv.Itoa(j), int64(i*j)))
+		}
+		require.NoError(t, cw.Add(doc))
+	}
+	require.NoError(t, cw.Flush())
+
+	return buf.Bytes()
+}
+
+func TestChunksParallel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := writeParallelStream(t, 25)
+
+	collect := func(t *testing.T, ctx context.Context, r io.Reader, workers int) ([]*Chunk, error) {
+		out := make(chan *Chunk)
+		errs := make(chan error, 1)
+		go func() { errs <- ChunksParallel(ctx, r, workers, out) }()
+
+		var chunks []*Chunk
+		for chunk := range out {
+			chunks = append(chunks, chunk)
+		}
+		return chunks, <-errs
+	}
+
+	t.Run("MatchesReadChunks", func(t *testing.T) {
+		var expected []*Chunk
+		iter := ReadChunks(ctx, bytes.NewReader(data))
+		for iter.Next() {
+			expected = append(expected, iter.Chunk())
+		}
+		require.NoError(t, iter.Err())
+		require.Len(t, expected, 25)
+
+		for _, workers := range []int{1, 4, 32} {
+			t.Run(strconv.Itoa(workers), func(t *testing.T) {
+				chunks, err := collect(t, ctx, bytes.NewReader(data), workers)
+				require.NoError(t, err)
+				require.Len(t, chunks, len(expected))
+
+				for idx, chunk := range chunks {
+					assert.Equal(t, expected[idx].Metrics, chunk.Metrics)
+					assert.Equal(t, expected[idx].nPoints, chunk.nPoints)
+					assert.Equal(t, expected[idx].next.Offset, chunk.next.Offset)
+					assert.Equal(t, expected[idx].next.Chunks, chunk.next.Chunks)
+					require.NotNil(t, chunk.GetMetadata())
+					assert.True(t, expected[idx].GetMetadata().EqualExcept(chunk.GetMetadata()))
+				}
+			})
+		}
+	})
+	t.Run("CorruptChunk", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		src := bytes.NewReader(data)
+		var offset int64
+		chunks := 0
+		for {
+			doc := &birch.Document{}
+			_, err := doc.ReadFrom(src)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			if isNum(1, doc.Lookup("type")) {
+				if chunks == 3 {
+					offset = int64(out.Len())
+					doc.Set(birch.EC.Binary("data", []byte{0, 0, 0, 0, 'n', 'o', 't', ' ', 'z', 'l', 'i', 'b'}))
+				}
+				chunks++
+			}
+			_, err = doc.WriteTo(out)
+			require.NoError(t, err)
+		}
+
+		got, err := collect(t, ctx, bytes.NewReader(out.Bytes()), 4)
+		require.Error(t, err)
+		assert.Len(t, got, 3)
+
+		var chunkErr *ChunkError
+		require.True(t, errors.As(err, &chunkErr))
+		assert.Equal(t, 3, chunkErr.Index)
+		assert.Equal(t, offset, chunkErr.Offset)
+	})
+	t.Run("ReadError", func(t *testing.T) {
+		// the last document is truncated.
+		chunks, err := collect(t, ctx, bytes.NewReader(data[:len(data)-3]), 4)
+		assert.Error(t, err)
+		assert.Len(t, chunks, 24)
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		cctx, ccancel := context.WithCancel(ctx)
+		out := make(chan *Chunk)
+		errs := make(chan error, 1)
+		go func() { errs <- ChunksParallel(cctx, bytes.NewReader(data), 4, out) }()
+
+		<-out
+		ccancel()
+		for range out {
+		}
+		assert.Equal(t, context.Canceled, <-errs)
+	})
+	t.Run("InvalidWorkers", func(t *testing.T) {
+		chunks, err := collect(t, ctx, bytes.NewReader(data), 0)
+		assert.Error(t, err)
+		assert.Empty(t, chunks)
+	})
+	t.Run("Empty", func(t *testing.T) {
+		chunks, err := collect(t, ctx, &bytes.Buffer{}, 4)
+		assert.NoError(t, err)
+		assert.Empty(t, chunks)
+	})
+}
+
+func BenchmarkChunksParallel(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := writeParallelStream(b, 200)
+
+	for _, workers := range []int{1, runtime.GOMAXPROCS(0)} {
+		b.Run("Workers"+strconv.Itoa(workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for n := 0; n < b.N; n++ {
+				out := make(chan *Chunk)
+				errs := make(chan error, 1)
+				go func() { errs <- ChunksParallel(ctx, bytes.NewReader(data), workers, out) }()
+				for range out {
+				}
+				if err := <-errs; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("ReadChunks", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for n := 0; n < b.N; n++ {
+			iter := ReadChunks(ctx, bytes.NewReader(data))
+			for iter.Next() {
+			}
+			if err := iter.Err(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package ftdc
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// MetricSummary holds the statistics of a metric's values that
+// TopNMetrics accumulates while it reads chunks, so that scoring
+// metrics does not require holding their values in memory.
+type MetricSummary struct {
+	Count int
+	First float64
+	Last  float64
+	Min   float64
+	Max   float64
+	Sum   float64
+	// Changes is the number of samples whose value differs from the
+	// value of the previous sample.
+	Changes int
+
+	// mean and m2 track the variance using Welford's algorithm.
+	mean float64
+	m2   float64
+}
+
+// Mean returns the average of the values.
+func (s MetricSummary) Mean() float64 { return s.mean }
+
+// Variance returns the population variance of the values.
+func (s MetricSummary) Variance() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.m2 / float64(s.Count)
+}
+
+// Range returns the difference between the largest and smallest
+// values.
+func (s MetricSummary) Range() float64 { return s.Max - s.Min }
+
+func (s *MetricSummary) add(v float64) {
+	if s.Count == 0 {
+		s.First, s.Min, s.Max = v, v, v
+	} else if v != s.Last {
+		s.Changes++
+	}
+
+	s.Count++
+	s.Last = v
+	s.Sum += v
+	s.Min = math.Min(s.Min, v)
+	s.Max = math.Max(s.Max, v)
+
+	delta := v - s.mean
+	s.mean += delta / float64(s.Count)
+	s.m2 += delta * (v - s.mean)
+}
+
+// TopNMetrics reads all chunks from the iterator and returns the keys
+// of the n integer and floating point metrics with the highest
+// scores, in order of decreasing score, for example to choose the
+// metrics that changed the most:
+//
+//	keys, err := TopNMetrics(iter, 10, func(_ string, s MetricSummary) float64 {
+//		return s.Variance()
+//	})
+//
+// The score function is called once for each metric, with the key of
+// the metric, as Chunk.Keys returns it, and the summary of its values
+// in all chunks, which TopNMetrics accumulates as it reads, so the
+// memory used depends on the number of metrics rather than on the
+// number of samples. Metrics with NaN scores are not ranked, and
+// metrics with the same score are ordered by key.
+//
+// TopNMetrics returns an error if n is not positive, or if the
+// iterator encounters an error.
+func TopNMetrics(iter *ChunkIterator, n int, score func(key string, summary MetricSummary) float64) ([]string, error) {
+	if n <= 0 {
+		return nil, errors.Errorf("invalid number of metrics %d", n)
+	}
+	if score == nil {
+		return nil, errors.New("must specify a score function")
+	}
+
+	summaries := map[string]*MetricSummary{}
+	for iter.Next() {
+		chunk := iter.Chunk()
+		for idx := range chunk.Metrics {
+			m := &chunk.Metrics[idx]
+
+			var value func(int64) float64
+			switch m.originalType {
+			case bsontype.Int32, bsontype.Int64:
+				value = func(v int64) float64 { return float64(v) }
+			case bsontype.Double:
+				value = restoreFloat
+			default:
+				continue
+			}
+
+			key := m.Key()
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &MetricSummary{}
+				summaries[key] = summary
+			}
+
+			for _, v := range m.Values {
+				summary.add(value(v))
+			}
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "problem reading chunks")
+	}
+
+	top := &scoredMetrics{}
+	for key, summary := range summaries {
+		s := score(key, *summary)
+		if math.IsNaN(s) {
+			continue
+		}
+
+		metric := scoredMetric{key: key, score: s}
+		if top.Len() < n {
+			heap.Push(top, metric)
+		} else if top.less((*top)[0], metric) {
+			(*top)[0] = metric
+			heap.Fix(top, 0)
+		}
+	}
+
+	sort.Slice(*top, func(i, j int) bool { return top.less((*top)[j], (*top)[i]) })
+
+	keys := make([]string, top.Len())
+	for idx, metric := range *top {
+		keys[idx] = metric.key
+	}
+
+	return keys, nil
+}
+
+type scoredMetric struct {
+	key   string
+	score float64
+}
+
+// scoredMetrics is a heap with the lowest ranked metric first.
+type scoredMetrics []scoredMetric
+
+// less reports whether a ranks below b: it has a lower score, or the
+// same score and a later key.
+func (h scoredMetrics) less(a, b scoredMetric) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.key > b.key
+}
+
+func (h scoredMetrics) Len() int            { return len(h) }
+func (h scoredMetrics) Less(i, j int) bool  { return h.less(h[i], h[j]) }
+func (h scoredMetrics) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *scoredMetrics) Push(x interface{}) { *h = append(*h, x.(scoredMetric)) }
+func (h *scoredMetrics) Pop() interface{} {
+	old := *h
+	out := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return out
+}
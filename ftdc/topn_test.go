@@ -0,0 +1,80 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestTopNMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the metrics span two chunks.
+	data := produceMockChunks(10, 5, func(idx int) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Int64("constant", 5),
+			birch.EC.Int64("counter", int64(idx)),
+			birch.EC.Int32("spiky", int32(100*(idx%2))),
+			birch.EC.SubDocumentFromElements("mem", birch.EC.Double("ratio", float64(idx)/10)),
+			birch.EC.String("host", "ignored"),
+			birch.EC.Boolean("ok", idx%2 == 0),
+		)
+	})
+
+	var summaries map[string]MetricSummary
+	top := func(t *testing.T, n int, score func(MetricSummary) float64) []string {
+		summaries = map[string]MetricSummary{}
+		keys, err := TopNMetrics(ReadChunks(ctx, bytes.NewReader(data)), n, func(key string, s MetricSummary) float64 {
+			summaries[key] = s
+			return score(s)
+		})
+		require.NoError(t, err)
+		return keys
+	}
+
+	t.Run("Variance", func(t *testing.T) {
+		assert.Equal(t, []string{"spiky", "counter"}, top(t, 2, MetricSummary.Variance))
+		assert.Len(t, summaries, 4, "only numeric metrics are scored")
+
+		counter := summaries["counter"]
+		assert.Equal(t, 10, counter.Count)
+		assert.Equal(t, 0.0, counter.First)
+		assert.Equal(t, 9.0, counter.Last)
+		assert.Equal(t, 45.0, counter.Sum)
+		assert.Equal(t, 4.5, counter.Mean())
+		assert.InDelta(t, 8.25, counter.Variance(), 1e-9)
+		assert.Equal(t, 9, counter.Changes)
+
+		ratio := summaries["mem.ratio"]
+		assert.Equal(t, 0.0, ratio.Min)
+		assert.Equal(t, 0.9, ratio.Max)
+		assert.InDelta(t, 0.9, ratio.Range(), 1e-9)
+	})
+	t.Run("Ties", func(t *testing.T) {
+		keys := top(t, 10, func(s MetricSummary) float64 { return float64(s.Count) })
+		assert.Equal(t, []string{"constant", "counter", "mem.ratio", "spiky"}, keys)
+	})
+	t.Run("NaNScores", func(t *testing.T) {
+		keys := top(t, 3, func(s MetricSummary) float64 {
+			if s.Changes == 0 {
+				return math.NaN()
+			}
+			return s.Range()
+		})
+		assert.Equal(t, []string{"spiky", "counter", "mem.ratio"}, keys)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := TopNMetrics(ReadChunks(ctx, bytes.NewReader(data)), 0, func(string, MetricSummary) float64 { return 0 })
+		assert.Error(t, err)
+		_, err = TopNMetrics(ReadChunks(ctx, bytes.NewReader(data)), 1, nil)
+		assert.Error(t, err)
+		_, err = TopNMetrics(ReadChunks(ctx, bytes.NewReader(data[:len(data)-10])), 1, func(string, MetricSummary) float64 { return 0 })
+		assert.Error(t, err)
+	})
+}
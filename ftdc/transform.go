@@ -0,0 +1,123 @@
+package ftdc
+
+import (
+	"strings"
+
+	"github.com/tychoish/birch"
+)
+
+// RenameOptions controls how RenameStreamWithOptions modifies
+// documents.
+type RenameOptions struct {
+	// Copy renames keys in a deep copy of each document, which
+	// shares no elements or values with the documents read from
+	// the input channel and leaves them unmodified. By default
+	// documents are modified in place, which avoids allocating a
+	// new document for every sample.
+	Copy bool
+}
+
+// RenameStream reads documents from the input channel, renames keys
+// according to the mapping, and sends the documents on the returned
+// channel, which is closed after the input channel is closed. The
+// documents are modified in place; use RenameStreamWithOptions to
+// rename keys in copies of the documents.
+//
+// The keys of the mapping are dot-separated paths to fields, which
+// may address fields in nested documents (e.g. "opcounters.query"),
+// and the values are the new names of those fields. A field keeps its
+// position and its parent document when it is renamed: the new name
+// replaces only the last component of the path. Paths do not descend
+// into arrays, and mappings for fields that are not present in a
+// document have no effect on it.
+//
+// This is useful for normalizing the names of metrics from different
+// sources before writing them out, and composes with the other
+// streaming operations in this package. Callers must read all
+// documents from the returned channel.
+func RenameStream(in <-chan *birch.Document, mapping map[string]string) <-chan *birch.Document {
+	return RenameStreamWithOptions(in, mapping, RenameOptions{})
+}
+
+// RenameStreamWithOptions is the same as RenameStream, but allows
+// callers to control whether documents are modified in place.
+func RenameStreamWithOptions(in <-chan *birch.Document, mapping map[string]string, opts RenameOptions) <-chan *birch.Document {
+	r := newRenamer(mapping, opts)
+	out := make(chan *birch.Document)
+
+	go func() {
+		defer close(out)
+
+		for doc := range in {
+			if doc != nil {
+				doc = r.document(doc, "")
+			}
+
+			out <- doc
+		}
+	}()
+
+	return out
+}
+
+type renamer struct {
+	mapping map[string]string
+	parents map[string]bool
+	copy    bool
+}
+
+func newRenamer(mapping map[string]string, opts RenameOptions) *renamer {
+	r := &renamer{
+		mapping: mapping,
+		parents: map[string]bool{},
+		copy:    opts.Copy,
+	}
+
+	for path := range mapping {
+		for idx := strings.LastIndex(path, "."); idx > 0; idx = strings.LastIndex(path[:idx], ".") {
+			r.parents[path[:idx]] = true
+		}
+	}
+
+	return r
+}
+
+func (r *renamer) document(doc *birch.Document, prefix string) *birch.Document {
+	elems := make([]*birch.Element, 0, doc.Len())
+
+	for _, elem := range doc.Elements() {
+		key := elem.Key()
+		path := prefix + key
+
+		descended := false
+		if r.parents[path] {
+			if sub, ok := elem.Value().MutableDocumentOK(); ok {
+				sub = r.document(sub, path+".")
+				descended = true
+
+				if r.copy {
+					elem = birch.EC.SubDocument(key, sub)
+				}
+			}
+		}
+
+		if r.copy && !descended {
+			elem = birch.EC.Value(key, elem.Value().Clone())
+		}
+
+		if name, ok := r.mapping[path]; ok {
+			elem = birch.EC.Value(name, elem.Value())
+		}
+
+		elems = append(elems, elem)
+	}
+
+	if r.copy {
+		return birch.DC.Elements(elems...)
+	}
+
+	doc.Reset()
+	doc.Append(elems...)
+
+	return doc
+}
@@ -0,0 +1,113 @@
+package ftdc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+func TestRenameStream(t *testing.T) {
+	mapping := map[string]string{
+		"ts":                 "timestamp",
+		"opcounters.query":   "queries",
+		"network.bytes.in":   "bytesIn",
+		"missing.nested.key": "ignored",
+	}
+
+	makeDoc := func(i int64) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Int64("ts", i),
+			birch.EC.SubDocumentFromElements("opcounters",
+				birch.EC.Int64("insert", i),
+				birch.EC.Int64("query", i*2),
+			),
+			birch.EC.SubDocumentFromElements("network",
+				birch.EC.SubDocumentFromElements("bytes",
+					birch.EC.Int64("in", i*3),
+					birch.EC.Int64("out", i*4),
+				),
+			),
+			birch.EC.ArrayFromElements("query", birch.VC.Int(1)),
+		)
+	}
+
+	send := func(docs ...*birch.Document) <-chan *birch.Document {
+		in := make(chan *birch.Document, len(docs))
+		for _, doc := range docs {
+			in <- doc
+		}
+		close(in)
+
+		return in
+	}
+
+	check := func(t *testing.T, i int64, doc *birch.Document) {
+		keys := []string{}
+		for _, elem := range doc.Elements() {
+			keys = append(keys, elem.Key())
+		}
+		assert.Equal(t, []string{"timestamp", "opcounters", "network", "query"}, keys)
+
+		assert.Equal(t, i, doc.Lookup("timestamp").Int64())
+		assert.Equal(t, i, doc.RecursiveLookup("opcounters", "insert").Int64())
+		assert.Equal(t, i*2, doc.RecursiveLookup("opcounters", "queries").Int64())
+		assert.Nil(t, doc.RecursiveLookup("opcounters", "query"))
+		assert.Equal(t, i*3, doc.RecursiveLookup("network", "bytes", "bytesIn").Int64())
+		assert.Equal(t, i*4, doc.RecursiveLookup("network", "bytes", "out").Int64())
+		assert.Equal(t, "bytesIn", doc.RecursiveLookup("network", "bytes").MutableDocument().ElementAt(0).Key())
+	}
+
+	t.Run("InPlace", func(t *testing.T) {
+		source := []*birch.Document{makeDoc(1), makeDoc(2)}
+
+		idx := int64(0)
+		for doc := range RenameStream(send(source...), mapping) {
+			assert.Same(t, source[idx], doc)
+			check(t, idx+1, doc)
+			idx++
+		}
+		assert.Equal(t, int64(2), idx)
+	})
+	t.Run("Copy", func(t *testing.T) {
+		source := makeDoc(1)
+		before := source.String()
+
+		out := RenameStreamWithOptions(send(source), mapping, RenameOptions{Copy: true})
+		doc := <-out
+		check(t, 1, doc)
+		assert.Equal(t, before, source.String())
+
+		_, ok := <-out
+		assert.False(t, ok)
+
+		// the copy shares no values with the source.
+		assert.NotSame(t, source.Lookup("ts"), doc.Lookup("timestamp"))
+		doc.Lookup("query").MutableArray().Append(birch.VC.Int(2))
+		assert.Equal(t, 2, doc.Lookup("query").MutableArray().Len())
+		assert.Equal(t, before, source.String())
+	})
+	t.Run("Parsed", func(t *testing.T) {
+		for name, opts := range map[string]RenameOptions{"InPlace": {}, "Copy": {Copy: true}} {
+			t.Run(name, func(t *testing.T) {
+				data, err := makeDoc(3).MarshalBSON()
+				require.NoError(t, err)
+				source, err := birch.ReadDocument(data)
+				require.NoError(t, err)
+
+				doc := <-RenameStreamWithOptions(send(source), mapping, opts)
+				check(t, 3, doc)
+
+				out, err := doc.MarshalBSON()
+				require.NoError(t, err)
+				roundtrip, err := birch.ReadDocument(out)
+				require.NoError(t, err)
+				check(t, 3, roundtrip)
+			})
+		}
+	})
+	t.Run("Nil", func(t *testing.T) {
+		assert.Nil(t, <-RenameStream(send(nil), mapping))
+	})
+}
@@ -83,6 +83,23 @@ func produceMockChunkIter(ctx context.Context, samples int, newDoc func() *birch
 
 }
 
+// produceMockChunks writes samples documents, flushing a chunk after
+// every chunkSize documents, or once at the end when chunkSize is zero,
+// and returns the encoded chunks.
+func produceMockChunks(samples, chunkSize int, newDoc func(idx int) *birch.Document) []byte {
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf)
+	for idx := 0; idx < samples; idx++ {
+		panicIfError(cw.Add(newDoc(idx)))
+		if chunkSize > 0 && idx%chunkSize == chunkSize-1 {
+			panicIfError(cw.Flush())
+		}
+	}
+	panicIfError(cw.Flush())
+
+	return buf.Bytes()
+}
+
 func produceMockMetrics(ctx context.Context, samples int, newDoc func() *birch.Document) []Metric {
 	iter := produceMockChunkIter(ctx, samples, newDoc)
 
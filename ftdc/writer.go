@@ -15,10 +15,7 @@ type writerCollector struct {
 func NewWriterCollector(chunkSize int, writer io.WriteCloser) io.WriteCloser {
 	return &writerCollector{
 		writer: writer,
-		collector: &streamingDynamicCollector{
-			output:             writer,
-			streamingCollector: newStreamingCollector(chunkSize, writer),
-		},
+		collector: newStreamingDynamicCollector(chunkSize, writer, EncodingDelta),
 	}
 }
 
@@ -37,3 +34,130 @@ func (w *writerCollector) Close() error {
 
 	return errors.Wrap(w.writer.Close(), "problem closing underlying writer")
 }
+
+// ChunkWriter accumulates sample documents and writes them to an
+// underlying writer as a stream of FTDC chunks, which can be read
+// with ReadChunks, ReadMetrics, and related functions. It is a thin
+// wrapper around the streaming dynamic collector (see
+// NewStreamingDynamicCollector) that counts chunk sizes in samples
+// and makes flushing explicit.
+//
+// Samples are delta-encoded and compressed into a chunk that is
+// written when the chunk reaches its maximum number of samples, when
+// a sample's structure (keys or types) differs from the previous
+// sample, or when Flush is called. Callers must call Flush (or Close)
+// after adding the last sample to write any buffered data.
+//
+// ChunkWriter is not safe for concurrent use.
+type ChunkWriter struct {
+	output     io.Writer
+	maxSamples int
+	metadata   *birch.Document
+	encoding   EncodingScheme
+	collector  *streamingDynamicCollector
+}
+
+// DefaultChunkSamples is the number of samples in each chunk written
+// by a ChunkWriter unless changed with SetMaxSamples.
+const DefaultChunkSamples = 300
+
+// NewChunkWriter constructs a ChunkWriter that writes FTDC chunks to
+// the provided writer.
+func NewChunkWriter(w io.Writer) *ChunkWriter {
+	return &ChunkWriter{
+		output:     w,
+		maxSamples: DefaultChunkSamples,
+	}
+}
+
+// SetMaxSamples sets the maximum number of samples in each chunk,
+// taking effect for the next chunk: samples that are already buffered
+// are written as a chunk before the next sample is added. Values less
+// than 1 are ignored.
+func (cw *ChunkWriter) SetMaxSamples(n int) {
+	if n < 1 {
+		return
+	}
+
+	cw.maxSamples = n
+}
+
+// SetEncoding sets the encoding of the samples in subsequent chunks.
+// Samples that are already buffered are first written as a chunk with
+// the previous encoding. Only chunks with the default EncodingDelta
+// can be read by FTDC readers outside of this package. With
+// EncodingPerMetric, the writer selects the encoding of each metric in
+// each chunk.
+func (cw *ChunkWriter) SetEncoding(scheme EncodingScheme) error {
+	if err := scheme.validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if scheme == cw.encoding {
+		return nil
+	}
+
+	if err := cw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	cw.encoding = scheme
+	cw.collector = nil
+
+	return nil
+}
+
+// SetMetadata sets a metadata document which is written before every
+// subsequent chunk. Pass nil to stop writing metadata.
+func (cw *ChunkWriter) SetMetadata(doc *birch.Document) {
+	cw.metadata = doc
+	if cw.collector != nil {
+		_ = cw.collector.SetMetadata(doc)
+	}
+}
+
+// Add adds a sample to the current chunk, first writing the current
+// chunk if it is full or if the sample's structure differs from that
+// of the previous sample.
+func (cw *ChunkWriter) Add(sample *birch.Document) error {
+	if sample == nil {
+		return errors.New("cannot add nil sample")
+	}
+
+	// the streaming collector's limit is the number of samples
+	// after the first, which is the chunk's reference document.
+	if cw.collector == nil || cw.collector.maxSamples != cw.maxSamples-1 {
+		if err := cw.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		cw.collector = newStreamingDynamicCollector(cw.maxSamples-1, cw.output, cw.encoding)
+		_ = cw.collector.SetMetadata(cw.metadata)
+	}
+
+	return errors.Wrap(cw.collector.Add(sample), "problem adding sample to chunk")
+}
+
+// Flush writes any buffered samples to the underlying writer as a
+// chunk. Flush is a noop if there are no buffered samples.
+func (cw *ChunkWriter) Flush() error {
+	if cw.collector == nil {
+		return nil
+	}
+
+	return errors.Wrap(FlushCollector(cw.collector, cw.output), "problem writing chunk")
+}
+
+// Close flushes any buffered samples and, if the underlying writer is
+// an io.Closer, closes it.
+func (cw *ChunkWriter) Close() error {
+	if err := cw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if closer, ok := cw.output.(io.Closer); ok {
+		return errors.Wrap(closer.Close(), "problem closing underlying writer")
+	}
+
+	return nil
+}
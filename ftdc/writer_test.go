@@ -0,0 +1,151 @@
+package ftdc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error { c.closed = true; return nil }
+
+func TestChunkWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	makeSample := func(i int64) *birch.Document {
+		return birch.DC.Elements(
+			birch.EC.Int64("counter", i*10),
+			birch.EC.SubDocumentFromElements("nested",
+				birch.EC.Int32("gauge", int32(i%7)),
+				birch.EC.Boolean("flag", i%2 == 0),
+			),
+		)
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(10)
+		cw.SetMetadata(birch.DC.Elements(birch.EC.String("host", "localhost")))
+
+		for i := int64(0); i < 25; i++ {
+			require.NoError(t, cw.Add(makeSample(i)))
+		}
+		require.NoError(t, cw.Flush())
+
+		var sizes []int
+		chunks := ReadChunks(ctx, bytes.NewBuffer(buf.Bytes()))
+		for chunks.Next() {
+			chunk := chunks.Chunk()
+			sizes = append(sizes, chunk.Size())
+			assert.Equal(t, "localhost", chunk.GetMetadata().RecursiveLookup("doc", "host").StringValue())
+		}
+		require.NoError(t, chunks.Err())
+		assert.Equal(t, []int{10, 10, 5}, sizes)
+
+		iter := ReadStructuredMetrics(ctx, bytes.NewBuffer(buf.Bytes()))
+		idx := int64(0)
+		for iter.Next() {
+			doc := iter.Document()
+			assert.Equal(t, idx*10, doc.Lookup("counter").Int64())
+			assert.EqualValues(t, idx%7, doc.RecursiveLookup("nested", "gauge").Interface())
+			assert.Equal(t, idx%2 == 0, doc.RecursiveLookup("nested", "flag").Boolean())
+			idx++
+		}
+		require.NoError(t, iter.Err())
+		assert.Equal(t, int64(25), idx)
+	})
+	t.Run("SchemaChange", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+
+		for i := int64(0); i < 3; i++ {
+			require.NoError(t, cw.Add(makeSample(i)))
+		}
+		for i := int64(0); i < 2; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Int64("counter", i))))
+		}
+		for i := int64(0); i < 4; i++ {
+			require.NoError(t, cw.Add(birch.DC.Elements(birch.EC.Double("counter", float64(i)))))
+		}
+		require.NoError(t, cw.Flush())
+
+		var sizes []int
+		chunks := ReadChunks(ctx, bytes.NewBuffer(buf.Bytes()))
+		for chunks.Next() {
+			sizes = append(sizes, chunks.Chunk().Size())
+		}
+		require.NoError(t, chunks.Err())
+		assert.Equal(t, []int{3, 2, 4}, sizes)
+	})
+	t.Run("ResizeBetweenChunks", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(4)
+
+		for i := int64(0); i < 6; i++ {
+			require.NoError(t, cw.Add(makeSample(i)))
+		}
+		cw.SetMaxSamples(3)
+		for i := int64(6); i < 12; i++ {
+			require.NoError(t, cw.Add(makeSample(i)))
+		}
+		require.NoError(t, cw.Flush())
+
+		var sizes []int
+		chunks := ReadChunks(ctx, bytes.NewBuffer(buf.Bytes()))
+		for chunks.Next() {
+			sizes = append(sizes, chunks.Chunk().Size())
+		}
+		require.NoError(t, chunks.Err())
+		assert.Equal(t, []int{4, 2, 3, 3}, sizes)
+	})
+	t.Run("SingleSampleChunks", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := NewChunkWriter(buf)
+		cw.SetMaxSamples(1)
+
+		for i := int64(0); i < 3; i++ {
+			require.NoError(t, cw.Add(makeSample(i)))
+		}
+		require.NoError(t, cw.Flush())
+
+		count := 0
+		chunks := ReadChunks(ctx, bytes.NewBuffer(buf.Bytes()))
+		for chunks.Next() {
+			assert.Equal(t, 1, chunks.Chunk().Size())
+			count++
+		}
+		require.NoError(t, chunks.Err())
+		assert.Equal(t, 3, count)
+	})
+	t.Run("Close", func(t *testing.T) {
+		out := &closeRecorder{}
+		cw := NewChunkWriter(out)
+		require.NoError(t, cw.Close())
+		assert.True(t, out.closed)
+		assert.Equal(t, 0, out.Len())
+
+		out = &closeRecorder{}
+		cw = NewChunkWriter(out)
+		require.NoError(t, cw.Add(makeSample(1)))
+		require.NoError(t, cw.Close())
+		assert.True(t, out.closed)
+		assert.NotZero(t, out.Len())
+	})
+	t.Run("Errors", func(t *testing.T) {
+		cw := NewChunkWriter(nil)
+		assert.Error(t, cw.Add(nil))
+		require.NoError(t, cw.Add(makeSample(1)))
+		assert.Error(t, cw.Flush())
+	})
+}
@@ -67,7 +67,7 @@ func NewFromIOReader(r io.Reader) (Reader, error) {
 // Validate validates the document. This method only validates the first document in
 // the slice, to validate other documents, the slice must be resliced.
 func (r Reader) Validate() (size uint32, err error) {
-	return r.readElements(func(elem *Element) error {
+	size, err = r.readElements(func(elem *Element) error {
 		var err error
 		switch elem.value.Type() {
 		case '\x03':
@@ -77,6 +77,17 @@ func (r Reader) Validate() (size uint32, err error) {
 		}
 		return err
 	})
+	if err != nil {
+		return size, err
+	}
+
+	// the null terminator must be the last byte of the document, as
+	// given by its length.
+	if int32(size) != readi32(r[0:4]) {
+		return size, bsonerr.InvalidLength
+	}
+
+	return size, nil
 }
 
 // validateKey will ensure the key is valid and return the length of the key
@@ -74,6 +74,13 @@ func TestReader(t *testing.T) {
 				t.Errorf("Did not get expected error. got %v; want %v", got, want)
 			}
 		})
+		t.Run("MarshalBSON-early-terminator", func(t *testing.T) {
+			r := Reader{'\x08', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00'}
+			_, err := r.MarshalBSON()
+			if err != bsonerr.InvalidLength {
+				t.Errorf("Did not get expected error. got %v; want %v", err, bsonerr.InvalidLength)
+			}
+		})
 		t.Run("validateValue-error", func(t *testing.T) {
 			want := newErrTooSmall()
 			r := make(Reader, 11)
@@ -111,6 +118,20 @@ func TestReader(t *testing.T) {
 				},
 				21, nil,
 			},
+			{"early-terminator",
+				Reader{'\x08', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00'},
+				5, bsonerr.InvalidLength,
+			},
+			{"subdocument-early-terminator",
+				Reader{
+					'\x12', '\x00', '\x00', '\x00',
+					'\x03',
+					'f', 'o', 'o', '\x00',
+					'\x08', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00',
+					'\x00',
+				},
+				17, bsonerr.InvalidLength,
+			},
 		}
 
 		for _, tc := range testCases {
@@ -188,6 +188,24 @@ func (v *Value) IsEmpty() bool {
 	}
 }
 
+// IsUndefined returns true if the value is the deprecated BSON
+// Undefined type.
+func (v *Value) IsUndefined() bool {
+	return v != nil && v.offset != 0 && v.data != nil && bsontype.Type(v.data[v.start]) == bsontype.Undefined
+}
+
+// IsMinKey returns true if the value is the BSON MinKey sentinel,
+// which sorts before all other values.
+func (v *Value) IsMinKey() bool {
+	return v != nil && v.offset != 0 && v.data != nil && bsontype.Type(v.data[v.start]) == bsontype.MinKey
+}
+
+// IsMaxKey returns true if the value is the BSON MaxKey sentinel,
+// which sorts after all other values.
+func (v *Value) IsMaxKey() bool {
+	return v != nil && v.offset != 0 && v.data != nil && bsontype.Type(v.data[v.start]) == bsontype.MaxKey
+}
+
 // Validate validates the value.
 func (v *Value) Validate() error {
 	_, err := v.validate(false)
@@ -217,7 +235,11 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 		l := readi32(v.data[v.offset : v.offset+4])
 		total += 4
 
-		if int32(v.offset)+4+l > int32(len(v.data)) {
+		if l < 1 {
+			return total, bsonerr.InvalidString
+		}
+
+		if int64(v.offset)+4+int64(l) > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 		// We check if the value that is the last element of the string is a
@@ -252,7 +274,7 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 			return total, bsonerr.InvalidReadOnlyDocument
 		}
 
-		if int32(v.offset)+l > int32(len(v.data)) {
+		if int64(v.offset)+int64(l) > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 
@@ -291,7 +313,7 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 			return total, bsonerr.InvalidReadOnlyDocument
 		}
 
-		if int32(v.offset)+l > int32(len(v.data)) {
+		if int64(v.offset)+int64(l) > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 
@@ -315,11 +337,15 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 		l := readi32(v.data[v.offset : v.offset+4])
 		total += 5
 
+		if l < 0 {
+			return total, bsonerr.InvalidLength
+		}
+
 		if v.data[v.offset+4] > '\x05' && v.data[v.offset+4] < '\x80' {
 			return total, bsonerr.InvalidBinarySubtype
 		}
 
-		if int32(v.offset)+5+l > int32(len(v.data)) {
+		if int64(v.offset)+5+int64(l) > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 
@@ -373,7 +399,11 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 		l := readi32(v.data[v.offset : v.offset+4])
 		total += 4
 
-		if int32(v.offset)+4+l+12 > int32(len(v.data)) {
+		if l < 1 {
+			return total, bsonerr.InvalidString
+		}
+
+		if int64(v.offset)+4+int64(l)+12 > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 
@@ -419,11 +449,19 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 		l := readi32(v.data[v.offset : v.offset+4])
 		total += 4
 
-		if int32(v.offset)+l > int32(len(v.data)) {
+		if l < 4 {
+			return total, bsonerr.InvalidLength
+		}
+
+		if int64(v.offset)+int64(l) > int64(len(v.data)) {
 			return total, newErrTooSmall()
 		}
 
 		if !sizeOnly {
+			if int(v.offset+8) > len(v.data) {
+				return total, newErrTooSmall()
+			}
+
 			sLength := readi32(v.data[v.offset+4 : v.offset+8])
 			total += 4
 			// If the length of the string is larger than the total length of the
@@ -435,6 +473,10 @@ func (v *Value) validate(sizeOnly bool) (uint32, error) {
 			if sLength > l-13 {
 				return total, bsonerr.StringLargerThanContainer
 			}
+
+			if sLength < 1 {
+				return total, bsonerr.InvalidString
+			}
 			// We check if the value that is the last element of the string is a
 			// null terminator. We take the value offset, add 4 to account for the
 			// length, add the length of the string, and subtract one since the size
@@ -497,6 +539,8 @@ func (v *Value) Type() bsontype.Type {
 
 // Double returns the float64 value for this element.
 // It panics if e's BSON type is not double ('\x01') or if e is uninitialized.
+// Double does not convert integer values; use DoubleOK to check the
+// type, or DoubleLenientOK to accept integers as well.
 func (v *Value) Double() float64 {
 	if v == nil || v.offset == 0 || v.data == nil {
 		panic(bsonerr.UninitializedElement)
@@ -1027,6 +1071,19 @@ func (v *Value) MutableJavaScriptWithScopeOK() (string, *Document, bool) {
 	return s, d, true
 }
 
+// JavaScriptWithScope returns the javascript code and the scope
+// document for this value. It panics if the value is a BSON type other
+// than JavaScript code with scope.
+func (v *Value) JavaScriptWithScope() (string, *Document) {
+	return v.MutableJavaScriptWithScope()
+}
+
+// JavaScriptWithScopeOK is the same as JavaScriptWithScope, except
+// that it returns a boolean instead of panicking.
+func (v *Value) JavaScriptWithScopeOK() (string, *Document, bool) {
+	return v.MutableJavaScriptWithScopeOK()
+}
+
 // Int32 returns the int32 the Value represents. It panics if the value is a BSON type other than
 // int32.
 func (v *Value) Int32() int32 {
@@ -1147,9 +1204,10 @@ func (v *Value) Decimal128() types.Decimal128 {
 		panic(bsonerr.NewElementTypeError("compact.Element.Decimal128", bsontype.Type(v.data[v.start])))
 	}
 
+	// the low word of the decimal is stored first.
 	return types.NewDecimal128(
-		binary.LittleEndian.Uint64(v.data[v.offset:v.offset+8]),
-		binary.LittleEndian.Uint64(v.data[v.offset+8:v.offset+16]))
+		binary.LittleEndian.Uint64(v.data[v.offset+8:v.offset+16]),
+		binary.LittleEndian.Uint64(v.data[v.offset:v.offset+8]))
 }
 
 // Decimal128OK is the same as Decimal128, except that it returns a boolean
@@ -1213,7 +1271,7 @@ func (v *Value) docToBytes(t bsontype.Type) ([]byte, error) {
 
 		code, _, ok := readJavaScriptValue(v.data[v.offset+4:])
 		if !ok {
-			return nil, errors.New("invalid code component")
+			return nil, errors.Wrap(bsonerr.CorruptDocument, "invalid code component")
 		}
 
 		return appendCodeWithScope(nil, code, scope), nil
@@ -5,8 +5,11 @@ import (
 	"math"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tychoish/birch/bsonerr"
 	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
 )
 
 func TestValue(t *testing.T) {
@@ -209,4 +212,54 @@ func TestValue(t *testing.T) {
 			})
 		}
 	})
+	t.Run("JavaScript", func(t *testing.T) {
+		doc := DC.Elements(
+			EC.JavaScript("code", "function() { return 1; }"),
+			EC.JavaScriptWithScope("cws", "function() { return x; }", DC.Elements(EC.Int32("x", 42))),
+		)
+
+		t.Run("BSON", func(t *testing.T) {
+			out, err := doc.MarshalBSON()
+			require.NoError(t, err)
+
+			rt, err := ReadDocument(out)
+			require.NoError(t, err)
+
+			code, ok := rt.Lookup("code").JavaScriptOK()
+			require.True(t, ok)
+			assert.Equal(t, "function() { return 1; }", code)
+
+			code, scope, ok := rt.Lookup("cws").JavaScriptWithScopeOK()
+			require.True(t, ok)
+			assert.Equal(t, "function() { return x; }", code)
+			assert.Equal(t, int32(42), scope.Lookup("x").Int32())
+
+			_, _, ok = rt.Lookup("code").JavaScriptWithScopeOK()
+			assert.False(t, ok)
+		})
+		t.Run("ExtendedJSON", func(t *testing.T) {
+			out, err := doc.MarshalJSON()
+			require.NoError(t, err)
+			assert.Equal(t, `{"code":{"$code":"function() { return 1; }"},"cws":{"$code":"function() { return x; }","$scope":{"x":42}}}`, string(out))
+
+			rt := DC.New()
+			require.NoError(t, rt.UnmarshalJSON(out))
+			assert.Equal(t, "function() { return 1; }", rt.Lookup("code").JavaScript())
+
+			code, scope := rt.Lookup("cws").JavaScriptWithScope()
+			assert.Equal(t, "function() { return x; }", code)
+			assert.EqualValues(t, 42, scope.Lookup("x").Interface())
+		})
+	})
+}
+
+func TestDecimal128RoundTrip(t *testing.T) {
+	for _, in := range []string{"42", "-0.5", "1.5E+3", "NaN", "Infinity"} {
+		d, err := types.ParseDecimal128(in)
+		require.NoError(t, err)
+
+		v := VC.Decimal128(d)
+		assert.Equal(t, d, v.Decimal128(), in)
+		assert.Equal(t, in, v.Decimal128().String())
+	}
 }
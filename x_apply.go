@@ -0,0 +1,92 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Apply replaces every leaf value in the document with the value
+// returned by fn, modifying the document in place. Returning the
+// original value leaves the element unchanged, returning a different
+// value (of any type) replaces it, and returning nil removes the
+// element from its containing document or array.
+//
+// Apply descends into sub-documents and arrays rather than passing
+// them to fn, and visits elements depth first in document order. The
+// path passed to fn is the dot-separated sequence of keys to the
+// value, using the position for elements of arrays (e.g.
+// "metrics.samples.2"); array positions refer to the array as it was
+// before any elements were removed. Values returned by fn are not
+// themselves visited, even when they are documents or arrays.
+func (d *Document) Apply(fn func(path string, v *Value) *Value) {
+	if d == nil {
+		return
+	}
+
+	d.apply("", false, fn)
+}
+
+func (d *Document) apply(prefix string, isArray bool, fn func(string, *Value) *Value) {
+	var changes []elementChange
+	kept := d.elems[:0]
+
+	for idx, elem := range d.elems {
+		var key string
+		if isArray {
+			key = strconv.Itoa(idx)
+		} else {
+			key = elem.Key()
+		}
+
+		path := prefix + key
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			elem.value.MutableDocument().apply(path+".", false, fn)
+		case bsontype.Array:
+			elem.value.MutableArray().doc.apply(path+".", true, fn)
+		default:
+			out := fn(path, elem.value)
+
+			switch {
+			case out == nil:
+				if d.onChange != nil {
+					changes = append(changes, elementChange{op: OpDelete, elem: elem})
+				}
+				continue
+			case out == elem.value:
+			case isArray:
+				elem = &Element{out}
+			default:
+				elem = EC.Value(key, out)
+				if d.onChange != nil {
+					changes = append(changes, elementChange{op: OpSet, elem: elem})
+				}
+			}
+		}
+
+		kept = append(kept, elem)
+	}
+
+	defer d.changedAll(changes)
+
+	if len(kept) == len(d.elems) {
+		return
+	}
+
+	for idx := len(kept); idx < len(d.elems); idx++ {
+		d.elems[idx] = nil
+	}
+
+	// removing elements invalidates the positions in the key index,
+	// so rebuild it, without reporting the kept elements to the
+	// OnChange hook as additions; the removals are reported once the
+	// document is consistent again.
+	hook := d.onChange
+	d.onChange = nil
+	d.elems = d.elems[:0]
+	d.index = d.index[:0]
+	d.Append(kept...)
+	d.onChange = hook
+}
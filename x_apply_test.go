@@ -0,0 +1,148 @@
+package birch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestApply(t *testing.T) {
+	makeDoc := func() *Document {
+		return DC.Elements(
+			EC.Int64("ops", 10),
+			EC.String("host", "localhost"),
+			EC.SubDocumentFromElements("metrics",
+				EC.Double("latency", 1.5),
+				EC.String("drop_me", "x"),
+				EC.ArrayFromElements("samples", VC.Int32(1), VC.String("drop_me"), VC.Int32(3)),
+			),
+			EC.Boolean("ok", true),
+		)
+	}
+
+	t.Run("Paths", func(t *testing.T) {
+		var paths []string
+		makeDoc().Apply(func(path string, v *Value) *Value {
+			paths = append(paths, path)
+			return v
+		})
+
+		assert.Equal(t, []string{
+			"ops", "host", "metrics.latency", "metrics.drop_me",
+			"metrics.samples.0", "metrics.samples.1", "metrics.samples.2", "ok",
+		}, paths)
+	})
+	t.Run("Unchanged", func(t *testing.T) {
+		doc := makeDoc()
+		doc.Apply(func(_ string, v *Value) *Value { return v })
+
+		expected, err := makeDoc().MarshalBSON()
+		require.NoError(t, err)
+		actual, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+	t.Run("Scale", func(t *testing.T) {
+		doc := makeDoc()
+		doc.Apply(func(_ string, v *Value) *Value {
+			switch v.Type() {
+			case bsontype.Int32:
+				return VC.Int32(v.Int32() * 2)
+			case bsontype.Int64:
+				return VC.Int64(v.Int64() * 2)
+			case bsontype.Double:
+				return VC.Double(v.Double() * 2)
+			default:
+				return v
+			}
+		})
+
+		assert.Equal(t, int64(20), doc.Lookup("ops").Int64())
+		assert.Equal(t, 3.0, doc.RecursiveLookup("metrics", "latency").Double())
+		assert.Equal(t, int32(6), doc.RecursiveLookup("metrics", "samples").MutableArray().Lookup(2).Int32())
+		assert.Equal(t, "localhost", doc.Lookup("host").StringValue())
+	})
+	t.Run("Delete", func(t *testing.T) {
+		doc := makeDoc()
+		doc.Apply(func(path string, v *Value) *Value {
+			if strings.HasSuffix(path, "drop_me") {
+				return nil
+			}
+
+			if s, ok := v.StringValueOK(); ok && s == "drop_me" {
+				return nil
+			}
+
+			return v
+		})
+
+		require.Equal(t, 4, doc.Len())
+		metrics := doc.Lookup("metrics").MutableDocument()
+		assert.Equal(t, 2, metrics.Len())
+		assert.Nil(t, metrics.Lookup("drop_me"))
+		assert.NotNil(t, metrics.Lookup("latency"))
+
+		samples := metrics.Lookup("samples").MutableArray()
+		require.Equal(t, 2, samples.Len())
+		assert.Equal(t, int32(3), samples.Lookup(1).Int32())
+
+		out, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		rt, err := ReadDocument(out)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), rt.RecursiveLookup("metrics", "samples", "1").Int32())
+		assert.True(t, rt.Lookup("ok").Boolean())
+	})
+	t.Run("DeleteAll", func(t *testing.T) {
+		doc := makeDoc()
+		doc.Apply(func(string, *Value) *Value { return nil })
+
+		assert.Equal(t, 1, doc.Len())
+		metrics := doc.Lookup("metrics").MutableDocument()
+		assert.Equal(t, 1, metrics.Len())
+		assert.Equal(t, 0, metrics.Lookup("samples").MutableArray().Len())
+	})
+	t.Run("ChangeType", func(t *testing.T) {
+		doc := makeDoc()
+		doc.Apply(func(path string, v *Value) *Value {
+			if path == "host" {
+				return VC.DocumentFromElements(EC.String("name", v.StringValue()))
+			}
+
+			if path == "metrics.samples.0" {
+				return VC.String("one")
+			}
+
+			return v
+		})
+
+		assert.Equal(t, "localhost", doc.RecursiveLookup("host", "name").StringValue())
+		assert.Equal(t, "one", doc.RecursiveLookup("metrics", "samples").MutableArray().Lookup(0).StringValue())
+		assert.Equal(t, int64(10), doc.Lookup("ops").Int64())
+
+		out, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		rt, err := ReadDocument(out)
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", rt.RecursiveLookup("host", "name").StringValue())
+	})
+	t.Run("NewContainersNotVisited", func(t *testing.T) {
+		count := 0
+		doc := DC.Elements(EC.Int("a", 1))
+		doc.Apply(func(_ string, v *Value) *Value {
+			count++
+			return VC.DocumentFromElements(EC.Int("b", 2))
+		})
+
+		assert.Equal(t, 1, count)
+		assert.Equal(t, 2, doc.RecursiveLookup("a", "b").Int())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			(*Document)(nil).Apply(func(_ string, v *Value) *Value { return v })
+		})
+	})
+}
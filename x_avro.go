@@ -0,0 +1,587 @@
+package birch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// avroBlockSize is the number of documents that WriteAvro encodes in
+// each block of the container file.
+const avroBlockSize = 1000
+
+var avroNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// avroTypes maps BSON types to the Avro types that can hold their
+// values, in order of preference. InferAvroSchema uses the first
+// type, and WriteAvro chooses the first of the types that appears in
+// a union.
+var avroTypes = map[bsontype.Type][]string{
+	bsontype.Null:             {"null"},
+	bsontype.Boolean:          {"boolean"},
+	bsontype.Int32:            {"int", "long", "double"},
+	bsontype.Int64:            {"long", "double"},
+	bsontype.Double:           {"double", "float"},
+	bsontype.DateTime:         {"timestamp-millis", "long"},
+	bsontype.Timestamp:        {"long"},
+	bsontype.String:           {"string"},
+	bsontype.Symbol:           {"string"},
+	bsontype.JavaScript:       {"string"},
+	bsontype.ObjectID:         {"string"},
+	bsontype.Decimal128:       {"string"},
+	bsontype.Binary:           {"bytes"},
+	bsontype.EmbeddedDocument: {"record"},
+	bsontype.Array:            {"array"},
+}
+
+// InferAvroSchema returns an Avro schema, as JSON, for a record type
+// that can hold each of the documents, for use with WriteAvro. The
+// schema has a field for every key that appears in any of the
+// documents, in order of first appearance. Fields that are missing
+// from some documents, or are null in some documents, are unions
+// with null that default to null, and fields with values of several
+// types are unions of those types.
+//
+// Embedded documents become nested records, and arrays become Avro
+// arrays of the union of the types of their elements. Values map to
+// Avro types as follows:
+//
+//   - Boolean, Int32 (int), Int64 (long) and Double: the corresponding
+//     Avro type.
+//   - DateTime: a long with the timestamp-millis logical type, or a
+//     long in unions that also hold Int64 values.
+//   - Timestamp: a long holding the seconds in the high 32 bits and
+//     the increment in the low 32 bits.
+//   - String, Symbol, JavaScript, ObjectID (as hex) and Decimal128:
+//     string.
+//   - Binary: bytes.
+//
+// InferAvroSchema returns an error if there are no documents, if a
+// document has a value of another type, or has a key that is not a
+// valid Avro name or that appears more than once in the document.
+func InferAvroSchema(docs []*Document) (string, error) {
+	if len(docs) == 0 {
+		return "", errors.New("cannot infer a schema without documents")
+	}
+
+	root := &avroRecord{name: "Record", index: map[string]*avroField{}}
+	records := 1
+
+	for idx, doc := range docs {
+		if doc == nil {
+			return "", errors.Errorf("document %d is nil", idx)
+		}
+
+		if err := root.merge(doc, &records); err != nil {
+			return "", errors.Wrapf(err, "document %d", idx)
+		}
+	}
+
+	out, err := json.Marshal(root.schema())
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(out), nil
+}
+
+type avroRecord struct {
+	name   string
+	fields []*avroField
+	index  map[string]*avroField
+	count  int
+}
+
+type avroField struct {
+	name  string
+	types avroUnion
+	count int
+}
+
+type avroUnion []*avroInferred
+
+type avroInferred struct {
+	kind   string
+	record *avroRecord
+	items  avroUnion
+}
+
+func (r *avroRecord) merge(doc *Document, records *int) error {
+	r.count++
+	seen := make(map[string]struct{}, len(doc.elems))
+
+	for _, elem := range doc.elems {
+		key := elem.Key()
+		if !avroNamePattern.MatchString(key) {
+			return errors.Errorf("key '%s' is not a valid Avro name", key)
+		}
+		if _, ok := seen[key]; ok {
+			return errors.Errorf("duplicate key '%s'", key)
+		}
+		seen[key] = struct{}{}
+
+		field, ok := r.index[key]
+		if !ok {
+			field = &avroField{name: key}
+			r.index[key] = field
+			r.fields = append(r.fields, field)
+		}
+		field.count++
+
+		if err := field.types.merge(elem.value, records); err != nil {
+			return errors.Wrapf(err, "field '%s'", key)
+		}
+	}
+
+	return nil
+}
+
+func (u *avroUnion) merge(v *Value, records *int) error {
+	kinds, ok := avroTypes[v.Type()]
+	if !ok {
+		return errors.Errorf("values of type %s have no Avro equivalent", v.Type())
+	}
+
+	t := u.get(kinds[0])
+
+	switch t.kind {
+	case "record":
+		if t.record == nil {
+			t.record = &avroRecord{name: "Record_" + strconv.Itoa(*records), index: map[string]*avroField{}}
+			*records++
+		}
+		return t.record.merge(v.MutableDocument(), records)
+	case "array":
+		for _, elem := range v.MutableArray().doc.elems {
+			if err := t.items.merge(elem.value, records); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *avroUnion) get(kind string) *avroInferred {
+	for _, t := range *u {
+		if t.kind == kind {
+			return t
+		}
+	}
+
+	t := &avroInferred{kind: kind}
+	*u = append(*u, t)
+
+	return t
+}
+
+func (u avroUnion) schema(optional bool) interface{} {
+	var hasLong bool
+	for _, t := range u {
+		hasLong = hasLong || t.kind == "long"
+	}
+
+	out := []interface{}{}
+	if optional {
+		out = append(out, "null")
+	}
+
+	for _, t := range u {
+		switch {
+		case t.kind == "null" && optional:
+		case t.kind == "null":
+			out = append([]interface{}{"null"}, out...)
+		case t.kind == "timestamp-millis" && hasLong:
+			// unions cannot hold two longs, and WriteAvro
+			// writes datetimes to longs.
+		default:
+			out = append(out, t.schema())
+		}
+	}
+
+	switch len(out) {
+	case 0:
+		return "null"
+	case 1:
+		return out[0]
+	default:
+		return out
+	}
+}
+
+func (t *avroInferred) schema() interface{} {
+	switch t.kind {
+	case "record":
+		return t.record.schema()
+	case "array":
+		return map[string]interface{}{"type": "array", "items": t.items.schema(false)}
+	case "timestamp-millis":
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}
+	default:
+		return t.kind
+	}
+}
+
+type avroRecordSchema struct {
+	Type   string            `json:"type"`
+	Name   string            `json:"name"`
+	Fields []avroFieldSchema `json:"fields"`
+}
+
+type avroFieldSchema struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+func (r *avroRecord) schema() avroRecordSchema {
+	out := avroRecordSchema{Type: "record", Name: r.name, Fields: make([]avroFieldSchema, len(r.fields))}
+
+	for idx, field := range r.fields {
+		optional := field.count < r.count
+		out.Fields[idx] = avroFieldSchema{Name: field.name, Type: field.types.schema(optional)}
+
+		if first, ok := out.Fields[idx].Type.([]interface{}); (ok && first[0] == "null") || out.Fields[idx].Type == "null" {
+			out.Fields[idx].Default = json.RawMessage("null")
+		}
+	}
+
+	return out
+}
+
+// WriteAvro writes the documents to w as an Avro object container
+// file, using the schema, which must describe a record, such as the
+// schemas that InferAvroSchema returns. Schemas may use the null,
+// boolean, int, long, float, double, bytes and string types, the
+// timestamp-millis logical type, records, arrays, unions and
+// references to named records. Documents are matched to records by
+// key; keys without a field in the schema are ignored, and missing
+// keys are written as null.
+//
+// Values are written to the first type that holds them, as described
+// by InferAvroSchema: for example, Int32 values may be written to
+// int, long or double types. WriteAvro returns an error if the schema
+// is not valid or if a value does not match its type, and, because it
+// writes in blocks, may have written part of the file.
+func WriteAvro(w io.Writer, schema string, docs []*Document) error {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(schema), &raw); err != nil {
+		return errors.Wrap(err, "problem parsing schema")
+	}
+
+	root, err := parseAvroSchema(raw, map[string]*avroSchema{})
+	if err != nil {
+		return errors.Wrap(err, "invalid schema")
+	}
+
+	if root.kind != "record" {
+		return errors.Errorf("schema must be a record, not %s", root.kind)
+	}
+
+	sync := make([]byte, 16)
+	if _, err = rand.Read(sync); err != nil {
+		return errors.Wrap(err, "problem generating sync marker")
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("Obj\x01")
+	putAvroLong(buf, 2)
+	putAvroBytes(buf, []byte("avro.schema"))
+	putAvroBytes(buf, []byte(schema))
+	putAvroBytes(buf, []byte("avro.codec"))
+	putAvroBytes(buf, []byte("null"))
+	putAvroLong(buf, 0)
+	buf.Write(sync)
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "problem writing header")
+	}
+
+	block := &bytes.Buffer{}
+	for start := 0; start < len(docs); start += avroBlockSize {
+		end := start + avroBlockSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+
+		block.Reset()
+		for idx := start; idx < end; idx++ {
+			if docs[idx] == nil {
+				return errors.Errorf("document %d is nil", idx)
+			}
+
+			if err = root.writeRecord(block, docs[idx]); err != nil {
+				return errors.Wrapf(err, "document %d", idx)
+			}
+		}
+
+		buf.Reset()
+		putAvroLong(buf, int64(end-start))
+		putAvroLong(buf, int64(block.Len()))
+		buf.Write(block.Bytes())
+		buf.Write(sync)
+
+		if _, err = w.Write(buf.Bytes()); err != nil {
+			return errors.Wrap(err, "problem writing block")
+		}
+	}
+
+	return nil
+}
+
+type avroSchema struct {
+	kind     string
+	fields   []avroSchemaField
+	items    *avroSchema
+	branches []*avroSchema
+}
+
+type avroSchemaField struct {
+	name   string
+	schema *avroSchema
+}
+
+func parseAvroSchema(raw interface{}, names map[string]*avroSchema) (*avroSchema, error) {
+	switch spec := raw.(type) {
+	case string:
+		switch spec {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroSchema{kind: spec}, nil
+		}
+
+		if named, ok := names[spec]; ok {
+			return named, nil
+		}
+
+		return nil, errors.Errorf("unsupported type '%s'", spec)
+	case []interface{}:
+		out := &avroSchema{kind: "union", branches: make([]*avroSchema, len(spec))}
+		for idx := range spec {
+			branch, err := parseAvroSchema(spec[idx], names)
+			if err != nil {
+				return nil, err
+			}
+			if branch.kind == "union" {
+				return nil, errors.New("unions cannot contain unions")
+			}
+			out.branches[idx] = branch
+		}
+
+		return out, nil
+	case map[string]interface{}:
+		switch spec["type"] {
+		case "record":
+			return parseAvroRecord(spec, names)
+		case "array":
+			items, err := parseAvroSchema(spec["items"], names)
+			if err != nil {
+				return nil, errors.Wrap(err, "array items")
+			}
+
+			return &avroSchema{kind: "array", items: items}, nil
+		case "long":
+			if spec["logicalType"] == "timestamp-millis" {
+				return &avroSchema{kind: "timestamp-millis"}, nil
+			}
+		}
+
+		if kind, ok := spec["type"].(string); ok {
+			return parseAvroSchema(kind, names)
+		}
+
+		return nil, errors.Errorf("unsupported type %v", spec["type"])
+	default:
+		return nil, errors.Errorf("unsupported schema %v", raw)
+	}
+}
+
+func parseAvroRecord(spec map[string]interface{}, names map[string]*avroSchema) (*avroSchema, error) {
+	name, ok := spec["name"].(string)
+	if !ok || name == "" {
+		return nil, errors.New("records must have a name")
+	}
+
+	fields, ok := spec["fields"].([]interface{})
+	if !ok {
+		return nil, errors.Errorf("record '%s' must have fields", name)
+	}
+
+	out := &avroSchema{kind: "record", fields: make([]avroSchemaField, len(fields))}
+	names[name] = out
+
+	for idx := range fields {
+		field, ok := fields[idx].(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid field %d of record '%s'", idx, name)
+		}
+
+		fieldName, ok := field["name"].(string)
+		if !ok {
+			return nil, errors.Errorf("field %d of record '%s' must have a name", idx, name)
+		}
+
+		schema, err := parseAvroSchema(field["type"], names)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field '%s' of record '%s'", fieldName, name)
+		}
+
+		out.fields[idx] = avroSchemaField{name: fieldName, schema: schema}
+	}
+
+	return out, nil
+}
+
+func (s *avroSchema) writeRecord(buf *bytes.Buffer, doc *Document) error {
+	for _, field := range s.fields {
+		if err := field.schema.write(buf, doc.Lookup(field.name)); err != nil {
+			return errors.Wrapf(err, "field '%s'", field.name)
+		}
+	}
+
+	return nil
+}
+
+// accepts reports whether the schema can hold the value; missing
+// values are nulls.
+func (s *avroSchema) accepts(v *Value) bool {
+	if v == nil {
+		return s.kind == "null"
+	}
+
+	for _, kind := range avroTypes[v.Type()] {
+		if kind == s.kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *avroSchema) write(buf *bytes.Buffer, v *Value) error {
+	if s.kind == "union" {
+		var types []string
+		if v == nil {
+			types = []string{"null"}
+		} else {
+			types = avroTypes[v.Type()]
+		}
+
+		for _, kind := range types {
+			for idx, branch := range s.branches {
+				if branch.kind == kind {
+					putAvroLong(buf, int64(idx))
+					return branch.write(buf, v)
+				}
+			}
+		}
+
+		return errors.Errorf("no type in union for %s value", avroTypeName(v))
+	}
+
+	if !s.accepts(v) {
+		return errors.Errorf("cannot write %s value as %s", avroTypeName(v), s.kind)
+	}
+
+	switch s.kind {
+	case "null":
+	case "boolean":
+		if v.Boolean() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "int":
+		putAvroLong(buf, int64(v.Int32()))
+	case "long", "timestamp-millis":
+		switch v.Type() {
+		case bsontype.Int32:
+			putAvroLong(buf, int64(v.Int32()))
+		case bsontype.Int64:
+			putAvroLong(buf, v.Int64())
+		case bsontype.DateTime:
+			putAvroLong(buf, v.DateTime())
+		case bsontype.Timestamp:
+			t, i := v.Timestamp()
+			putAvroLong(buf, int64(t)<<32|int64(i))
+		}
+	case "float":
+		var out [4]byte
+		binary.LittleEndian.PutUint32(out[:], math.Float32bits(float32(v.Double())))
+		buf.Write(out[:])
+	case "double":
+		var f float64
+		switch v.Type() {
+		case bsontype.Int32:
+			f = float64(v.Int32())
+		case bsontype.Int64:
+			f = float64(v.Int64())
+		default:
+			f = v.Double()
+		}
+
+		var out [8]byte
+		binary.LittleEndian.PutUint64(out[:], math.Float64bits(f))
+		buf.Write(out[:])
+	case "string":
+		var str string
+		switch v.Type() {
+		case bsontype.Symbol:
+			str = v.Symbol()
+		case bsontype.JavaScript:
+			str = v.JavaScript()
+		case bsontype.ObjectID:
+			str = v.ObjectID().Hex()
+		case bsontype.Decimal128:
+			str = v.Decimal128().String()
+		default:
+			str = v.StringValue()
+		}
+		putAvroBytes(buf, []byte(str))
+	case "bytes":
+		_, data := v.Binary()
+		putAvroBytes(buf, data)
+	case "record":
+		return s.writeRecord(buf, v.MutableDocument())
+	case "array":
+		elems := v.MutableArray().doc.elems
+		if len(elems) > 0 {
+			putAvroLong(buf, int64(len(elems)))
+			for idx, elem := range elems {
+				if err := s.items.write(buf, elem.value); err != nil {
+					return errors.Wrapf(err, "element %d", idx)
+				}
+			}
+		}
+		putAvroLong(buf, 0)
+	}
+
+	return nil
+}
+
+func avroTypeName(v *Value) string {
+	if v == nil {
+		return "missing"
+	}
+
+	return v.Type().String()
+}
+
+// putAvroLong writes a zig-zag encoded variable length integer, which
+// is the encoding of binary.PutVarint.
+func putAvroLong(buf *bytes.Buffer, n int64) {
+	var out [binary.MaxVarintLen64]byte
+	buf.Write(out[:binary.PutVarint(out[:], n)])
+}
+
+func putAvroBytes(buf *bytes.Buffer, data []byte) {
+	putAvroLong(buf, int64(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,287 @@
+package birch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/types"
+)
+
+// readAvro decodes an object container file written by WriteAvro,
+// returning the schema from the header and the records as documents.
+func readAvro(t *testing.T, data []byte) (string, []*Document) {
+	buf := bufio.NewReader(bytes.NewReader(data))
+
+	magic := make([]byte, 4)
+	_, err := io.ReadFull(buf, magic)
+	require.NoError(t, err)
+	require.Equal(t, "Obj\x01", string(magic))
+
+	meta := map[string]string{}
+	for {
+		count, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		if count == 0 {
+			break
+		}
+		for i := int64(0); i < count; i++ {
+			meta[string(readAvroBytes(t, buf))] = string(readAvroBytes(t, buf))
+		}
+	}
+	require.Equal(t, "null", meta["avro.codec"])
+
+	sync := make([]byte, 16)
+	_, err = io.ReadFull(buf, sync)
+	require.NoError(t, err)
+
+	var raw interface{}
+	require.NoError(t, json.Unmarshal([]byte(meta["avro.schema"]), &raw))
+	schema, err := parseAvroSchema(raw, map[string]*avroSchema{})
+	require.NoError(t, err)
+
+	var docs []*Document
+	for {
+		count, err := binary.ReadVarint(buf)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		size, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+
+		block := make([]byte, size)
+		_, err = io.ReadFull(buf, block)
+		require.NoError(t, err)
+		blockBuf := bufio.NewReader(bytes.NewReader(block))
+		for i := int64(0); i < count; i++ {
+			docs = append(docs, readAvroValue(t, blockBuf, schema).MutableDocument())
+		}
+		_, err = blockBuf.ReadByte()
+		require.Equal(t, io.EOF, err)
+
+		marker := make([]byte, 16)
+		_, err = io.ReadFull(buf, marker)
+		require.NoError(t, err)
+		require.Equal(t, sync, marker)
+	}
+
+	return meta["avro.schema"], docs
+}
+
+func readAvroBytes(t *testing.T, buf *bufio.Reader) []byte {
+	size, err := binary.ReadVarint(buf)
+	require.NoError(t, err)
+	out := make([]byte, size)
+	_, err = io.ReadFull(buf, out)
+	require.NoError(t, err)
+	return out
+}
+
+func readAvroValue(t *testing.T, buf *bufio.Reader, s *avroSchema) *Value {
+	switch s.kind {
+	case "union":
+		idx, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		return readAvroValue(t, buf, s.branches[idx])
+	case "null":
+		return VC.Null()
+	case "boolean":
+		b, err := buf.ReadByte()
+		require.NoError(t, err)
+		return VC.Boolean(b == 1)
+	case "int":
+		n, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		return VC.Int32(int32(n))
+	case "long":
+		n, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		return VC.Int64(n)
+	case "timestamp-millis":
+		n, err := binary.ReadVarint(buf)
+		require.NoError(t, err)
+		return VC.DateTime(n)
+	case "double":
+		out := make([]byte, 8)
+		_, err := io.ReadFull(buf, out)
+		require.NoError(t, err)
+		return VC.Double(math.Float64frombits(binary.LittleEndian.Uint64(out)))
+	case "string":
+		return VC.String(string(readAvroBytes(t, buf)))
+	case "bytes":
+		return VC.Binary(readAvroBytes(t, buf))
+	case "record":
+		doc := DC.Make(len(s.fields))
+		for _, field := range s.fields {
+			doc.Append(EC.Value(field.name, readAvroValue(t, buf, field.schema)))
+		}
+		return VC.Document(doc)
+	case "array":
+		arr := MakeArray(0)
+		for {
+			count, err := binary.ReadVarint(buf)
+			require.NoError(t, err)
+			if count == 0 {
+				return VC.Array(arr)
+			}
+			for i := int64(0); i < count; i++ {
+				arr.Append(readAvroValue(t, buf, s.items))
+			}
+		}
+	default:
+		require.FailNow(t, "unexpected kind "+s.kind)
+		return nil
+	}
+}
+
+func TestAvro(t *testing.T) {
+	oid := types.NewObjectID()
+	now := time.Unix(1600000000, 123*int64(time.Millisecond))
+	docs := []*Document{
+		DC.Elements(
+			EC.ObjectID("_id", oid),
+			EC.Int32("count", 1),
+			EC.Double("ratio", 0.5),
+			EC.Time("at", now),
+			EC.SubDocumentFromElements("host", EC.String("name", "a"), EC.Boolean("ok", true)),
+			EC.ArrayFromElements("load", VC.Int64(1), VC.Int64(2)),
+		),
+		DC.Elements(
+			EC.ObjectID("_id", oid),
+			EC.Int64("count", 2),
+			EC.Double("ratio", 1.5),
+			EC.Time("at", now),
+			EC.SubDocumentFromElements("host", EC.String("name", "b")),
+			EC.ArrayFromElements("load"),
+			EC.Binary("raw", []byte{1, 2}),
+			EC.Null("note"),
+		),
+	}
+
+	t.Run("InferSchema", func(t *testing.T) {
+		schema, err := InferAvroSchema(docs)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "record", "name": "Record", "fields": [
+				{"name": "_id", "type": "string"},
+				{"name": "count", "type": ["int", "long"]},
+				{"name": "ratio", "type": "double"},
+				{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+				{"name": "host", "type": {"type": "record", "name": "Record_1", "fields": [
+					{"name": "name", "type": "string"},
+					{"name": "ok", "type": ["null", "boolean"], "default": null}
+				]}},
+				{"name": "load", "type": {"type": "array", "items": "long"}},
+				{"name": "raw", "type": ["null", "bytes"], "default": null},
+				{"name": "note", "type": "null", "default": null}
+			]}`, schema)
+	})
+	t.Run("DateTimeAndLong", func(t *testing.T) {
+		schema, err := InferAvroSchema([]*Document{
+			DC.Elements(EC.Time("at", now)),
+			DC.Elements(EC.Int64("at", 1)),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type": "record", "name": "Record", "fields": [{"name": "at", "type": "long"}]}`, schema)
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		schema, err := InferAvroSchema(docs)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteAvro(buf, schema, docs))
+
+		header, out := readAvro(t, buf.Bytes())
+		assert.Equal(t, schema, header)
+		require.Len(t, out, 2)
+
+		assert.Equal(t, oid.Hex(), out[0].Lookup("_id").StringValue())
+		assert.Equal(t, int32(1), out[0].Lookup("count").Int32())
+		assert.Equal(t, int64(2), out[1].Lookup("count").Int64())
+		assert.Equal(t, 1.5, out[1].Lookup("ratio").Double())
+		assert.Equal(t, now.UnixNano()/int64(time.Millisecond), out[0].Lookup("at").DateTime())
+		assert.Equal(t, "a", out[0].RecursiveLookup("host", "name").StringValue())
+		assert.True(t, out[0].RecursiveLookup("host", "ok").Boolean())
+		assert.Equal(t, "null", out[1].RecursiveLookup("host", "ok").Type().String())
+		assert.Equal(t, 2, out[0].Lookup("load").MutableArray().Len())
+		assert.Equal(t, 0, out[1].Lookup("load").MutableArray().Len())
+		assert.Equal(t, "null", out[0].Lookup("raw").Type().String())
+		_, raw := out[1].Lookup("raw").Binary()
+		assert.Equal(t, []byte{1, 2}, raw)
+	})
+	t.Run("Encoding", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		schema := `{"type": "record", "name": "r", "fields": [
+			{"name": "a", "type": "long"},
+			{"name": "b", "type": ["null", "string"]},
+			{"name": "c", "type": {"type": "array", "items": "int"}}
+		]}`
+		require.NoError(t, WriteAvro(buf, schema, []*Document{
+			DC.Elements(EC.Int64("a", -2), EC.String("b", "hi"), EC.ArrayFromElements("c", VC.Int32(1))),
+		}))
+
+		// the block holds one record, then the sync marker.
+		data := buf.Bytes()
+		block := data[len(data)-16-10 : len(data)-16]
+		assert.Equal(t, []byte{0x02, 0x10, 0x03, 0x02, 0x04, 'h', 'i', 0x02, 0x02, 0x00}, block)
+	})
+	t.Run("Blocks", func(t *testing.T) {
+		many := make([]*Document, avroBlockSize+1)
+		for idx := range many {
+			many[idx] = DC.Elements(EC.Int32("n", int32(idx)))
+		}
+		schema, err := InferAvroSchema(many)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteAvro(buf, schema, many))
+		_, out := readAvro(t, buf.Bytes())
+		require.Len(t, out, len(many))
+		assert.Equal(t, int32(avroBlockSize), out[avroBlockSize].Lookup("n").Int32())
+
+		buf.Reset()
+		require.NoError(t, WriteAvro(buf, schema, nil))
+		_, out = readAvro(t, buf.Bytes())
+		assert.Empty(t, out)
+	})
+	t.Run("InferErrors", func(t *testing.T) {
+		for name, docs := range map[string][]*Document{
+			"Empty":        {},
+			"NilDocument":  {nil},
+			"InvalidKey":   {DC.Elements(EC.Int32("not-valid", 1))},
+			"DuplicateKey": {DC.Elements(EC.Int32("a", 1), EC.Int32("a", 2))},
+			"Unsupported":  {DC.Elements(EC.Regex("a", "^a", ""))},
+			"Nested":       {DC.Elements(EC.SubDocumentFromElements("a", EC.MinKey("b")))},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := InferAvroSchema(docs)
+				assert.Error(t, err)
+			})
+		}
+	})
+	t.Run("WriteErrors", func(t *testing.T) {
+		doc := DC.Elements(EC.String("a", "x"))
+		for name, schema := range map[string]string{
+			"InvalidJSON":   `{`,
+			"NotRecord":     `"long"`,
+			"UnknownType":   `{"type": "record", "name": "r", "fields": [{"name": "a", "type": "thing"}]}`,
+			"NestedUnion":   `{"type": "record", "name": "r", "fields": [{"name": "a", "type": ["null", ["string"]]}]}`,
+			"TypeMismatch":  `{"type": "record", "name": "r", "fields": [{"name": "a", "type": "long"}]}`,
+			"MissingField":  `{"type": "record", "name": "r", "fields": [{"name": "b", "type": "string"}]}`,
+			"UnionMismatch": `{"type": "record", "name": "r", "fields": [{"name": "a", "type": ["null", "long"]}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.Error(t, WriteAvro(&bytes.Buffer{}, schema, []*Document{doc}))
+			})
+		}
+		assert.Error(t, WriteAvro(&bytes.Buffer{}, `{"type": "record", "name": "r", "fields": []}`, []*Document{nil}))
+	})
+}
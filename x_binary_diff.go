@@ -0,0 +1,314 @@
+package birch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// DiffOpType is the kind of change that a DiffOp makes.
+type DiffOpType int
+
+const (
+	// DiffAdd appends an element to the document or array that
+	// contains the path.
+	DiffAdd DiffOpType = iota
+	// DiffRemove removes the element at the path.
+	DiffRemove
+	// DiffReplace replaces the value of the element at the path,
+	// in place.
+	DiffReplace
+)
+
+func (t DiffOpType) String() string {
+	switch t {
+	case DiffAdd:
+		return "add"
+	case DiffRemove:
+		return "remove"
+	case DiffReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffOp is a change to a document that BinaryDiff reports and
+// ApplyDiff applies.
+type DiffOp struct {
+	Type DiffOpType
+	// Path is the sequence of keys from the top-level document to
+	// the element, using the position for elements of arrays. An
+	// empty path refers to the document itself, which only
+	// DiffReplace supports.
+	Path []string
+	// Value is the new value for DiffAdd and DiffReplace, and nil
+	// for DiffRemove.
+	Value *Value
+}
+
+func (op DiffOp) String() string {
+	path := strings.Join(op.Path, ".")
+	if op.Value == nil {
+		return fmt.Sprintf("%s '%s'", op.Type, path)
+	}
+
+	return fmt.Sprintf("%s '%s': %v", op.Type, path, op.Value.Interface())
+}
+
+// BinaryDiff compares two raw BSON documents without decoding them
+// into Documents, and returns the changes that ApplyDiff applies to a
+// to produce a document with the same bytes as b, or no changes if
+// the documents are equal, as Reader.Equal compares them. The values
+// of the changes refer to the bytes of b rather than copying them.
+//
+// Sub-documents and arrays with the same key in both documents are
+// compared element by element. For each document, the changes remove
+// the elements of a that are not in b, or that would be out of order,
+// then change the elements that remain, and then add the rest of the
+// elements of b in order, so the changes are not always the smallest
+// possible. Arrays are compared by position. Since paths cannot
+// distinguish elements with the same key, a document that has
+// duplicate keys in either a or b is replaced as a whole.
+//
+// BinaryDiff returns an error if either document is not valid BSON.
+func BinaryDiff(a, b []byte) ([]DiffOp, error) {
+	if _, err := Reader(a).Validate(); err != nil {
+		return nil, errors.Wrap(err, "problem reading first document")
+	}
+
+	if _, err := Reader(b).Validate(); err != nil {
+		return nil, errors.Wrap(err, "problem reading second document")
+	}
+
+	var ops []DiffOp
+	diffReaderDocuments(&ops, nil, Reader(a), Reader(b), VC.DocumentFromReader(Reader(b)))
+
+	return ops, nil
+}
+
+// diffReaderDocuments appends the changes between two documents to
+// ops, where value is the value of the second document, to replace
+// the first with if either has duplicate keys.
+func diffReaderDocuments(ops *[]DiffOp, path []string, a, b Reader, value *Value) {
+	aElems, bElems := readerElements(a), readerElements(b)
+
+	positions := make(map[string]int, len(aElems))
+	for idx, elem := range aElems {
+		positions[elem.Key()] = idx
+	}
+
+	bKeys := make(map[string]struct{}, len(bElems))
+	for _, elem := range bElems {
+		bKeys[elem.Key()] = struct{}{}
+	}
+
+	if len(positions) != len(aElems) || len(bKeys) != len(bElems) {
+		*ops = append(*ops, DiffOp{Type: DiffReplace, Path: path, Value: value})
+		return
+	}
+
+	// keep the longest prefix of b whose keys are in a in the same
+	// order, and add the rest of b.
+	matched := make([]int, 0, len(bElems))
+	last := -1
+	for _, elem := range bElems {
+		pos, ok := positions[elem.Key()]
+		if !ok || pos <= last {
+			break
+		}
+		matched = append(matched, pos)
+		last = pos
+	}
+
+	kept := make(map[int]struct{}, len(matched))
+	for _, pos := range matched {
+		kept[pos] = struct{}{}
+	}
+
+	for idx, elem := range aElems {
+		if _, ok := kept[idx]; !ok {
+			*ops = append(*ops, DiffOp{Type: DiffRemove, Path: diffPath(path, elem.Key())})
+		}
+	}
+
+	for idx, pos := range matched {
+		diffReaderValues(ops, diffPath(path, bElems[idx].Key()), aElems[pos].value, bElems[idx].value)
+	}
+
+	for _, elem := range bElems[len(matched):] {
+		*ops = append(*ops, DiffOp{Type: DiffAdd, Path: diffPath(path, elem.Key()), Value: elem.value})
+	}
+}
+
+func diffReaderArrays(ops *[]DiffOp, path []string, a, b Reader) {
+	aElems, bElems := readerElements(a), readerElements(b)
+
+	for idx := 0; idx < len(aElems) && idx < len(bElems); idx++ {
+		diffReaderValues(ops, diffPath(path, strconv.Itoa(idx)), aElems[idx].value, bElems[idx].value)
+	}
+
+	// remove from the end, so that the positions of the elements
+	// that remain do not change.
+	for idx := len(aElems) - 1; idx >= len(bElems); idx-- {
+		*ops = append(*ops, DiffOp{Type: DiffRemove, Path: diffPath(path, strconv.Itoa(idx))})
+	}
+
+	for idx := len(aElems); idx < len(bElems); idx++ {
+		*ops = append(*ops, DiffOp{Type: DiffAdd, Path: diffPath(path, strconv.Itoa(idx)), Value: bElems[idx].value})
+	}
+}
+
+func diffReaderValues(ops *[]DiffOp, path []string, a, b *Value) {
+	if readerValueEqual(a, b, ReaderEqualOptions{}) {
+		return
+	}
+
+	switch {
+	case a.Type() == bsontype.EmbeddedDocument && b.Type() == bsontype.EmbeddedDocument:
+		diffReaderDocuments(ops, path, a.ReaderDocument(), b.ReaderDocument(), b)
+	case a.Type() == bsontype.Array && b.Type() == bsontype.Array:
+		diffReaderArrays(ops, path, a.ReaderArray(), b.ReaderArray())
+	default:
+		*ops = append(*ops, DiffOp{Type: DiffReplace, Path: path, Value: b})
+	}
+}
+
+// diffPath returns a copy of the path with the key appended, so that
+// the paths of different changes do not share memory.
+func diffPath(path []string, key string) []string {
+	out := make([]string, len(path)+1)
+	copy(out, path)
+	out[len(path)] = key
+	return out
+}
+
+// ApplyDiff applies the changes, in order, to the raw BSON document a,
+// and returns the resulting document, such as to reconstruct the
+// second document passed to BinaryDiff from the first. It returns an
+// error if a is not valid BSON, or if a change does not apply to the
+// document: for example, when its path does not exist, when it adds
+// an element with a key that already exists, or when it adds an
+// element to an array at a position other than the end.
+func ApplyDiff(a []byte, ops []DiffOp) ([]byte, error) {
+	doc, err := ReadDocument(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading document")
+	}
+
+	for idx, op := range ops {
+		if doc, err = applyDiffOp(doc, op); err != nil {
+			return nil, errors.Wrapf(err, "problem applying change %d (%s)", idx, op)
+		}
+	}
+
+	out, err := doc.MarshalBSON()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem encoding document")
+	}
+
+	return out, nil
+}
+
+// applyDiffOp applies the change to the document, and returns the
+// document, which is a different document only when the change
+// replaces the document as a whole.
+func applyDiffOp(doc *Document, op DiffOp) (*Document, error) {
+	if op.Type != DiffAdd && op.Type != DiffRemove && op.Type != DiffReplace {
+		return nil, errors.Errorf("invalid change type %d", op.Type)
+	}
+
+	if op.Type != DiffRemove && op.Value == nil {
+		return nil, errors.New("change has no value")
+	}
+
+	if len(op.Path) == 0 {
+		if op.Type != DiffReplace || op.Value.Type() != bsontype.EmbeddedDocument {
+			return nil, errors.New("can only replace the document with a document")
+		}
+		return op.Value.MutableDocument(), nil
+	}
+
+	parent := doc
+	isArray := false
+	for _, key := range op.Path[:len(op.Path)-1] {
+		v, err := diffLookup(parent, isArray, key)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v.Type() {
+		case bsontype.EmbeddedDocument:
+			parent, isArray = v.MutableDocument(), false
+		case bsontype.Array:
+			parent, isArray = v.MutableArray().doc, true
+		default:
+			return nil, errors.Errorf("'%s' is a %s, not a document or array", key, v.Type())
+		}
+	}
+
+	key := op.Path[len(op.Path)-1]
+
+	if isArray {
+		array := &Array{doc: parent}
+		if op.Type == DiffAdd {
+			if key != strconv.Itoa(array.Len()) {
+				return nil, errors.Errorf("cannot add '%s' to array of length %d", key, array.Len())
+			}
+			array.Append(op.Value)
+			return doc, nil
+		}
+
+		if _, err := diffLookup(parent, true, key); err != nil {
+			return nil, err
+		}
+
+		pos, _ := strconv.Atoi(key)
+		if op.Type == DiffRemove {
+			array.Delete(uint(pos))
+		} else {
+			array.Set(uint(pos), op.Value)
+		}
+
+		return doc, nil
+	}
+
+	existing := parent.Lookup(key)
+	switch {
+	case op.Type == DiffAdd && existing != nil:
+		return nil, errors.Errorf("'%s' already exists", key)
+	case op.Type != DiffAdd && existing == nil:
+		return nil, errors.Errorf("'%s' does not exist", key)
+	}
+
+	switch op.Type {
+	case DiffAdd:
+		parent.Append(EC.Value(key, op.Value))
+	case DiffRemove:
+		parent.Delete(key)
+	case DiffReplace:
+		parent.Set(EC.Value(key, op.Value))
+	}
+
+	return doc, nil
+}
+
+func diffLookup(d *Document, isArray bool, key string) (*Value, error) {
+	if !isArray {
+		v := d.Lookup(key)
+		if v == nil {
+			return nil, errors.Errorf("'%s' does not exist", key)
+		}
+		return v, nil
+	}
+
+	pos, err := strconv.Atoi(key)
+	if err != nil || pos < 0 || pos >= len(d.elems) {
+		return nil, errors.Errorf("'%s' is not a position in array of length %d", key, len(d.elems))
+	}
+
+	return d.elems[pos].value, nil
+}
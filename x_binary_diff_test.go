@@ -0,0 +1,207 @@
+package birch
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryDiff(t *testing.T) {
+	marshal := func(t *testing.T, doc *Document) []byte {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		return data
+	}
+
+	describe := func(ops []DiffOp) []string {
+		out := make([]string, len(ops))
+		for idx, op := range ops {
+			out[idx] = op.Type.String() + " " + strings.Join(op.Path, ".")
+		}
+		return out
+	}
+
+	roundTrip := func(t *testing.T, a, b []byte) []DiffOp {
+		ops, err := BinaryDiff(a, b)
+		require.NoError(t, err)
+
+		out, err := ApplyDiff(a, ops)
+		require.NoError(t, err)
+		assert.Equal(t, b, out)
+
+		return ops
+	}
+
+	base := DC.Elements(
+		EC.String("host", "a"),
+		EC.Int64("ops", 150),
+		EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5)),
+		EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 1))),
+		EC.Boolean("ok", true),
+	)
+
+	for _, test := range []struct {
+		name     string
+		b        *Document
+		expected []string
+	}{
+		{
+			name: "Equal",
+			b:    base.Copy(),
+		},
+		{
+			name: "ReplaceScalar",
+			b: DC.Elements(
+				EC.String("host", "b"),
+				EC.Int64("ops", 150),
+				EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5)),
+				EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 1))),
+				EC.Boolean("ok", true),
+			),
+			expected: []string{"replace host"},
+		},
+		{
+			name: "Nested",
+			b: DC.Elements(
+				EC.String("host", "a"),
+				EC.Int64("ops", 150),
+				EC.SubDocumentFromElements("mem", EC.Int32("resident", 21), EC.Int64("mapped", 1)),
+				EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 2)), VC.Null()),
+				EC.Boolean("ok", true),
+			),
+			expected: []string{"remove mem.virtual", "replace mem.resident", "add mem.mapped", "replace load.2.x", "add load.3"},
+		},
+		{
+			name: "RemoveAndAdd",
+			b: DC.Elements(
+				EC.String("host", "a"),
+				EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5)),
+				EC.ArrayFromElements("load", VC.Int32(3)),
+				EC.Boolean("ok", true),
+				EC.Int32("conns", 4),
+			),
+			expected: []string{"remove ops", "remove load.2", "remove load.1", "add conns"},
+		},
+		{
+			name: "Reordered",
+			b: DC.Elements(
+				EC.Int64("ops", 150),
+				EC.String("host", "a"),
+				EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5)),
+				EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 1))),
+				EC.Boolean("ok", true),
+			),
+			expected: []string{"remove host", "remove mem", "remove load", "remove ok", "add host", "add mem", "add load", "add ok"},
+		},
+		{
+			name: "ChangedType",
+			b: DC.Elements(
+				EC.String("host", "a"),
+				EC.Int32("ops", 150),
+				EC.Int32("mem", 1),
+				EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 1))),
+				EC.Boolean("ok", true),
+			),
+			expected: []string{"replace ops", "replace mem"},
+		},
+		{
+			name: "DuplicateKeys",
+			b: DC.Elements(
+				EC.String("host", "a"),
+				EC.Int64("ops", 150),
+				EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int32("resident", 21)),
+				EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.DocumentFromElements(EC.Int32("x", 1))),
+				EC.Boolean("ok", true),
+			),
+			expected: []string{"replace mem"},
+		},
+		{
+			name:     "TopLevelDuplicateKeys",
+			b:        DC.Elements(EC.Int32("a", 1), EC.Int32("a", 2)),
+			expected: []string{"replace "},
+		},
+		{
+			name:     "Empty",
+			b:        DC.New(),
+			expected: []string{"remove host", "remove ops", "remove mem", "remove load", "remove ok"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ops := roundTrip(t, marshal(t, base), marshal(t, test.b))
+			if len(test.expected) == 0 {
+				assert.Empty(t, ops)
+				return
+			}
+			assert.Equal(t, test.expected, describe(ops))
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "remove 'a.0'", DiffOp{Type: DiffRemove, Path: []string{"a", "0"}}.String())
+		assert.Equal(t, "add 'a.b': 1", DiffOp{Type: DiffAdd, Path: []string{"a", "b"}, Value: VC.Int32(1)}.String())
+	})
+	t.Run("Random", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(42))
+		var randomDoc func(depth int) *Document
+		randomValue := func(depth int) *Value {
+			switch n := rng.Intn(6); {
+			case n == 0 && depth < 3:
+				return VC.Document(randomDoc(depth + 1))
+			case n == 1 && depth < 3:
+				arr := NewArray()
+				for i := rng.Intn(4); i > 0; i-- {
+					arr.Append(VC.Int32(int32(rng.Intn(3))))
+				}
+				return VC.Array(arr)
+			case n == 2:
+				return VC.String(strconv.Itoa(rng.Intn(3)))
+			default:
+				return VC.Int64(int64(rng.Intn(3)))
+			}
+		}
+		randomDoc = func(depth int) *Document {
+			doc := DC.New()
+			for _, key := range rng.Perm(5)[:rng.Intn(5)] {
+				doc.Append(EC.Value(string(rune('a'+key)), randomValue(depth)))
+			}
+			return doc
+		}
+
+		for i := 0; i < 500; i++ {
+			roundTrip(t, marshal(t, randomDoc(0)), marshal(t, randomDoc(0)))
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		valid := marshal(t, base)
+		_, err := BinaryDiff([]byte{1, 2}, valid)
+		assert.Error(t, err)
+		_, err = BinaryDiff(valid, []byte{1, 2})
+		assert.Error(t, err)
+		_, err = ApplyDiff([]byte{1, 2}, nil)
+		assert.Error(t, err)
+	})
+	t.Run("ApplyErrors", func(t *testing.T) {
+		a := marshal(t, base)
+		for name, op := range map[string]DiffOp{
+			"MissingPath":      {Type: DiffRemove, Path: []string{"missing"}},
+			"MissingParent":    {Type: DiffReplace, Path: []string{"missing", "x"}, Value: VC.Int32(1)},
+			"ScalarParent":     {Type: DiffRemove, Path: []string{"ops", "x"}},
+			"AddExisting":      {Type: DiffAdd, Path: []string{"ops"}, Value: VC.Int32(1)},
+			"AddInsideArray":   {Type: DiffAdd, Path: []string{"load", "0"}, Value: VC.Int32(1)},
+			"ArrayPosition":    {Type: DiffReplace, Path: []string{"load", "9"}, Value: VC.Int32(1)},
+			"NoValue":          {Type: DiffReplace, Path: []string{"ops"}},
+			"RemoveDocument":   {Type: DiffRemove},
+			"ReplaceWithValue": {Type: DiffReplace, Value: VC.Int32(1)},
+			"InvalidType":      {Type: DiffOpType(9), Path: []string{"ops"}, Value: VC.Int32(1)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ApplyDiff(a, []DiffOp{op})
+				assert.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package birch
+
+import (
+	"fmt"
+
+	"github.com/tychoish/birch/bsonerr"
+)
+
+// Op identifies the kind of change that an OnChange hook observes.
+type Op int
+
+const (
+	// OpAppend is the addition of an element to the end of the
+	// document, by Append or its variants, or by Set when the
+	// document has no element with the key.
+	OpAppend Op = iota
+	// OpInsert is the addition of an element before the end of the
+	// document, by Prepend or InsertAt and its variants.
+	OpInsert
+	// OpSet is the replacement of an element, by Set, ReplacePath,
+	// Apply, or MapNumeric.
+	OpSet
+	// OpDelete is the removal of an element, by Delete, Reset, or
+	// Apply.
+	OpDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpAppend:
+		return "append"
+	case OpInsert:
+		return "insert"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+}
+
+// OnChange registers fn to be called after each change to the
+// elements of the document, with the kind of change and the key and
+// value of the added, replaced (the new value), or removed element,
+// for example to keep a cache or an index in sync with the document.
+// A document has at most one hook: OnChange replaces any previous
+// hook, and OnChange(nil) removes it. Copies of the document do not
+// share the hook.
+//
+// The hook observes every method that adds, replaces, or removes
+// elements: Append, Prepend, Set, Delete, Reset, InsertAt,
+// UnmarshalBSON, ReplacePath, Apply, MapNumeric, and the methods built
+// on them. Changes to nested documents call the nested document's
+// hook, if any, rather than this one. Arrays have no hooks, so
+// changes to the elements of arrays, including with Array.Set and
+// Array.Delete, are not observed. The hook runs synchronously, after
+// the change, and must not modify the document.
+//
+// Documents have no hook by default, and without one, the cost to
+// each change is a single nil check. With a hook, every change pays
+// for a function call, which is significant for documents that are
+// built element by element.
+func (d *Document) OnChange(fn func(op Op, key string, v *Value)) {
+	if d == nil {
+		panic(bsonerr.NilDocument)
+	}
+
+	d.onChange = fn
+}
+
+func (d *Document) changed(op Op, elem *Element) {
+	if d.onChange != nil {
+		d.onChange(op, elem.Key(), elem.value)
+	}
+}
+
+// elementChange is a change recorded while the document is being
+// rewritten, to report to the hook once the document is consistent.
+type elementChange struct {
+	op   Op
+	elem *Element
+}
+
+func (d *Document) changedAll(changes []elementChange) {
+	for _, change := range changes {
+		d.changed(change.op, change.elem)
+	}
+}
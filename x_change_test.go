@@ -0,0 +1,141 @@
+package birch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentOnChange(t *testing.T) {
+	record := func(doc *Document) *[]string {
+		var changes []string
+		doc.OnChange(func(op Op, key string, v *Value) {
+			changes = append(changes, fmt.Sprintf("%s %s %v", op, key, v.Interface()))
+		})
+		return &changes
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		doc := DC.New()
+		changes := record(doc)
+		doc.Append(EC.Int32("a", 1), EC.String("b", "x"))
+		doc.AppendOmitEmpty(EC.Int32("c", 0), EC.Int32("d", 2))
+		assert.Equal(t, []string{"append a 1", "append b x", "append d 2"}, *changes)
+	})
+	t.Run("Prepend", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("c", 3))
+		changes := record(doc)
+		doc.Prepend(EC.Int32("a", 1), EC.Int32("b", 2))
+		assert.Equal(t, []string{"insert a 1", "insert b 2"}, *changes)
+		assert.Equal(t, []string{"a", "b", "c"}, documentKeys(doc))
+	})
+	t.Run("InsertAt", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("c", 3))
+		changes := record(doc)
+		require.True(t, doc.InsertBefore("c", EC.Int32("b", 2)))
+		require.False(t, doc.InsertAt(10, EC.Int32("z", 0)))
+		assert.Equal(t, []string{"insert b 2"}, *changes)
+	})
+	t.Run("Set", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1))
+		changes := record(doc)
+		doc.Set(EC.Int32("a", 2))
+		doc.Set(EC.Int32("b", 3))
+		assert.Equal(t, []string{"set a 2", "append b 3"}, *changes)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.SubDocumentFromElements("b", EC.Int32("c", 2)))
+		changes := record(doc)
+		doc.Delete("missing")
+		doc.Delete("b", "c")
+		assert.Empty(t, *changes)
+		doc.Delete("a")
+		assert.Equal(t, []string{"delete a 1"}, *changes)
+	})
+	t.Run("Reset", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2))
+		changes := record(doc)
+		doc.Reset()
+		assert.Equal(t, []string{"delete a 1", "delete b 2"}, *changes)
+		assert.Equal(t, 0, doc.Len())
+	})
+	t.Run("ReplacePath", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.SubDocumentFromElements("b", EC.Int32("c", 2)))
+		changes := record(doc)
+		nested := record(doc.Lookup("b").MutableDocument())
+		require.NoError(t, doc.ReplacePath("a", VC.Int32(5)))
+		require.NoError(t, doc.ReplacePath("b.c", VC.Int32(6)))
+		assert.Equal(t, []string{"set a 5"}, *changes)
+		assert.Equal(t, []string{"set c 6"}, *nested)
+	})
+	t.Run("UnmarshalBSON", func(t *testing.T) {
+		data, err := DC.Elements(EC.Int32("a", 1)).MarshalBSON()
+		require.NoError(t, err)
+		doc := DC.New()
+		changes := record(doc)
+		require.NoError(t, doc.UnmarshalBSON(data))
+		assert.Equal(t, []string{"append a 1"}, *changes)
+	})
+	t.Run("Apply", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("c", 3))
+		changes := record(doc)
+		var keys []string
+		doc.OnChange(func(op Op, key string, v *Value) {
+			// the hook runs once the document is consistent.
+			keys = documentKeys(doc)
+			*changes = append(*changes, fmt.Sprintf("%s %s %v", op, key, v.Interface()))
+		})
+		doc.Apply(func(path string, v *Value) *Value {
+			switch path {
+			case "a":
+				return nil
+			case "b":
+				return VC.Int32(20)
+			}
+			return v
+		})
+		assert.Equal(t, []string{"delete a 1", "set b 20"}, *changes)
+		assert.Equal(t, []string{"b", "c"}, keys)
+		assert.Equal(t, []string{"b", "c"}, documentKeys(doc))
+
+		*changes = nil
+		doc.Apply(func(path string, v *Value) *Value { return v })
+		assert.Empty(t, *changes)
+	})
+	t.Run("MapNumeric", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.ArrayFromElements("c", VC.Int32(3)))
+		changes := record(doc)
+		doc.MapNumeric(func(path string, v float64) float64 {
+			if path == "a" {
+				return v
+			}
+			return v * 10
+		})
+		assert.Equal(t, []string{"set b 20"}, *changes)
+		assert.Equal(t, int32(30), doc.Lookup("c").MutableArray().Lookup(0).Int32())
+	})
+	t.Run("Remove", func(t *testing.T) {
+		doc := DC.New()
+		changes := record(doc)
+		doc.Append(EC.Int32("a", 1))
+		doc.OnChange(nil)
+		doc.Append(EC.Int32("b", 2))
+		assert.Equal(t, []string{"append a 1"}, *changes)
+	})
+	t.Run("CopyHasNoHook", func(t *testing.T) {
+		doc := DC.New()
+		changes := record(doc)
+		doc.Copy().Append(EC.Int32("a", 1))
+		assert.Empty(t, *changes)
+	})
+	t.Run("NilDocument", func(t *testing.T) {
+		var doc *Document
+		assert.Panics(t, func() { doc.OnChange(nil) })
+	})
+	t.Run("OpString", func(t *testing.T) {
+		assert.Equal(t, "delete", OpDelete.String())
+		assert.Equal(t, "Op(42)", Op(42).String())
+	})
+}
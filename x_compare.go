@@ -0,0 +1,467 @@
+package birch
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// canonicalTypeOrder returns the position of a BSON type in the sort
+// order used by MongoDB when comparing values of different types.
+// Types that compare as equivalent (e.g. all of the numeric types)
+// share a position.
+func canonicalTypeOrder(t bsontype.Type) int {
+	switch t {
+	case bsontype.MinKey:
+		return 0
+	case bsontype.Undefined, bsontype.Null:
+		return 1
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return 2
+	case bsontype.String, bsontype.Symbol:
+		return 3
+	case bsontype.EmbeddedDocument:
+		return 4
+	case bsontype.Array:
+		return 5
+	case bsontype.Binary:
+		return 6
+	case bsontype.ObjectID:
+		return 7
+	case bsontype.Boolean:
+		return 8
+	case bsontype.DateTime:
+		return 9
+	case bsontype.Timestamp:
+		return 10
+	case bsontype.Regex:
+		return 11
+	case bsontype.DBPointer:
+		return 12
+	case bsontype.JavaScript:
+		return 13
+	case bsontype.CodeWithScope:
+		return 14
+	case bsontype.MaxKey:
+		return 15
+	default:
+		return 16
+	}
+}
+
+// Compare returns an integer comparing two values using the BSON sort
+// order that MongoDB uses for indexes and range queries: the result is
+// negative if v sorts before v2, zero if they are equivalent, and
+// positive if v sorts after v2.
+//
+// Values of different types sort by type, with MinKey before all
+// other values and MaxKey after all other values. Numeric values of
+// any type compare by their exact numeric value, so that numbers of
+// different types are equivalent exactly when EqualValues reports
+// them as equal, and strings compare with symbols. Documents and
+// arrays compare element by element. A nil value sorts before all
+// other values.
+func (v *Value) Compare(v2 *Value) int {
+	switch {
+	case v == nil && v2 == nil:
+		return 0
+	case v == nil:
+		return -1
+	case v2 == nil:
+		return 1
+	}
+
+	t1, t2 := v.Type(), v2.Type()
+
+	if o1, o2 := canonicalTypeOrder(t1), canonicalTypeOrder(t2); o1 != o2 {
+		return compareInt64(int64(o1), int64(o2))
+	}
+
+	switch t1 {
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return compareNumeric(v, v2)
+	case bsontype.String, bsontype.Symbol:
+		return strings.Compare(compareStringValue(v), compareStringValue(v2))
+	case bsontype.EmbeddedDocument:
+		return compareDocuments(v.MutableDocument(), v2.MutableDocument())
+	case bsontype.Array:
+		return compareDocuments(v.MutableArray().doc, v2.MutableArray().doc)
+	case bsontype.Binary:
+		st1, data1 := v.Binary()
+		st2, data2 := v2.Binary()
+
+		if len(data1) != len(data2) {
+			return compareInt64(int64(len(data1)), int64(len(data2)))
+		}
+
+		if st1 != st2 {
+			return compareInt64(int64(st1), int64(st2))
+		}
+
+		return bytes.Compare(data1, data2)
+	case bsontype.ObjectID:
+		oid1, oid2 := v.ObjectID(), v2.ObjectID()
+		return bytes.Compare(oid1[:], oid2[:])
+	case bsontype.Boolean:
+		b1, b2 := v.Boolean(), v2.Boolean()
+		switch {
+		case b1 == b2:
+			return 0
+		case b1:
+			return 1
+		default:
+			return -1
+		}
+	case bsontype.DateTime:
+		return compareInt64(v.DateTime(), v2.DateTime())
+	case bsontype.Timestamp:
+		ts1, i1 := v.Timestamp()
+		ts2, i2 := v2.Timestamp()
+
+		if ts1 != ts2 {
+			return compareInt64(int64(ts1), int64(ts2))
+		}
+
+		return compareInt64(int64(i1), int64(i2))
+	case bsontype.Regex:
+		p1, o1 := v.Regex()
+		p2, o2 := v2.Regex()
+
+		if c := strings.Compare(p1, p2); c != 0 {
+			return c
+		}
+
+		return strings.Compare(o1, o2)
+	case bsontype.DBPointer:
+		ns1, oid1 := v.DBPointer()
+		ns2, oid2 := v2.DBPointer()
+
+		if c := strings.Compare(ns1, ns2); c != 0 {
+			return c
+		}
+
+		return bytes.Compare(oid1[:], oid2[:])
+	case bsontype.JavaScript:
+		return strings.Compare(v.JavaScript(), v2.JavaScript())
+	case bsontype.CodeWithScope:
+		c1, s1 := v.MutableJavaScriptWithScope()
+		c2, s2 := v2.MutableJavaScriptWithScope()
+
+		if c := strings.Compare(c1, c2); c != 0 {
+			return c
+		}
+
+		return compareDocuments(s1, s2)
+	default:
+		return 0
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareStringValue(v *Value) string {
+	if v.Type() == bsontype.Symbol {
+		return v.Symbol()
+	}
+
+	return v.StringValue()
+}
+
+// compareNumeric compares numbers exactly, so that numbers of
+// different types compare as equal only when EqualValues reports them
+// as equal. NaN sorts before all other numbers.
+func compareNumeric(v1, v2 *Value) int {
+	i1, ok1 := compareIntegerValue(v1)
+	i2, ok2 := compareIntegerValue(v2)
+	t1, t2 := v1.Type(), v2.Type()
+
+	switch {
+	case ok1 && ok2:
+		return compareInt64(i1, i2)
+	case ok1 && t2 == bsontype.Double:
+		return -compareDoubleInteger(v2.Double(), i1)
+	case ok2 && t1 == bsontype.Double:
+		return compareDoubleInteger(v1.Double(), i2)
+	case t1 == bsontype.Double && t2 == bsontype.Double:
+		return compareDoubles(v1.Double(), v2.Double())
+	}
+
+	// at least one of the values is a decimal.
+	c1, r1 := numericClass(v1)
+	c2, r2 := numericClass(v2)
+
+	switch {
+	case c1 != c2:
+		return compareInt64(int64(c1), int64(c2))
+	case c1 == classFinite:
+		return r1.Cmp(r2)
+	case t1 != t2:
+		// EqualValues never considers NaN or infinities of
+		// different types equal, so order them by type.
+		return compareInt64(int64(t1), int64(t2))
+	default:
+		return 0
+	}
+}
+
+func compareIntegerValue(v *Value) (int64, bool) {
+	switch v.Type() {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	default:
+		return 0, false
+	}
+}
+
+func compareDoubles(f1, f2 float64) int {
+	switch {
+	case math.IsNaN(f1) && math.IsNaN(f2):
+		return 0
+	case math.IsNaN(f1):
+		return -1
+	case math.IsNaN(f2):
+		return 1
+	case f1 < f2:
+		return -1
+	case f1 > f2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareDoubleInteger compares a double with an integer exactly.
+func compareDoubleInteger(f float64, i int64) int {
+	if math.IsNaN(f) {
+		return -1
+	}
+
+	// rounding an integer to a double is monotonic, so when the
+	// rounded integer differs from f, it orders the same way as the
+	// integer; when they are the same, f is an integer in
+	// [-2^63, 2^63].
+	if c := compareDoubles(f, float64(i)); c != 0 {
+		return c
+	}
+
+	if f >= 1<<63 {
+		return 1
+	}
+
+	return compareInt64(int64(f), i)
+}
+
+const (
+	classNaN = iota
+	classNegativeInfinity
+	classFinite
+	classPositiveInfinity
+)
+
+// numericClass returns the position of a number in the sort order of
+// NaN, negative infinity, finite numbers, and positive infinity, and
+// for finite numbers, the exact value.
+func numericClass(v *Value) (int, *big.Rat) {
+	if r, ok := exactRatValue(v); ok {
+		return classFinite, r
+	}
+
+	var f float64
+	if v.Type() == bsontype.Double {
+		f = v.Double()
+	} else {
+		f, _ = strconv.ParseFloat(v.Decimal128().String(), 64)
+	}
+
+	switch {
+	case math.IsInf(f, -1):
+		return classNegativeInfinity, nil
+	case math.IsInf(f, 1):
+		return classPositiveInfinity, nil
+	default:
+		return classNaN, nil
+	}
+}
+
+func compareDocuments(d1, d2 *Document) int {
+	l1, l2 := d1.Len(), d2.Len()
+
+	for i := 0; i < l1 && i < l2; i++ {
+		e1, e2 := d1.elems[i], d2.elems[i]
+
+		o1, o2 := canonicalTypeOrder(e1.value.Type()), canonicalTypeOrder(e2.value.Type())
+		if o1 != o2 {
+			return compareInt64(int64(o1), int64(o2))
+		}
+
+		if c := strings.Compare(e1.Key(), e2.Key()); c != 0 {
+			return c
+		}
+
+		if c := e1.value.Compare(e2.value); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt64(int64(l1), int64(l2))
+}
+
+// EqualValues reports whether v and v2 are equal, like Equal, except
+// that numeric values of different types are equal when they
+// represent exactly the same number. An int32 and an int64 are equal
+// when their integer values are equal. A double is equal to an integer
+// only when the double has no fractional part and its value is the
+// integer exactly, so 1.0 equals 1 but 1.5 does not equal any integer,
+// and doubles beyond the range where every integer is representable
+// equal only the integers they store exactly. A decimal128 value is
+// equal to another number when both have the same exact decimal
+// value. NaN and infinite values are equal only to values of the same
+// type that Equal reports as equal.
+//
+// Embedded documents are equal when their elements have the same keys
+// in the same order, and arrays are equal when they have the same
+// length, and the values at each position are equal according to
+// EqualValues.
+func (v *Value) EqualValues(v2 *Value) bool {
+	if v == nil || v2 == nil {
+		return v == v2
+	}
+
+	t1, t2 := v.Type(), v2.Type()
+
+	if t1 != t2 {
+		return isNumericType(t1) && isNumericType(t2) && equalNumeric(v, v2)
+	}
+
+	switch t1 {
+	case bsontype.EmbeddedDocument:
+		return v.MutableDocument().EqualValues(v2.MutableDocument())
+	case bsontype.Array:
+		return v.MutableArray().EqualValues(v2.MutableArray())
+	default:
+		return v.Equal(v2)
+	}
+}
+
+// EqualValues reports whether the arrays have the same length and
+// values at each position that are equal according to
+// Value.EqualValues.
+func (a *Array) EqualValues(other *Array) bool {
+	if a == nil || other == nil {
+		return a == other
+	}
+
+	if len(a.doc.elems) != len(other.doc.elems) {
+		return false
+	}
+
+	for i := range a.doc.elems {
+		if !a.doc.elems[i].value.EqualValues(other.doc.elems[i].value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EqualValues reports whether the documents have elements with the
+// same keys, in the same order, with values that are equal according
+// to Value.EqualValues. Nil documents are equal only to other nil
+// documents.
+func (d *Document) EqualValues(other *Document) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+
+	if len(d.elems) != len(other.elems) {
+		return false
+	}
+
+	for i := range d.elems {
+		if d.elems[i].Key() != other.elems[i].Key() {
+			return false
+		}
+
+		if !d.elems[i].value.EqualValues(other.elems[i].value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNumericType(t bsontype.Type) bool {
+	switch t {
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return true
+	default:
+		return false
+	}
+}
+
+// equalNumeric compares numeric values of different types exactly.
+func equalNumeric(v1, v2 *Value) bool {
+	i1, ok1 := compareIntegerValue(v1)
+	i2, ok2 := compareIntegerValue(v2)
+
+	switch {
+	case ok1 && ok2:
+		return i1 == i2
+	case ok1 && v2.Type() == bsontype.Double:
+		return equalDoubleInteger(v2.Double(), i1)
+	case ok2 && v1.Type() == bsontype.Double:
+		return equalDoubleInteger(v1.Double(), i2)
+	}
+
+	r1, ok1 := exactRatValue(v1)
+	r2, ok2 := exactRatValue(v2)
+
+	return ok1 && ok2 && r1.Cmp(r2) == 0
+}
+
+func equalDoubleInteger(f float64, i int64) bool {
+	// every int64 is in [-2^63, 2^63), and both bounds are exact
+	// doubles, so converting f is exact inside that range.
+	if f != math.Trunc(f) || f < -(1<<63) || f >= 1<<63 {
+		return false
+	}
+
+	return int64(f) == i
+}
+
+// exactRatValue returns the exact value of a finite number.
+func exactRatValue(v *Value) (*big.Rat, bool) {
+	switch v.Type() {
+	case bsontype.Double:
+		f := v.Double()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+
+		return new(big.Rat).SetFloat64(f), true
+	case bsontype.Int32:
+		return new(big.Rat).SetInt64(int64(v.Int32())), true
+	case bsontype.Int64:
+		return new(big.Rat).SetInt64(v.Int64()), true
+	case bsontype.Decimal128:
+		// NaN and infinities do not parse as rationals.
+		return new(big.Rat).SetString(v.Decimal128().String())
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,231 @@
+package birch
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/types"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		assert.Equal(t, 0, (*Value)(nil).Compare(nil))
+		assert.Equal(t, -1, (*Value)(nil).Compare(VC.MinKey()))
+		assert.Equal(t, 1, VC.MinKey().Compare(nil))
+	})
+	t.Run("Sentinels", func(t *testing.T) {
+		values := []*Value{
+			VC.Null(),
+			VC.Int32(-1),
+			VC.Double(math.Inf(1)),
+			VC.String(""),
+			VC.DocumentFromElements(),
+			VC.ArrayFromValues(),
+			VC.Binary([]byte{}),
+			VC.ObjectID(types.NewObjectID()),
+			VC.Boolean(true),
+			VC.Time(time.Now()),
+			VC.Timestamp(math.MaxUint32, math.MaxUint32),
+			VC.Regex(".*", ""),
+			VC.JavaScript("return 1;"),
+		}
+
+		for _, v := range values {
+			assert.True(t, VC.MinKey().Compare(v) < 0, v.Type().String())
+			assert.True(t, v.Compare(VC.MinKey()) > 0, v.Type().String())
+			assert.True(t, VC.MaxKey().Compare(v) > 0, v.Type().String())
+			assert.True(t, v.Compare(VC.MaxKey()) < 0, v.Type().String())
+		}
+
+		assert.Equal(t, 0, VC.MinKey().Compare(VC.MinKey()))
+		assert.Equal(t, 0, VC.MaxKey().Compare(VC.MaxKey()))
+		assert.True(t, VC.MinKey().Compare(VC.MaxKey()) < 0)
+
+		assert.True(t, VC.MinKey().IsMinKey())
+		assert.False(t, VC.MinKey().IsMaxKey())
+		assert.True(t, VC.MaxKey().IsMaxKey())
+		assert.False(t, VC.MaxKey().IsMinKey())
+		assert.False(t, VC.Null().IsMinKey())
+		assert.False(t, (*Value)(nil).IsMaxKey())
+	})
+	t.Run("Numeric", func(t *testing.T) {
+		assert.Equal(t, 0, VC.Int32(42).Compare(VC.Int64(42)))
+		assert.Equal(t, 0, VC.Int64(42).Compare(VC.Double(42.0)))
+		assert.True(t, VC.Int32(41).Compare(VC.Double(41.5)) < 0)
+		assert.True(t, VC.Int64(math.MaxInt64).Compare(VC.Int64(math.MaxInt64-1)) > 0)
+		assert.True(t, VC.Double(math.NaN()).Compare(VC.Double(math.Inf(-1))) < 0)
+		assert.True(t, VC.Int64(1<<53+1).Compare(VC.Double(1<<53)) > 0)
+		assert.True(t, VC.Double(1<<53).Compare(VC.Int64(1<<53+1)) < 0)
+		assert.True(t, VC.Int64(math.MaxInt64).Compare(VC.Double(math.MaxInt64)) < 0)
+	})
+	t.Run("NumericMatchesEqualValues", func(t *testing.T) {
+		dec := func(s string) *Value {
+			d, err := types.ParseDecimal128(s)
+			require.NoError(t, err)
+			return VC.Decimal128(d)
+		}
+
+		values := []*Value{
+			VC.Int32(0), VC.Int32(1), VC.Int32(-7), VC.Int32(math.MaxInt32),
+			VC.Int64(1), VC.Int64(-7), VC.Int64(1 << 53), VC.Int64(1<<53 + 1),
+			VC.Int64(math.MaxInt64), VC.Int64(math.MinInt64),
+			VC.Double(0), VC.Double(math.Copysign(0, -1)), VC.Double(1), VC.Double(0.1), VC.Double(2.5),
+			VC.Double(1 << 53), VC.Double(math.MaxInt64), VC.Double(math.MinInt64),
+			VC.Double(math.NaN()), VC.Double(math.Inf(1)), VC.Double(math.Inf(-1)),
+			dec("1"), dec("0.1"), dec("2.50"), dec("9007199254740993"), dec("-7"),
+			dec("NaN"), dec("Infinity"), dec("-Infinity"),
+		}
+
+		for _, v1 := range values {
+			for _, v2 := range values {
+				if v1.Type() == v2.Type() {
+					continue
+				}
+
+				c := v1.Compare(v2)
+				assert.Equal(t, v1.EqualValues(v2), c == 0, "%s %s", v1, v2)
+				assert.Equal(t, -c, v2.Compare(v1), "%s %s", v1, v2)
+			}
+		}
+
+		assert.True(t, dec("9007199254740993").Compare(VC.Double(1<<53)) > 0)
+		assert.True(t, dec("0.1").Compare(VC.Double(0.1)) < 0)
+		assert.True(t, dec("-Infinity").Compare(VC.Int64(math.MinInt64)) < 0)
+		assert.True(t, dec("NaN").Compare(dec("-Infinity")) < 0)
+	})
+	t.Run("SameType", func(t *testing.T) {
+		assert.True(t, VC.String("a").Compare(VC.Symbol("b")) < 0)
+		assert.True(t, VC.Boolean(false).Compare(VC.Boolean(true)) < 0)
+		assert.True(t, VC.Timestamp(1, 2).Compare(VC.Timestamp(1, 1)) > 0)
+		assert.True(t, VC.Binary([]byte{0xFF}).Compare(VC.Binary([]byte{0x00, 0x00})) < 0)
+		assert.True(t, VC.DocumentFromElements(EC.Int("a", 1)).Compare(VC.DocumentFromElements(EC.Int("a", 2))) < 0)
+		assert.True(t, VC.DocumentFromElements(EC.Int("a", 1)).Compare(VC.DocumentFromElements(EC.Int("a", 1), EC.Int("b", 1))) < 0)
+		assert.True(t, VC.ArrayFromValues(VC.Int(1), VC.MaxKey()).Compare(VC.ArrayFromValues(VC.Int(1), VC.Int(2))) > 0)
+	})
+	t.Run("Sort", func(t *testing.T) {
+		values := []*Value{VC.MaxKey(), VC.String("b"), VC.Int(2), VC.MinKey(), VC.Null(), VC.Double(1.5)}
+		sort.SliceStable(values, func(i, j int) bool { return values[i].Compare(values[j]) < 0 })
+
+		assert.True(t, values[0].IsMinKey())
+		assert.Equal(t, 1.5, values[2].Double())
+		assert.Equal(t, 2, values[3].Int())
+		assert.True(t, values[5].IsMaxKey())
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		doc := DC.Elements(
+			EC.SubDocumentFromElements("range",
+				EC.MinKey("min"),
+				EC.MaxKey("max"),
+			),
+		)
+
+		out, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		rt, err := ReadDocument(out)
+		require.NoError(t, err)
+		assert.True(t, rt.RecursiveLookup("range", "min").IsMinKey())
+		assert.True(t, rt.RecursiveLookup("range", "max").IsMaxKey())
+
+		js, err := doc.MarshalJSON()
+		require.NoError(t, err)
+		assert.Equal(t, `{"range":{"min":{"$minKey":1},"max":{"$maxKey":1}}}`, string(js))
+
+		rt = DC.New()
+		require.NoError(t, rt.UnmarshalJSON(js))
+		assert.True(t, rt.RecursiveLookup("range", "min").IsMinKey())
+		assert.True(t, rt.RecursiveLookup("range", "max").IsMaxKey())
+	})
+}
+
+func TestEqualValues(t *testing.T) {
+	dec := func(s string) *Value {
+		d, err := types.ParseDecimal128(s)
+		require.NoError(t, err)
+		return VC.Decimal128(d)
+	}
+
+	t.Run("Nil", func(t *testing.T) {
+		assert.True(t, (*Value)(nil).EqualValues(nil))
+		assert.False(t, (*Value)(nil).EqualValues(VC.Null()))
+		assert.False(t, VC.Null().EqualValues(nil))
+		assert.True(t, (*Document)(nil).EqualValues(nil))
+		assert.False(t, DC.New().EqualValues(nil))
+	})
+	t.Run("Equal", func(t *testing.T) {
+		for name, pair := range map[string][2]*Value{
+			"Int32Int64":          {VC.Int32(42), VC.Int64(42)},
+			"Int64Double":         {VC.Int64(-7), VC.Double(-7.0)},
+			"Int32Double":         {VC.Int32(0), VC.Double(math.Copysign(0, -1))},
+			"MaxInt32":            {VC.Int32(math.MaxInt32), VC.Double(math.MaxInt32)},
+			"MinInt64":            {VC.Int64(math.MinInt64), VC.Double(math.MinInt64)},
+			"DecimalInt":          {dec("42"), VC.Int32(42)},
+			"DecimalExponent":     {dec("1.5E+3"), VC.Int64(1500)},
+			"DecimalTrailingZero": {dec("2.50"), VC.Double(2.5)},
+			"DecimalDouble":       {dec("0.5"), VC.Double(0.5)},
+			"SameType":            {VC.String("a"), VC.String("a")},
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.True(t, pair[0].EqualValues(pair[1]))
+				assert.True(t, pair[1].EqualValues(pair[0]))
+			})
+		}
+	})
+	t.Run("NotEqual", func(t *testing.T) {
+		for name, pair := range map[string][2]*Value{
+			"Fraction":         {VC.Int32(1), VC.Double(1.5)},
+			"Values":           {VC.Int32(1), VC.Int64(2)},
+			"BeyondInt64":      {VC.Int64(math.MaxInt64), VC.Double(math.MaxInt64)},
+			"InexactDouble":    {VC.Int64(1<<53 + 1), VC.Double(1 << 53)},
+			"InexactDecimal":   {dec("0.1"), VC.Double(0.1)},
+			"NaN":              {VC.Double(math.NaN()), dec("NaN")},
+			"Infinity":         {VC.Double(math.Inf(1)), dec("Infinity")},
+			"StringNumber":     {VC.String("1"), VC.Int32(1)},
+			"BooleanNumber":    {VC.Boolean(true), VC.Int32(1)},
+			"DateTimeInt64":    {VC.DateTime(1), VC.Int64(1)},
+			"DocumentAndArray": {VC.DocumentFromElements(EC.Int("0", 1)), VC.ArrayFromValues(VC.Int(1))},
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.False(t, pair[0].EqualValues(pair[1]))
+				assert.False(t, pair[1].EqualValues(pair[0]))
+			})
+		}
+	})
+	t.Run("Documents", func(t *testing.T) {
+		doc := DC.Elements(
+			EC.Int32("port", 27017),
+			EC.SubDocumentFromElements("limits", EC.Int32("conns", 100), EC.Double("ratio", 1)),
+			EC.ArrayFromElements("sizes", VC.Int32(1), VC.Int32(2)),
+		)
+		promoted := DC.Elements(
+			EC.Int64("port", 27017),
+			EC.SubDocumentFromElements("limits", EC.Int64("conns", 100), EC.Int64("ratio", 1)),
+			EC.ArrayFromElements("sizes", VC.Int64(1), VC.Double(2)),
+		)
+
+		assert.True(t, doc.EqualValues(promoted))
+		assert.True(t, promoted.EqualValues(doc))
+		assert.False(t, VC.Document(doc).Equal(VC.Document(promoted)))
+
+		data, err := promoted.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+		assert.True(t, doc.EqualValues(parsed))
+
+		assert.False(t, doc.EqualValues(DC.Elements(EC.Int64("port", 27017))))
+		assert.False(t, doc.EqualValues(DC.Elements(
+			EC.ArrayFromElements("sizes", VC.Int32(1), VC.Int32(2)),
+			EC.SubDocumentFromElements("limits", EC.Int32("conns", 100), EC.Double("ratio", 1)),
+			EC.Int32("port", 27017),
+		)))
+		assert.False(t, doc.EqualValues(DC.Elements(
+			EC.Int32("port", 27017),
+			EC.SubDocumentFromElements("limits", EC.Int32("conns", 100), EC.Double("ratio", 1.5)),
+			EC.ArrayFromElements("sizes", VC.Int32(1), VC.Int32(2)),
+		)))
+	})
+}
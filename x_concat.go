@@ -0,0 +1,26 @@
+package birch
+
+// ConcatPrefixed appends a deep copy of each element of other to the
+// document, with prefix and a "." before its key, such as to merge
+// the metrics of several subsystems into one sample without key
+// collisions, and returns the document. Only the keys of other's
+// top-level elements are prefixed; the keys of sub-documents are
+// unchanged. With an empty prefix, the keys are unchanged, as with
+// Extend. Like Extend, ConcatPrefixed may produce a document with
+// duplicate keys.
+func (d *Document) ConcatPrefixed(prefix string, other *Document) *Document {
+	if d == nil || other == nil {
+		return d
+	}
+
+	if prefix != "" {
+		prefix += "."
+	}
+
+	elems := make([]*Element, len(other.elems))
+	for idx, elem := range other.elems {
+		elems[idx] = EC.Value(prefix+elem.Key(), elem.value.Clone())
+	}
+
+	return d.Append(elems...)
+}
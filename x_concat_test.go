@@ -0,0 +1,54 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcatPrefixed(t *testing.T) {
+	other := func() *Document {
+		return DC.Elements(
+			EC.Int64("ops", 10),
+			EC.SubDocumentFromElements("cache", EC.Int32("hits", 1), EC.Int32("misses", 2)),
+			EC.ArrayFromElements("samples", VC.Int32(1)),
+		)
+	}
+
+	t.Run("Prefixed", func(t *testing.T) {
+		src := other()
+		doc := DC.Elements(EC.String("host", "localhost"))
+		assert.True(t, doc == doc.ConcatPrefixed("db", src))
+
+		assert.Equal(t, []string{"host", "db.ops", "db.cache", "db.samples"}, doc.KeyNames())
+		assert.Equal(t, int64(10), doc.Lookup("db.ops").Int64())
+
+		// nested documents keep their keys.
+		assert.Equal(t, []string{"hits", "misses"}, doc.Lookup("db.cache").MutableDocument().KeyNames())
+		assert.Equal(t, int32(1), doc.RecursiveLookup("db.cache", "hits").Int32())
+	})
+	t.Run("DeepCopy", func(t *testing.T) {
+		src := other()
+		doc := DC.New().ConcatPrefixed("db", src)
+
+		src.Lookup("cache").MutableDocument().Set(EC.Int32("hits", 100))
+		src.Lookup("samples").MutableArray().Append(VC.Int32(2))
+
+		assert.Equal(t, int32(1), doc.RecursiveLookup("db.cache", "hits").Int32())
+		assert.Equal(t, 1, doc.Lookup("db.samples").MutableArray().Len())
+	})
+	t.Run("EmptyPrefix", func(t *testing.T) {
+		doc := DC.Elements(EC.String("host", "localhost")).ConcatPrefixed("", other())
+		assert.True(t, doc.EqualExcept(DC.Elements(EC.String("host", "localhost")).Extend(other())))
+	})
+	t.Run("Collisions", func(t *testing.T) {
+		doc := DC.New().ConcatPrefixed("a", other()).ConcatPrefixed("b", other())
+		assert.Equal(t, []string{"a.ops", "a.cache", "a.samples", "b.ops", "b.cache", "b.samples"}, doc.KeyNames())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1))
+		assert.True(t, doc == doc.ConcatPrefixed("x", nil))
+		assert.Equal(t, 1, doc.Len())
+		assert.Nil(t, (*Document)(nil).ConcatPrefixed("x", other()))
+	})
+}
@@ -0,0 +1,120 @@
+package birch
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/jsonx"
+)
+
+// Format identifies the encoding of a document for LoadConfig.
+type Format int
+
+const (
+	// FormatAuto detects whether the input is BSON or JSON.
+	FormatAuto Format = iota
+	// FormatJSON is a JSON object, which may use MongoDB's extended
+	// JSON wrappers (e.g. {"$oid": "..."}) for BSON types that
+	// have no equivalent in JSON.
+	FormatJSON
+	// FormatCanonicalJSON is a JSON object in canonical extended
+	// JSON, where every number uses a type wrapper (e.g.
+	// {"$numberLong": "42"}) so that its BSON type is explicit.
+	FormatCanonicalJSON
+	// FormatBSON is a single BSON document.
+	FormatBSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+	case FormatJSON:
+		return "json"
+	case FormatCanonicalJSON:
+		return "canonical-json"
+	case FormatBSON:
+		return "bson"
+	default:
+		return "unknown"
+	}
+}
+
+// LoadConfig reads all of r and parses it as a single document in the
+// given format, so that services can accept configuration in any of
+// the formats through one entry point.
+//
+// With FormatAuto, input is BSON if its first four bytes, as the
+// little-endian length of a BSON document, equal the length of the
+// input and its last byte is the null terminator of a document, and
+// is JSON if its first byte, after any whitespace, is '{'. Since JSON
+// cannot end with a null byte, this does not mistake JSON for BSON,
+// but it means that BSON input with trailing data, such as a second
+// document, is not detected as BSON: when its first byte happens to
+// be '{' (a document length of 123, 379, and so on) it fails to parse
+// as JSON, and otherwise LoadConfig returns an error that the format
+// is unknown. Auto detection never requires canonical extended JSON,
+// which FormatJSON also accepts.
+func LoadConfig(r io.Reader, format Format) (*Document, error) {
+	if r == nil {
+		return nil, errors.New("cannot load config from nil reader")
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading config")
+	}
+
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+
+	switch format {
+	case FormatBSON:
+		doc, err := ReadDocument(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "problem parsing bson config")
+		}
+		if size := readi32(data); int(size) != len(data) {
+			return nil, errors.Errorf("bson config has %d bytes after the document", len(data)-int(size))
+		}
+		return doc, nil
+	case FormatJSON, FormatCanonicalJSON:
+		jdoc, err := jsonx.DC.BytesErr(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "problem parsing json config")
+		}
+
+		if format == FormatCanonicalJSON {
+			if err = checkCanonicalJSON(jsonx.VC.Object(jdoc)); err != nil {
+				return nil, errors.Wrap(err, "problem parsing canonical json config")
+			}
+		}
+
+		doc, err := DC.JSONXErr(jdoc)
+		if err != nil {
+			return nil, errors.Wrap(err, "problem converting json config")
+		}
+		return doc, nil
+	case FormatAuto:
+		return nil, errors.New("config is not in a known format")
+	default:
+		return nil, errors.Errorf("invalid config format %d", format)
+	}
+}
+
+// detectFormat returns the format of the data, or FormatAuto if it is
+// neither BSON nor JSON.
+func detectFormat(data []byte) Format {
+	if len(data) >= 5 && int(readi32(data)) == len(data) && data[len(data)-1] == 0 {
+		return FormatBSON
+	}
+
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatJSON
+	}
+
+	return FormatAuto
+}
@@ -0,0 +1,87 @@
+package birch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestLoadConfig(t *testing.T) {
+	expected := DC.Elements(
+		EC.String("name", "svc"),
+		EC.Int32("port", 8080),
+		EC.SubDocumentFromElements("limits", EC.Int64("max", 1<<40)),
+	)
+	data, err := expected.MarshalBSON()
+	require.NoError(t, err)
+
+	jsonConfig := `{"name": "svc", "port": 8080, "limits": {"max": {"$numberLong": "1099511627776"}}}`
+	canonical := `{"name": "svc", "port": {"$numberInt": "8080"}, "limits": {"max": {"$numberLong": "1099511627776"}}}`
+
+	for _, test := range []struct {
+		name   string
+		input  []byte
+		format Format
+	}{
+		{name: "BSON", input: data, format: FormatBSON},
+		{name: "AutoBSON", input: data, format: FormatAuto},
+		{name: "JSON", input: []byte(jsonConfig), format: FormatJSON},
+		{name: "AutoJSON", input: []byte("\n  " + jsonConfig), format: FormatAuto},
+		{name: "CanonicalJSON", input: []byte(canonical), format: FormatCanonicalJSON},
+		{name: "AutoCanonicalJSON", input: []byte(canonical), format: FormatAuto},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := LoadConfig(bytes.NewReader(test.input), test.format)
+			require.NoError(t, err)
+			assert.True(t, expected.EqualExcept(doc), doc.String())
+		})
+	}
+
+	t.Run("BSONStartingWithBrace", func(t *testing.T) {
+		// a 123 byte document has a length prefix that starts with '{'.
+		doc := DC.Elements(EC.String("pad", strings.Repeat("x", 123-4-1-4-4-1-1)))
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		require.Equal(t, byte('{'), data[0])
+
+		out, err := LoadConfig(bytes.NewReader(data), FormatAuto)
+		require.NoError(t, err)
+		assert.True(t, doc.EqualExcept(out))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		for name, test := range map[string]struct {
+			input  string
+			format Format
+		}{
+			"UnknownFormat":    {input: "name: svc", format: FormatAuto},
+			"Empty":            {input: "", format: FormatAuto},
+			"InvalidJSON":      {input: `{"name": `, format: FormatJSON},
+			"NotCanonical":     {input: jsonConfig, format: FormatCanonicalJSON},
+			"JSONAsBSON":       {input: jsonConfig, format: FormatBSON},
+			"TrailingBSON":     {input: string(data) + "x", format: FormatBSON},
+			"TrailingAutoBSON": {input: string(data) + string(data), format: FormatAuto},
+			"InvalidFormat":    {input: jsonConfig, format: Format(42)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := LoadConfig(strings.NewReader(test.input), test.format)
+				assert.Error(t, err)
+			})
+		}
+
+		_, err := LoadConfig(nil, FormatAuto)
+		assert.Error(t, err)
+		_, err = LoadConfig(failingReader{}, FormatAuto)
+		assert.Error(t, err)
+	})
+	t.Run("Types", func(t *testing.T) {
+		doc, err := LoadConfig(strings.NewReader(canonical), FormatAuto)
+		require.NoError(t, err)
+		assert.Equal(t, bsontype.Int32, doc.Lookup("port").Type())
+		assert.Equal(t, bsontype.Int64, doc.RecursiveLookup("limits", "max").Type())
+		assert.Equal(t, "canonical-json", FormatCanonicalJSON.String())
+	})
+}
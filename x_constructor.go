@@ -39,6 +39,118 @@ func (DocumentConstructor) ElementsOmitEmpty(elems ...*Element) *Document {
 	return DC.New().AppendOmitEmpty(elems...)
 }
 
+// AC is a convenience variable provided for access to the ArrayConstructor methods.
+var AC ArrayConstructor
+
+// ArrayConstructor is used as a namespace for array constructor functions.
+type ArrayConstructor struct{}
+
+// SubDocuments returns an array with a value for each of the
+// documents, in order. The array holds deep copies of the documents,
+// so later changes to the documents do not modify the array. Nil
+// documents become null values.
+func (ArrayConstructor) SubDocuments(docs ...*Document) *Array {
+	arr := MakeArray(len(docs))
+
+	for _, doc := range docs {
+		if doc == nil {
+			arr.Append(VC.Null())
+			continue
+		}
+
+		arr.Append(VC.Document(doc).Clone())
+	}
+
+	return arr
+}
+
+// ArrayFromDocuments creates an array element with the given key,
+// holding deep copies of the documents as described by
+// AC.SubDocuments.
+func (ElementConstructor) ArrayFromDocuments(key string, docs ...*Document) *Element {
+	return EC.Array(key, AC.SubDocuments(docs...))
+}
+
+// KV is a key and value pair, for constructing documents with
+// DC.Pairs.
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// Pairs returns a document containing an element for each pair, in
+// the order given. Values are converted with MarshalValue, so they
+// may be of any type that Marshal supports, including structs, maps,
+// and slices; nil values become null. Returns an error, naming the
+// key, if a value cannot be converted.
+func (DocumentConstructor) Pairs(pairs ...KV) (*Document, error) {
+	doc := DC.Make(len(pairs))
+
+	for _, kv := range pairs {
+		val, err := MarshalValue(kv.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting value for key '%s'", kv.Key)
+		}
+
+		elem, err := EC.ValueErr(kv.Key, val)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		doc.Append(elem)
+	}
+
+	return doc, nil
+}
+
+// OrderedMap returns an element with the given key whose value is a
+// sub-document containing an element for each pair, in the order
+// given, for sub-documents, such as sections of a configuration,
+// whose field order matters. Values are converted as they are by
+// DC.Pairs, except that a []KV value becomes a nested sub-document that
+// also keeps the order of its pairs. Returns an error, naming the key
+// prefixed by the keys of its parents, if a value cannot be converted.
+func (ElementConstructor) OrderedMap(key string, pairs []KV) (*Element, error) {
+	doc, err := orderedMapDocument(key, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	return EC.SubDocument(key, doc), nil
+}
+
+func orderedMapDocument(prefix string, pairs []KV) (*Document, error) {
+	doc := DC.Make(len(pairs))
+
+	for _, kv := range pairs {
+		path := prefix + "." + kv.Key
+
+		if nested, ok := kv.Value.([]KV); ok {
+			sub, err := orderedMapDocument(path, nested)
+			if err != nil {
+				return nil, err
+			}
+
+			doc.Append(EC.SubDocument(kv.Key, sub))
+			continue
+		}
+
+		val, err := MarshalValue(kv.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting value for key '%s'", path)
+		}
+
+		elem := EC.Value(kv.Key, val)
+		if elem == nil {
+			return nil, errors.Errorf("could not convert '%s' value to an element", path)
+		}
+
+		doc.Append(elem)
+	}
+
+	return doc, nil
+}
+
 // Reader constructs a document from a bson reader, which is a wrapper
 // around a byte slice representation of a bson document. Reader
 // panics if there is a problem reading the document.
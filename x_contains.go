@@ -0,0 +1,106 @@
+package birch
+
+import "github.com/tychoish/birch/bsontype"
+
+// ContainsOptions controls how Document.ContainsWithOptions matches
+// documents.
+type ContainsOptions struct {
+	// SubsetArrays allows an array in the sub-document to match an
+	// array that contains additional elements, or the same
+	// elements in a different order: each element of the
+	// sub-document's array must match some element of the other
+	// array.
+	SubsetArrays bool
+}
+
+// Contains returns true if every element in sub is present in the
+// document with an equal value, ignoring any additional elements in
+// the document. This is a partial match, useful for asserting that a
+// document has certain fields without caring about the rest.
+//
+// Contains recurses into sub-documents, so a sub-document in sub only
+// needs to match a subset of the corresponding sub-document. Arrays
+// must have the same length and match element for element, where
+// documents within the arrays are themselves matched partially. Use
+// ContainsWithOptions to relax the matching of arrays.
+//
+// Other values must be equal as reported by Value.Equal, which is
+// strict about types: numeric values only match values of the same
+// BSON type, so an int32 of 1 does not match an int64 or a double
+// of 1. An empty or nil sub-document is contained in every document.
+func (d *Document) Contains(sub *Document) bool {
+	return d.ContainsWithOptions(sub, ContainsOptions{})
+}
+
+// ContainsWithOptions is the same as Contains, but allows callers to
+// control how arrays are matched.
+func (d *Document) ContainsWithOptions(sub *Document, opts ContainsOptions) bool {
+	if sub == nil {
+		return true
+	}
+
+	if d == nil {
+		return sub.Len() == 0
+	}
+
+	for _, elem := range sub.elems {
+		other := d.LookupElement(elem.Key())
+		if other == nil {
+			return false
+		}
+
+		if !opts.value(other.value, elem.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (opts ContainsOptions) value(v, sub *Value) bool {
+	if v.Type() != sub.Type() {
+		return false
+	}
+
+	switch sub.Type() {
+	case bsontype.EmbeddedDocument:
+		return v.MutableDocument().ContainsWithOptions(sub.MutableDocument(), opts)
+	case bsontype.Array:
+		return opts.array(v.MutableArray(), sub.MutableArray())
+	default:
+		return v.Equal(sub)
+	}
+}
+
+func (opts ContainsOptions) array(a, sub *Array) bool {
+	if !opts.SubsetArrays {
+		if a.Len() != sub.Len() {
+			return false
+		}
+
+		for idx, elem := range sub.doc.elems {
+			if !opts.value(a.doc.elems[idx].value, elem.value) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, elem := range sub.doc.elems {
+		found := false
+
+		for _, candidate := range a.doc.elems {
+			if opts.value(candidate.value, elem.value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,85 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContains(t *testing.T) {
+	doc := DC.Elements(
+		EC.String("status", "ok"),
+		EC.Int64("count", 42),
+		EC.SubDocumentFromElements("host",
+			EC.String("name", "localhost"),
+			EC.Int32("port", 27017),
+			EC.SubDocumentFromElements("os", EC.String("type", "linux"), EC.String("arch", "amd64")),
+		),
+		EC.ArrayFromElements("tags", VC.String("a"), VC.String("b"), VC.String("c")),
+		EC.ArrayFromElements("members",
+			VC.DocumentFromElements(EC.Int("id", 1), EC.String("state", "primary")),
+			VC.DocumentFromElements(EC.Int("id", 2), EC.String("state", "secondary")),
+		),
+	)
+
+	t.Run("Matching", func(t *testing.T) {
+		for name, sub := range map[string]*Document{
+			"Nil":       nil,
+			"Empty":     DC.New(),
+			"Self":      doc,
+			"TopLevel":  DC.Elements(EC.String("status", "ok"), EC.Int64("count", 42)),
+			"Nested":    DC.Elements(EC.SubDocumentFromElements("host", EC.SubDocumentFromElements("os", EC.String("type", "linux")))),
+			"Array":     DC.Elements(EC.ArrayFromElements("tags", VC.String("a"), VC.String("b"), VC.String("c"))),
+			"ArrayDocs": DC.Elements(EC.ArrayFromElements("members", VC.DocumentFromElements(EC.Int("id", 1)), VC.DocumentFromElements(EC.Int("id", 2)))),
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.True(t, doc.Contains(sub))
+				assert.True(t, doc.ContainsWithOptions(sub, ContainsOptions{SubsetArrays: true}))
+			})
+		}
+	})
+	t.Run("NotMatching", func(t *testing.T) {
+		for name, sub := range map[string]*Document{
+			"Missing":       DC.Elements(EC.String("missing", "ok")),
+			"Value":         DC.Elements(EC.String("status", "failed")),
+			"Type":          DC.Elements(EC.String("count", "42")),
+			"NumericType":   DC.Elements(EC.Int32("count", 42)),
+			"NestedMissing": DC.Elements(EC.SubDocumentFromElements("host", EC.String("user", "root"))),
+			"NestedValue":   DC.Elements(EC.SubDocumentFromElements("host", EC.Int32("port", 27018))),
+			"NotDocument":   DC.Elements(EC.SubDocumentFromElements("status", EC.String("ok", "ok"))),
+			"ArrayElement":  DC.Elements(EC.ArrayFromElements("tags", VC.String("d"))),
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.False(t, doc.Contains(sub))
+				assert.False(t, doc.ContainsWithOptions(sub, ContainsOptions{SubsetArrays: true}))
+			})
+		}
+	})
+	t.Run("SubsetArrays", func(t *testing.T) {
+		for name, sub := range map[string]*Document{
+			"Shorter":   DC.Elements(EC.ArrayFromElements("tags", VC.String("a"), VC.String("b"))),
+			"Reordered": DC.Elements(EC.ArrayFromElements("tags", VC.String("c"), VC.String("a"), VC.String("b"))),
+			"Documents": DC.Elements(EC.ArrayFromElements("members", VC.DocumentFromElements(EC.String("state", "secondary")))),
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.False(t, doc.Contains(sub))
+				assert.True(t, doc.ContainsWithOptions(sub, ContainsOptions{SubsetArrays: true}))
+			})
+		}
+	})
+	t.Run("Parsed", func(t *testing.T) {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		assert.True(t, parsed.Contains(DC.Elements(EC.SubDocumentFromElements("host", EC.String("name", "localhost")))))
+		assert.False(t, parsed.Contains(DC.Elements(EC.SubDocumentFromElements("host", EC.String("name", "remote")))))
+	})
+	t.Run("NilDocument", func(t *testing.T) {
+		var empty *Document
+		assert.True(t, empty.Contains(DC.New()))
+		assert.False(t, empty.Contains(DC.Elements(EC.Int("a", 1))))
+	})
+}
@@ -0,0 +1,110 @@
+package birch
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+)
+
+// DocumentLog writes documents to an append-only log, which is the
+// concatenation of the documents' BSON encodings, as StreamReaders and
+// ValidateStream read, and reports the offset of each document in the
+// log so that ReadDocumentAt can read it later. A DocumentLog is safe
+// for concurrent use.
+type DocumentLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	offset int64
+}
+
+// NewDocumentLog returns a DocumentLog that writes to w, where the
+// first document has offset 0, such as for a new, empty file. Use
+// NewDocumentLogAt to add to an existing log.
+func NewDocumentLog(w io.Writer) *DocumentLog { return NewDocumentLogAt(w, 0) }
+
+// NewDocumentLogAt returns a DocumentLog that writes to w, where the
+// first document has the given offset, such as the size of an
+// existing log that w appends to.
+func NewDocumentLogAt(w io.Writer, offset int64) *DocumentLog {
+	return &DocumentLog{w: w, offset: offset}
+}
+
+// Append writes the document to the log with a single write, and
+// returns the offset of the document in the log. If the write fails
+// after writing some of the document, the log is corrupt from the
+// offset of the document, and the offsets of later documents account
+// for the bytes that were written.
+func (l *DocumentLog) Append(d *Document) (int64, error) {
+	if d == nil {
+		return 0, errors.WithStack(bsonerr.NilDocument)
+	}
+
+	data, err := d.MarshalBSON()
+	if err != nil {
+		return 0, errors.Wrap(err, "problem encoding document")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	offset := l.offset
+	n, err := l.w.Write(data)
+	l.offset += int64(n)
+	if err != nil {
+		return offset, errors.Wrapf(err, "problem writing document at offset %d", offset)
+	}
+
+	return offset, nil
+}
+
+// Offset returns the offset of the next document that Append writes,
+// which is the size of the log.
+func (l *DocumentLog) Offset() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.offset
+}
+
+// ReadDocumentAt reads the document that begins at the offset in r,
+// such as an offset that DocumentLog.Append returned. It returns an
+// error that wraps ErrUnexpectedEOF if r ends within the document, an
+// error that wraps ErrCorruptDocument if the document's length prefix
+// is less than 5 bytes or greater than the largest document that
+// MongoDB allows, and an error if the document is not valid.
+func ReadDocumentAt(r io.ReaderAt, offset int64) (*Document, error) {
+	if r == nil {
+		return nil, bsonerr.NilReader
+	}
+
+	var length [4]byte
+	if _, err := r.ReadAt(length[:], offset); err != nil {
+		if err == io.EOF {
+			return nil, errors.Wrapf(bsonerr.UnexpectedEOF, "no document at offset %d", offset)
+		}
+		return nil, errors.Wrapf(err, "problem reading document at offset %d", offset)
+	}
+
+	size := readi32(length[:])
+	if size < 5 || size > maxStreamDocumentSize {
+		return nil, errors.Wrapf(bsonerr.InvalidLength, "document at offset %d has length %d", offset, size)
+	}
+
+	data := make([]byte, size)
+	n, err := r.ReadAt(data, offset)
+	if n < len(data) {
+		if err == nil || err == io.EOF {
+			return nil, errors.Wrapf(bsonerr.UnexpectedEOF, "truncated document of length %d at offset %d", size, offset)
+		}
+		return nil, errors.Wrapf(err, "problem reading document at offset %d", offset)
+	}
+
+	doc, err := ReadDocument(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem parsing document at offset %d", offset)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,122 @@
+package birch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentLog(t *testing.T) {
+	docs := []*Document{
+		DC.Elements(EC.String("event", "start"), EC.Int32("n", 0)),
+		DC.Elements(EC.String("event", "update"), EC.SubDocumentFromElements("data", EC.Int64("size", 1024))),
+		DC.New(),
+		DC.Elements(EC.String("event", "stop"), EC.ArrayFromElements("codes", VC.Int32(1), VC.Int32(2))),
+	}
+
+	buf := &bytes.Buffer{}
+	log := NewDocumentLog(buf)
+
+	offsets := make([]int64, len(docs))
+	for idx, doc := range docs {
+		offset, err := log.Append(doc)
+		require.NoError(t, err)
+		offsets[idx] = offset
+	}
+	require.Equal(t, int64(0), offsets[0])
+	require.Equal(t, int64(buf.Len()), log.Offset())
+
+	t.Run("ReadOutOfOrder", func(t *testing.T) {
+		r := bytes.NewReader(buf.Bytes())
+		for _, idx := range []int{3, 0, 2, 1, 3} {
+			doc, err := ReadDocumentAt(r, offsets[idx])
+			require.NoError(t, err)
+			assert.True(t, docs[idx].EqualExcept(doc), "document %d", idx)
+		}
+	})
+	t.Run("StreamCompatible", func(t *testing.T) {
+		out := make(chan Reader, len(docs))
+		require.NoError(t, StreamReaders(context.Background(), bytes.NewReader(buf.Bytes()), out))
+		count := 0
+		for range out {
+			count++
+		}
+		assert.Equal(t, len(docs), count)
+	})
+	t.Run("AppendToExisting", func(t *testing.T) {
+		existing := bytes.NewBuffer(append([]byte(nil), buf.Bytes()...))
+		log := NewDocumentLogAt(existing, int64(existing.Len()))
+		offset, err := log.Append(docs[0])
+		require.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), offset)
+
+		doc, err := ReadDocumentAt(bytes.NewReader(existing.Bytes()), offset)
+		require.NoError(t, err)
+		assert.True(t, docs[0].EqualExcept(doc))
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := NewDocumentLog(buf)
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			results = map[int64]int32{}
+		)
+		for i := int32(0); i < 20; i++ {
+			wg.Add(1)
+			go func(i int32) {
+				defer wg.Done()
+				offset, err := log.Append(DC.Elements(EC.Int32("n", i)))
+				assert.NoError(t, err)
+				mu.Lock()
+				results[offset] = i
+				mu.Unlock()
+			}(i)
+		}
+		wg.Wait()
+
+		require.Len(t, results, 20)
+		for offset, i := range results {
+			doc, err := ReadDocumentAt(bytes.NewReader(buf.Bytes()), offset)
+			require.NoError(t, err)
+			assert.Equal(t, i, doc.Lookup("n").Int32())
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		data := buf.Bytes()
+
+		_, err := ReadDocumentAt(bytes.NewReader(data), int64(len(data)))
+		assert.True(t, errors.Is(err, ErrUnexpectedEOF))
+
+		_, err = ReadDocumentAt(bytes.NewReader(data[:len(data)-1]), offsets[3])
+		assert.True(t, errors.Is(err, ErrUnexpectedEOF))
+
+		_, err = ReadDocumentAt(bytes.NewReader([]byte{1, 0, 0, 0, 0}), 0)
+		assert.True(t, errors.Is(err, ErrCorruptDocument))
+
+		// an offset within a document reads an invalid length.
+		_, err = ReadDocumentAt(bytes.NewReader(data), offsets[1]+1)
+		assert.Error(t, err)
+
+		_, err = ReadDocumentAt(nil, 0)
+		assert.Error(t, err)
+
+		_, err = log.Append(nil)
+		assert.Error(t, err)
+
+		failing := NewDocumentLog(errWriter{})
+		_, err = failing.Append(docs[0])
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), failing.Offset())
+	})
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
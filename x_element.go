@@ -1,4 +1,53 @@
 package birch
 
+import (
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
 // SetValue makes it possible to modify the value of an element in place
 func (e *Element) SetValue(v *Value) { e.value = v }
+
+// Type returns the BSON type of the element's value, which is the
+// first byte of its encoding, without decoding the value, so that
+// code that iterates over many elements, including those from a
+// Reader, can dispatch on their types cheaply. Type panics if the
+// element is uninitialized.
+func (e *Element) Type() bsontype.Type {
+	if e == nil {
+		panic(bsonerr.UninitializedElement)
+	}
+
+	return e.value.Type()
+}
+
+// ReadElement reads a single element, encoded as its type, key, and
+// value, from the beginning of data, as Element.MarshalBSON writes
+// it, and returns the element and the number of bytes that it
+// occupies, so that callers can read a stream of elements framed
+// back to back. As with ReadDocument, the element refers to data
+// rather than copying it. ReadElement validates the element,
+// including the contents of embedded documents and arrays.
+func ReadElement(data []byte) (*Element, int, error) {
+	if len(data) < 2 {
+		return nil, 0, newErrTooSmall()
+	}
+
+	keyLen, err := Reader(data).validateKey(1, uint32(len(data)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	elem := newElement(0, 1+keyLen)
+	elem.value.data = data
+
+	size, err := elem.value.validate(false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n := 1 + keyLen + size
+	elem.value.data = data[:n]
+
+	return elem, int(n), nil
+}
@@ -0,0 +1,115 @@
+package birch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// EqualExcept reports whether the documents are equal after removing
+// the fields at the ignored paths from both documents. Fields must
+// have the same keys in the same order, and values other than
+// documents and arrays are compared with Value.Equal.
+//
+// Ignored paths are dot-separated keys (e.g. "info.start"), which
+// use the index of an array element as its key (e.g.
+// "hosts.0.start"). A path that begins with "*." matches the rest of
+// the path at any depth, so "*.timestamp" ignores every field named
+// timestamp, and "*.info.start" ignores the start field of every
+// document named info.
+func (d *Document) EqualExcept(other *Document, ignore ...string) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+
+	m := ignoredPaths(ignore)
+	return m.equalElements("", d.elems, other.elems, false)
+}
+
+type ignoredPaths []string
+
+func (m ignoredPaths) match(path string) bool {
+	for _, pattern := range m {
+		if suffix := strings.TrimPrefix(pattern, "*."); suffix != pattern {
+			if path == suffix || strings.HasSuffix(path, "."+suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if path == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filter returns the elements whose paths are not ignored, along with
+// their paths.
+func (m ignoredPaths) filter(prefix string, elems []*Element, array bool) ([]*Element, []string) {
+	out := make([]*Element, 0, len(elems))
+	paths := make([]string, 0, len(elems))
+
+	for idx, elem := range elems {
+		key := elem.Key()
+		if array {
+			key = strconv.Itoa(idx)
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if m.match(path) {
+			continue
+		}
+
+		out = append(out, elem)
+		paths = append(paths, path)
+	}
+
+	return out, paths
+}
+
+func (m ignoredPaths) equalElements(prefix string, e1, e2 []*Element, array bool) bool {
+	e1, paths1 := m.filter(prefix, e1, array)
+	e2, paths2 := m.filter(prefix, e2, array)
+
+	if len(e1) != len(e2) {
+		return false
+	}
+
+	for idx := range e1 {
+		// the paths of array elements are their indexes, which
+		// must line up after removing ignored elements.
+		if paths1[idx] != paths2[idx] {
+			return false
+		}
+
+		v1, v2 := e1[idx].value, e2[idx].value
+		if v1.Type() != v2.Type() {
+			return false
+		}
+
+		switch v1.Type() {
+		case bsontype.EmbeddedDocument:
+			if !m.equalElements(paths1[idx], v1.MutableDocument().elems, v2.MutableDocument().elems, false) {
+				return false
+			}
+		case bsontype.Array:
+			if !m.equalElements(paths1[idx], v1.MutableArray().doc.elems, v2.MutableArray().doc.elems, true) {
+				return false
+			}
+		default:
+			if !v1.Equal(v2) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package birch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqualExcept(t *testing.T) {
+	makeDoc := func(start time.Time, id string) *Document {
+		return DC.Elements(
+			EC.String("name", "golden"),
+			EC.Time("start", start),
+			EC.SubDocumentFromElements("info",
+				EC.String("id", id),
+				EC.Time("start", start),
+				EC.SubDocumentFromElements("host", EC.String("name", "localhost"), EC.Time("timestamp", start)),
+			),
+			EC.ArrayFromElements("samples",
+				VC.DocumentFromElements(EC.Int("value", 1), EC.Time("timestamp", start)),
+				VC.DocumentFromElements(EC.Int("value", 2), EC.Time("timestamp", start)),
+			),
+			EC.Time("timestamp", start),
+		)
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	doc := makeDoc(now, "a")
+	other := makeDoc(now.Add(time.Hour), "b")
+
+	t.Run("Nil", func(t *testing.T) {
+		assert.True(t, (*Document)(nil).EqualExcept(nil))
+		assert.False(t, doc.EqualExcept(nil))
+		assert.False(t, (*Document)(nil).EqualExcept(doc))
+	})
+	t.Run("Identical", func(t *testing.T) {
+		assert.True(t, doc.EqualExcept(makeDoc(now, "a")))
+		assert.True(t, doc.EqualExcept(doc, "start"))
+	})
+	t.Run("Different", func(t *testing.T) {
+		assert.False(t, doc.EqualExcept(other))
+		assert.False(t, doc.EqualExcept(other, "start", "info.start", "info.id"))
+		assert.False(t, doc.EqualExcept(other, "*.timestamp", "*.start"))
+	})
+	t.Run("IgnoredPaths", func(t *testing.T) {
+		assert.True(t, doc.EqualExcept(other, "start", "timestamp", "info.start", "info.id", "info.host.timestamp", "samples.0.timestamp", "samples.1.timestamp"))
+		assert.True(t, doc.EqualExcept(other, "*.timestamp", "*.start", "info.id"))
+		assert.True(t, doc.EqualExcept(other, "*.timestamp", "start", "*.info.start", "*.id"))
+	})
+	t.Run("IgnoredSubDocument", func(t *testing.T) {
+		assert.True(t, doc.EqualExcept(other, "start", "timestamp", "info", "samples"))
+		assert.True(t, doc.EqualExcept(DC.Elements(EC.String("name", "golden")), "start", "timestamp", "info", "samples"))
+	})
+	t.Run("MissingIgnoredField", func(t *testing.T) {
+		trimmed := DC.Elements(EC.String("name", "golden"))
+		assert.True(t, trimmed.EqualExcept(DC.Elements(EC.String("name", "golden"), EC.Time("start", now)), "start"))
+		assert.False(t, trimmed.EqualExcept(DC.Elements(EC.String("name", "golden"), EC.Time("start", now))))
+	})
+	t.Run("KeyOrder", func(t *testing.T) {
+		assert.False(t, DC.Elements(EC.Int("a", 1), EC.Int("b", 2)).EqualExcept(DC.Elements(EC.Int("b", 2), EC.Int("a", 1))))
+	})
+	t.Run("WildcardSuffixOnly", func(t *testing.T) {
+		// "*.start" matches the key start, not keys that end
+		// with it
+		a := DC.Elements(EC.Int("restart", 1))
+		b := DC.Elements(EC.Int("restart", 2))
+		assert.False(t, a.EqualExcept(b, "*.start"))
+		assert.True(t, a.EqualExcept(b, "*.restart"))
+	})
+	t.Run("ArrayElements", func(t *testing.T) {
+		a := DC.Elements(EC.ArrayFromElements("values", VC.Int(1), VC.Int(2), VC.Int(3)))
+		b := DC.Elements(EC.ArrayFromElements("values", VC.Int(1), VC.Int(5), VC.Int(3)))
+		assert.False(t, a.EqualExcept(b))
+		assert.True(t, a.EqualExcept(b, "values.1"))
+		assert.False(t, a.EqualExcept(b, "values.0"))
+		assert.False(t, a.EqualExcept(DC.Elements(EC.ArrayFromElements("values", VC.Int(1), VC.Int(3))), "values.1"))
+	})
+	t.Run("Parsed", func(t *testing.T) {
+		data, err := other.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		assert.True(t, doc.EqualExcept(parsed, "*.timestamp", "*.start", "info.id"))
+		assert.False(t, doc.EqualExcept(parsed, "*.timestamp", "*.start"))
+	})
+}
@@ -2,8 +2,78 @@ package birch
 
 import (
 	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
 )
 
-var errTooSmall = errors.New("error: too small")
+// These errors classify the errors that reading, parsing, and looking
+// up values in documents return. Use errors.Is to distinguish, for
+// example, a missing key from a corrupt document; the errors that
+// methods return may be more specific, or wrapped with context, and
+// still match their kind. ErrUnexpectedEOF is io.ErrUnexpectedEOF.
+var (
+	ErrCorruptDocument = bsonerr.CorruptDocument
+	ErrUnexpectedEOF   = bsonerr.UnexpectedEOF
+	ErrInvalidType     = bsonerr.InvalidType
+	ErrKeyNotFound     = bsonerr.KeyNotFound
+)
+
+var errTooSmall = bsonerr.TooSmall
 
 func newErrTooSmall() error { return errors.WithStack(errTooSmall) }
+
+// Error returns an element that holds err as a sub-document, for
+// recording errors in logs and other documents. The document has the
+// message of the error and, when the error wraps another error, the
+// wrapped error as a sub-document in the same form:
+//
+//	{message: "op: cause", cause: {message: "cause"}}
+//
+// Errors are unwrapped with Unwrap, or with Cause for errors from
+// github.com/pkg/errors, and wrappers that do not change the message,
+// such as those that only add a stack trace, are skipped. Errors that
+// implement DocumentMarshaler are stored as the document that
+// MarshalDocument returns, unless MarshalDocument returns an error.
+// A nil error is stored as null.
+func (ElementConstructor) Error(key string, err error) *Element {
+	if err == nil {
+		return EC.Null(key)
+	}
+
+	return EC.SubDocument(key, errorDocument(err))
+}
+
+func errorDocument(err error) *Document {
+	if marshaler, ok := err.(DocumentMarshaler); ok {
+		if doc, merr := marshaler.MarshalDocument(); merr == nil && doc != nil {
+			return doc
+		}
+	}
+
+	doc := DC.Elements(EC.String("message", err.Error()))
+
+	cause := unwrapError(err)
+	for cause != nil && cause.Error() == err.Error() {
+		if _, ok := cause.(DocumentMarshaler); ok {
+			break
+		}
+		cause = unwrapError(cause)
+	}
+
+	if cause != nil {
+		doc.Append(EC.SubDocument("cause", errorDocument(cause)))
+	}
+
+	return doc
+}
+
+func unwrapError(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		return causer.Cause()
+	}
+
+	return nil
+}
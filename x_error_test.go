@@ -0,0 +1,158 @@
+package birch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestErrorKinds(t *testing.T) {
+	doc := DC.Elements(EC.Int32("a", 1), EC.SubDocumentFromElements("b", EC.String("c", "d")))
+	data, err := doc.MarshalBSON()
+	require.NoError(t, err)
+
+	badLength := append([]byte{}, data...)
+	badLength[0] = 0xff
+	badTerminator := append([]byte{}, data...)
+	badTerminator[len(badTerminator)-1] = 0x01
+
+	kinds := []error{ErrCorruptDocument, ErrUnexpectedEOF, ErrInvalidType, ErrKeyNotFound}
+
+	for _, test := range []struct {
+		name string
+		err  func() error
+		kind error
+	}{
+		{
+			name: "TruncatedBuffer",
+			err:  func() error { _, err := ReadDocument(data[:3]); return err },
+			kind: ErrUnexpectedEOF,
+		},
+		{
+			name: "TruncatedReader",
+			err:  func() error { _, err := DC.New().ReadFrom(bytes.NewReader(data[:len(data)-2])); return err },
+			kind: ErrUnexpectedEOF,
+		},
+		{
+			name: "InvalidLength",
+			err:  func() error { _, err := Reader(badLength).Validate(); return err },
+			kind: ErrCorruptDocument,
+		},
+		{
+			name: "MissingTerminator",
+			err:  func() error { _, err := Reader(badTerminator).Validate(); return err },
+			kind: ErrCorruptDocument,
+		},
+		{
+			name: "MissingKey",
+			err:  func() error { _, err := doc.LookupErr("missing"); return err },
+			kind: ErrKeyNotFound,
+		},
+		{
+			name: "MissingReaderKey",
+			err:  func() error { _, err := Reader(data).RecursiveLookup("b", "missing"); return err },
+			kind: ErrKeyNotFound,
+		},
+		{
+			name: "TraverseScalar",
+			err:  func() error { _, err := doc.RecursiveLookupErr("a", "b"); return err },
+			kind: ErrKeyNotFound,
+		},
+		{
+			name: "WrongType",
+			err:  func() error { return doc.Lookup("a").SetDocument(DC.New()) },
+			kind: ErrInvalidType,
+		},
+		{
+			name: "Wrapped",
+			err:  func() error { return pkgerrors.Wrap(bsonerr.InvalidString, "problem reading value") },
+			kind: ErrCorruptDocument,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.err()
+			require.Error(t, err)
+
+			for _, kind := range kinds {
+				assert.Equal(t, kind == test.kind, errors.Is(err, kind), "%v is %v", err, kind)
+			}
+		})
+	}
+	t.Run("SpecificErrors", func(t *testing.T) {
+		err := pkgerrors.Wrap(bsonerr.ElementNotFound, "context")
+		assert.True(t, errors.Is(err, bsonerr.ElementNotFound))
+		assert.Equal(t, bsonerr.ElementNotFound, pkgerrors.Cause(err))
+		assert.Equal(t, "context: element not found", err.Error())
+
+		assert.False(t, errors.Is(bsonerr.ElementNotFound, bsonerr.InvalidDepthTraversal))
+		assert.False(t, errors.Is(bsonerr.NilDocument, ErrCorruptDocument))
+		assert.True(t, errors.Is(newErrTooSmall(), io.ErrUnexpectedEOF))
+		assert.True(t, IsTooSmall(newErrTooSmall()))
+	})
+}
+
+type testDocumentError struct {
+	code int
+	fail bool
+}
+
+func (e testDocumentError) Error() string { return "document error" }
+
+func (e testDocumentError) MarshalDocument() (*Document, error) {
+	if e.fail {
+		return nil, errors.New("cannot marshal")
+	}
+	return DC.Elements(EC.Int("code", e.code)), nil
+}
+
+func TestErrorElement(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		elem := EC.Error("err", nil)
+		assert.Equal(t, "err", elem.Key())
+		assert.Equal(t, bsontype.Null, elem.Value().Type())
+	})
+	t.Run("Simple", func(t *testing.T) {
+		elem := EC.Error("err", errors.New("failed"))
+		assert.True(t, DC.Elements(EC.String("message", "failed")).EqualExcept(elem.Value().MutableDocument()))
+	})
+	t.Run("WrappedChain", func(t *testing.T) {
+		root := errors.New("disk full")
+		err := fmt.Errorf("write: %w", fmt.Errorf("flush: %w", root))
+
+		doc := EC.Error("err", err).Value().MutableDocument()
+		assert.Equal(t, "write: flush: disk full", doc.Lookup("message").StringValue())
+		assert.Equal(t, "flush: disk full", doc.RecursiveLookup("cause", "message").StringValue())
+		assert.Equal(t, "disk full", doc.RecursiveLookup("cause", "cause", "message").StringValue())
+		assert.Nil(t, doc.RecursiveLookup("cause", "cause", "cause"))
+	})
+	t.Run("PkgErrors", func(t *testing.T) {
+		err := pkgerrors.Wrap(pkgerrors.New("disk full"), "write")
+
+		doc := EC.Error("err", err).Value().MutableDocument()
+		expected := DC.Elements(
+			EC.String("message", "write: disk full"),
+			EC.SubDocumentFromElements("cause", EC.String("message", "disk full")),
+		)
+		assert.True(t, expected.EqualExcept(doc), doc.String())
+	})
+	t.Run("DocumentMarshaler", func(t *testing.T) {
+		doc := EC.Error("err", testDocumentError{code: 42}).Value().MutableDocument()
+		assert.True(t, DC.Elements(EC.Int("code", 42)).EqualExcept(doc))
+
+		doc = EC.Error("err", fmt.Errorf("op: %w", testDocumentError{code: 7})).Value().MutableDocument()
+		assert.Equal(t, "op: document error", doc.Lookup("message").StringValue())
+		assert.True(t, DC.Elements(EC.Int("code", 7)).EqualExcept(doc.Lookup("cause").MutableDocument()))
+	})
+	t.Run("DocumentMarshalerFails", func(t *testing.T) {
+		doc := EC.Error("err", testDocumentError{fail: true}).Value().MutableDocument()
+		assert.True(t, DC.Elements(EC.String("message", "document error")).EqualExcept(doc))
+	})
+}
@@ -0,0 +1,192 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// ForEachString calls fn with the key and value of each string
+// element at the top level of the document, in document order,
+// skipping elements of other types. It does not allocate beyond the
+// key and value strings passed to fn.
+func (d *Document) ForEachString(fn func(key, val string)) {
+	if d == nil {
+		return
+	}
+
+	for _, elem := range d.elems {
+		if elem.value.Type() == bsontype.String {
+			fn(elem.Key(), elem.value.StringValue())
+		}
+	}
+}
+
+// ForEachStringRecursive is the same as ForEachString, but also visits
+// the string elements of sub-documents and arrays, depth first. The
+// key passed to fn is the dot-separated path to the value, using the
+// position for elements of arrays (e.g. "labels.tags.2"), as with
+// Apply.
+func (d *Document) ForEachStringRecursive(fn func(key, val string)) {
+	if d == nil {
+		return
+	}
+
+	d.forEachLeaf("", false, func(path string, v *Value) {
+		if v.Type() == bsontype.String {
+			fn(path, v.StringValue())
+		}
+	})
+}
+
+// ForEachInt64 calls fn with the key and value of each 32-bit or
+// 64-bit integer element at the top level of the document, in
+// document order, skipping elements of other types. It does not
+// allocate beyond the key strings passed to fn.
+func (d *Document) ForEachInt64(fn func(key string, val int64)) {
+	if d == nil {
+		return
+	}
+
+	for _, elem := range d.elems {
+		switch elem.value.Type() {
+		case bsontype.Int32:
+			fn(elem.Key(), int64(elem.value.Int32()))
+		case bsontype.Int64:
+			fn(elem.Key(), elem.value.Int64())
+		}
+	}
+}
+
+// ForEachInt64Recursive is the same as ForEachInt64, but also visits
+// the integer elements of sub-documents and arrays, depth first, and
+// passes the path to each value as ForEachStringRecursive does.
+func (d *Document) ForEachInt64Recursive(fn func(key string, val int64)) {
+	if d == nil {
+		return
+	}
+
+	d.forEachLeaf("", false, func(path string, v *Value) {
+		switch v.Type() {
+		case bsontype.Int32:
+			fn(path, int64(v.Int32()))
+		case bsontype.Int64:
+			fn(path, v.Int64())
+		}
+	})
+}
+
+// Int64Map returns the 32-bit and 64-bit integer elements at the top
+// level of the document as a map of their keys to their values,
+// skipping elements of other types. Doubles are skipped rather than
+// truncated, so that no value in the map has lost precision. When
+// keys are duplicated, the map holds the first value, as Lookup
+// returns it.
+func (d *Document) Int64Map() map[string]int64 {
+	if d == nil {
+		return map[string]int64{}
+	}
+
+	count := 0
+	for _, elem := range d.elems {
+		if t := elem.value.Type(); t == bsontype.Int32 || t == bsontype.Int64 {
+			count++
+		}
+	}
+
+	out := make(map[string]int64, count)
+	d.ForEachInt64(func(key string, val int64) {
+		if _, ok := out[key]; !ok {
+			out[key] = val
+		}
+	})
+
+	return out
+}
+
+// Int64MapFlat is the same as Int64Map, but also includes the integer
+// elements of sub-documents and arrays, keyed by their paths as with
+// ForEachInt64Recursive (e.g. "mem.resident" or "counts.2").
+func (d *Document) Int64MapFlat() map[string]int64 {
+	out := map[string]int64{}
+	d.ForEachInt64Recursive(func(key string, val int64) {
+		if _, ok := out[key]; !ok {
+			out[key] = val
+		}
+	})
+
+	return out
+}
+
+// IterateType calls fn with the key and value of each element of
+// type t at the top level of the document, in document order,
+// skipping elements of other types. It does not allocate beyond the
+// key strings passed to fn.
+func (d *Document) IterateType(t bsontype.Type, fn func(key string, v *Value)) {
+	if d == nil {
+		return
+	}
+
+	for _, elem := range d.elems {
+		if elem.value.Type() == t {
+			fn(elem.Key(), elem.value)
+		}
+	}
+}
+
+// IterateTypeRecursive is the same as IterateType, but also visits the
+// elements of sub-documents and arrays, depth first, and passes the
+// path to each value as ForEachStringRecursive does. Sub-documents and
+// arrays are always descended into, whether or not they match t;
+// when t is EmbeddedDocument or Array, fn is called with each
+// matching document or array before the values nested within it.
+func (d *Document) IterateTypeRecursive(t bsontype.Type, fn func(key string, v *Value)) {
+	if d == nil {
+		return
+	}
+
+	d.iterateType("", false, t, fn)
+}
+
+func (d *Document) iterateType(prefix string, isArray bool, t bsontype.Type, fn func(string, *Value)) {
+	for idx, elem := range d.elems {
+		var key string
+		if isArray {
+			key = strconv.Itoa(idx)
+		} else {
+			key = elem.Key()
+		}
+
+		vt := elem.value.Type()
+		if vt == t {
+			fn(prefix+key, elem.value)
+		}
+
+		switch vt {
+		case bsontype.EmbeddedDocument:
+			elem.value.MutableDocument().iterateType(prefix+key+".", false, t, fn)
+		case bsontype.Array:
+			elem.value.MutableArray().doc.iterateType(prefix+key+".", true, t, fn)
+		}
+	}
+}
+
+func (d *Document) forEachLeaf(prefix string, isArray bool, fn func(string, *Value)) {
+	for idx, elem := range d.elems {
+		var key string
+		if isArray {
+			key = strconv.Itoa(idx)
+		} else {
+			key = elem.Key()
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			elem.value.MutableDocument().forEachLeaf(prefix+key+".", false, fn)
+		case bsontype.Array:
+			elem.value.MutableArray().doc.forEachLeaf(prefix+key+".", true, fn)
+		default:
+			fn(prefix+key, elem.value)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestDocumentForEach(t *testing.T) {
+	doc := DC.Elements(
+		EC.String("host", "localhost"),
+		EC.Int32("port", 27017),
+		EC.SubDocumentFromElements("labels",
+			EC.String("region", "us-east"),
+			EC.Int64("shard", 3),
+			EC.ArrayFromElements("tags", VC.String("a"), VC.Int32(7), VC.String("b")),
+		),
+		EC.Int64("uptime", 42),
+		EC.Double("ratio", 0.5),
+		EC.String("name", "primary"),
+	)
+
+	type pair struct {
+		key string
+		val interface{}
+	}
+
+	for _, parsed := range []bool{false, true} {
+		input := doc
+		if parsed {
+			data, err := doc.MarshalBSON()
+			require.NoError(t, err)
+			input, err = ReadDocument(data)
+			require.NoError(t, err)
+		}
+
+		t.Run("String", func(t *testing.T) {
+			var out []pair
+			input.ForEachString(func(key, val string) { out = append(out, pair{key, val}) })
+			assert.Equal(t, []pair{{"host", "localhost"}, {"name", "primary"}}, out)
+		})
+		t.Run("StringRecursive", func(t *testing.T) {
+			var out []pair
+			input.ForEachStringRecursive(func(key, val string) { out = append(out, pair{key, val}) })
+			assert.Equal(t, []pair{
+				{"host", "localhost"},
+				{"labels.region", "us-east"},
+				{"labels.tags.0", "a"},
+				{"labels.tags.2", "b"},
+				{"name", "primary"},
+			}, out)
+		})
+		t.Run("Int64", func(t *testing.T) {
+			var out []pair
+			input.ForEachInt64(func(key string, val int64) { out = append(out, pair{key, val}) })
+			assert.Equal(t, []pair{{"port", int64(27017)}, {"uptime", int64(42)}}, out)
+		})
+		t.Run("Int64Recursive", func(t *testing.T) {
+			var out []pair
+			input.ForEachInt64Recursive(func(key string, val int64) { out = append(out, pair{key, val}) })
+			assert.Equal(t, []pair{
+				{"port", int64(27017)},
+				{"labels.shard", int64(3)},
+				{"labels.tags.1", int64(7)},
+				{"uptime", int64(42)},
+			}, out)
+		})
+		t.Run("IterateType", func(t *testing.T) {
+			var out []pair
+			input.IterateType(bsontype.Int64, func(key string, v *Value) { out = append(out, pair{key, v.Int64()}) })
+			assert.Equal(t, []pair{{"uptime", int64(42)}}, out)
+
+			var keys []string
+			input.IterateType(bsontype.EmbeddedDocument, func(key string, v *Value) { keys = append(keys, key) })
+			assert.Equal(t, []string{"labels"}, keys)
+		})
+		t.Run("IterateTypeRecursive", func(t *testing.T) {
+			var out []pair
+			input.IterateTypeRecursive(bsontype.Int32, func(key string, v *Value) { out = append(out, pair{key, v.Int32()}) })
+			assert.Equal(t, []pair{{"port", int32(27017)}, {"labels.tags.1", int32(7)}}, out)
+
+			var keys []string
+			for _, bt := range []bsontype.Type{bsontype.EmbeddedDocument, bsontype.Array} {
+				input.IterateTypeRecursive(bt, func(key string, v *Value) { keys = append(keys, key) })
+			}
+			assert.Equal(t, []string{"labels", "labels.tags"}, keys)
+		})
+		t.Run("Int64Map", func(t *testing.T) {
+			assert.Equal(t, map[string]int64{"port": 27017, "uptime": 42}, input.Int64Map())
+		})
+		t.Run("Int64MapFlat", func(t *testing.T) {
+			assert.Equal(t, map[string]int64{
+				"port":          27017,
+				"labels.shard":  3,
+				"labels.tags.1": 7,
+				"uptime":        42,
+			}, input.Int64MapFlat())
+		})
+	}
+	t.Run("Int64MapDuplicates", func(t *testing.T) {
+		dup := DC.Elements(EC.Int32("a", 1), EC.Int64("a", 2), EC.Double("b", 2.5))
+		assert.Equal(t, map[string]int64{"a": 1}, dup.Int64Map())
+		assert.Equal(t, map[string]int64{"a": 1}, dup.Int64MapFlat())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		var nilDoc *Document
+		for _, d := range []*Document{nilDoc, DC.New()} {
+			d.ForEachString(func(string, string) { t.Fail() })
+			d.ForEachStringRecursive(func(string, string) { t.Fail() })
+			d.ForEachInt64(func(string, int64) { t.Fail() })
+			d.ForEachInt64Recursive(func(string, int64) { t.Fail() })
+			d.IterateType(bsontype.String, func(string, *Value) { t.Fail() })
+			d.IterateTypeRecursive(bsontype.String, func(string, *Value) { t.Fail() })
+			assert.Empty(t, d.Int64Map())
+			assert.Empty(t, d.Int64MapFlat())
+		}
+	})
+	t.Run("Allocations", func(t *testing.T) {
+		numbers := DC.Elements(EC.Int64("a", 1), EC.Int32("b", 2), EC.Double("c", 3), EC.Boolean("d", true))
+		strs := DC.Elements(EC.Int64("a", 1), EC.Double("b", 3))
+
+		// at most the keys of the two matching elements
+		var total int64
+		assert.LessOrEqual(t, testing.AllocsPerRun(100, func() {
+			numbers.ForEachInt64(func(_ string, val int64) { total += val })
+		}), float64(2))
+		assert.Zero(t, testing.AllocsPerRun(100, func() {
+			strs.ForEachString(func(string, string) { t.Fail() })
+		}))
+		assert.Zero(t, testing.AllocsPerRun(100, func() {
+			strs.IterateType(bsontype.String, func(string, *Value) { t.Fail() })
+		}))
+		assert.LessOrEqual(t, testing.AllocsPerRun(100, func() {
+			numbers.IterateType(bsontype.Int64, func(_ string, v *Value) { total += v.Int64() })
+		}), float64(1))
+	})
+}
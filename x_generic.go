@@ -0,0 +1,98 @@
+//go:build go1.18
+// +build go1.18
+
+package birch
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeInto returns a new value of type T, which may be a struct, a
+// map with string keys, or a pointer to either, populated from the
+// document as by Unmarshal. On error, DecodeInto returns the zero
+// value of T.
+func DecodeInto[T any](d *Document) (T, error) { return DecodeIntoWithRegistry[T](nil, d) }
+
+// DecodeIntoWithRegistry is the same as DecodeInto, but uses the
+// registry's decoders where they apply, as Registry.Unmarshal does. A
+// nil registry uses only the built-in type handling.
+func DecodeIntoWithRegistry[T any](r *Registry, d *Document) (T, error) {
+	var out T
+	if err := unmarshalReflect(r, d, &out); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return out, nil
+}
+
+// MarshalFrom converts a value of type T into a document as by
+// Marshal.
+func MarshalFrom[T any](v T) (*Document, error) { return marshalReflect(nil, v) }
+
+// MarshalFromWithRegistry is the same as MarshalFrom, but uses the
+// registry's encoders where they apply, as Registry.Marshal does.
+func MarshalFromWithRegistry[T any](r *Registry, v T) (*Document, error) {
+	return marshalReflect(r, v)
+}
+
+// NewTypedArray returns an array holding the values of the slice,
+// converted as by MarshalValue, so that, for example, a []string
+// becomes an array of strings. Slices of strings, bools, float64s,
+// ints, int32s, int64s, time.Times and documents are converted
+// without reflection. NewTypedArray returns an error if an element
+// cannot be converted.
+func NewTypedArray[T any](vals []T) (*Array, error) {
+	arr := MakeArray(len(vals))
+
+	switch typed := any(vals).(type) {
+	case []string:
+		for _, v := range typed {
+			arr.Append(VC.String(v))
+		}
+	case []bool:
+		for _, v := range typed {
+			arr.Append(VC.Boolean(v))
+		}
+	case []float64:
+		for _, v := range typed {
+			arr.Append(VC.Double(v))
+		}
+	case []int:
+		for _, v := range typed {
+			arr.Append(VC.Int(v))
+		}
+	case []int32:
+		for _, v := range typed {
+			arr.Append(VC.Int32(v))
+		}
+	case []int64:
+		for _, v := range typed {
+			arr.Append(VC.Int64(v))
+		}
+	case []time.Time:
+		for _, v := range typed {
+			arr.Append(VC.Time(v))
+		}
+	case []*Document:
+		for _, v := range typed {
+			if v == nil {
+				arr.Append(VC.Null())
+				continue
+			}
+			arr.Append(VC.Document(v))
+		}
+	default:
+		for idx := range vals {
+			val, err := MarshalValue(vals[idx])
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding array index %d", idx)
+			}
+			arr.Append(val)
+		}
+	}
+
+	return arr, nil
+}
@@ -0,0 +1,182 @@
+//go:build go1.18
+// +build go1.18
+
+package birch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenericDecoding(t *testing.T) {
+	source := testRegistryStruct{
+		testRegistryEmbedded: testRegistryEmbedded{Host: "localhost"},
+		Name:                 "test",
+		Ratio:                0.5,
+		Tags:                 []string{"a", "b"},
+		Inner:                testRegistryInner{Count: 42},
+		Labels:               map[string]int32{"a": 1},
+		Created:              time.Now().Round(time.Millisecond),
+		ID:                   testUUID{1, 2, 3},
+	}
+
+	t.Run("Struct", func(t *testing.T) {
+		doc, err := MarshalFrom(source)
+		require.NoError(t, err)
+
+		expected, err := Marshal(source)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Len(), doc.Len())
+		assert.Equal(t, "localhost", doc.Lookup("host").StringValue())
+
+		out, err := DecodeInto[testRegistryStruct](doc)
+		require.NoError(t, err)
+		assert.Equal(t, source, out)
+	})
+	t.Run("Pointer", func(t *testing.T) {
+		doc, err := MarshalFrom(&source)
+		require.NoError(t, err)
+
+		out, err := DecodeInto[*testRegistryStruct](doc)
+		require.NoError(t, err)
+		require.NotNil(t, out)
+		assert.Equal(t, source, *out)
+	})
+	t.Run("Map", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.String("b", "two"))
+
+		out, err := DecodeInto[map[string]interface{}](doc)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"a": int32(1), "b": "two"}, out)
+	})
+	t.Run("Registry", func(t *testing.T) {
+		reg := NewRegistry().
+			RegisterEncoder(reflect.TypeOf(testUUID{}), func(rv reflect.Value) (*Value, error) {
+				id := rv.Interface().(testUUID)
+				return VC.String(string(id[:3])), nil
+			}).
+			RegisterDecoder(reflect.TypeOf(testUUID{}), func(v *Value, rv reflect.Value) error {
+				var id testUUID
+				copy(id[:], v.StringValue())
+				rv.Set(reflect.ValueOf(id))
+				return nil
+			})
+
+		doc, err := MarshalFromWithRegistry(reg, source)
+		require.NoError(t, err)
+		assert.Equal(t, "\x01\x02\x03", doc.Lookup("id").StringValue())
+
+		out, err := DecodeIntoWithRegistry[testRegistryStruct](reg, doc)
+		require.NoError(t, err)
+		assert.Equal(t, source.ID, out.ID)
+
+		_, err = DecodeInto[testRegistryStruct](doc)
+		assert.Error(t, err)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		out, err := DecodeInto[testRegistryStruct](nil)
+		assert.Error(t, err)
+		assert.Equal(t, testRegistryStruct{}, out)
+
+		partial, err := DecodeInto[testRegistryStruct](DC.Elements(EC.String("name", "set"), EC.String("ratio", "invalid")))
+		assert.Error(t, err)
+		assert.Equal(t, testRegistryStruct{}, partial)
+
+		_, err = MarshalFrom(42)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTypedArray(t *testing.T) {
+	now := time.Now().Round(time.Millisecond)
+
+	t.Run("FastPaths", func(t *testing.T) {
+		for name, test := range map[string]struct {
+			build    func() (*Array, error)
+			expected []interface{}
+		}{
+			"String":  {build: func() (*Array, error) { return NewTypedArray([]string{"a", "b"}) }, expected: []interface{}{"a", "b"}},
+			"Bool":    {build: func() (*Array, error) { return NewTypedArray([]bool{true, false}) }, expected: []interface{}{true, false}},
+			"Float64": {build: func() (*Array, error) { return NewTypedArray([]float64{1.5, 2}) }, expected: []interface{}{1.5, 2.0}},
+			"Int":     {build: func() (*Array, error) { return NewTypedArray([]int{1, 1 << 40}) }, expected: []interface{}{int32(1), int64(1 << 40)}},
+			"Int32":   {build: func() (*Array, error) { return NewTypedArray([]int32{1, 2}) }, expected: []interface{}{int32(1), int32(2)}},
+			"Int64":   {build: func() (*Array, error) { return NewTypedArray([]int64{1, 2}) }, expected: []interface{}{int64(1), int64(2)}},
+			"Time":    {build: func() (*Array, error) { return NewTypedArray([]time.Time{now}) }, expected: []interface{}{now}},
+			"Empty":   {build: func() (*Array, error) { return NewTypedArray([]int64{}) }, expected: []interface{}{}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				arr, err := test.build()
+				require.NoError(t, err)
+				require.Equal(t, len(test.expected), arr.Len())
+				for idx, expected := range test.expected {
+					val := arr.Lookup(uint(idx)).Interface()
+					if ts, ok := val.(time.Time); ok {
+						assert.True(t, now.Equal(ts))
+						continue
+					}
+					assert.Equal(t, expected, val)
+				}
+			})
+		}
+	})
+	t.Run("MatchesMarshalValue", func(t *testing.T) {
+		docs := []*Document{DC.Elements(EC.Int32("a", 1)), nil}
+		arr, err := NewTypedArray(docs)
+		require.NoError(t, err)
+
+		val, err := MarshalValue(docs)
+		require.NoError(t, err)
+		assert.True(t, VC.Array(arr).Equal(val))
+
+		ints := []int{1, 1 << 40}
+		arr, err = NewTypedArray(ints)
+		require.NoError(t, err)
+		val, err = MarshalValue(ints)
+		require.NoError(t, err)
+		assert.True(t, VC.Array(arr).Equal(val))
+	})
+	t.Run("Reflection", func(t *testing.T) {
+		arr, err := NewTypedArray([]uint16{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), arr.Lookup(1).Int32())
+
+		arr, err = NewTypedArray([]testRegistryInner{{Count: 3}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), arr.Lookup(0).MutableDocument().Lookup("count").Int64())
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := NewTypedArray([]chan int{make(chan int)})
+		assert.Error(t, err)
+
+		_, err = NewTypedArray([]map[int]string{{1: "a"}})
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkNewTypedArray(b *testing.B) {
+	vals := make([]int64, 1000)
+	for idx := range vals {
+		vals[idx] = int64(idx)
+	}
+
+	b.Run("Int64", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewTypedArray(vals); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Int64Reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := MarshalValue(vals); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
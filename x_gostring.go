@@ -0,0 +1,175 @@
+package birch
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
+)
+
+// GoString implements fmt.GoStringer, so that formatting a document
+// with the %#v verb produces the birch.DC.Elements call that builds
+// the document, for example to capture a document in a test:
+//
+//	birch.DC.Elements(
+//		birch.EC.String("name", "test"),
+//		birch.EC.SubDocumentFromElements("meta",
+//			birch.EC.Int64("count", 1),
+//		),
+//	)
+//
+// Every BSON type uses the constructor for that type, so the code
+// rebuilds a document with the same types and values. The code
+// refers to the birch and github.com/tychoish/birch/types packages,
+// and to the math package for infinite, NaN, and negative zero
+// doubles.
+func (d *Document) GoString() string {
+	if d == nil {
+		return "(*birch.Document)(nil)"
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString("birch.DC.Elements(")
+	writeGoElements(buf, d.elems, "", false)
+	buf.WriteString(")")
+
+	return buf.String()
+}
+
+// writeGoElements writes the constructor calls for the elements, one
+// per line, followed by the indentation of the enclosing call.
+func writeGoElements(buf *strings.Builder, elems []*Element, indent string, array bool) {
+	if len(elems) == 0 {
+		return
+	}
+
+	buf.WriteString("\n")
+	for _, elem := range elems {
+		buf.WriteString(indent + "\t")
+		if array {
+			writeGoValue(buf, "birch.VC.", "", elem.value, indent+"\t")
+		} else {
+			writeGoValue(buf, "birch.EC.", strconv.Quote(elem.Key()), elem.value, indent+"\t")
+		}
+		buf.WriteString(",\n")
+	}
+	buf.WriteString(indent)
+}
+
+// writeGoValue writes the constructor call for the value, where
+// prefix is the constructor namespace and key is the quoted key
+// argument of the call, which is empty for array values.
+func writeGoValue(buf *strings.Builder, prefix, key string, v *Value, indent string) {
+	if key != "" {
+		key += ", "
+	}
+	call := func(name string, args ...string) {
+		buf.WriteString(prefix + name + "(" + strings.TrimSuffix(key+strings.Join(args, ", "), ", ") + ")")
+	}
+	container := func(name string, elems []*Element, array bool) {
+		if len(elems) == 0 {
+			call(name)
+			return
+		}
+		buf.WriteString(prefix + name + "(" + strings.TrimSuffix(key, " "))
+		writeGoElements(buf, elems, indent, array)
+		buf.WriteString(")")
+	}
+
+	switch v.Type() {
+	case bsontype.Double:
+		call("Double", goFloat(v.Double()))
+	case bsontype.String:
+		call("String", strconv.Quote(v.StringValue()))
+	case bsontype.EmbeddedDocument:
+		name := "SubDocumentFromElements"
+		if key == "" {
+			name = "DocumentFromElements"
+		}
+		container(name, v.MutableDocument().elems, false)
+	case bsontype.Array:
+		name := "ArrayFromElements"
+		if key == "" {
+			name = "ArrayFromValues"
+		}
+		container(name, v.MutableArray().doc.elems, true)
+	case bsontype.Binary:
+		subtype, data := v.Binary()
+		if subtype == 0 {
+			call("Binary", goBytes(data))
+		} else {
+			call("BinaryWithSubtype", goBytes(data), fmt.Sprintf("0x%02x", subtype))
+		}
+	case bsontype.Undefined:
+		call("Undefined")
+	case bsontype.ObjectID:
+		call("ObjectID", goObjectID(v.ObjectID()))
+	case bsontype.Boolean:
+		call("Boolean", strconv.FormatBool(v.Boolean()))
+	case bsontype.DateTime:
+		call("DateTime", strconv.FormatInt(v.DateTime(), 10))
+	case bsontype.Null:
+		call("Null")
+	case bsontype.Regex:
+		pattern, options := v.Regex()
+		call("Regex", strconv.Quote(pattern), strconv.Quote(options))
+	case bsontype.DBPointer:
+		ns, oid := v.DBPointer()
+		call("DBPointer", strconv.Quote(ns), goObjectID(oid))
+	case bsontype.JavaScript:
+		call("JavaScript", strconv.Quote(v.JavaScript()))
+	case bsontype.Symbol:
+		call("Symbol", strconv.Quote(v.Symbol()))
+	case bsontype.CodeWithScope:
+		code, scope := v.MutableJavaScriptWithScope()
+		buf.WriteString(prefix + "CodeWithScope(" + key + strconv.Quote(code) + ", birch.DC.Elements(")
+		writeGoElements(buf, scope.elems, indent, false)
+		buf.WriteString("))")
+	case bsontype.Int32:
+		call("Int32", strconv.FormatInt(int64(v.Int32()), 10))
+	case bsontype.Timestamp:
+		t, i := v.Timestamp()
+		call("Timestamp", strconv.FormatUint(uint64(t), 10), strconv.FormatUint(uint64(i), 10))
+	case bsontype.Int64:
+		call("Int64", strconv.FormatInt(v.Int64(), 10))
+	case bsontype.Decimal128:
+		h, l := v.Decimal128().GetBytes()
+		call("Decimal128", fmt.Sprintf("types.NewDecimal128(0x%x, 0x%x)", h, l))
+	case bsontype.MinKey:
+		call("MinKey")
+	case bsontype.MaxKey:
+		call("MaxKey")
+	default:
+		buf.WriteString(fmt.Sprintf("nil /* unknown type %s */", v.Type()))
+	}
+}
+
+func goFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)"
+	case f == 0 && math.Signbit(f):
+		return "math.Copysign(0, -1)"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+func goBytes(data []byte) string { return "[]byte{" + goByteList(data) + "}" }
+
+func goObjectID(oid types.ObjectID) string { return "types.ObjectID{" + goByteList(oid[:]) + "}" }
+
+func goByteList(data []byte) string {
+	parts := make([]string, len(data))
+	for idx, b := range data {
+		parts[idx] = fmt.Sprintf("0x%02x", b)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,107 @@
+package birch
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/types"
+)
+
+func TestDocumentGoString(t *testing.T) {
+	oid := types.ObjectID{0x5f, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+	doc := DC.Elements(
+		EC.Double("double", 1.5),
+		EC.Double("nan", math.NaN()),
+		EC.Double("inf", math.Inf(-1)),
+		EC.String("string", "a \"quoted\"\n"),
+		EC.SubDocumentFromElements("doc",
+			EC.Int32("int32", -1),
+			EC.SubDocumentFromElements("empty"),
+		),
+		EC.ArrayFromElements("array",
+			VC.Int64(2),
+			VC.DocumentFromElements(EC.Boolean("ok", true)),
+			VC.ArrayFromValues(),
+		),
+		EC.Binary("binary", []byte{0x01, 0xff}),
+		EC.BinaryWithSubtype("uuid", []byte{0x02}, 0x04),
+		EC.Undefined("undefined"),
+		EC.ObjectID("oid", oid),
+		EC.Boolean("bool", false),
+		EC.DateTime("datetime", 1600000000000),
+		EC.Null("null"),
+		EC.Regex("regex", "^a", "i"),
+		EC.DBPointer("dbpointer", "db.coll", oid),
+		EC.JavaScript("js", "f()"),
+		EC.Symbol("symbol", "sym"),
+		EC.CodeWithScope("scope", "g(x)", DC.Elements(EC.Int32("x", 1))),
+		EC.Timestamp("timestamp", 10, 2),
+		EC.Int64("int64", math.MaxInt64),
+		EC.Decimal128("decimal", types.NewDecimal128(1, 2)),
+		EC.MinKey("min"),
+		EC.MaxKey("max"),
+	)
+
+	expected := `birch.DC.Elements(
+	birch.EC.Double("double", 1.5),
+	birch.EC.Double("nan", math.NaN()),
+	birch.EC.Double("inf", math.Inf(-1)),
+	birch.EC.String("string", "a \"quoted\"\n"),
+	birch.EC.SubDocumentFromElements("doc",
+		birch.EC.Int32("int32", -1),
+		birch.EC.SubDocumentFromElements("empty"),
+	),
+	birch.EC.ArrayFromElements("array",
+		birch.VC.Int64(2),
+		birch.VC.DocumentFromElements(
+			birch.EC.Boolean("ok", true),
+		),
+		birch.VC.ArrayFromValues(),
+	),
+	birch.EC.Binary("binary", []byte{0x01, 0xff}),
+	birch.EC.BinaryWithSubtype("uuid", []byte{0x02}, 0x04),
+	birch.EC.Undefined("undefined"),
+	birch.EC.ObjectID("oid", types.ObjectID{0x5f, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}),
+	birch.EC.Boolean("bool", false),
+	birch.EC.DateTime("datetime", 1600000000000),
+	birch.EC.Null("null"),
+	birch.EC.Regex("regex", "^a", "i"),
+	birch.EC.DBPointer("dbpointer", "db.coll", types.ObjectID{0x5f, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}),
+	birch.EC.JavaScript("js", "f()"),
+	birch.EC.Symbol("symbol", "sym"),
+	birch.EC.CodeWithScope("scope", "g(x)", birch.DC.Elements(
+		birch.EC.Int32("x", 1),
+	)),
+	birch.EC.Timestamp("timestamp", 10, 2),
+	birch.EC.Int64("int64", 9223372036854775807),
+	birch.EC.Decimal128("decimal", types.NewDecimal128(0x1, 0x2)),
+	birch.EC.MinKey("min"),
+	birch.EC.MaxKey("max"),
+)`
+
+	out := fmt.Sprintf("%#v", doc)
+	assert.Equal(t, expected, out)
+
+	t.Run("Formatted", func(t *testing.T) {
+		// the output is already formatted as gofmt would format it.
+		src := "package p\n\nvar doc = " + out + "\n"
+		formatted, err := format.Source([]byte(src))
+		require.NoError(t, err)
+		assert.Equal(t, src, string(formatted))
+	})
+	t.Run("FromReader", func(t *testing.T) {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		read, err := ReadDocument(data)
+		require.NoError(t, err)
+		assert.Equal(t, expected, read.GoString())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, "birch.DC.Elements()", DC.New().GoString())
+		assert.Equal(t, "(*birch.Document)(nil)", fmt.Sprintf("%#v", (*Document)(nil)))
+	})
+}
@@ -0,0 +1,90 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// DocumentIndex maps the dot-separated path of every value in a
+// document to the value, so that looking up many paths in a large
+// document does not search the document for each of them. Build an
+// index with Document.BuildIndex.
+//
+// The index refers to the values of the document rather than copying
+// them, and does not observe changes to the document, so it is only
+// valid while the document is unchanged: after modifying the
+// document, call Invalidate and build a new index. Concurrent lookups
+// are safe, as long as nothing modifies the document or invalidates
+// the index at the same time.
+type DocumentIndex struct {
+	values map[string]*Value
+}
+
+// BuildIndex returns an index of every value in the document,
+// including sub-documents, arrays, and their contents, keyed by the
+// dot-separated path to the value, using the position for elements of
+// arrays (e.g. "metrics.samples.2"), as with Apply. When more than one
+// value has the same path, because of duplicate keys or keys that
+// contain dots, the index holds the first in document order.
+func (d *Document) BuildIndex() *DocumentIndex {
+	idx := &DocumentIndex{values: map[string]*Value{}}
+	if d != nil {
+		idx.add("", d, false)
+	}
+
+	return idx
+}
+
+func (idx *DocumentIndex) add(prefix string, d *Document, isArray bool) {
+	for pos, elem := range d.elems {
+		var path string
+		if isArray {
+			path = prefix + strconv.Itoa(pos)
+		} else {
+			path = prefix + elem.Key()
+		}
+
+		if _, ok := idx.values[path]; !ok {
+			idx.values[path] = elem.value
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			idx.add(path+".", elem.value.MutableDocument(), false)
+		case bsontype.Array:
+			idx.add(path+".", elem.value.MutableArray().doc, true)
+		}
+	}
+}
+
+// Lookup returns the value at the dot-separated path, or nil if the
+// document has no value at the path or the index is invalid.
+func (idx *DocumentIndex) Lookup(path string) *Value {
+	if idx == nil {
+		return nil
+	}
+
+	return idx.values[path]
+}
+
+// Len returns the number of paths in the index.
+func (idx *DocumentIndex) Len() int {
+	if idx == nil {
+		return 0
+	}
+
+	return len(idx.values)
+}
+
+// Invalidate releases the index, after which Lookup returns nil for
+// every path, so that code holding an index for a document that has
+// changed cannot return stale values.
+func (idx *DocumentIndex) Invalidate() {
+	if idx != nil {
+		idx.values = nil
+	}
+}
+
+// Valid reports whether the index has not been invalidated.
+func (idx *DocumentIndex) Valid() bool { return idx != nil && idx.values != nil }
@@ -0,0 +1,102 @@
+package birch
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentIndex(t *testing.T) {
+	doc := DC.Elements(
+		EC.Int64("ops", 10),
+		EC.SubDocumentFromElements("metrics",
+			EC.Double("latency", 1.5),
+			EC.ArrayFromElements("samples", VC.Int32(1), VC.DocumentFromElements(EC.String("name", "x"))),
+		),
+		EC.String("a.b", "dotted"),
+		EC.SubDocumentFromElements("a", EC.String("b", "nested")),
+	)
+
+	idx := doc.BuildIndex()
+	require.True(t, idx.Valid())
+
+	t.Run("Lookup", func(t *testing.T) {
+		assert.Equal(t, int64(10), idx.Lookup("ops").Int64())
+		assert.Equal(t, 1.5, idx.Lookup("metrics.latency").Double())
+		assert.Equal(t, int32(1), idx.Lookup("metrics.samples.0").Int32())
+		assert.Equal(t, "x", idx.Lookup("metrics.samples.1.name").StringValue())
+		assert.True(t, idx.Lookup("metrics") == doc.Lookup("metrics"), "values are shared with the document")
+		assert.Nil(t, idx.Lookup("missing"))
+		assert.Nil(t, idx.Lookup("metrics.samples.2"))
+	})
+	t.Run("FirstPathWins", func(t *testing.T) {
+		assert.Equal(t, "dotted", idx.Lookup("a.b").StringValue())
+	})
+	t.Run("Len", func(t *testing.T) {
+		// ops, metrics, metrics.latency, metrics.samples, its two
+		// elements, the name in the second, a.b, and a.
+		assert.Equal(t, 9, idx.Len())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, 0, DC.New().BuildIndex().Len())
+		assert.Equal(t, 0, (*Document)(nil).BuildIndex().Len())
+		assert.Nil(t, (*DocumentIndex)(nil).Lookup("ops"))
+	})
+	t.Run("Invalidate", func(t *testing.T) {
+		idx := doc.BuildIndex()
+		idx.Invalidate()
+		assert.False(t, idx.Valid())
+		assert.Nil(t, idx.Lookup("ops"))
+		assert.Equal(t, 0, idx.Len())
+	})
+}
+
+func BenchmarkDocumentIndex(b *testing.B) {
+	doc := DC.Make(100)
+	paths := make([][]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		key := "section" + strconv.Itoa(i)
+		sub := DC.Make(10)
+		for j := 0; j < 10; j++ {
+			sub.Append(EC.Int64("metric"+strconv.Itoa(j), int64(j)))
+		}
+		doc.Append(EC.SubDocument(key, sub))
+		paths = append(paths, []string{key, "metric" + strconv.Itoa(i%10)})
+	}
+
+	dotted := make([]string, len(paths))
+	for i, path := range paths {
+		dotted[i] = path[0] + "." + path[1]
+	}
+
+	b.Run("RecursiveLookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, path := range paths {
+				if doc.RecursiveLookup(path...) == nil {
+					b.Fatal("missing value")
+				}
+			}
+		}
+	})
+	b.Run("Index", func(b *testing.B) {
+		idx := doc.BuildIndex()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, path := range dotted {
+				if idx.Lookup(path) == nil {
+					b.Fatal("missing value")
+				}
+			}
+		}
+	})
+	b.Run("BuildIndex", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			doc.BuildIndex()
+		}
+	})
+}
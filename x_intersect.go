@@ -0,0 +1,43 @@
+package birch
+
+import "github.com/tychoish/birch/bsontype"
+
+// Intersect returns a document with the elements of the document
+// whose keys are also in other, in the order of the document, such as
+// to compare two samples of metrics on only the metrics that they
+// share. The values are deep copies of the document's values; the
+// values in other are not used.
+//
+// When the values for a key are sub-documents in both documents, the
+// result holds the intersection of the sub-documents, which may be
+// empty. Otherwise, including when the values are arrays, which are
+// not matched by position, or when one value is a sub-document and
+// the other is not, only the key matters, and the result holds a copy
+// of the document's value. Intersect returns nil if the document is
+// nil, and an empty document if other is nil.
+func (d *Document) Intersect(other *Document) *Document {
+	if d == nil {
+		return nil
+	}
+
+	if other == nil {
+		return DC.New()
+	}
+
+	out := DC.Make(len(d.elems))
+	for _, elem := range d.elems {
+		match := other.Lookup(elem.Key())
+		if match == nil {
+			continue
+		}
+
+		if elem.value.Type() == bsontype.EmbeddedDocument && match.Type() == bsontype.EmbeddedDocument {
+			out.Append(EC.SubDocument(elem.Key(), elem.value.MutableDocument().Intersect(match.MutableDocument())))
+			continue
+		}
+
+		out.Append(EC.Value(elem.Key(), elem.value.Clone()))
+	}
+
+	return out
+}
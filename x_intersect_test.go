@@ -0,0 +1,75 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestDocumentIntersect(t *testing.T) {
+	current := DC.Elements(
+		EC.String("host", "a"),
+		EC.Int64("ops", 150),
+		EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5), EC.Int32("mapped", 1)),
+		EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.Int32(9)),
+		EC.SubDocumentFromElements("locks", EC.Int32("global", 1)),
+		EC.Int32("conns", 10),
+		EC.SubDocumentFromElements("repl", EC.Int32("lag", 1)),
+		EC.Int32("onlyCurrent", 1),
+	)
+	previous := DC.Elements(
+		EC.Int32("conns", 12),
+		EC.String("host", "b"),
+		EC.Int32("ops", 100),
+		EC.SubDocumentFromElements("mem", EC.Int32("resident", 15), EC.Int64("virtual", 5)),
+		EC.ArrayFromElements("load", VC.Int32(1)),
+		EC.Int32("locks", 2),
+		EC.SubDocumentFromElements("repl", EC.Int32("state", 1)),
+		EC.Int32("onlyPrevious", 1),
+	)
+
+	out := current.Intersect(previous)
+
+	t.Run("Keys", func(t *testing.T) {
+		assert.Equal(t, []string{"host", "ops", "mem", "load", "locks", "conns", "repl"}, out.KeyNames())
+	})
+	t.Run("ValuesFromReceiver", func(t *testing.T) {
+		assert.Equal(t, "a", out.Lookup("host").StringValue())
+		assert.Equal(t, bsontype.Int64, out.Lookup("ops").Type())
+		assert.Equal(t, int64(150), out.Lookup("ops").Int64())
+		assert.Equal(t, int32(10), out.Lookup("conns").Int32())
+	})
+	t.Run("SubDocuments", func(t *testing.T) {
+		mem := out.Lookup("mem").MutableDocument()
+		assert.Equal(t, []string{"resident", "virtual"}, mem.KeyNames())
+		assert.Equal(t, int32(20), mem.Lookup("resident").Int32())
+
+		// sub-documents without common keys remain, empty.
+		assert.Equal(t, 0, out.Lookup("repl").MutableDocument().Len())
+	})
+	t.Run("Arrays", func(t *testing.T) {
+		assert.Equal(t, 3, out.Lookup("load").MutableArray().Len())
+	})
+	t.Run("MixedTypes", func(t *testing.T) {
+		locks := out.Lookup("locks")
+		require.Equal(t, bsontype.EmbeddedDocument, locks.Type())
+		assert.Equal(t, int32(1), locks.MutableDocument().Lookup("global").Int32())
+	})
+	t.Run("DeepCopy", func(t *testing.T) {
+		current.Lookup("mem").MutableDocument().Set(EC.Int32("resident", 100))
+		current.Lookup("load").MutableArray().Append(VC.Int32(4))
+		defer func() {
+			current.Lookup("mem").MutableDocument().Set(EC.Int32("resident", 20))
+		}()
+
+		assert.Equal(t, int32(20), out.RecursiveLookup("mem", "resident").Int32())
+		assert.Equal(t, 3, out.Lookup("load").MutableArray().Len())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		assert.Nil(t, (*Document)(nil).Intersect(previous))
+		assert.Equal(t, 0, current.Intersect(nil).Len())
+		assert.Equal(t, 0, current.Intersect(DC.New()).Len())
+	})
+}
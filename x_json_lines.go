@@ -0,0 +1,138 @@
+package birch
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/jsonx"
+)
+
+// JSONLinesReader reads a stream of newline-delimited extended JSON
+// documents, such as the output of Document.MarshalJSON written one
+// document per line, or the output of the FTDC JSON collectors.
+//
+// Use the reader as follows:
+//
+//    reader := NewJSONLinesReader(file, false)
+//
+//    for reader.Next() {
+//        doc := reader.Document()
+//
+//        // <manipulate document>
+//    }
+//
+//    if err := reader.Err(); err != nil {
+//        return err
+//    }
+//
+// The reader reads one line at a time and does not buffer the entire
+// stream. Blank lines are skipped.
+type JSONLinesReader struct {
+	buf       *bufio.Reader
+	canonical bool
+	line      int
+	doc       *Document
+	err       error
+}
+
+// NewJSONLinesReader constructs a reader for newline-delimited extended
+// JSON. When canonical is true, every number must use the canonical
+// extended JSON type wrappers (e.g. {"$numberLong": "42"}), so that
+// numeric types round trip exactly, and lines with plain JSON numbers
+// are rejected. Otherwise, the reader also accepts relaxed extended
+// JSON, and plain JSON numbers become int32, int64, or double values
+// depending on their value.
+func NewJSONLinesReader(r io.Reader, canonical bool) *JSONLinesReader {
+	return &JSONLinesReader{
+		buf:       bufio.NewReader(r),
+		canonical: canonical,
+	}
+}
+
+// Next advances the reader to the next document, and returns false
+// when there are no more documents or the reader encountered an
+// error.
+func (r *JSONLinesReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	for {
+		line, err := r.buf.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			r.err = errors.Wrapf(err, "problem reading line %d", r.line+1)
+			return false
+		}
+
+		if len(line) == 0 && err == io.EOF {
+			r.doc = nil
+			return false
+		}
+
+		r.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		doc, perr := r.parse(line)
+		if perr != nil {
+			r.err = errors.Wrapf(perr, "problem parsing line %d", r.line)
+			r.doc = nil
+			return false
+		}
+
+		r.doc = doc
+		return true
+	}
+}
+
+// Document returns the document read by the most recent call to Next.
+func (r *JSONLinesReader) Document() *Document { return r.doc }
+
+// Err returns an error if the reader could not read or parse a line.
+// Parse errors identify the line number, starting at one.
+func (r *JSONLinesReader) Err() error { return r.err }
+
+func (r *JSONLinesReader) parse(line []byte) (*Document, error) {
+	jdoc, err := jsonx.DC.BytesErr(line)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if r.canonical {
+		if err = checkCanonicalJSON(jsonx.VC.Object(jdoc)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return DC.JSONXErr(jdoc)
+}
+
+// checkCanonicalJSON returns an error if a plain JSON number, which
+// has no type in canonical extended JSON, appears in the value.
+func checkCanonicalJSON(v *jsonx.Value) error {
+	switch v.Type() {
+	case jsonx.Number, jsonx.NumberInteger, jsonx.NumberDouble:
+		return errors.Errorf("number %v is not in canonical extended json form", v.Interface())
+	case jsonx.ObjectValue:
+		iter := v.Document().Iterator()
+		for iter.Next() {
+			if err := checkCanonicalJSON(iter.Element().Value()); err != nil {
+				return errors.Wrapf(err, "at '%s'", iter.Element().Key())
+			}
+		}
+	case jsonx.ArrayValue:
+		iter := v.Array().Iterator()
+		for iter.Next() {
+			if err := checkCanonicalJSON(iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package birch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestJSONLinesReader(t *testing.T) {
+	readAll := func(t *testing.T, r *JSONLinesReader) []*Document {
+		var out []*Document
+		for r.Next() {
+			out = append(out, r.Document())
+		}
+		return out
+	}
+
+	t.Run("Relaxed", func(t *testing.T) {
+		input := "{\"a\":1,\"b\":\"one\"}\n\n   \n{\"a\":2,\"b\":{\"c\":1.5}}\r\n{\"a\":3,\"d\":[1,2]}"
+
+		r := NewJSONLinesReader(strings.NewReader(input), false)
+		docs := readAll(t, r)
+		require.NoError(t, r.Err())
+		require.Len(t, docs, 3)
+
+		assert.Equal(t, 1, docs[0].Lookup("a").Int())
+		assert.Equal(t, "one", docs[0].Lookup("b").StringValue())
+		assert.Equal(t, 1.5, docs[1].RecursiveLookup("b", "c").Double())
+		assert.Equal(t, 2, docs[2].Lookup("d").MutableArray().Len())
+		assert.Nil(t, r.Document())
+		assert.False(t, r.Next())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		r := NewJSONLinesReader(strings.NewReader("\n\n"), false)
+		assert.Empty(t, readAll(t, r))
+		assert.NoError(t, r.Err())
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		docs := []*Document{
+			DC.Elements(EC.Int32("counter", 1), EC.Boolean("ok", true), EC.String("host", "localhost")),
+			DC.Elements(EC.Int64("counter", 1<<40), EC.SubDocumentFromElements("nested", EC.Double("ratio", 0.25))),
+			DC.Elements(EC.MinKey("min"), EC.Null("none")),
+		}
+
+		buf := &bytes.Buffer{}
+		for _, doc := range docs {
+			data, err := doc.MarshalJSON()
+			require.NoError(t, err)
+			buf.Write(data)
+			buf.WriteString("\n")
+		}
+
+		r := NewJSONLinesReader(buf, false)
+		out := readAll(t, r)
+		require.NoError(t, r.Err())
+		require.Len(t, out, len(docs))
+		for idx := range docs {
+			assert.True(t, docs[idx].EqualValues(out[idx]), "%d: %s", idx, out[idx])
+		}
+	})
+	t.Run("ParseErrorLine", func(t *testing.T) {
+		input := "{\"a\":1}\n\n{\"a\":\n{\"a\":3}\n"
+
+		r := NewJSONLinesReader(strings.NewReader(input), false)
+		docs := readAll(t, r)
+		assert.Len(t, docs, 1)
+		require.Error(t, r.Err())
+		assert.Contains(t, r.Err().Error(), "line 3")
+		assert.Nil(t, r.Document())
+		assert.False(t, r.Next())
+	})
+	t.Run("Canonical", func(t *testing.T) {
+		input := `{"i":{"$numberInt":"1"},"l":{"$numberLong":"2"},"d":{"$numberDouble":"1.5"},"t":{"$date":{"$numberLong":"1500"}},"a":[{"$numberInt":"3"}]}` + "\n"
+
+		for _, canonical := range []bool{true, false} {
+			r := NewJSONLinesReader(strings.NewReader(input), canonical)
+			docs := readAll(t, r)
+			require.NoError(t, r.Err())
+			require.Len(t, docs, 1)
+
+			doc := docs[0]
+			assert.Equal(t, int32(1), doc.Lookup("i").Int32())
+			assert.Equal(t, int64(2), doc.Lookup("l").Int64())
+			assert.Equal(t, 1.5, doc.Lookup("d").Double())
+			assert.Equal(t, int64(1500), doc.Lookup("t").DateTime())
+			assert.Equal(t, bsontype.Int32, doc.Lookup("a").MutableArray().doc.elems[0].value.Type())
+		}
+	})
+	t.Run("CanonicalRejectsPlainNumbers", func(t *testing.T) {
+		for name, line := range map[string]string{
+			"TopLevel": `{"a":1}`,
+			"Nested":   `{"a":{"b":1.5}}`,
+			"Array":    `{"a":[{"$numberInt":"1"},2]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				r := NewJSONLinesReader(strings.NewReader(`{"ok":true}`+"\n"+line+"\n"), true)
+				assert.Len(t, readAll(t, r), 1)
+				require.Error(t, r.Err())
+				assert.Contains(t, r.Err().Error(), "line 2")
+			})
+		}
+	})
+	t.Run("InvalidCanonicalValue", func(t *testing.T) {
+		r := NewJSONLinesReader(strings.NewReader(`{"a":{"$numberInt":"3000000000"}}`), true)
+		assert.False(t, r.Next())
+		assert.Error(t, r.Err())
+	})
+	t.Run("Streaming", func(t *testing.T) {
+		r := NewJSONLinesReader(io.MultiReader(strings.NewReader("{\"a\":1}\n"), failingReader{}), false)
+		require.True(t, r.Next())
+		assert.Equal(t, 1, r.Document().Lookup("a").Int())
+		assert.False(t, r.Next())
+		require.Error(t, r.Err())
+		assert.Contains(t, r.Err().Error(), "read failed")
+	})
+	t.Run("LongLine", func(t *testing.T) {
+		value := strings.Repeat("x", 256*1024)
+		r := NewJSONLinesReader(strings.NewReader(`{"a":"`+value+`"}`), false)
+		require.True(t, r.Next())
+		assert.Equal(t, value, r.Document().Lookup("a").StringValue())
+		assert.NoError(t, r.Err())
+	})
+}
@@ -1,6 +1,8 @@
 package birch
 
 import (
+	"encoding/base64"
+	"fmt"
 	"time"
 
 	"github.com/tychoish/birch/bsontype"
@@ -46,7 +48,19 @@ func (a *Array) toJSON() *jsonx.Array {
 	return out
 }
 
-func (v *Value) MarshalJSON() ([]byte, error) { return v.toJSON().MarshalJSON() }
+// MarshalJSON produces a JSON representation of the Value, in the
+// same form as the value of an element in Document.MarshalJSON, so
+// that values, and slices of values, can be written with
+// encoding/json. Embedded documents and arrays become JSON objects
+// and arrays, and types with no JSON equivalent use MongoDB's extended
+// JSON format. A nil Value produces null.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+
+	return v.toJSON().MarshalJSON()
+}
 
 func (v *Value) toJSON() *jsonx.Value {
 	switch v.Type() {
@@ -63,8 +77,8 @@ func (v *Value) toJSON() *jsonx.Value {
 
 		return jsonx.VC.ObjectFromElements(
 			jsonx.EC.ObjectFromElements("$binary",
-				jsonx.EC.String("base64", string(t)),
-				jsonx.EC.String("subType", string(d)),
+				jsonx.EC.String("base64", base64.StdEncoding.EncodeToString(d)),
+				jsonx.EC.String("subType", fmt.Sprintf("%02x", t)),
 			),
 		)
 	case bsontype.Undefined:
@@ -1,6 +1,7 @@
 package birch
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"testing"
@@ -273,6 +274,16 @@ func makeValueTestCases(depth int) []jsonValueTestCase {
 			Val:      VC.ObjectID(types.MustObjectIDFromHex("5df67fa01cbe64e51b598f18")),
 			Expected: `{"$oid":"5df67fa01cbe64e51b598f18"}`,
 		},
+		{
+			Name:     "Binary",
+			Val:      VC.Binary([]byte("hello")),
+			Expected: `{"$binary":{"base64":"aGVsbG8=","subType":"00"}}`,
+		},
+		{
+			Name:     "BinarySubtype",
+			Val:      VC.BinaryWithSubtype([]byte{0xff, 0x00}, 0x80),
+			Expected: `{"$binary":{"base64":"/wA=","subType":"80"}}`,
+		},
 		{
 			Name:     "SingleKeyDoc",
 			Val:      VC.Document(DC.Elements(EC.Int("a", 1))),
@@ -415,3 +426,21 @@ func TestJSON(t *testing.T) {
 
 	})
 }
+
+func TestValueJSONMarshaler(t *testing.T) {
+	values := []*Value{
+		VC.Int32(1),
+		VC.String("two"),
+		VC.DocumentFromElements(EC.Int64("a", 3), EC.ArrayFromElements("b", VC.Boolean(true))),
+		VC.ArrayFromValues(VC.DocumentFromElements(EC.Null("c")), VC.ObjectID(types.MustObjectIDFromHex("5df67fa01cbe64e51b598f18"))),
+		nil,
+	}
+
+	out, err := json.Marshal(values)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,"two",{"a":3,"b":[true]},[{"c":null},{"$oid":"5df67fa01cbe64e51b598f18"}],null]`, string(out))
+
+	out, err = (*Value)(nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(out))
+}
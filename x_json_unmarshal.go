@@ -1,6 +1,8 @@
 package birch
 
 import (
+	"encoding/base64"
+	"strconv"
 	"time"
 
 	"github.com/tychoish/birch/jsonx"
@@ -213,6 +215,13 @@ func convertJSONElements(in *jsonx.Element) (*Element, error) {
 					}
 				case "$id":
 					oid, ok = elem.Value().StringValueOK()
+					if !ok {
+						// canonical extended json wraps the id
+						// as {"$oid": <hex>}
+						if iddoc, isDoc := elem.Value().DocumentOK(); isDoc && iddoc.Len() == 1 && iddoc.KeyAtIndex(0) == "$oid" {
+							oid, ok = iddoc.ElementAtIndex(0).Value().StringValueOK()
+						}
+					}
 					if !ok {
 						return nil, errors.Errorf("problem decoding ns for oid in %s", in.Key())
 					}
@@ -261,11 +270,48 @@ func convertJSONElements(in *jsonx.Element) (*Element, error) {
 
 			return EC.Regex(in.Key(), pattern, options), nil
 		case "$date":
+			// canonical extended json wraps the date as
+			// milliseconds since the epoch in {"$numberLong": <int>}
+			if ms, ok := indoc.ElementAtIndex(0).Value().DocumentOK(); ok && ms.Len() == 1 && ms.KeyAtIndex(0) == "$numberLong" {
+				val, err := parseExtendedInt(ms, 64)
+				if err != nil {
+					return nil, errors.Wrapf(err, "problem parsing date at %s", in.Key())
+				}
+
+				return EC.DateTime(in.Key(), val), nil
+			}
+
 			date, err := time.Parse(time.RFC3339, indoc.ElementAtIndex(0).Value().StringValue())
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
 			return EC.Time(in.Key(), date), nil
+		case "$numberInt":
+			val, err := parseExtendedInt(indoc, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing int32 at %s", in.Key())
+			}
+
+			return EC.Int32(in.Key(), int32(val)), nil
+		case "$numberLong":
+			val, err := parseExtendedInt(indoc, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing int64 at %s", in.Key())
+			}
+
+			return EC.Int64(in.Key(), val), nil
+		case "$numberDouble":
+			str, ok := indoc.ElementAtIndex(0).Value().StringValueOK()
+			if !ok {
+				return nil, errors.Errorf("invalid double at %s", in.Key())
+			}
+
+			val, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing double at %s", in.Key())
+			}
+
+			return EC.Double(in.Key(), val), nil
 		case "$oid":
 			oid, err := types.ObjectIDFromHex(indoc.ElementAtIndex(0).Value().StringValue())
 			if err != nil {
@@ -276,6 +322,12 @@ func convertJSONElements(in *jsonx.Element) (*Element, error) {
 		case "$undefined":
 			return EC.Undefined(in.Key()), nil
 		case "$binary":
+			// extended json wraps the data as
+			// {"base64": <string>, "subType": <hex string>}
+			if bin, ok := indoc.ElementAtIndex(0).Value().DocumentOK(); ok {
+				return parseExtendedBinary(in.Key(), bin)
+			}
+
 			return EC.Binary(in.Key(), []byte(indoc.ElementAtIndex(0).Value().StringValue())), nil
 		default:
 			iter := indoc.Iterator()
@@ -309,3 +361,48 @@ func convertJSONElements(in *jsonx.Element) (*Element, error) {
 		return nil, errors.Errorf("unknown value type '%s' [%v]", inv.Type(), inv.Interface())
 	}
 }
+
+// parseExtendedInt parses the string value of an extended JSON
+// integer wrapper, such as {"$numberLong": "42"}.
+func parseExtendedInt(doc *jsonx.Document, bitSize int) (int64, error) {
+	str, ok := doc.ElementAtIndex(0).Value().StringValueOK()
+	if !ok {
+		return 0, errors.Errorf("%s value is not a string", doc.KeyAtIndex(0))
+	}
+
+	return strconv.ParseInt(str, 10, bitSize)
+}
+
+func parseExtendedBinary(key string, bin *jsonx.Document) (*Element, error) {
+	var (
+		data    []byte
+		subtype uint64
+		err     error
+	)
+
+	iter := bin.Iterator()
+	for iter.Next() {
+		elem := iter.Element()
+		str, ok := elem.Value().StringValueOK()
+		if !ok {
+			return nil, errors.Errorf("invalid %s for binary at %s", elem.Key(), key)
+		}
+
+		switch elem.Key() {
+		case "base64":
+			data, err = base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem decoding binary at %s", key)
+			}
+		case "subType":
+			subtype, err = strconv.ParseUint(str, 16, 8)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing binary subtype at %s", key)
+			}
+		default:
+			return nil, errors.Errorf("invalid key %s for binary at %s", elem.Key(), key)
+		}
+	}
+
+	return EC.BinaryWithSubtype(key, data, byte(subtype)), nil
+}
@@ -0,0 +1,190 @@
+package birch
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/jsonx"
+)
+
+// WriteExtJSON writes the document to w as extended JSON, writing
+// each element as it is reached rather than building the whole output
+// in memory first, which allows large documents to be written
+// directly to files or network connections. Because WriteExtJSON
+// makes many small writes, callers may want to wrap unbuffered
+// writers in a bufio.Writer.
+//
+// When canonical is false, the output is the same as the output of
+// MarshalJSON, except that keys are escaped as JSON strings. When
+// canonical is true, numbers and dates use the canonical extended JSON
+// type wrappers, so that their types round trip exactly:
+// {"$numberInt": "1"}, {"$numberLong": "1"}, {"$numberDouble": "1.5"}
+// and {"$date": {"$numberLong": "<millis>"}}, and the ObjectIDs of
+// DBPointers are wrapped in {"$oid": <hex>}.
+//
+// WriteExtJSON stops and returns the error at the first write that
+// fails, in which case w holds a partial document.
+func (d *Document) WriteExtJSON(w io.Writer, canonical bool) error {
+	if d == nil {
+		return errors.New("cannot write nil document")
+	}
+
+	ew := &extJSONWriter{w: w, canonical: canonical}
+
+	return ew.document(d)
+}
+
+type extJSONWriter struct {
+	w         io.Writer
+	canonical bool
+}
+
+func (ew *extJSONWriter) write(s string) error {
+	_, err := io.WriteString(ew.w, s)
+	return errors.Wrap(err, "problem writing extended json")
+}
+
+func (ew *extJSONWriter) writeString(s string) error {
+	out, err := jsonx.VC.String(s).MarshalJSON()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ew.write(string(out))
+}
+
+func (ew *extJSONWriter) document(d *Document) error {
+	if err := ew.write("{"); err != nil {
+		return err
+	}
+
+	iter := d.Iterator()
+	for idx := 0; iter.Next(); idx++ {
+		elem := iter.Element()
+		if idx > 0 {
+			if err := ew.write(","); err != nil {
+				return err
+			}
+		}
+
+		if err := ew.writeString(elem.Key()); err != nil {
+			return err
+		}
+		if err := ew.write(":"); err != nil {
+			return err
+		}
+		if err := ew.value(elem.Value()); err != nil {
+			return errors.Wrapf(err, "at '%s'", elem.Key())
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ew.write("}")
+}
+
+func (ew *extJSONWriter) array(a *Array) error {
+	if err := ew.write("["); err != nil {
+		return err
+	}
+
+	iter := a.Iterator()
+	for idx := 0; iter.Next(); idx++ {
+		if idx > 0 {
+			if err := ew.write(","); err != nil {
+				return err
+			}
+		}
+
+		if err := ew.value(iter.Value()); err != nil {
+			return errors.Wrapf(err, "at index %d", idx)
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ew.write("]")
+}
+
+func (ew *extJSONWriter) value(v *Value) error {
+	switch v.Type() {
+	case bsontype.EmbeddedDocument:
+		return ew.document(v.MutableDocument())
+	case bsontype.Array:
+		return ew.array(v.MutableArray())
+	case bsontype.CodeWithScope:
+		code, scope := v.MutableJavaScriptWithScope()
+		if err := ew.write(`{"$code":`); err != nil {
+			return err
+		}
+		if err := ew.writeString(code); err != nil {
+			return err
+		}
+		if err := ew.write(`,"$scope":`); err != nil {
+			return err
+		}
+		if err := ew.document(scope); err != nil {
+			return err
+		}
+		return ew.write("}")
+	}
+
+	if ew.canonical {
+		switch v.Type() {
+		case bsontype.Double:
+			return ew.write(`{"$numberDouble":"` + canonicalDouble(v.Double()) + `"}`)
+		case bsontype.Int32:
+			return ew.write(`{"$numberInt":"` + strconv.FormatInt(int64(v.Int32()), 10) + `"}`)
+		case bsontype.Int64:
+			return ew.write(`{"$numberLong":"` + strconv.FormatInt(v.Int64(), 10) + `"}`)
+		case bsontype.DateTime:
+			return ew.write(`{"$date":{"$numberLong":"` + strconv.FormatInt(v.DateTime(), 10) + `"}}`)
+		case bsontype.DBPointer:
+			ns, oid := v.DBPointer()
+			if err := ew.write(`{"$dbPointer":{"$ref":`); err != nil {
+				return err
+			}
+			if err := ew.writeString(ns); err != nil {
+				return err
+			}
+			return ew.write(`,"$id":{"$oid":"` + oid.Hex() + `"}}}`)
+		}
+	}
+
+	out, err := v.toJSON().MarshalJSON()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ew.write(string(out))
+}
+
+// canonicalDouble formats a double as canonical extended JSON does:
+// the shortest representation that round trips, with a decimal point
+// for integral values, and Infinity, -Infinity and NaN for the
+// special values.
+func canonicalDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	out := strconv.FormatFloat(f, 'G', -1, 64)
+	if !strings.ContainsAny(out, ".E") {
+		out += ".0"
+	}
+
+	return out
+}
@@ -0,0 +1,145 @@
+package birch
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/jsonx"
+	"github.com/tychoish/birch/types"
+)
+
+// failureCountingWriter counts the writes that fail, so that tests
+// can check that writing stops at the first failure. The limitWriter
+// is not embedded so that its WriteString method, which bypasses the
+// limit, is not promoted.
+type failureCountingWriter struct {
+	out      limitWriter
+	failures int
+}
+
+func (w *failureCountingWriter) Write(b []byte) (int, error) {
+	n, err := w.out.Write(b)
+	if err != nil {
+		w.failures++
+	}
+	return n, err
+}
+
+func TestDocumentWriteExtJSON(t *testing.T) {
+	oid := types.NewObjectID()
+	now := time.Unix(1600000000, 0)
+	doc := DC.Elements(
+		EC.Double("double", 1.5),
+		EC.String("string", "a \"quoted\"\nline"),
+		EC.SubDocumentFromElements("document", EC.Int32("a", 1), EC.ArrayFromElements("b", VC.String("c"), VC.Int64(2))),
+		EC.ArrayFromElements("array", VC.DocumentFromElements(EC.Boolean("d", true)), VC.Null()),
+		EC.Binary("binary", []byte("data")),
+		EC.Undefined("undefined"),
+		EC.ObjectID("objectid", oid),
+		EC.Boolean("boolean", false),
+		EC.Time("datetime", now),
+		EC.Null("null"),
+		EC.Regex("regex", "^a", "i"),
+		EC.DBPointer("dbpointer", "db.coll", oid),
+		EC.JavaScript("javascript", "function() {}"),
+		EC.Symbol("symbol", "sym"),
+		EC.CodeWithScope("codewithscope", "function() {}", DC.Elements(EC.Int32("x", 1))),
+		EC.Int32("int32", -42),
+		EC.Timestamp("timestamp", 10, 20),
+		EC.Int64("int64", 1<<40),
+		EC.Decimal128("decimal", types.NewDecimal128(0, 15)),
+		EC.MinKey("minkey"),
+		EC.MaxKey("maxkey"),
+	)
+
+	t.Run("Relaxed", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, doc.WriteExtJSON(buf, false))
+
+		expected, err := doc.MarshalJSON()
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String())
+	})
+	t.Run("Canonical", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, doc.WriteExtJSON(buf, true))
+		out := buf.String()
+
+		for _, fragment := range []string{
+			`"double":{"$numberDouble":"1.5"}`,
+			`"document":{"a":{"$numberInt":"1"},"b":["c",{"$numberLong":"2"}]}`,
+			`"datetime":{"$date":{"$numberLong":"1600000000000"}}`,
+			`"dbpointer":{"$dbPointer":{"$ref":"db.coll","$id":{"$oid":"` + oid.Hex() + `"}}}`,
+			`"codewithscope":{"$code":"function() {}","$scope":{"x":{"$numberInt":"1"}}}`,
+			`"int32":{"$numberInt":"-42"}`,
+			`"int64":{"$numberLong":"1099511627776"}`,
+		} {
+			assert.Contains(t, out, fragment)
+		}
+
+		reader := NewJSONLinesReader(strings.NewReader(out), false)
+		require.True(t, reader.Next(), "%v", reader.Err())
+		assert.True(t, doc.EqualExcept(reader.Document()))
+	})
+	t.Run("CanonicalDoubles", func(t *testing.T) {
+		for _, test := range []struct {
+			value    float64
+			expected string
+		}{
+			{value: 1, expected: "1.0"},
+			{value: -0.25, expected: "-0.25"},
+			{value: 1e300, expected: "1E+300"},
+			{value: math.Inf(1), expected: "Infinity"},
+			{value: math.Inf(-1), expected: "-Infinity"},
+			{value: math.NaN(), expected: "NaN"},
+		} {
+			buf := &bytes.Buffer{}
+			require.NoError(t, DC.Elements(EC.Double("f", test.value)).WriteExtJSON(buf, true))
+			assert.Equal(t, `{"f":{"$numberDouble":"`+test.expected+`"}}`, buf.String())
+
+			jdoc, err := jsonx.DC.BytesErr(buf.Bytes())
+			require.NoError(t, err)
+			out, err := DC.JSONXErr(jdoc)
+			require.NoError(t, err)
+			if math.IsNaN(test.value) {
+				assert.True(t, math.IsNaN(out.Lookup("f").Double()))
+			} else {
+				assert.Equal(t, test.value, out.Lookup("f").Double())
+			}
+		}
+	})
+	t.Run("EscapedKeys", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, DC.Elements(EC.Int32("a\"b", 1)).WriteExtJSON(buf, false))
+		assert.Equal(t, `{"a\"b":1}`, buf.String())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, DC.New().WriteExtJSON(buf, true))
+		assert.Equal(t, "{}", buf.String())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var nilDoc *Document
+		assert.Error(t, nilDoc.WriteExtJSON(&bytes.Buffer{}, false))
+	})
+	t.Run("WriterError", func(t *testing.T) {
+		full := &bytes.Buffer{}
+		require.NoError(t, doc.WriteExtJSON(full, true))
+
+		for _, limit := range []int{0, 1, 40, full.Len() / 2, full.Len() - 1} {
+			w := &failureCountingWriter{out: limitWriter{limit: limit, err: errors.New("limit exceeded")}}
+			err := doc.WriteExtJSON(w, true)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "limit exceeded")
+			assert.Equal(t, limit, w.out.Len())
+			assert.True(t, strings.HasPrefix(full.String(), w.out.String()))
+			assert.Equal(t, 1, w.failures, "writing stops at the first error")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// KeyNames returns the keys of the top-level elements of the document,
+// in document order, including duplicate keys. Unlike Keys, it
+// returns plain strings and allocates a single slice.
+func (d *Document) KeyNames() []string {
+	if d == nil {
+		return nil
+	}
+
+	out := make([]string, len(d.elems))
+	for idx, elem := range d.elems {
+		out[idx] = elem.Key()
+	}
+
+	return out
+}
+
+// KeysRecursive returns the dot-separated path to every leaf value in
+// the document, in document order and including duplicates, using
+// the position for elements of arrays (e.g. "metrics.samples.2"), as
+// with Apply. Empty sub-documents and arrays are leaves, so that
+// comparing the paths of two documents detects them.
+func (d *Document) KeysRecursive() []string {
+	if d == nil {
+		return nil
+	}
+
+	return d.appendKeyPaths(make([]string, 0, len(d.elems)), "", false)
+}
+
+func (d *Document) appendKeyPaths(out []string, prefix string, isArray bool) []string {
+	for idx, elem := range d.elems {
+		var key string
+		if isArray {
+			key = prefix + strconv.Itoa(idx)
+		} else {
+			key = prefix + elem.Key()
+		}
+
+		var sub *Document
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			sub = elem.value.MutableDocument()
+		case bsontype.Array:
+			sub = elem.value.MutableArray().doc
+		}
+
+		if sub == nil || len(sub.elems) == 0 {
+			out = append(out, key)
+			continue
+		}
+
+		out = sub.appendKeyPaths(out, key+".", elem.value.Type() == bsontype.Array)
+	}
+
+	return out
+}
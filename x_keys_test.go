@@ -0,0 +1,43 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentKeyNames(t *testing.T) {
+	doc := DC.Elements(
+		EC.Int32("a", 1),
+		EC.SubDocumentFromElements("b",
+			EC.String("c", "d"),
+			EC.ArrayFromElements("e", VC.Int32(1), VC.DocumentFromElements(EC.Int32("f", 2))),
+		),
+		EC.SubDocument("empty", DC.New()),
+		EC.ArrayFromElements("none"),
+		EC.Int32("a", 2),
+	)
+	data, err := doc.MarshalBSON()
+	require.NoError(t, err)
+	parsed, err := ReadDocument(data)
+	require.NoError(t, err)
+
+	for name, d := range map[string]*Document{"Constructed": doc, "Parsed": parsed} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, []string{"a", "b", "empty", "none", "a"}, d.KeyNames())
+			assert.Equal(t, []string{"a", "b.c", "b.e.0", "b.e.1.f", "empty", "none", "a"}, d.KeysRecursive())
+		})
+	}
+	t.Run("Empty", func(t *testing.T) {
+		var nilDoc *Document
+		assert.Nil(t, nilDoc.KeyNames())
+		assert.Nil(t, nilDoc.KeysRecursive())
+		assert.Empty(t, DC.New().KeyNames())
+		assert.Empty(t, DC.New().KeysRecursive())
+	})
+	t.Run("Allocations", func(t *testing.T) {
+		flat := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("c", 3))
+		assert.Equal(t, float64(1), testing.AllocsPerRun(100, func() { flat.KeyNames() }))
+	})
+}
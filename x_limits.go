@@ -0,0 +1,90 @@
+package birch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// ReadLimits bounds the size of individual values when reading a
+// document from untrusted input. Zero values impose no limit.
+type ReadLimits struct {
+	// MaxStringLength is the maximum length, in bytes, of string,
+	// symbol, JavaScript code, and DBPointer namespace values.
+	MaxStringLength int
+	// MaxArrayLength is the maximum number of elements in an array.
+	MaxArrayLength int
+}
+
+// ReadWithLimits creates a Document from the provided slice of
+// bytes, like ReadDocument, but first verifies that no value in the
+// document, at any depth, exceeds the limits. Violations return an
+// error that wraps bsonerr.LimitExceeded and names the path of the
+// offending key.
+//
+// The limits are checked before any part of the document is
+// materialized, so oversized input does not cause large allocations.
+func ReadWithLimits(b []byte, limits ReadLimits) (*Document, error) {
+	if err := Reader(b).checkLimits(limits, ""); err != nil {
+		return nil, err
+	}
+
+	return ReadDocument(b)
+}
+
+func (r Reader) checkLimits(limits ReadLimits, prefix string) error {
+	_, err := r.readElements(func(elem *Element) error {
+		key := prefix + elem.Key()
+		v := elem.value
+
+		switch v.Type() {
+		case bsontype.String, bsontype.Symbol, bsontype.JavaScript, bsontype.DBPointer:
+			return limits.checkString(key, readi32(v.data[v.offset:])-1)
+		case bsontype.CodeWithScope:
+			// readElements only checks the outer length, so the code
+			// length and scope must be validated before slicing them.
+			if _, err := v.validate(false); err != nil {
+				return errors.Wrapf(err, "invalid code with scope '%s'", key)
+			}
+
+			if err := limits.checkString(key, readi32(v.data[v.offset+4:])-1); err != nil {
+				return err
+			}
+
+			_, scope := v.ReaderJavaScriptWithScope()
+
+			return scope.checkLimits(limits, key+".")
+		case bsontype.EmbeddedDocument:
+			return v.ReaderDocument().checkLimits(limits, key+".")
+		case bsontype.Array:
+			arr := v.ReaderArray()
+
+			if limits.MaxArrayLength > 0 {
+				count := 0
+				if _, err := arr.readElements(func(*Element) error { count++; return nil }); err != nil {
+					return err
+				}
+
+				if count > limits.MaxArrayLength {
+					return errors.Wrapf(bsonerr.LimitExceeded, "array '%s' has %d elements, exceeding the limit of %d",
+						key, count, limits.MaxArrayLength)
+				}
+			}
+
+			return arr.checkLimits(limits, key+".")
+		default:
+			return nil
+		}
+	})
+
+	return err
+}
+
+func (l ReadLimits) checkString(key string, size int32) error {
+	if l.MaxStringLength > 0 && int(size) > l.MaxStringLength {
+		return errors.Wrapf(bsonerr.LimitExceeded, "string '%s' has length %d, exceeding the limit of %d",
+			key, size, l.MaxStringLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package birch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsonerr"
+)
+
+func TestReadWithLimits(t *testing.T) {
+	doc := DC.Elements(
+		EC.String("name", "hello"),
+		EC.CodeWithScope("cws", "return x;", DC.Elements(EC.Symbol("x", "abcdefghij"))),
+		EC.SubDocumentFromElements("meta",
+			EC.String("long", strings.Repeat("a", 16)),
+			EC.ArrayFromElements("tags", VC.String("a"), VC.String("b"), VC.String("c")),
+		),
+	)
+	data, err := doc.MarshalBSON()
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		Name   string
+		Limits ReadLimits
+		Key    string
+	}{
+		{Name: "Unlimited"},
+		{Name: "WithinLimits", Limits: ReadLimits{MaxStringLength: 16, MaxArrayLength: 3}},
+		{Name: "String", Limits: ReadLimits{MaxStringLength: 15}, Key: "meta.long"},
+		{Name: "Array", Limits: ReadLimits{MaxArrayLength: 2}, Key: "meta.tags"},
+		{Name: "Code", Limits: ReadLimits{MaxStringLength: 8}, Key: "cws"},
+		{Name: "Scope", Limits: ReadLimits{MaxStringLength: 9}, Key: "cws.x"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			out, err := ReadWithLimits(data, test.Limits)
+			if test.Key == "" {
+				require.NoError(t, err)
+				assert.Equal(t, doc.Len(), out.Len())
+				return
+			}
+
+			require.Error(t, err)
+			assert.Nil(t, out)
+			assert.Equal(t, bsonerr.LimitExceeded, errors.Cause(err))
+			assert.Contains(t, err.Error(), "'"+test.Key+"'")
+		})
+	}
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ReadWithLimits(data[:len(data)-1], ReadLimits{})
+		assert.Error(t, err)
+	})
+	t.Run("ShortCodeWithScope", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			_, err := ReadWithLimits([]byte{
+				0x10, 0, 0, 0,
+				0x0f, 'a', 0,
+				0x08, 0, 0, 0,
+				0x00, 0, 0, 0,
+				0, 0,
+			}, ReadLimits{MaxStringLength: 8})
+			assert.Error(t, err)
+		})
+	})
+	t.Run("CorruptNestedCodeLength", func(t *testing.T) {
+		nested, err := DC.Elements(EC.SubDocumentFromElements("outer",
+			EC.CodeWithScope("cws", "return x;", DC.Elements(EC.Int32("x", 1))),
+		)).MarshalBSON()
+		require.NoError(t, err)
+
+		// outer document length, type, "outer\x00", nested document
+		// length, type, "cws\x00", then the code with scope length
+		// precedes the code length.
+		codeLength := 4 + 1 + 6 + 4 + 1 + 4 + 4
+		require.Equal(t, byte(len("return x;")+1), nested[codeLength])
+		nested[codeLength+3] = 0xff
+
+		assert.NotPanics(t, func() {
+			_, err := ReadWithLimits(nested, ReadLimits{MaxStringLength: 8})
+			assert.Error(t, err)
+		})
+	})
+}
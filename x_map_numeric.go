@@ -0,0 +1,112 @@
+package birch
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// MapNumeric replaces every double, int32, and int64 value in the
+// document, including those in sub-documents and arrays, with the
+// result of calling fn with the value as a float64, modifying the
+// document in place, such as to normalize a document of metrics. It
+// is the numeric case of Apply, but fn neither receives nor returns a
+// *Value, and elements whose value fn does not change are not
+// replaced. Paths are the same as for Apply, and other values,
+// including decimals, are left unchanged.
+//
+// Each value keeps its BSON type. The results for integers are
+// rounded to the nearest integer, with halves rounded away from zero,
+// and results outside the range of the type are clamped to its
+// minimum or maximum; NaN becomes 0. Since int64 values are passed as
+// float64 values, integers with more than 53 significant bits lose
+// precision even when fn returns its argument unchanged.
+func (d *Document) MapNumeric(fn func(path string, v float64) float64) {
+	if d == nil {
+		return
+	}
+
+	d.mapNumeric("", false, fn)
+}
+
+func (d *Document) mapNumeric(prefix string, isArray bool, fn func(string, float64) float64) {
+	for idx, elem := range d.elems {
+		var key string
+		if isArray {
+			key = strconv.Itoa(idx)
+		} else {
+			key = elem.Key()
+		}
+
+		var out *Value
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			elem.value.MutableDocument().mapNumeric(prefix+key+".", false, fn)
+			continue
+		case bsontype.Array:
+			elem.value.MutableArray().doc.mapNumeric(prefix+key+".", true, fn)
+			continue
+		case bsontype.Double:
+			in := elem.value.Double()
+			val := fn(prefix+key, in)
+			if math.Float64bits(val) == math.Float64bits(in) {
+				continue
+			}
+			out = VC.Double(val)
+		case bsontype.Int32:
+			in := elem.value.Int32()
+			val := int32(roundClamp(fn(prefix+key, float64(in)), math.MinInt32, math.MaxInt32))
+			if val == in {
+				continue
+			}
+			out = VC.Int32(val)
+		case bsontype.Int64:
+			in := elem.value.Int64()
+			val := roundClampInt64(fn(prefix+key, float64(in)))
+			if val == in {
+				continue
+			}
+			out = VC.Int64(val)
+		default:
+			continue
+		}
+
+		if isArray {
+			d.elems[idx] = &Element{out}
+		} else {
+			d.elems[idx] = EC.Value(key, out)
+			d.changed(OpSet, d.elems[idx])
+		}
+	}
+}
+
+// roundClamp rounds v to the nearest integer, clamped to [min, max],
+// with NaN rounding to 0.
+func roundClamp(v, min, max float64) float64 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v <= min:
+		return min
+	case v >= max:
+		return max
+	default:
+		return math.Round(v)
+	}
+}
+
+// roundClampInt64 is roundClamp for int64 values, where the maximum is
+// not exactly representable as a float64.
+func roundClampInt64(v float64) int64 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v >= math.MaxInt64:
+		return math.MaxInt64
+	case v <= math.MinInt64:
+		return math.MinInt64
+	default:
+		return int64(math.Round(v))
+	}
+}
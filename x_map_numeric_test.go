@@ -0,0 +1,140 @@
+package birch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
+)
+
+func TestMapNumeric(t *testing.T) {
+	makeDoc := func() *Document {
+		return DC.Elements(
+			EC.Int64("ops", 10),
+			EC.String("host", "localhost"),
+			EC.SubDocumentFromElements("metrics",
+				EC.Double("latency", 1.5),
+				EC.ArrayFromElements("samples", VC.Int32(1), VC.String("x"), VC.Int32(3)),
+				EC.Decimal128("total", types.NewDecimal128(0, 1)),
+			),
+			EC.Boolean("ok", true),
+		)
+	}
+
+	t.Run("Paths", func(t *testing.T) {
+		var paths []string
+		var values []float64
+		makeDoc().MapNumeric(func(path string, v float64) float64 {
+			paths = append(paths, path)
+			values = append(values, v)
+			return v
+		})
+		assert.Equal(t, []string{"ops", "metrics.latency", "metrics.samples.0", "metrics.samples.2"}, paths)
+		assert.Equal(t, []float64{10, 1.5, 1, 3}, values)
+	})
+	t.Run("PreservesTypes", func(t *testing.T) {
+		doc := makeDoc()
+		doc.MapNumeric(func(_ string, v float64) float64 { return v * 2.25 })
+
+		ops := doc.Lookup("ops")
+		assert.Equal(t, bsontype.Int64, ops.Type())
+		assert.Equal(t, int64(23), ops.Int64())
+
+		assert.Equal(t, 3.375, doc.RecursiveLookup("metrics", "latency").Double())
+
+		samples := doc.RecursiveLookup("metrics", "samples").MutableArray()
+		assert.Equal(t, int32(2), samples.Lookup(0).Int32())
+		assert.Equal(t, "x", samples.Lookup(1).StringValue())
+		assert.Equal(t, int32(7), samples.Lookup(2).Int32())
+
+		assert.Equal(t, "localhost", doc.Lookup("host").StringValue())
+		assert.Equal(t, bsontype.Decimal128, doc.RecursiveLookup("metrics", "total").Type())
+
+		// the document remains valid BSON with the same keys.
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		assert.Equal(t, int64(23), out.Lookup("ops").Int64())
+	})
+	t.Run("Rounding", func(t *testing.T) {
+		for _, test := range []struct {
+			name  string
+			in    float64
+			int32 int32
+			int64 int64
+		}{
+			{name: "HalfUp", in: 2.5, int32: 3, int64: 3},
+			{name: "HalfDown", in: -2.5, int32: -3, int64: -3},
+			{name: "Nearest", in: 2.4, int32: 2, int64: 2},
+			{name: "NaN", in: math.NaN(), int32: 0, int64: 0},
+			{name: "Overflow", in: math.Inf(1), int32: math.MaxInt32, int64: math.MaxInt64},
+			{name: "Underflow", in: -1e300, int32: math.MinInt32, int64: math.MinInt64},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				doc := DC.Elements(EC.Int32("a", 1), EC.Int64("b", 1))
+				doc.MapNumeric(func(string, float64) float64 { return test.in })
+				assert.Equal(t, test.int32, doc.Lookup("a").Int32())
+				assert.Equal(t, test.int64, doc.Lookup("b").Int64())
+			})
+		}
+	})
+	t.Run("UnchangedElementsKept", func(t *testing.T) {
+		doc := makeDoc()
+		before := doc.LookupElement("ops")
+		doc.MapNumeric(func(_ string, v float64) float64 { return v })
+		assert.True(t, before == doc.LookupElement("ops"))
+	})
+	t.Run("Nil", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			(*Document)(nil).MapNumeric(func(_ string, v float64) float64 { return v })
+		})
+	})
+}
+
+func BenchmarkMapNumeric(b *testing.B) {
+	makeDoc := func() *Document {
+		doc := DC.Make(100)
+		for i := 0; i < 25; i++ {
+			key := string(rune('a' + i))
+			doc.Append(
+				EC.Int64(key+"int64", int64(i)),
+				EC.Int32(key+"int32", int32(i)),
+				EC.Double(key+"double", float64(i)),
+				EC.String(key+"string", key),
+			)
+		}
+		return doc
+	}
+
+	b.Run("MapNumeric", func(b *testing.B) {
+		doc := makeDoc()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			doc.MapNumeric(func(_ string, v float64) float64 { return -v })
+		}
+	})
+	b.Run("Apply", func(b *testing.B) {
+		doc := makeDoc()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			doc.Apply(func(_ string, v *Value) *Value {
+				switch v.Type() {
+				case bsontype.Double:
+					return VC.Double(-v.Double())
+				case bsontype.Int32:
+					return VC.Int32(-v.Int32())
+				case bsontype.Int64:
+					return VC.Int64(-v.Int64())
+				default:
+					return v
+				}
+			})
+		}
+	})
+}
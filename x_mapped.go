@@ -0,0 +1,132 @@
+package birch
+
+import (
+	"bytes"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// MappedReader provides read-only access to the contents of a file
+// that holds one or more BSON documents, such as a dump or an FTDC
+// diagnostic file, without reading the file into the heap. Where the
+// platform supports it, the file is memory mapped, and the operating
+// system pages its contents in as they are accessed; elsewhere, the
+// file is read into memory when it is opened.
+//
+// The slices and readers that a MappedReader returns refer directly to
+// the mapped memory, and must not be used after Close: accessing them
+// afterwards faults and crashes the program. Documents that must
+// outlive the MappedReader should be copied, for example with
+// ReadDocument followed by Copy, or by copying the bytes. The mapping
+// is read-only, and the returned slices must not be modified.
+type MappedReader struct {
+	data   []byte
+	mapped bool
+	closed bool
+}
+
+// OpenMapped opens the file at path and maps its contents into
+// memory, falling back to reading the file on platforms without
+// memory mapping. Call Close to release the mapping.
+//
+// OpenMapped returns an error if the file cannot be opened, read, or
+// mapped, or if it is too large to address on this platform.
+func OpenMapped(path string) (*MappedReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening '%s'", path)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading size of '%s'", path)
+	}
+
+	size := info.Size()
+	if size > math.MaxInt32 && int64(int(size)) != size {
+		return nil, errors.Errorf("'%s' is too large to map (%d bytes)", path, size)
+	}
+
+	m := &MappedReader{}
+	if size == 0 {
+		return m, nil
+	}
+
+	m.data, m.mapped, err = mapFile(file, int(size))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem mapping '%s'", path)
+	}
+
+	return m, nil
+}
+
+// Close releases the mapping. Slices and readers returned by the
+// MappedReader must not be used afterwards. Close is safe to call
+// more than once.
+func (m *MappedReader) Close() error {
+	if m.closed {
+		return nil
+	}
+
+	m.closed = true
+	data := m.data
+	m.data = nil
+
+	if !m.mapped || data == nil {
+		return nil
+	}
+
+	return errors.Wrap(unmapFile(data), "problem unmapping file")
+}
+
+// Len returns the size of the file, in bytes, or 0 after Close.
+func (m *MappedReader) Len() int { return len(m.data) }
+
+// Mapped reports whether the file is memory mapped, rather than read
+// into memory because the platform does not support memory mapping.
+func (m *MappedReader) Mapped() bool { return m.mapped }
+
+// Bytes returns the contents of the file, which are only valid until
+// Close, and must not be modified.
+func (m *MappedReader) Bytes() []byte { return m.data }
+
+// NewReader returns a reader over the contents of the file, with its
+// own position, for use with readers of document streams. Because it
+// implements io.ReadSeeker, it can be passed to ftdc.ResumeChunks
+// with a ScanState from an index of chunk offsets to start reading at
+// a chunk without reading the chunks before it. The reader is only
+// valid until Close.
+func (m *MappedReader) NewReader() *bytes.Reader { return bytes.NewReader(m.data) }
+
+// DocumentAt returns the document that starts at offset bytes into
+// the file, for random access to documents at known offsets. The
+// returned Reader is only valid until Close, and is not validated
+// beyond its length; use Validate or ToDocumentStrict to check it.
+//
+// DocumentAt returns an error if the MappedReader is closed, if offset
+// is outside the file, or if the document's length prefix is invalid
+// or extends past the end of the file.
+func (m *MappedReader) DocumentAt(offset int64) (Reader, error) {
+	if m.closed {
+		return nil, errors.New("mapped file is closed")
+	}
+
+	if offset < 0 || offset > int64(len(m.data)) {
+		return nil, errors.Errorf("offset %d is outside the file of %d bytes", offset, len(m.data))
+	}
+
+	rest := m.data[offset:]
+	if len(rest) < 4 {
+		return nil, errors.Wrapf(newErrTooSmall(), "at offset %d", offset)
+	}
+
+	length := int64(readi32(rest[0:4]))
+	if length < 5 || length > int64(len(rest)) {
+		return nil, errors.Wrapf(ErrCorruptDocument, "invalid document length %d at offset %d", length, offset)
+	}
+
+	return Reader(rest[:length]), nil
+}
@@ -0,0 +1,128 @@
+package birch
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenMapped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "birch-mapped")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first, err := DC.Elements(EC.String("a", "one")).MarshalBSON()
+	require.NoError(t, err)
+	second, err := DC.Elements(EC.Int64("b", 2), EC.SubDocumentFromElements("c", EC.Boolean("d", true))).MarshalBSON()
+	require.NoError(t, err)
+	data := append(append([]byte{}, first...), second...)
+
+	path := filepath.Join(dir, "docs.bson")
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	t.Run("Contents", func(t *testing.T) {
+		m, err := OpenMapped(path)
+		require.NoError(t, err)
+		defer m.Close()
+
+		assert.Equal(t, len(data), m.Len())
+		assert.Equal(t, data, m.Bytes())
+	})
+	t.Run("DocumentAt", func(t *testing.T) {
+		m, err := OpenMapped(path)
+		require.NoError(t, err)
+		defer m.Close()
+
+		r, err := m.DocumentAt(0)
+		require.NoError(t, err)
+		assert.Equal(t, Reader(first), r)
+
+		r, err = m.DocumentAt(int64(len(first)))
+		require.NoError(t, err)
+		doc, err := r.ToDocumentStrict()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), doc.Lookup("b").Int64())
+
+		for _, offset := range []int64{-1, int64(len(data)), int64(len(data)) + 1, int64(len(data)) - 2} {
+			_, err = m.DocumentAt(offset)
+			assert.Error(t, err, "%d", offset)
+		}
+
+		// the middle of the first document reads as a document of
+		// an invalid length.
+		_, err = m.DocumentAt(4)
+		assert.True(t, errors.Is(err, ErrCorruptDocument))
+	})
+	t.Run("NewReader", func(t *testing.T) {
+		m, err := OpenMapped(path)
+		require.NoError(t, err)
+		defer m.Close()
+
+		var docs int
+		r := m.NewReader()
+		for {
+			doc := DC.New()
+			if _, err := doc.ReadFrom(r); err == io.EOF {
+				break
+			} else {
+				require.NoError(t, err)
+			}
+			docs++
+		}
+		assert.Equal(t, 2, docs)
+
+		// readers are independent and seekable
+		other := m.NewReader()
+		_, err = other.Seek(int64(len(first)), io.SeekStart)
+		require.NoError(t, err)
+		doc := DC.New()
+		_, err = doc.ReadFrom(other)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), doc.Lookup("b").Int64())
+	})
+	t.Run("Close", func(t *testing.T) {
+		m, err := OpenMapped(path)
+		require.NoError(t, err)
+
+		require.NoError(t, m.Close())
+		require.NoError(t, m.Close())
+		assert.Zero(t, m.Len())
+		_, err = m.DocumentAt(0)
+		assert.Error(t, err)
+	})
+	t.Run("EmptyFile", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.bson")
+		require.NoError(t, ioutil.WriteFile(empty, nil, 0600))
+
+		m, err := OpenMapped(empty)
+		require.NoError(t, err)
+		assert.Zero(t, m.Len())
+		assert.False(t, m.Mapped())
+		_, err = m.DocumentAt(0)
+		assert.Error(t, err)
+		assert.NoError(t, m.Close())
+	})
+	t.Run("MissingFile", func(t *testing.T) {
+		_, err := OpenMapped(filepath.Join(dir, "missing.bson"))
+		assert.Error(t, err)
+	})
+	t.Run("CopiedDocumentOutlivesMapping", func(t *testing.T) {
+		m, err := OpenMapped(path)
+		require.NoError(t, err)
+
+		r, err := m.DocumentAt(0)
+		require.NoError(t, err)
+		copied := Reader(append([]byte{}, r...))
+		require.NoError(t, m.Close())
+
+		doc, err := copied.ToDocumentStrict()
+		require.NoError(t, err)
+		assert.Equal(t, "one", doc.Lookup("a").StringValue())
+	})
+}
@@ -0,0 +1,141 @@
+package birch
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// marshalContextInterval is the number of elements that
+// MarshalBSONContext encodes between checks of its context.
+const marshalContextInterval = 1024
+
+// MarshalBSONContext is the same as MarshalBSON, but aborts and
+// returns ctx.Err() if the context is canceled while the document is
+// encoded.
+//
+// The context is checked before encoding begins and then once every
+// 1024 elements, counting the elements of embedded documents and
+// arrays along with the elements of the document itself. Individual
+// values, such as a large binary value or a read-only embedded
+// document that was never modified, are copied without checking the
+// context, so cancellation takes effect at the next element boundary.
+//
+// Unlike MarshalBSON, MarshalBSONContext does not validate the whole
+// document before encoding it, and grows its output as it goes.
+func (d *Document) MarshalBSONContext(ctx context.Context) ([]byte, error) {
+	if d == nil {
+		return nil, bsonerr.NilDocument
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m := &contextMarshaler{ctx: ctx}
+
+	return m.appendDocument(nil, d)
+}
+
+type contextMarshaler struct {
+	ctx   context.Context
+	count int
+}
+
+func (m *contextMarshaler) check() error {
+	m.count++
+	if m.count%marshalContextInterval != 0 {
+		return nil
+	}
+
+	return m.ctx.Err()
+}
+
+func (m *contextMarshaler) appendDocument(dst []byte, d *Document) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+
+	for _, elem := range d.elems {
+		if err := m.check(); err != nil {
+			return nil, err
+		}
+
+		if _, err := elem.validateKey(); err != nil {
+			return nil, err
+		}
+
+		var err error
+
+		dst = append(dst, elem.value.data[elem.value.start:elem.value.offset]...)
+		if dst, err = m.appendValue(dst, elem.value); err != nil {
+			return nil, err
+		}
+	}
+
+	dst = append(dst, 0)
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start))
+
+	return dst, nil
+}
+
+func (m *contextMarshaler) appendArray(dst []byte, d *Document) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+
+	for i, elem := range d.elems {
+		if err := m.check(); err != nil {
+			return nil, err
+		}
+
+		if elem.value.data == nil {
+			return nil, bsonerr.UninitializedElement
+		}
+
+		var err error
+
+		dst = append(dst, elem.value.data[elem.value.start])
+		dst = append(strconv.AppendInt(dst, int64(i), 10), 0)
+		if dst, err = m.appendValue(dst, elem.value); err != nil {
+			return nil, err
+		}
+	}
+
+	dst = append(dst, 0)
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start))
+
+	return dst, nil
+}
+
+// appendValue appends the encoded value, without its type or key, to
+// dst.
+func (m *contextMarshaler) appendValue(dst []byte, v *Value) ([]byte, error) {
+	if v.d != nil {
+		switch v.Type() {
+		case bsontype.EmbeddedDocument:
+			return m.appendDocument(dst, v.d)
+		case bsontype.Array:
+			return m.appendArray(dst, v.d)
+		}
+	}
+
+	size, err := v.validate(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.d == nil {
+		return append(dst, v.data[v.offset:v.offset+size]...), nil
+	}
+
+	// code with scope that was constructed or modified in memory
+	header := v.offset - v.start
+	buf := make([]byte, header+size)
+	if _, err = (&Element{v}).writeByteSlice(true, 0, header+size, buf); err != nil {
+		return nil, err
+	}
+
+	return append(dst, buf[header:]...), nil
+}
@@ -0,0 +1,104 @@
+package birch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalBSONContext(t *testing.T) {
+	t.Run("MatchesMarshalBSON", func(t *testing.T) {
+		data, err := DC.Elements(
+			EC.String("raw", "value"),
+			EC.SubDocumentFromElements("rawdoc", EC.Int32("a", 1)),
+			EC.ArrayFromElements("rawarray", VC.Int32(1), VC.String("two")),
+		).MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		for name, doc := range map[string]*Document{
+			"Empty":  DC.New(),
+			"Parsed": parsed,
+			"Constructed": DC.Elements(
+				EC.Int64("int", 42),
+				EC.Double("double", 4.2),
+				EC.Time("time", time.Now()),
+				EC.Binary("binary", []byte("data")),
+				EC.SubDocumentFromElements("doc",
+					EC.ArrayFromElements("array", VC.Int32(1), VC.DocumentFromElements(EC.Boolean("b", true))),
+				),
+				EC.CodeWithScope("code", "function() {}", DC.Elements(EC.Int32("x", 1))),
+				EC.SubDocument("parsed", parsed),
+				EC.Null("null"),
+			),
+		} {
+			t.Run(name, func(t *testing.T) {
+				expected, err := doc.MarshalBSON()
+				require.NoError(t, err)
+
+				out, err := doc.MarshalBSONContext(context.Background())
+				require.NoError(t, err)
+				assert.Equal(t, expected, out)
+			})
+		}
+	})
+	t.Run("Large", func(t *testing.T) {
+		doc := makeLargeContextDocument(10)
+		expected, err := doc.MarshalBSON()
+		require.NoError(t, err)
+
+		out, err := doc.MarshalBSONContext(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, out)
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		out, err := DC.Elements(EC.Int32("a", 1)).MarshalBSONContext(ctx)
+		assert.Nil(t, out)
+		assert.Equal(t, context.Canceled, err)
+	})
+	t.Run("CanceledWhileEncoding", func(t *testing.T) {
+		doc := makeLargeContextDocument(10)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		m := &contextMarshaler{ctx: ctx}
+		for i := 0; i < marshalContextInterval-1; i++ {
+			require.NoError(t, m.check())
+		}
+		cancel()
+		assert.Equal(t, context.Canceled, m.check())
+
+		out, err := doc.MarshalBSONContext(ctx)
+		assert.Nil(t, out)
+		assert.Equal(t, context.Canceled, err)
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var doc *Document
+		_, err := doc.MarshalBSONContext(context.Background())
+		assert.Error(t, err)
+	})
+	t.Run("InvalidElement", func(t *testing.T) {
+		doc := DC.Elements(&Element{&Value{}})
+		_, err := doc.MarshalBSONContext(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func makeLargeContextDocument(n int) *Document {
+	doc := DC.Make(n)
+	for i := 0; i < n; i++ {
+		arr := MakeArray(marshalContextInterval)
+		for j := 0; j < marshalContextInterval; j++ {
+			arr.Append(VC.Int64(int64(j)))
+		}
+		doc.Append(EC.Array("array", arr))
+	}
+
+	return doc
+}
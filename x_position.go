@@ -0,0 +1,122 @@
+package birch
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/types"
+)
+
+// Index returns the position of the first element in the document
+// with the given key, for use with ElementAtOK and InsertAt, or -1 if
+// the document has no such element. Like LookupElement, Index is not
+// recursive.
+func (d *Document) Index(key string) int {
+	if d == nil {
+		return -1
+	}
+
+	for idx, elem := range d.elems {
+		if elemKey, ok := elem.KeyOK(); ok && elemKey == key {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// InsertAt inserts the element into the document at position i,
+// moving the element at that position, and all following elements,
+// back by one. Inserting at the length of the document appends the
+// element.
+//
+// InsertAt returns false, without modifying the document, if i is
+// out of range (negative or greater than the length of the document)
+// or the element is nil.
+func (d *Document) InsertAt(i int, elem *Element) bool {
+	if d == nil || elem == nil || i < 0 || i > len(d.elems) {
+		return false
+	}
+
+	d.elems = append(d.elems, nil)
+	copy(d.elems[i+1:], d.elems[i:])
+	d.elems[i] = elem
+
+	position := uint32(i)
+	for idx := range d.index {
+		if d.index[idx] >= position {
+			d.index[idx]++
+		}
+	}
+
+	key := elem.value.data[elem.value.start+1 : elem.value.offset]
+	j := sort.Search(len(d.index), func(j int) bool { return bytes.Compare(d.keyFromIndex(j), key) >= 0 })
+
+	d.index = append(d.index, 0)
+	copy(d.index[j+1:], d.index[j:])
+	d.index[j] = position
+
+	d.changed(OpInsert, elem)
+
+	return true
+}
+
+// InsertBefore inserts the element into the document immediately
+// before the first element with the given key, and returns false,
+// without modifying the document, if there is no such element or the
+// element is nil.
+func (d *Document) InsertBefore(key string, elem *Element) bool {
+	idx := d.Index(key)
+	if idx < 0 {
+		return false
+	}
+
+	return d.InsertAt(idx, elem)
+}
+
+// InsertAfter inserts the element into the document immediately
+// after the first element with the given key, and returns false,
+// without modifying the document, if there is no such element or the
+// element is nil.
+func (d *Document) InsertAfter(key string, elem *Element) bool {
+	idx := d.Index(key)
+	if idx < 0 {
+		return false
+	}
+
+	return d.InsertAt(idx+1, elem)
+}
+
+// WithID makes the given value the document's _id and moves the _id
+// element to the front of the document, where MongoDB conventionally
+// places it, replacing any existing _id elements. If id is nil, WithID
+// moves the document's first existing _id element to the front, or,
+// if there is none, adds a new ObjectID. WithID returns the document
+// to allow chaining, and panics if the document is nil.
+func (d *Document) WithID(id *Value) *Document {
+	if d == nil {
+		panic(bsonerr.NilDocument)
+	}
+
+	var elem *Element
+	if idx := d.Index("_id"); idx >= 0 && id == nil {
+		elem = d.elems[idx]
+	}
+
+	for d.Index("_id") >= 0 {
+		d.Delete("_id")
+	}
+
+	if elem == nil {
+		if id == nil {
+			elem = EC.ObjectID("_id", types.NewObjectID())
+		} else {
+			elem = EC.Value("_id", id)
+		}
+	}
+
+	d.InsertAt(0, elem)
+
+	return d
+}
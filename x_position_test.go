@@ -0,0 +1,214 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/types"
+)
+
+func documentKeys(doc *Document) []string {
+	var out []string
+	iter := doc.Iterator()
+	for iter.Next() {
+		out = append(out, iter.Element().Key())
+	}
+	return out
+}
+
+func TestDocumentPosition(t *testing.T) {
+	build := func() *Document {
+		return DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("c", 3), EC.Int32("b", 4))
+	}
+
+	t.Run("Index", func(t *testing.T) {
+		doc := build()
+		assert.Equal(t, 0, doc.Index("a"))
+		assert.Equal(t, 1, doc.Index("b"))
+		assert.Equal(t, 2, doc.Index("c"))
+		assert.Equal(t, -1, doc.Index("d"))
+		assert.Equal(t, -1, DC.New().Index("a"))
+
+		var nilDoc *Document
+		assert.Equal(t, -1, nilDoc.Index("a"))
+
+		elem, ok := doc.ElementAtOK(uint(doc.Index("c")))
+		require.True(t, ok)
+		assert.Equal(t, int32(3), elem.Value().Int32())
+	})
+	t.Run("InsertAt", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			position int
+			keys     []string
+		}{
+			{name: "Head", position: 0, keys: []string{"x", "a", "b", "c", "b"}},
+			{name: "Middle", position: 2, keys: []string{"a", "b", "x", "c", "b"}},
+			{name: "Tail", position: 4, keys: []string{"a", "b", "c", "b", "x"}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				doc := build()
+				require.True(t, doc.InsertAt(test.position, EC.String("x", "new")))
+				assert.Equal(t, test.keys, documentKeys(doc))
+				assert.Equal(t, test.position, doc.Index("x"))
+
+				// the key index must follow the move, for
+				// the methods that use it.
+				assert.Equal(t, "new", doc.RecursiveLookup("x").StringValue())
+				assert.Equal(t, int32(3), doc.RecursiveLookup("c").Int32())
+				doc.Set(EC.Int32("c", 30))
+				assert.Equal(t, int32(30), doc.ElementAt(uint(doc.Index("c"))).Value().Int32())
+				require.NotNil(t, doc.Delete("x"))
+				assert.Equal(t, []string{"a", "b", "c", "b"}, documentKeys(doc))
+
+				data, err := doc.MarshalBSON()
+				require.NoError(t, err)
+				out, err := ReadDocument(data)
+				require.NoError(t, err)
+				assert.Equal(t, []string{"a", "b", "c", "b"}, documentKeys(out))
+			})
+		}
+	})
+	t.Run("InsertAtEmpty", func(t *testing.T) {
+		doc := DC.New()
+		require.True(t, doc.InsertAt(0, EC.Int32("a", 1)))
+		assert.Equal(t, []string{"a"}, documentKeys(doc))
+		assert.Equal(t, int32(1), doc.RecursiveLookup("a").Int32())
+	})
+	t.Run("OutOfRange", func(t *testing.T) {
+		doc := build()
+		assert.False(t, doc.InsertAt(-1, EC.Int32("x", 1)))
+		assert.False(t, doc.InsertAt(5, EC.Int32("x", 1)))
+		assert.False(t, doc.InsertAt(0, nil))
+		assert.Equal(t, []string{"a", "b", "c", "b"}, documentKeys(doc))
+
+		var nilDoc *Document
+		assert.False(t, nilDoc.InsertAt(0, EC.Int32("x", 1)))
+
+		_, ok := doc.ElementAtOK(4)
+		assert.False(t, ok)
+	})
+}
+
+func TestDocumentInsertRelative(t *testing.T) {
+	build := func() *Document {
+		return DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("c", 3), EC.Int32("b", 4))
+	}
+
+	for _, test := range []struct {
+		name   string
+		insert func(*Document, *Element) bool
+		keys   []string
+	}{
+		{
+			name:   "BeforeHead",
+			insert: func(d *Document, e *Element) bool { return d.InsertBefore("a", e) },
+			keys:   []string{"_id", "a", "b", "c", "b"},
+		},
+		{
+			name:   "AfterHead",
+			insert: func(d *Document, e *Element) bool { return d.InsertAfter("a", e) },
+			keys:   []string{"a", "_id", "b", "c", "b"},
+		},
+		{
+			name:   "BeforeFirstDuplicate",
+			insert: func(d *Document, e *Element) bool { return d.InsertBefore("b", e) },
+			keys:   []string{"a", "_id", "b", "c", "b"},
+		},
+		{
+			name:   "AfterFirstDuplicate",
+			insert: func(d *Document, e *Element) bool { return d.InsertAfter("b", e) },
+			keys:   []string{"a", "b", "_id", "c", "b"},
+		},
+		{
+			name:   "BeforeTail",
+			insert: func(d *Document, e *Element) bool { return d.InsertBefore("c", e) },
+			keys:   []string{"a", "b", "_id", "c", "b"},
+		},
+		{
+			name: "AfterTail",
+			insert: func(d *Document, e *Element) bool {
+				d.Delete("b")
+				d.Delete("b")
+				return d.InsertAfter("c", e)
+			},
+			keys: []string{"a", "c", "_id"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			doc := build()
+			require.True(t, test.insert(doc, EC.ObjectID("_id", types.NewObjectID())))
+			assert.Equal(t, test.keys, documentKeys(doc))
+			assert.NotNil(t, doc.RecursiveLookup("_id"))
+		})
+	}
+	t.Run("MissingAnchor", func(t *testing.T) {
+		doc := build()
+		assert.False(t, doc.InsertBefore("missing", EC.Int32("x", 1)))
+		assert.False(t, doc.InsertAfter("missing", EC.Int32("x", 1)))
+		assert.Equal(t, []string{"a", "b", "c", "b"}, documentKeys(doc))
+
+		assert.False(t, DC.New().InsertAfter("a", EC.Int32("x", 1)))
+
+		var nilDoc *Document
+		assert.False(t, nilDoc.InsertBefore("a", EC.Int32("x", 1)))
+	})
+	t.Run("NilElement", func(t *testing.T) {
+		doc := build()
+		assert.False(t, doc.InsertBefore("a", nil))
+		assert.False(t, doc.InsertAfter("a", nil))
+		assert.Equal(t, []string{"a", "b", "c", "b"}, documentKeys(doc))
+	})
+}
+
+func TestDocumentWithID(t *testing.T) {
+	t.Run("Generated", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2)).WithID(nil)
+		assert.Equal(t, []string{"_id", "a", "b"}, documentKeys(doc))
+		assert.Equal(t, 0, doc.Index("_id"))
+
+		oid, ok := doc.ElementAt(0).Value().ObjectIDOK()
+		require.True(t, ok)
+		assert.False(t, oid.IsZero())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		doc := DC.New().WithID(nil)
+		assert.Equal(t, []string{"_id"}, documentKeys(doc))
+	})
+	t.Run("MovesExisting", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.String("_id", "existing"), EC.Int32("b", 2)).WithID(nil)
+		assert.Equal(t, []string{"_id", "a", "b"}, documentKeys(doc))
+		assert.Equal(t, "existing", doc.RecursiveLookup("_id").StringValue())
+	})
+	t.Run("Replaces", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.String("_id", "existing"), EC.Int32("b", 2)).WithID(VC.Int64(42))
+		assert.Equal(t, []string{"_id", "a", "b"}, documentKeys(doc))
+		assert.Equal(t, int64(42), doc.RecursiveLookup("_id").Int64())
+	})
+	t.Run("Duplicates", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.String("_id", "first"), EC.String("_id", "second"))
+		doc.WithID(nil)
+		assert.Equal(t, []string{"_id", "a"}, documentKeys(doc))
+		assert.Equal(t, "first", doc.RecursiveLookup("_id").StringValue())
+
+		doc = DC.Elements(EC.String("_id", "first"), EC.Int32("a", 1), EC.String("_id", "second")).WithID(VC.Int32(7))
+		assert.Equal(t, []string{"_id", "a"}, documentKeys(doc))
+		assert.Equal(t, int32(7), doc.RecursiveLookup("_id").Int32())
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		oid := types.NewObjectID()
+		doc := DC.Elements(EC.Int32("a", 1)).WithID(VC.ObjectID(oid))
+
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"_id", "a"}, documentKeys(out))
+		assert.Equal(t, oid, out.ElementAt(0).Value().ObjectID())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var doc *Document
+		assert.Panics(t, func() { doc.WithID(nil) })
+	})
+}
@@ -0,0 +1,253 @@
+package birch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Project returns a new document with the fields of the document
+// selected by a MongoDB-style projection spec, which maps
+// dot-separated paths to 1 or true, to include the fields, or to 0 or
+// false, to exclude them. Numeric values other than 0 include fields.
+//
+// As in MongoDB, a spec either includes or excludes fields: an
+// inclusion spec returns only the listed fields, and an exclusion spec
+// returns all fields except the listed ones. Project returns an error
+// for specs that mix the two, except that the top-level _id field,
+// which inclusion specs return unless they exclude it with {_id: 0},
+// may appear in either. An empty spec returns the whole document.
+//
+// Paths that pass through an array apply to each document in the
+// array, so that {"items.name": 1} returns the name of every item. A
+// path component that is the position of an element in an array
+// (e.g. "items.0.name") applies only to that element. Inclusion specs
+// drop array elements that are not documents or selected by position,
+// while exclusion specs keep them. Fields appear in the result in the
+// same order as in the document.
+func (d *Document) Project(spec *Document) (*Document, error) {
+	if d == nil || spec == nil {
+		return nil, errors.New("cannot project nil documents")
+	}
+
+	root, include, err := parseProjection(spec)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if include {
+		return root.include(d), nil
+	}
+
+	return root.exclude(d), nil
+}
+
+// projectionNode is a component of the paths in a projection spec. A
+// leaf node selects the entire value at its path.
+type projectionNode struct {
+	leaf     bool
+	children map[string]*projectionNode
+}
+
+func parseProjection(spec *Document) (*projectionNode, bool, error) {
+	root := &projectionNode{children: map[string]*projectionNode{}}
+
+	var (
+		hasInclude bool
+		hasExclude bool
+		idExcluded bool
+		idSeen     bool
+	)
+
+	for _, elem := range spec.elems {
+		path := elem.Key()
+
+		include, err := projectionValue(elem.value)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "invalid projection for '%s'", path)
+		}
+
+		if path == "_id" {
+			idSeen = true
+			idExcluded = !include
+		} else if include {
+			hasInclude = true
+		} else {
+			hasExclude = true
+		}
+
+		if hasInclude && hasExclude {
+			return nil, false, errors.Errorf("cannot mix inclusion and exclusion in a projection, at '%s'", path)
+		}
+
+		if err = root.add(path); err != nil {
+			return nil, false, err
+		}
+	}
+
+	include := hasInclude || (idSeen && !idExcluded && !hasExclude)
+
+	switch {
+	case include && !idSeen:
+		// inclusion specs return _id unless they exclude it.
+		root.children["_id"] = &projectionNode{leaf: true}
+	case include && idExcluded, !include && idSeen && !idExcluded:
+		// {_id: 1} does not exclude _id from an exclusion spec.
+		delete(root.children, "_id")
+	}
+
+	return root, include, nil
+}
+
+func projectionValue(v *Value) (bool, error) {
+	switch v.Type() {
+	case bsontype.Boolean:
+		return v.Boolean(), nil
+	case bsontype.Int32:
+		return v.Int32() != 0, nil
+	case bsontype.Int64:
+		return v.Int64() != 0, nil
+	case bsontype.Double:
+		return v.Double() != 0, nil
+	default:
+		return false, errors.Errorf("unsupported projection value of type %s", v.Type())
+	}
+}
+
+func (n *projectionNode) add(path string) error {
+	node := n
+	parts := strings.Split(path, ".")
+
+	for idx, part := range parts {
+		if part == "" {
+			return errors.Errorf("invalid projection path '%s'", path)
+		}
+
+		if node.leaf {
+			return errors.Errorf("path collision at '%s'", path)
+		}
+
+		child, ok := node.children[part]
+		if !ok {
+			child = &projectionNode{children: map[string]*projectionNode{}}
+			node.children[part] = child
+		} else if idx == len(parts)-1 {
+			return errors.Errorf("path collision at '%s'", path)
+		}
+
+		node = child
+	}
+
+	node.leaf = true
+
+	return nil
+}
+
+func (n *projectionNode) include(d *Document) *Document {
+	out := DC.Make(len(n.children))
+
+	for _, elem := range d.elems {
+		child, ok := n.children[elem.Key()]
+		if !ok {
+			continue
+		}
+
+		if child.leaf {
+			out.Append(elem.Copy())
+			continue
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			out.Append(EC.SubDocument(elem.Key(), child.include(elem.value.MutableDocument())))
+		case bsontype.Array:
+			out.Append(EC.Array(elem.Key(), child.includeArray(elem.value.MutableArray())))
+		}
+	}
+
+	return out
+}
+
+func (n *projectionNode) includeArray(a *Array) *Array {
+	out := MakeArray(a.Len())
+
+	// elements that are not selected by position are only
+	// included when the spec has paths that apply to every element.
+	fields := false
+	for key := range n.children {
+		if _, err := strconv.Atoi(key); err != nil {
+			fields = true
+			break
+		}
+	}
+
+	for idx, elem := range a.doc.elems {
+		node, positional := n.children[strconv.Itoa(idx)]
+		if positional && node.leaf {
+			out.Append(elem.value.Copy())
+			continue
+		} else if !positional {
+			if !fields {
+				continue
+			}
+			node = n
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			out.Append(VC.Document(node.include(elem.value.MutableDocument())))
+		case bsontype.Array:
+			out.Append(VC.Array(node.includeArray(elem.value.MutableArray())))
+		}
+	}
+
+	return out
+}
+
+func (n *projectionNode) exclude(d *Document) *Document {
+	out := DC.Make(len(d.elems))
+
+	for _, elem := range d.elems {
+		child, ok := n.children[elem.Key()]
+		switch {
+		case !ok:
+			out.Append(elem.Copy())
+		case child.leaf:
+			continue
+		case elem.value.Type() == bsontype.EmbeddedDocument:
+			out.Append(EC.SubDocument(elem.Key(), child.exclude(elem.value.MutableDocument())))
+		case elem.value.Type() == bsontype.Array:
+			out.Append(EC.Array(elem.Key(), child.excludeArray(elem.value.MutableArray())))
+		default:
+			out.Append(elem.Copy())
+		}
+	}
+
+	return out
+}
+
+func (n *projectionNode) excludeArray(a *Array) *Array {
+	out := MakeArray(a.Len())
+
+	for idx, elem := range a.doc.elems {
+		node, positional := n.children[strconv.Itoa(idx)]
+		if positional && node.leaf {
+			continue
+		} else if !positional {
+			node = n
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			out.Append(VC.Document(node.exclude(elem.value.MutableDocument())))
+		case bsontype.Array:
+			out.Append(VC.Array(node.excludeArray(elem.value.MutableArray())))
+		default:
+			out.Append(elem.value.Copy())
+		}
+	}
+
+	return out
+}
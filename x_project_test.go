@@ -0,0 +1,176 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentProject(t *testing.T) {
+	doc := DC.Elements(
+		EC.Int32("_id", 1),
+		EC.String("name", "widget"),
+		EC.SubDocumentFromElements("size",
+			EC.Double("h", 10),
+			EC.Double("w", 20),
+			EC.String("uom", "cm"),
+		),
+		EC.ArrayFromElements("items",
+			VC.DocumentFromElements(EC.String("sku", "a"), EC.Int32("qty", 1)),
+			VC.DocumentFromElements(EC.String("sku", "b"), EC.Int32("qty", 2)),
+			VC.String("loose"),
+		),
+		EC.String("status", "A"),
+	)
+
+	for _, test := range []struct {
+		name     string
+		spec     *Document
+		expected *Document
+	}{
+		{
+			name:     "Empty",
+			spec:     DC.New(),
+			expected: doc.Copy(),
+		},
+		{
+			name: "Include",
+			spec: DC.Elements(EC.Int32("status", 1), EC.Boolean("name", true)),
+			expected: DC.Elements(
+				EC.Int32("_id", 1),
+				EC.String("name", "widget"),
+				EC.String("status", "A"),
+			),
+		},
+		{
+			name:     "IncludeWithoutID",
+			spec:     DC.Elements(EC.Int32("name", 1), EC.Int32("_id", 0)),
+			expected: DC.Elements(EC.String("name", "widget")),
+		},
+		{
+			name:     "OnlyID",
+			spec:     DC.Elements(EC.Int64("_id", 1)),
+			expected: DC.Elements(EC.Int32("_id", 1)),
+		},
+		{
+			name: "IncludeNested",
+			spec: DC.Elements(EC.Int32("size.uom", 1), EC.Int32("_id", 0)),
+			expected: DC.Elements(
+				EC.SubDocumentFromElements("size", EC.String("uom", "cm")),
+			),
+		},
+		{
+			name: "IncludeThroughArray",
+			spec: DC.Elements(EC.Int32("items.sku", 1), EC.Int32("_id", 0)),
+			expected: DC.Elements(
+				EC.ArrayFromElements("items",
+					VC.DocumentFromElements(EC.String("sku", "a")),
+					VC.DocumentFromElements(EC.String("sku", "b")),
+				),
+			),
+		},
+		{
+			name: "IncludePositional",
+			spec: DC.Elements(EC.Int32("items.1.qty", 1), EC.Int32("items.2", 1), EC.Int32("_id", 0)),
+			expected: DC.Elements(
+				EC.ArrayFromElements("items",
+					VC.DocumentFromElements(EC.Int32("qty", 2)),
+					VC.String("loose"),
+				),
+			),
+		},
+		{
+			name: "Exclude",
+			spec: DC.Elements(EC.Int32("size", 0), EC.Boolean("items", false), EC.Double("status", 0)),
+			expected: DC.Elements(
+				EC.Int32("_id", 1),
+				EC.String("name", "widget"),
+			),
+		},
+		{
+			name: "ExcludeID",
+			spec: DC.Elements(EC.Int32("_id", 0)),
+			expected: DC.Elements(
+				EC.String("name", "widget"),
+				doc.LookupElement("size"),
+				doc.LookupElement("items"),
+				EC.String("status", "A"),
+			),
+		},
+		{
+			name: "ExcludeKeepingID",
+			spec: DC.Elements(EC.Int32("_id", 1), EC.Int32("name", 0), EC.Int32("size", 0), EC.Int32("items", 0)),
+			expected: DC.Elements(
+				EC.Int32("_id", 1),
+				EC.String("status", "A"),
+			),
+		},
+		{
+			name: "ExcludeNestedAndPositional",
+			spec: DC.Elements(EC.Int32("size.w", 0), EC.Int32("items.qty", 0), EC.Int32("items.0", 0), EC.Int32("name", 0)),
+			expected: DC.Elements(
+				EC.Int32("_id", 1),
+				EC.SubDocumentFromElements("size", EC.Double("h", 10), EC.String("uom", "cm")),
+				EC.ArrayFromElements("items",
+					VC.DocumentFromElements(EC.String("sku", "b")),
+					VC.String("loose"),
+				),
+				EC.String("status", "A"),
+			),
+		},
+		{
+			name:     "MissingFields",
+			spec:     DC.Elements(EC.Int32("missing", 1), EC.Int32("name.first", 1), EC.Int32("_id", 0)),
+			expected: DC.New(),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := doc.Project(test.spec)
+			require.NoError(t, err)
+
+			expected, err := test.expected.MarshalBSON()
+			require.NoError(t, err)
+			actual, err := out.MarshalBSON()
+			require.NoError(t, err)
+			assert.Equal(t, Reader(expected).String(), Reader(actual).String())
+		})
+	}
+	t.Run("DoesNotModify", func(t *testing.T) {
+		before, err := doc.MarshalBSON()
+		require.NoError(t, err)
+
+		out, err := doc.Project(DC.Elements(EC.Int32("size.h", 0)))
+		require.NoError(t, err)
+		out.Lookup("size").MutableDocument().Append(EC.Int32("extra", 1))
+
+		after, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		for name, spec := range map[string]*Document{
+			"Mixed":         DC.Elements(EC.Int32("name", 1), EC.Int32("status", 0)),
+			"MixedNested":   DC.Elements(EC.Int32("size.h", 0), EC.Int32("name", 1)),
+			"StringValue":   DC.Elements(EC.String("name", "1")),
+			"DocumentValue": DC.Elements(EC.SubDocumentFromElements("name", EC.Int32("$slice", 1))),
+			"Collision":     DC.Elements(EC.Int32("size", 1), EC.Int32("size.h", 1)),
+			"CollisionLeaf": DC.Elements(EC.Int32("size.h", 1), EC.Int32("size", 1)),
+			"Duplicate":     DC.Elements(EC.Int32("name", 1), EC.Int32("name", 1)),
+			"EmptyPath":     DC.Elements(EC.Int32("size..h", 1)),
+		} {
+			t.Run(name, func(t *testing.T) {
+				out, err := doc.Project(spec)
+				assert.Error(t, err)
+				assert.Nil(t, out)
+			})
+		}
+
+		_, err := doc.Project(nil)
+		assert.Error(t, err)
+
+		var nilDoc *Document
+		_, err = nilDoc.Project(DC.New())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,48 @@
+package birch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// RawLookup returns the encoded bytes and the type of the value at
+// the path, which is a sequence of keys as for RecursiveLookupErr,
+// without decoding the value, so that single values can be forwarded
+// to other documents or written to the wire cheaply. The bytes are
+// the value as it appears in an encoded element, without the type
+// byte or the key; for example, 4 little-endian bytes for an int32,
+// or a complete encoded document for a sub-document.
+//
+// When the value was read from encoded bytes, or constructed directly
+// as bytes, the returned slice aliases the document's storage: it must
+// not be modified, and it does not reflect later changes to the
+// document. Sub-documents, arrays, and JavaScript scopes that have been
+// constructed from, or accessed as, mutable documents are encoded into
+// a new slice.
+//
+// RawLookup returns the same errors as RecursiveLookupErr when the
+// path does not lead to a value, and an error if the value is not
+// valid BSON.
+func (d *Document) RawLookup(path ...string) ([]byte, bsontype.Type, error) {
+	elem, err := d.RecursiveLookupElementErr(path...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := elem.Validate()
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	value := elem.value
+	if value.d == nil {
+		return value.data[value.offset : value.start+size], value.Type(), nil
+	}
+
+	out, err := elem.MarshalBSON()
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	return out[value.offset-value.start:], value.Type(), nil
+}
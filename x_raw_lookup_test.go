@@ -0,0 +1,101 @@
+package birch
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestDocumentRawLookup(t *testing.T) {
+	sub := DC.Elements(EC.String("name", "db0"), EC.Int64("size", 7))
+	doc := DC.Elements(
+		EC.Int32("port", 27017),
+		EC.String("host", "localhost"),
+		EC.SubDocument("db", sub),
+		EC.ArrayFromElements("tags", VC.String("a"), VC.Int32(2)),
+	)
+
+	data, err := doc.MarshalBSON()
+	require.NoError(t, err)
+	parsed, err := ReadDocument(data)
+	require.NoError(t, err)
+
+	subData, err := sub.MarshalBSON()
+	require.NoError(t, err)
+
+	for name, input := range map[string]*Document{"Constructed": doc, "Parsed": parsed} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Int32", func(t *testing.T) {
+				raw, bt, err := input.RawLookup("port")
+				require.NoError(t, err)
+				assert.Equal(t, bsontype.Int32, bt)
+				assert.Equal(t, uint32(27017), binary.LittleEndian.Uint32(raw))
+				assert.Len(t, raw, 4)
+			})
+			t.Run("String", func(t *testing.T) {
+				raw, bt, err := input.RawLookup("host")
+				require.NoError(t, err)
+				assert.Equal(t, bsontype.String, bt)
+				assert.Equal(t, append([]byte{10, 0, 0, 0}, "localhost\x00"...), raw)
+			})
+			t.Run("SubDocument", func(t *testing.T) {
+				raw, bt, err := input.RawLookup("db")
+				require.NoError(t, err)
+				assert.Equal(t, bsontype.EmbeddedDocument, bt)
+				assert.Equal(t, subData, raw)
+			})
+			t.Run("Nested", func(t *testing.T) {
+				raw, bt, err := input.RawLookup("db", "size")
+				require.NoError(t, err)
+				assert.Equal(t, bsontype.Int64, bt)
+				assert.Equal(t, uint64(7), binary.LittleEndian.Uint64(raw))
+			})
+			t.Run("ArrayElement", func(t *testing.T) {
+				raw, bt, err := input.RawLookup("tags", "1")
+				require.NoError(t, err)
+				assert.Equal(t, bsontype.Int32, bt)
+				assert.Equal(t, []byte{2, 0, 0, 0}, raw)
+			})
+			t.Run("Errors", func(t *testing.T) {
+				for _, path := range [][]string{{"missing"}, {"db", "missing"}, {"port", "x"}, {"tags", "x"}, {}} {
+					_, expected := input.RecursiveLookupErr(path...)
+					require.Error(t, expected)
+
+					raw, _, err := input.RawLookup(path...)
+					assert.Nil(t, raw)
+					assert.Equal(t, expected, err, "%v", path)
+				}
+			})
+		})
+	}
+	t.Run("Aliasing", func(t *testing.T) {
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		raw, _, err := parsed.RawLookup("port")
+		require.NoError(t, err)
+		raw[0]++
+		assert.Equal(t, int32(27018), parsed.Lookup("port").Int32())
+	})
+	t.Run("MutatedSubDocument", func(t *testing.T) {
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+		parsed.Lookup("db").MutableDocument().Set(EC.Int64("size", 8))
+
+		raw, _, err := parsed.RawLookup("db")
+		require.NoError(t, err)
+		out, err := ReadDocument(raw)
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), out.Lookup("size").Int64())
+	})
+	t.Run("NilDocument", func(t *testing.T) {
+		var nilDoc *Document
+		_, _, err := nilDoc.RawLookup("a")
+		assert.True(t, errors.Is(err, bsonerr.NilDocument))
+	})
+}
@@ -0,0 +1,112 @@
+package birch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// ReaderEqualOptions configures the comparison of Reader.EqualWithOptions.
+type ReaderEqualOptions struct {
+	// IgnoreKeyOrder compares the fields of documents, including
+	// embedded documents, regardless of their order. The elements
+	// of arrays are always compared in order.
+	IgnoreKeyOrder bool
+}
+
+// Equal compares two raw BSON documents without decoding them into
+// Documents, and returns true if they have the same fields, in the
+// same order, with equal values. Embedded documents and arrays are
+// compared element by element, and other values are equal when they
+// have the same type and encoding.
+//
+// Equal validates both documents first, and returns an error, rather
+// than false, if either is not valid BSON.
+func (r Reader) Equal(other Reader) (bool, error) {
+	return r.EqualWithOptions(other, ReaderEqualOptions{})
+}
+
+// EqualWithOptions is the same as Equal, but allows the comparison to
+// ignore the order of the fields in documents.
+func (r Reader) EqualWithOptions(other Reader, opts ReaderEqualOptions) (bool, error) {
+	if _, err := r.Validate(); err != nil {
+		return false, errors.Wrap(err, "problem reading first document")
+	}
+
+	if _, err := other.Validate(); err != nil {
+		return false, errors.Wrap(err, "problem reading second document")
+	}
+
+	return readerEqual(r, other, opts, opts.IgnoreKeyOrder), nil
+}
+
+// readerEqual compares two valid documents, or two valid arrays when
+// unordered is false.
+func readerEqual(r, other Reader, opts ReaderEqualOptions, unordered bool) bool {
+	elems := readerElements(r)
+	otherElems := readerElements(other)
+
+	if len(elems) != len(otherElems) {
+		return false
+	}
+
+	if !unordered {
+		for idx := range elems {
+			if elems[idx].Key() != otherElems[idx].Key() {
+				return false
+			}
+
+			if !readerValueEqual(elems[idx].value, otherElems[idx].value, opts) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// fields with duplicate keys are matched in order.
+	byKey := make(map[string][]*Element, len(otherElems))
+	for _, elem := range otherElems {
+		byKey[elem.Key()] = append(byKey[elem.Key()], elem)
+	}
+
+	for _, elem := range elems {
+		candidates := byKey[elem.Key()]
+		if len(candidates) == 0 {
+			return false
+		}
+
+		if !readerValueEqual(elem.value, candidates[0].value, opts) {
+			return false
+		}
+
+		byKey[elem.Key()] = candidates[1:]
+	}
+
+	return true
+}
+
+func readerElements(r Reader) []*Element {
+	var elems []*Element
+
+	_, _ = r.readElements(func(elem *Element) error {
+		elems = append(elems, elem)
+		return nil
+	})
+
+	return elems
+}
+
+func readerValueEqual(v, other *Value, opts ReaderEqualOptions) bool {
+	if v.Type() != other.Type() {
+		return false
+	}
+
+	switch v.Type() {
+	case bsontype.EmbeddedDocument:
+		return readerEqual(v.ReaderDocument(), other.ReaderDocument(), opts, opts.IgnoreKeyOrder)
+	case bsontype.Array:
+		return readerEqual(v.ReaderArray(), other.ReaderArray(), opts, false)
+	default:
+		return checkEqualVal(v.Type(), other.Type(), v.data[v.offset:], other.data[other.offset:])
+	}
+}
@@ -0,0 +1,116 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderEqual(t *testing.T) {
+	marshal := func(t *testing.T, doc *Document) Reader {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		return Reader(data)
+	}
+
+	base := DC.Elements(
+		EC.Int32("a", 1),
+		EC.String("b", "two"),
+		EC.SubDocumentFromElements("c", EC.Int64("x", 1), EC.Boolean("y", true)),
+		EC.ArrayFromElements("d", VC.Int32(1), VC.DocumentFromElements(EC.Int32("p", 1), EC.Int32("q", 2))),
+	)
+	reordered := DC.Elements(
+		EC.ArrayFromElements("d", VC.Int32(1), VC.DocumentFromElements(EC.Int32("q", 2), EC.Int32("p", 1))),
+		EC.SubDocumentFromElements("c", EC.Boolean("y", true), EC.Int64("x", 1)),
+		EC.String("b", "two"),
+		EC.Int32("a", 1),
+	)
+
+	for _, test := range []struct {
+		name      string
+		other     *Document
+		ordered   bool
+		unordered bool
+	}{
+		{name: "Same", other: base.Copy(), ordered: true, unordered: true},
+		{name: "Reordered", other: reordered, ordered: false, unordered: true},
+		{name: "DifferentValue", other: DC.Elements(
+			EC.Int32("a", 1), EC.String("b", "three"),
+			EC.SubDocumentFromElements("c", EC.Int64("x", 1), EC.Boolean("y", true)),
+			EC.ArrayFromElements("d", VC.Int32(1), VC.DocumentFromElements(EC.Int32("p", 1), EC.Int32("q", 2))),
+		)},
+		{name: "DifferentType", other: DC.Elements(
+			EC.Int64("a", 1), EC.String("b", "two"),
+			EC.SubDocumentFromElements("c", EC.Int64("x", 1), EC.Boolean("y", true)),
+			EC.ArrayFromElements("d", VC.Int32(1), VC.DocumentFromElements(EC.Int32("p", 1), EC.Int32("q", 2))),
+		)},
+		{name: "NestedDifference", other: DC.Elements(
+			EC.Int32("a", 1), EC.String("b", "two"),
+			EC.SubDocumentFromElements("c", EC.Int64("x", 2), EC.Boolean("y", true)),
+			EC.ArrayFromElements("d", VC.Int32(1), VC.DocumentFromElements(EC.Int32("p", 1), EC.Int32("q", 2))),
+		)},
+		{name: "ArrayOrder", other: DC.Elements(
+			EC.Int32("a", 1), EC.String("b", "two"),
+			EC.SubDocumentFromElements("c", EC.Int64("x", 1), EC.Boolean("y", true)),
+			EC.ArrayFromElements("d", VC.DocumentFromElements(EC.Int32("p", 1), EC.Int32("q", 2)), VC.Int32(1)),
+		)},
+		{name: "MissingField", other: DC.Elements(
+			EC.Int32("a", 1), EC.String("b", "two"),
+			EC.SubDocumentFromElements("c", EC.Int64("x", 1), EC.Boolean("y", true)),
+		)},
+		{name: "ExtraField", other: base.Copy().Append(EC.Null("e"))},
+		{name: "Empty", other: DC.New()},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, other := marshal(t, base), marshal(t, test.other)
+
+			equal, err := r.Equal(other)
+			require.NoError(t, err)
+			assert.Equal(t, test.ordered, equal)
+
+			equal, err = other.Equal(r)
+			require.NoError(t, err)
+			assert.Equal(t, test.ordered, equal)
+
+			equal, err = r.EqualWithOptions(other, ReaderEqualOptions{IgnoreKeyOrder: true})
+			require.NoError(t, err)
+			assert.Equal(t, test.unordered, equal)
+
+			equal, err = other.EqualWithOptions(r, ReaderEqualOptions{IgnoreKeyOrder: true})
+			require.NoError(t, err)
+			assert.Equal(t, test.unordered, equal)
+		})
+	}
+	t.Run("DuplicateKeys", func(t *testing.T) {
+		r := marshal(t, DC.Elements(EC.Int32("a", 1), EC.Int32("a", 2), EC.Int32("b", 3)))
+		other := marshal(t, DC.Elements(EC.Int32("b", 3), EC.Int32("a", 1), EC.Int32("a", 2)))
+		swapped := marshal(t, DC.Elements(EC.Int32("b", 3), EC.Int32("a", 2), EC.Int32("a", 1)))
+
+		equal, err := r.EqualWithOptions(other, ReaderEqualOptions{IgnoreKeyOrder: true})
+		require.NoError(t, err)
+		assert.True(t, equal)
+
+		equal, err = r.EqualWithOptions(swapped, ReaderEqualOptions{IgnoreKeyOrder: true})
+		require.NoError(t, err)
+		assert.False(t, equal)
+	})
+	t.Run("ParseErrors", func(t *testing.T) {
+		r := marshal(t, base)
+		truncated := r[:len(r)-3]
+		corrupt := append(Reader{}, r...)
+		corrupt[len(corrupt)-1] = 0x01
+
+		for _, invalid := range []Reader{nil, truncated, corrupt} {
+			equal, err := r.Equal(invalid)
+			assert.False(t, equal)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "second document")
+
+			equal, err = invalid.Equal(r)
+			assert.False(t, equal)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "first document")
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package birch
+
+import "github.com/pkg/errors"
+
+// ToDocumentStrict decodes the document in the slice, as ReadDocument
+// does, but returns an error if the slice holds bytes after the end of
+// the document, as given by its length prefix, for example to detect
+// framing errors when each slice should hold exactly one document.
+// ReadDocument, by contrast, ignores trailing bytes, so that it can
+// read the first of several documents in a stream. The document,
+// including its embedded documents and arrays, is validated before it
+// is decoded.
+//
+// The error for trailing bytes reports how many bytes follow the
+// document, and matches ErrCorruptDocument with errors.Is.
+func (r Reader) ToDocumentStrict() (*Document, error) {
+	if len(r) < 4 {
+		return nil, newErrTooSmall()
+	}
+
+	if length := readi32(r[0:4]); length >= 0 && int64(length) < int64(len(r)) {
+		return nil, errors.Wrapf(ErrCorruptDocument, "%d trailing bytes after document of %d bytes", len(r)-int(length), length)
+	}
+
+	if _, err := r.Validate(); err != nil {
+		return nil, errors.Wrap(err, "problem validating document")
+	}
+
+	return ReadDocument(r)
+}
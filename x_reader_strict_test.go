@@ -0,0 +1,62 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderToDocumentStrict(t *testing.T) {
+	doc := DC.Elements(
+		EC.String("a", "b"),
+		EC.SubDocumentFromElements("c", EC.Int32("d", 1)),
+		EC.ArrayFromElements("e", VC.Int64(2)),
+	)
+	data, err := doc.MarshalBSON()
+	require.NoError(t, err)
+
+	t.Run("Exact", func(t *testing.T) {
+		out, err := Reader(data).ToDocumentStrict()
+		require.NoError(t, err)
+		assert.True(t, doc.EqualExcept(out))
+	})
+	t.Run("TrailingBytes", func(t *testing.T) {
+		padded := append(append([]byte{}, data...), 0x00, 0x01, 0x02)
+
+		out, err := Reader(padded).ToDocumentStrict()
+		require.Error(t, err)
+		assert.Nil(t, out)
+		assert.True(t, errors.Is(err, ErrCorruptDocument))
+		assert.Contains(t, err.Error(), "3 trailing bytes")
+
+		// the lenient reader ignores the trailing bytes.
+		out, err = ReadDocument(padded)
+		require.NoError(t, err)
+		assert.True(t, doc.EqualExcept(out))
+	})
+	t.Run("TwoDocuments", func(t *testing.T) {
+		_, err := Reader(append(append([]byte{}, data...), data...)).ToDocumentStrict()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "trailing bytes")
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		for _, size := range []int{0, 3, 4, len(data) - 1} {
+			out, err := Reader(data[:size]).ToDocumentStrict()
+			assert.Error(t, err, "%d bytes", size)
+			assert.Nil(t, out)
+		}
+	})
+	t.Run("CorruptEmbeddedDocument", func(t *testing.T) {
+		corrupt := append([]byte{}, data...)
+		// the length of the embedded document "c" follows its
+		// type byte and key.
+		pos := 4 + 1 + 2 + 4 + 2 + 1 + 2
+		require.Equal(t, byte(0x03), corrupt[pos-3])
+		corrupt[pos]++
+
+		_, err := Reader(corrupt).ToDocumentStrict()
+		assert.Error(t, err)
+	})
+}
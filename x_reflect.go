@@ -0,0 +1,638 @@
+package birch
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
+)
+
+var (
+	tValue      = reflect.TypeOf((*Value)(nil))
+	tDocument   = reflect.TypeOf((*Document)(nil))
+	tArray      = reflect.TypeOf((*Array)(nil))
+	tTime       = reflect.TypeOf(time.Time{})
+	tObjectID   = reflect.TypeOf(types.ObjectID{})
+	tTimestamp  = reflect.TypeOf(types.Timestamp{})
+	tDecimal128 = reflect.TypeOf(types.Decimal128{})
+	tBinary     = reflect.TypeOf(types.Binary{})
+	tBytes      = reflect.TypeOf([]byte(nil))
+)
+
+// Marshal converts a Go struct, map, or pointer to either into a
+// document using reflection and only the built-in type handling. Use
+// a Registry to customize the encoding of specific types.
+//
+// Struct fields are named by the first component of their "bson"
+// tag, or the lowercased field name when no name is given. The
+// "omitempty" option skips zero values, the "inline" option (or an
+// untagged embedded struct) flattens the embedded struct's fields
+// into the parent, and a tag of "-" skips the field.
+func Marshal(in interface{}) (*Document, error) { return marshalReflect(nil, in) }
+
+// Unmarshal populates the value pointed to by out, which may be a
+// struct, a map with string keys, or an empty interface, from the
+// document using reflection and only the built-in type handling. Use
+// a Registry to customize the decoding of specific types.
+//
+// Elements without a corresponding struct field are ignored.
+func Unmarshal(doc *Document, out interface{}) error { return unmarshalReflect(nil, doc, out) }
+
+// MarshalValue converts any Go value that Marshal supports, including
+// scalars and slices, into a single BSON value using only the
+// built-in type handling. Nil values become null.
+func MarshalValue(in interface{}) (*Value, error) {
+	return (*Registry)(nil).encode(reflect.ValueOf(in))
+}
+
+func marshalReflect(r *Registry, in interface{}) (*Document, error) {
+	val, err := r.encode(reflect.ValueOf(in))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	doc, ok := val.MutableDocumentOK()
+	if !ok {
+		return nil, errors.Errorf("cannot marshal %T as a document", in)
+	}
+
+	return doc, nil
+}
+
+func unmarshalReflect(r *Registry, doc *Document, out interface{}) error {
+	if doc == nil {
+		return errors.New("cannot unmarshal nil document")
+	}
+
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.Errorf("cannot unmarshal into non-pointer or nil value %T", out)
+	}
+
+	return r.decode(VC.Document(doc), rv.Elem())
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// encoding
+
+func (r *Registry) encode(rv reflect.Value) (*Value, error) {
+	if !rv.IsValid() {
+		return VC.Null(), nil
+	}
+
+	if enc, ok := r.LookupEncoder(rv.Type()); ok {
+		return enc(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return VC.Null(), nil
+		}
+	}
+
+	if rv.CanInterface() {
+		switch val := rv.Interface().(type) {
+		case *Value:
+			return val, nil
+		case *Document:
+			return VC.Document(val), nil
+		case *Array:
+			return VC.Array(val), nil
+		case Reader:
+			return VC.DocumentFromReader(val), nil
+		case time.Time:
+			return VC.Time(val), nil
+		case types.ObjectID:
+			return VC.ObjectID(val), nil
+		case types.Timestamp:
+			return VC.Timestamp(val.T, val.I), nil
+		case types.Decimal128:
+			return VC.Decimal128(val), nil
+		case types.Regex:
+			return VC.Regex(val.Pattern, val.Options), nil
+		case types.Binary:
+			return VC.BinaryWithSubtype(val.Data, val.Subtype), nil
+		case []byte:
+			return VC.Binary(val), nil
+		case DocumentMarshaler:
+			return VC.DocumentMarshalerErr(val)
+		case Marshaler:
+			return VC.MarshalerErr(val)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return r.encode(rv.Elem())
+	case reflect.Bool:
+		return VC.Boolean(rv.Bool()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return VC.Int32(int32(rv.Int())), nil
+	case reflect.Int:
+		return VC.Int(int(rv.Int())), nil
+	case reflect.Int64:
+		return VC.Int64(rv.Int()), nil
+	case reflect.Uint8, reflect.Uint16:
+		return VC.Int32(int32(rv.Uint())), nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		num := rv.Uint()
+
+		switch {
+		case num < math.MaxInt32:
+			return VC.Int32(int32(num)), nil
+		case num > math.MaxInt64:
+			return nil, errors.Errorf("BSON only has signed integer types and %d overflows an int64", num)
+		default:
+			return VC.Int64(int64(num)), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return VC.Double(rv.Float()), nil
+	case reflect.String:
+		return VC.String(rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		array := MakeArray(rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			val, err := r.encode(rv.Index(i))
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding array index %d", i)
+			}
+
+			array.Append(val)
+		}
+
+		return VC.Array(array), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, errors.Errorf("cannot encode map with non-string keys of type %s", rv.Type())
+		}
+
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		doc := DC.Make(len(keys))
+
+		for _, key := range keys {
+			val, err := r.encode(rv.MapIndex(key))
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding key '%s'", key.String())
+			}
+
+			doc.Append(EC.Value(key.String(), val))
+		}
+
+		return VC.Document(doc), nil
+	case reflect.Struct:
+		doc, err := r.encodeStruct(rv)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return VC.Document(doc), nil
+	default:
+		return nil, errors.Errorf("cannot encode value of type %s", rv.Type())
+	}
+}
+
+func (r *Registry) encodeStruct(rv reflect.Value) (*Document, error) {
+	fields := getStructFields(rv.Type())
+	doc := DC.Make(len(fields))
+
+	for _, field := range fields {
+		fv, ok := fieldByIndex(rv, field.index)
+		if !ok || (field.omitEmpty && fv.IsZero()) {
+			continue
+		}
+
+		val, err := r.encode(fv)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encoding field '%s'", field.name)
+		}
+
+		doc.Append(EC.Value(field.name, val))
+	}
+
+	return doc, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// decoding
+
+func (r *Registry) decode(v *Value, rv reflect.Value) error {
+	if dec, ok := r.LookupDecoder(rv.Type()); ok {
+		return dec(v, rv)
+	}
+
+	if t := v.Type(); t == bsontype.Null || t == bsontype.Undefined {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	switch rv.Type() {
+	case tValue:
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	case tDocument:
+		doc, ok := v.MutableDocumentOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(doc))
+
+		return nil
+	case tArray:
+		array, ok := v.MutableArrayOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(array))
+
+		return nil
+	case tTime:
+		ts, ok := v.TimeOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(ts))
+
+		return nil
+	case tObjectID:
+		oid, ok := v.ObjectIDOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(oid))
+
+		return nil
+	case tTimestamp:
+		t, i, ok := v.TimestampOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(types.Timestamp{T: t, I: i}))
+
+		return nil
+	case tDecimal128:
+		dec, ok := v.Decimal128OK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(dec))
+
+		return nil
+	case tBinary:
+		subtype, data, ok := v.BinaryOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.Set(reflect.ValueOf(types.Binary{Subtype: subtype, Data: data}))
+
+		return nil
+	case tBytes:
+		_, data, ok := v.BinaryOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.SetBytes(data)
+
+		return nil
+	}
+
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		switch um := rv.Addr().Interface().(type) {
+		case DocumentUnmarshaler:
+			doc, ok := v.MutableDocumentOK()
+			if !ok {
+				return decodeTypeError(v, rv)
+			}
+
+			return errors.WithStack(um.UnmarshalDocument(doc))
+		case Unmarshaler:
+			doc, ok := v.MutableDocumentOK()
+			if !ok {
+				return decodeTypeError(v, rv)
+			}
+
+			data, err := doc.MarshalBSON()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			return errors.WithStack(um.UnmarshalBSON(data))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		return r.decode(v, rv.Elem())
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return decodeTypeError(v, rv)
+		}
+
+		if out := v.Interface(); out != nil {
+			rv.Set(reflect.ValueOf(out))
+		} else {
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+
+		return nil
+	case reflect.Bool:
+		b, ok := v.BooleanOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.SetBool(b)
+
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, ok := decodeInteger(v)
+		if !ok || rv.OverflowInt(num) {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.SetInt(num)
+
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, ok := decodeInteger(v)
+		if !ok || num < 0 || rv.OverflowUint(uint64(num)) {
+			return decodeTypeError(v, rv)
+		}
+
+		rv.SetUint(uint64(num))
+
+		return nil
+	case reflect.Float32, reflect.Float64:
+		var num float64
+
+		switch v.Type() {
+		case bsontype.Double:
+			num = v.Double()
+		case bsontype.Int32:
+			num = float64(v.Int32())
+		case bsontype.Int64:
+			num = float64(v.Int64())
+		default:
+			return decodeTypeError(v, rv)
+		}
+
+		rv.SetFloat(num)
+
+		return nil
+	case reflect.String:
+		switch v.Type() {
+		case bsontype.String:
+			rv.SetString(v.StringValue())
+		case bsontype.Symbol:
+			rv.SetString(v.Symbol())
+		default:
+			return decodeTypeError(v, rv)
+		}
+
+		return nil
+	case reflect.Slice:
+		array, ok := v.MutableArrayOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		out := reflect.MakeSlice(rv.Type(), array.Len(), array.Len())
+
+		iter := array.Iterator()
+		for idx := 0; iter.Next(); idx++ {
+			if err := r.decode(iter.Value(), out.Index(idx)); err != nil {
+				return errors.Wrapf(err, "decoding array index %d", idx)
+			}
+		}
+
+		if err := iter.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		rv.Set(out)
+
+		return nil
+	case reflect.Array:
+		array, ok := v.MutableArrayOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		if array.Len() > rv.Len() {
+			return errors.Errorf("cannot decode array of length %d into %s", array.Len(), rv.Type())
+		}
+
+		iter := array.Iterator()
+		for idx := 0; iter.Next(); idx++ {
+			if err := r.decode(iter.Value(), rv.Index(idx)); err != nil {
+				return errors.Wrapf(err, "decoding array index %d", idx)
+			}
+		}
+
+		return errors.WithStack(iter.Err())
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return errors.Errorf("cannot decode into map with non-string keys of type %s", rv.Type())
+		}
+
+		doc, ok := v.MutableDocumentOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), doc.Len()))
+		}
+
+		iter := doc.Iterator()
+		for iter.Next() {
+			elem := iter.Element()
+			item := reflect.New(rv.Type().Elem()).Elem()
+
+			if err := r.decode(elem.Value(), item); err != nil {
+				return errors.Wrapf(err, "decoding key '%s'", elem.Key())
+			}
+
+			rv.SetMapIndex(reflect.ValueOf(elem.Key()).Convert(rv.Type().Key()), item)
+		}
+
+		return errors.WithStack(iter.Err())
+	case reflect.Struct:
+		doc, ok := v.MutableDocumentOK()
+		if !ok {
+			return decodeTypeError(v, rv)
+		}
+
+		return r.decodeStruct(doc, rv)
+	default:
+		return decodeTypeError(v, rv)
+	}
+}
+
+func (r *Registry) decodeStruct(doc *Document, rv reflect.Value) error {
+	fields := getStructFields(rv.Type())
+	byName := make(map[string]structField, len(fields))
+
+	for _, field := range fields {
+		byName[field.name] = field
+	}
+
+	iter := doc.Iterator()
+	for iter.Next() {
+		elem := iter.Element()
+
+		field, ok := byName[elem.Key()]
+		if !ok {
+			continue
+		}
+
+		fv := allocFieldByIndex(rv, field.index)
+		if err := r.decode(elem.Value(), fv); err != nil {
+			return errors.Wrapf(err, "decoding field '%s'", field.name)
+		}
+	}
+
+	return errors.WithStack(iter.Err())
+}
+
+func decodeInteger(v *Value) (int64, bool) {
+	switch v.Type() {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	case bsontype.Double:
+		f := v.Double()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+
+		return int64(f), true
+	default:
+		return 0, false
+	}
+}
+
+func decodeTypeError(v *Value, rv reflect.Value) error {
+	return errors.Errorf("cannot decode BSON %s into %s", v.Type(), rv.Type())
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// struct field handling
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+func getStructFields(t reflect.Type) []structField {
+	out := make([]structField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("bson")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		sf := structField{index: []int{i}}
+		inline := false
+
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "omitempty":
+				sf.omitEmpty = true
+			case "inline":
+				inline = true
+			}
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && (inline || name == "") {
+			for _, sub := range getStructFields(field.Type) {
+				sub.index = append([]int{i}, sub.index...)
+				out = append(out, sub)
+			}
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		sf.name = name
+		out = append(out, sf)
+	}
+
+	return out
+}
+
+// fieldByIndex resolves a possibly nested field, reporting false if
+// the path passes through a nil pointer.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(idx)
+	}
+
+	return rv, true
+}
+
+// allocFieldByIndex resolves a possibly nested field, allocating any
+// nil pointers along the path.
+func allocFieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(idx)
+	}
+
+	return rv
+}
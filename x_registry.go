@@ -0,0 +1,97 @@
+package birch
+
+import (
+	"reflect"
+)
+
+// ValueEncoder converts a Go value into a BSON value. Encoders are
+// registered with a Registry for a specific type.
+type ValueEncoder func(reflect.Value) (*Value, error)
+
+// ValueDecoder populates a settable Go value from a BSON value.
+// Decoders are registered with a Registry for a specific type.
+type ValueDecoder func(*Value, reflect.Value) error
+
+// Registry holds user-defined encoders and decoders for Go types,
+// which the reflective Marshal and Unmarshal operations consult
+// before falling back to their built-in handling.
+//
+// There is no global registry: pass a Registry explicitly to the
+// functions that use it. A nil *Registry is valid and provides only
+// the built-in behavior. The Register methods are not safe to call
+// concurrently with encoding or decoding operations that use the
+// same registry.
+type Registry struct {
+	encoders map[reflect.Type]ValueEncoder
+	decoders map[reflect.Type]ValueDecoder
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		encoders: map[reflect.Type]ValueEncoder{},
+		decoders: map[reflect.Type]ValueDecoder{},
+	}
+}
+
+// RegisterEncoder adds an encoder for values of exactly the type t,
+// replacing any previously registered encoder for that type.
+func (r *Registry) RegisterEncoder(t reflect.Type, enc ValueEncoder) *Registry {
+	if r.encoders == nil {
+		r.encoders = map[reflect.Type]ValueEncoder{}
+	}
+
+	r.encoders[t] = enc
+	return r
+}
+
+// RegisterDecoder adds a decoder for values of exactly the type t,
+// replacing any previously registered decoder for that type.
+func (r *Registry) RegisterDecoder(t reflect.Type, dec ValueDecoder) *Registry {
+	if r.decoders == nil {
+		r.decoders = map[reflect.Type]ValueDecoder{}
+	}
+
+	r.decoders[t] = dec
+	return r
+}
+
+// LookupEncoder returns the encoder registered for the type, if any.
+func (r *Registry) LookupEncoder(t reflect.Type) (ValueEncoder, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	enc, ok := r.encoders[t]
+
+	return enc, ok
+}
+
+// LookupDecoder returns the decoder registered for the type, if any.
+func (r *Registry) LookupDecoder(t reflect.Type) (ValueDecoder, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	dec, ok := r.decoders[t]
+
+	return dec, ok
+}
+
+// Marshal converts a Go struct or map into a document, using the
+// registry's encoders where they apply.
+func (r *Registry) Marshal(in interface{}) (*Document, error) {
+	return marshalReflect(r, in)
+}
+
+// MarshalValue converts a Go value into a single BSON value, using the
+// registry's encoders where they apply.
+func (r *Registry) MarshalValue(in interface{}) (*Value, error) {
+	return r.encode(reflect.ValueOf(in))
+}
+
+// Unmarshal populates the value pointed to by out from the document,
+// using the registry's decoders where they apply.
+func (r *Registry) Unmarshal(doc *Document, out interface{}) error {
+	return unmarshalReflect(r, doc, out)
+}
@@ -0,0 +1,151 @@
+package birch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
+)
+
+type testUUID [16]byte
+
+type testRegistryInner struct {
+	Count int64 `bson:"count"`
+}
+
+type testRegistryEmbedded struct {
+	Host string `bson:"host"`
+}
+
+type testRegistryStruct struct {
+	testRegistryEmbedded
+	Name     string             `bson:"name"`
+	Skipped  string             `bson:"-"`
+	Empty    string             `bson:"empty,omitempty"`
+	Ratio    float64            `bson:"ratio"`
+	Tags     []string           `bson:"tags"`
+	Inner    testRegistryInner  `bson:"inner"`
+	Ptr      *testRegistryInner `bson:"ptr,omitempty"`
+	Labels   map[string]int32   `bson:"labels"`
+	Created  time.Time          `bson:"created"`
+	OID      types.ObjectID     `bson:"oid"`
+	ID       testUUID           `bson:"id"`
+	Untagged bool
+}
+
+func TestRegistry(t *testing.T) {
+	now := time.Now().Round(time.Millisecond)
+	oid := types.NewObjectID()
+	source := testRegistryStruct{
+		testRegistryEmbedded: testRegistryEmbedded{Host: "localhost"},
+		Name:                 "test",
+		Skipped:              "not-encoded",
+		Ratio:                0.5,
+		Tags:                 []string{"a", "b"},
+		Inner:                testRegistryInner{Count: 42},
+		Labels:               map[string]int32{"b": 2, "a": 1},
+		Created:              now,
+		OID:                  oid,
+		ID:                   testUUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Untagged:             true,
+	}
+
+	t.Run("BuiltIn", func(t *testing.T) {
+		doc, err := Marshal(source)
+		require.NoError(t, err)
+
+		assert.Equal(t, "localhost", doc.Lookup("host").StringValue())
+		assert.Equal(t, "test", doc.Lookup("name").StringValue())
+		assert.Nil(t, doc.Lookup("skipped"))
+		assert.Nil(t, doc.Lookup("empty"))
+		assert.Nil(t, doc.Lookup("ptr"))
+		assert.Equal(t, int64(42), doc.RecursiveLookup("inner", "count").Int64())
+		assert.Equal(t, "a", doc.Lookup("labels").MutableDocument().ElementAt(0).Key())
+		assert.True(t, doc.Lookup("untagged").Boolean())
+		assert.Equal(t, 16, doc.Lookup("id").MutableArray().Len())
+
+		var out testRegistryStruct
+		require.NoError(t, Unmarshal(doc, &out))
+
+		source.Skipped = ""
+		assert.Equal(t, source, out)
+	})
+	t.Run("CustomType", func(t *testing.T) {
+		reg := NewRegistry().
+			RegisterEncoder(reflect.TypeOf(testUUID{}), func(rv reflect.Value) (*Value, error) {
+				id := rv.Interface().(testUUID)
+				return VC.BinaryWithSubtype(id[:], 0x04), nil
+			}).
+			RegisterDecoder(reflect.TypeOf(testUUID{}), func(v *Value, rv reflect.Value) error {
+				subtype, data, ok := v.BinaryOK()
+				if !ok || subtype != 0x04 || len(data) != 16 {
+					return errors.New("invalid uuid")
+				}
+
+				var id testUUID
+				copy(id[:], data)
+				rv.Set(reflect.ValueOf(id))
+
+				return nil
+			})
+
+		doc, err := reg.Marshal(source)
+		require.NoError(t, err)
+
+		subtype, data, ok := doc.Lookup("id").BinaryOK()
+		require.True(t, ok)
+		assert.Equal(t, byte(0x04), subtype)
+		assert.Equal(t, source.ID[:], data)
+
+		var out testRegistryStruct
+		require.NoError(t, reg.Unmarshal(doc, &out))
+		assert.Equal(t, source.ID, out.ID)
+
+		t.Run("NotUsedWithoutRegistry", func(t *testing.T) {
+			var other testRegistryStruct
+			require.Error(t, Unmarshal(doc, &other))
+		})
+	})
+	t.Run("Map", func(t *testing.T) {
+		doc, err := Marshal(map[string]interface{}{"b": 2, "a": "one"})
+		require.NoError(t, err)
+		require.Equal(t, 2, doc.Len())
+		assert.Equal(t, "a", doc.ElementAt(0).Key())
+
+		out := map[string]interface{}{}
+		require.NoError(t, Unmarshal(doc, &out))
+		assert.Equal(t, "one", out["a"])
+		assert.EqualValues(t, 2, out["b"])
+	})
+	t.Run("Value", func(t *testing.T) {
+		val, err := MarshalValue(42)
+		require.NoError(t, err)
+		assert.Equal(t, 42, val.Int())
+
+		val, err = MarshalValue(nil)
+		require.NoError(t, err)
+		assert.Equal(t, bsontype.Null, val.Type())
+
+		val, err = MarshalValue(source)
+		require.NoError(t, err)
+		assert.Equal(t, "test", val.MutableDocument().Lookup("name").StringValue())
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Marshal(42)
+		assert.Error(t, err)
+
+		_, err = Marshal(map[int]string{1: "one"})
+		assert.Error(t, err)
+
+		var out testRegistryStruct
+		assert.Error(t, Unmarshal(DC.New(), out))
+		assert.Error(t, Unmarshal(nil, &out))
+		assert.Error(t, Unmarshal(DC.Elements(EC.String("ratio", "high")), &out))
+		assert.Error(t, Unmarshal(DC.Elements(EC.Double("inner", 1.5)), &out))
+	})
+}
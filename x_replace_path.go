@@ -0,0 +1,102 @@
+package birch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// PathError is the error that Document.ReplacePath returns when a path
+// does not lead to an existing value. Use errors.Is with
+// ErrKeyNotFound or ErrInvalidType to tell a missing key from a value
+// that cannot contain the rest of the path.
+type PathError struct {
+	// Path is the path that was looked up.
+	Path string
+	// At is the prefix of the path where the lookup failed: the
+	// first key that does not exist, or the value that is neither
+	// a document nor an array.
+	At string
+	// Err is ErrKeyNotFound or ErrInvalidType.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	return "path '" + e.Path + "': " + e.Err.Error() + " at '" + e.At + "'"
+}
+
+// Unwrap returns the kind of the error.
+func (e *PathError) Unwrap() error { return e.Err }
+
+// ReplacePath replaces the value at the dot-separated path, which
+// must already exist, so that edits to documents of a known structure
+// fail rather than add a misspelled key. Array elements are addressed
+// by their position (e.g. "hosts.0.name"). Where a document has
+// duplicate keys, the path follows, and replaces, the first of them.
+//
+// ReplacePath returns a *PathError if a key or array position in the
+// path does not exist, or if a value before the end of the path is
+// not a document or an array, and an error if the document or value
+// is nil or the path has an empty segment.
+func (d *Document) ReplacePath(path string, v *Value) error {
+	if d == nil {
+		return errors.WithStack(bsonerr.NilDocument)
+	}
+
+	parts := strings.Split(path, ".")
+	for _, part := range parts {
+		if part == "" {
+			return errors.Errorf("invalid path '%s'", path)
+		}
+	}
+
+	elem, err := EC.ValueErr(parts[len(parts)-1], v)
+	if err != nil {
+		return errors.Wrapf(err, "cannot replace '%s'", path)
+	}
+
+	doc := d
+	isArray := false
+	for idx, part := range parts {
+		at := strings.Join(parts[:idx+1], ".")
+
+		pos := -1
+		if isArray {
+			if n, err := strconv.Atoi(part); err == nil && n >= 0 && n < len(doc.elems) {
+				pos = n
+			}
+		} else {
+			pos = doc.Index(part)
+		}
+
+		if pos < 0 {
+			return &PathError{Path: path, At: at, Err: ErrKeyNotFound}
+		}
+
+		if idx == len(parts)-1 {
+			if isArray {
+				(&Array{doc: doc}).Set(uint(pos), v)
+			} else {
+				doc.elems[pos] = elem
+				doc.changed(OpSet, elem)
+			}
+			return nil
+		}
+
+		value := doc.elems[pos].value
+		switch value.Type() {
+		case bsontype.EmbeddedDocument:
+			doc, isArray = value.MutableDocument(), false
+		case bsontype.Array:
+			doc, isArray = value.MutableArray().doc, true
+		default:
+			return &PathError{Path: path, At: at, Err: ErrInvalidType}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentReplacePath(t *testing.T) {
+	build := func() *Document {
+		return DC.Elements(
+			EC.String("name", "config"),
+			EC.SubDocumentFromElements("net",
+				EC.Int32("port", 27017),
+				EC.SubDocumentFromElements("tls", EC.Boolean("enabled", false)),
+			),
+			EC.ArrayFromElements("hosts",
+				VC.DocumentFromElements(EC.String("host", "a"), EC.Int32("priority", 1)),
+				VC.DocumentFromElements(EC.String("host", "b"), EC.Int32("priority", 2)),
+			),
+			EC.ArrayFromElements("tags", VC.String("x"), VC.String("y")),
+		)
+	}
+
+	roundTrip := func(t *testing.T, doc *Document) *Document {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("TopLevel", func(t *testing.T) {
+		doc := build()
+		require.NoError(t, doc.ReplacePath("name", VC.String("renamed")))
+		assert.Equal(t, "renamed", doc.Lookup("name").StringValue())
+		assert.Equal(t, []string{"name", "net", "hosts", "tags"}, doc.KeyNames())
+	})
+	t.Run("Nested", func(t *testing.T) {
+		doc := build()
+		require.NoError(t, doc.ReplacePath("net.tls.enabled", VC.Boolean(true)))
+		require.NoError(t, doc.ReplacePath("net.port", VC.Int64(1)))
+
+		out := roundTrip(t, doc)
+		assert.True(t, out.RecursiveLookup("net", "tls", "enabled").Boolean())
+		assert.Equal(t, int64(1), out.RecursiveLookup("net", "port").Int64())
+		assert.Equal(t, []string{"port", "tls"}, out.Lookup("net").MutableDocument().KeyNames())
+	})
+	t.Run("ArrayPositions", func(t *testing.T) {
+		doc := build()
+		require.NoError(t, doc.ReplacePath("hosts.1.priority", VC.Int32(5)))
+		require.NoError(t, doc.ReplacePath("tags.0", VC.String("z")))
+
+		out := roundTrip(t, doc)
+		assert.Equal(t, int32(5), out.RecursiveLookup("hosts", "1", "priority").Int32())
+		assert.Equal(t, int32(1), out.RecursiveLookup("hosts", "0", "priority").Int32())
+		assert.Equal(t, "z", out.Lookup("tags").MutableArray().Lookup(0).StringValue())
+		assert.Equal(t, "y", out.Lookup("tags").MutableArray().Lookup(1).StringValue())
+	})
+	t.Run("ReadDocument", func(t *testing.T) {
+		doc := roundTrip(t, build())
+		require.NoError(t, doc.ReplacePath("hosts.0.host", VC.String("c")))
+		assert.Equal(t, "c", roundTrip(t, doc).RecursiveLookup("hosts", "0", "host").StringValue())
+	})
+	t.Run("Container", func(t *testing.T) {
+		doc := build()
+		require.NoError(t, doc.ReplacePath("net", VC.DocumentFromElements(EC.Int32("port", 1))))
+		assert.Equal(t, []string{"port"}, roundTrip(t, doc).Lookup("net").MutableDocument().KeyNames())
+	})
+	t.Run("FirstDuplicate", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", 1), EC.Int32("b", 2), EC.Int32("a", 3))
+		require.NoError(t, doc.ReplacePath("a", VC.Int32(10)))
+		assert.Equal(t, int32(10), doc.ElementAt(0).Value().Int32())
+		assert.Equal(t, int32(3), doc.ElementAt(2).Value().Int32())
+		assert.Equal(t, int32(2), doc.Lookup("b").Int32())
+	})
+	t.Run("Missing", func(t *testing.T) {
+		for path, at := range map[string]string{
+			"missing":           "missing",
+			"net.missing":       "net.missing",
+			"net.tls.missing.x": "net.tls.missing",
+			"hosts.2.host":      "hosts.2",
+			"hosts.-1":          "hosts.-1",
+			"hosts.first":       "hosts.first",
+		} {
+			t.Run(path, func(t *testing.T) {
+				doc := build()
+				err := doc.ReplacePath(path, VC.Int32(1))
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrKeyNotFound))
+				assert.False(t, errors.Is(err, ErrInvalidType))
+
+				var pathErr *PathError
+				require.True(t, errors.As(err, &pathErr))
+				assert.Equal(t, path, pathErr.Path)
+				assert.Equal(t, at, pathErr.At)
+				assert.True(t, build().EqualExcept(doc), "the document is not changed")
+			})
+		}
+	})
+	t.Run("NotContainer", func(t *testing.T) {
+		doc := build()
+		err := doc.ReplacePath("net.port.value", VC.Int32(1))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidType))
+		assert.False(t, errors.Is(err, ErrKeyNotFound))
+
+		var pathErr *PathError
+		require.True(t, errors.As(err, &pathErr))
+		assert.Equal(t, "net.port", pathErr.At)
+		assert.Equal(t, "path 'net.port.value': invalid type at 'net.port'", err.Error())
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		doc := build()
+		assert.Error(t, doc.ReplacePath("", VC.Int32(1)))
+		assert.Error(t, doc.ReplacePath("net..port", VC.Int32(1)))
+		assert.Error(t, doc.ReplacePath("name", nil))
+		assert.Error(t, doc.ReplacePath("name", &Value{}))
+
+		var nilDoc *Document
+		assert.Error(t, nilDoc.ReplacePath("name", VC.Int32(1)))
+	})
+}
@@ -0,0 +1,32 @@
+package birch
+
+import "github.com/pkg/errors"
+
+// RoundTrip parses the BSON document at the beginning of data and
+// marshals it again, returning the re-encoded bytes. It is primarily
+// useful as a correctness check on the parser and encoder, and as a
+// fuzzing target.
+//
+// For well-formed input the output is byte-for-byte identical to the
+// input, with one normalization: any bytes following the end of the
+// document, as given by its length prefix, are not included in the
+// output. RoundTrip returns an error if the document, or any
+// document or array nested within it, is invalid.
+func RoundTrip(data []byte) ([]byte, error) {
+	size, err := Reader(data).Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid document")
+	}
+
+	doc, err := ReadDocument(data[:size])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	out, err := doc.MarshalBSON()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
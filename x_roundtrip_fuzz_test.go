@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package birch
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range makeRoundTripSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := RoundTrip(data)
+		if err != nil {
+			return
+		}
+
+		if !bytes.Equal(out, data[:len(out)]) {
+			t.Fatalf("round trip changed document:\n in: %x\nout: %x", data, out)
+		}
+
+		again, err := RoundTrip(out)
+		if err != nil {
+			t.Fatalf("round trip output is not valid: %v", err)
+		}
+
+		if !bytes.Equal(out, again) {
+			t.Fatalf("round trip is not stable:\n first: %x\nsecond: %x", out, again)
+		}
+	})
+}
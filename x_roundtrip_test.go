@@ -0,0 +1,92 @@
+package birch
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/types"
+)
+
+func makeRoundTripSeeds(t testing.TB) [][]byte {
+	docs := []*Document{
+		DC.New(),
+		DC.Elements(EC.String("hello", "world")),
+		DC.Elements(
+			EC.Double("double", math.Pi),
+			EC.Int32("int32", math.MaxInt32),
+			EC.Int64("int64", math.MinInt64),
+			EC.Boolean("bool", true),
+			EC.Null("null"),
+			EC.Time("time", time.Unix(1600000000, 0)),
+			EC.Timestamp("ts", 1, 2),
+			EC.ObjectID("oid", types.ObjectID{0x1, 0x2, 0x3}),
+			EC.Binary("bin", []byte("binary")),
+			EC.Regex("regex", "^a.*", "i"),
+			EC.JavaScript("js", "return 1;"),
+			EC.Symbol("sym", "symbol"),
+			EC.Decimal128("dec", types.NewDecimal128(1, 1)),
+			EC.MinKey("min"),
+			EC.MaxKey("max"),
+			EC.Undefined("undefined"),
+			EC.DBPointer("dbp", "db.coll", types.ObjectID{0x4}),
+		),
+		DC.Elements(
+			EC.SubDocumentFromElements("metrics",
+				EC.Int64("ops", 42),
+				EC.ArrayFromElements("samples", VC.Int(1), VC.Double(2.5), VC.String("three")),
+			),
+			EC.CodeWithScope("cws", "return x;", DC.Elements(EC.Int("x", 1))),
+		),
+	}
+
+	seeds := make([][]byte, 0, len(docs))
+	for _, doc := range docs {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		seeds = append(seeds, data)
+	}
+
+	return seeds
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		for _, seed := range makeRoundTripSeeds(t) {
+			out, err := RoundTrip(seed)
+			require.NoError(t, err)
+			assert.Equal(t, seed, out)
+		}
+	})
+	t.Run("TrailingBytes", func(t *testing.T) {
+		seed := makeRoundTripSeeds(t)[1]
+		out, err := RoundTrip(append(append([]byte{}, seed...), 0x01, 0x02))
+		require.NoError(t, err)
+		assert.Equal(t, seed, out)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, input := range [][]byte{
+			nil,
+			{0x05, 0x00, 0x00},
+			{0x06, 0x00, 0x00, 0x00, 0x00, 0x00},
+			{0x05, 0x00, 0x00, 0x00, 0x01},
+		} {
+			_, err := RoundTrip(input)
+			assert.Error(t, err, "%v", input)
+		}
+	})
+	t.Run("InvalidNested", func(t *testing.T) {
+		data, err := DC.Elements(EC.SubDocumentFromElements("sub", EC.String("a", "b"))).MarshalBSON()
+		require.NoError(t, err)
+
+		// corrupt the length of the nested string
+		idx := bytes.Index(data, []byte{0x02, 'a', 0x00}) + 3
+		data[idx] = 0x7F
+
+		_, err = RoundTrip(data)
+		assert.Error(t, err)
+	})
+}
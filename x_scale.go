@@ -0,0 +1,64 @@
+package birch
+
+import (
+	"math"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// ScaleOptions controls how Document.ScaleWithOptions scales integers.
+type ScaleOptions struct {
+	// RoundIntegers rounds the scaled values of integers to the
+	// nearest integer of the same type, instead of converting them
+	// to doubles. Scaled int32 values that overflow become int64
+	// values, and values that overflow an int64 remain doubles.
+	RoundIntegers bool
+}
+
+// Scale returns a copy of the document with every numeric value,
+// including those in sub-documents and arrays, multiplied by factor,
+// such as to convert a document of metrics from bytes to megabytes.
+// Scaled integers become doubles; use ScaleWithOptions to keep them
+// integers. The document is not modified.
+func (d *Document) Scale(factor float64) *Document {
+	return d.ScaleWithOptions(factor, ScaleOptions{})
+}
+
+// ScaleWithOptions is the same as Scale, but allows callers to control
+// how integers are scaled.
+func (d *Document) ScaleWithOptions(factor float64, opts ScaleOptions) *Document {
+	if d == nil {
+		return nil
+	}
+
+	out := VC.Document(d).Clone().MutableDocument()
+	out.Apply(func(_ string, v *Value) *Value {
+		var val float64
+		switch v.Type() {
+		case bsontype.Double:
+			return VC.Double(v.Double() * factor)
+		case bsontype.Int32:
+			val = float64(v.Int32()) * factor
+		case bsontype.Int64:
+			val = float64(v.Int64()) * factor
+		default:
+			return v
+		}
+
+		if !opts.RoundIntegers {
+			return VC.Double(val)
+		}
+
+		val = math.Round(val)
+		switch {
+		case v.Type() == bsontype.Int32 && val >= math.MinInt32 && val <= math.MaxInt32:
+			return VC.Int32(int32(val))
+		case val >= math.MinInt64 && val < math.MaxInt64:
+			return VC.Int64(int64(val))
+		default:
+			return VC.Double(val)
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,65 @@
+package birch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestDocumentScale(t *testing.T) {
+	build := func() *Document {
+		return DC.Elements(
+			EC.String("host", "a"),
+			EC.Int64("bytes", 3*1024*1024),
+			EC.Int32("small", 3),
+			EC.Double("ratio", 0.5),
+			EC.SubDocumentFromElements("mem", EC.Int64("resident", 1024*1024), EC.Boolean("ok", true)),
+			EC.ArrayFromElements("samples", VC.Int32(512*1024), VC.String("x")),
+		)
+	}
+	mb := 1.0 / (1024 * 1024)
+
+	t.Run("Default", func(t *testing.T) {
+		doc := build()
+		out := doc.Scale(mb)
+
+		assert.Equal(t, 3.0, out.Lookup("bytes").Double())
+		assert.Equal(t, 3*mb, out.Lookup("small").Double())
+		assert.Equal(t, 0.5*mb, out.Lookup("ratio").Double())
+		assert.Equal(t, 1.0, out.RecursiveLookup("mem", "resident").Double())
+		assert.True(t, out.RecursiveLookup("mem", "ok").Boolean())
+		assert.Equal(t, 0.5, out.Lookup("samples").MutableArray().Lookup(0).Double())
+		assert.Equal(t, "x", out.Lookup("samples").MutableArray().Lookup(1).StringValue())
+		assert.Equal(t, "a", out.Lookup("host").StringValue())
+
+		// the original is untouched.
+		assert.Equal(t, int64(3*1024*1024), doc.Lookup("bytes").Int64())
+		assert.Equal(t, int64(1024*1024), doc.RecursiveLookup("mem", "resident").Int64())
+		assert.Equal(t, int32(512*1024), doc.Lookup("samples").MutableArray().Lookup(0).Int32())
+	})
+	t.Run("RoundIntegers", func(t *testing.T) {
+		out := build().ScaleWithOptions(mb, ScaleOptions{RoundIntegers: true})
+
+		assert.Equal(t, bsontype.Int64, out.Lookup("bytes").Type())
+		assert.Equal(t, int64(3), out.Lookup("bytes").Int64())
+		assert.Equal(t, int32(0), out.Lookup("small").Int32())
+		assert.Equal(t, 0.5*mb, out.Lookup("ratio").Double())
+		assert.Equal(t, int32(1), out.Lookup("samples").MutableArray().Lookup(0).Int32())
+	})
+	t.Run("RoundOverflow", func(t *testing.T) {
+		doc := DC.Elements(EC.Int32("a", math.MaxInt32), EC.Int64("b", math.MaxInt64/2))
+		out := doc.ScaleWithOptions(4, ScaleOptions{RoundIntegers: true})
+
+		require.Equal(t, bsontype.Int64, out.Lookup("a").Type())
+		assert.Equal(t, int64(math.MaxInt32)*4, out.Lookup("a").Int64())
+		assert.Equal(t, bsontype.Double, out.Lookup("b").Type())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var doc *Document
+		assert.Nil(t, doc.Scale(2))
+		assert.Equal(t, 0, DC.New().Scale(2).Len())
+	})
+}
@@ -0,0 +1,123 @@
+package birch
+
+import "github.com/tychoish/birch/bsontype"
+
+// schemaArrayItems is the key that InferSchema uses for the elements
+// of arrays, after MongoDB's all positional operator.
+const schemaArrayItems = "$[]"
+
+// InferSchema returns a document that summarizes the structure of the
+// documents, such as to understand a sample of data before exporting
+// it with WriteAvro. The summary has the form:
+//
+//	{
+//		"documents": <int64>,
+//		"paths": {
+//			<path>: {
+//				"count": <int64>,
+//				"frequency": <double>,
+//				"types": {<type>: <int64>, ...}
+//			},
+//			...
+//		}
+//	}
+//
+// where documents is the number of documents, and paths holds, in
+// order of first appearance, each dot-separated path in the
+// documents, including the paths of sub-documents and arrays. The
+// elements of an array share the path of the array followed by "$[]"
+// (e.g. "metrics.samples.$[]"), rather than having a path for each
+// position. For each path, count is the number of documents that have
+// the path, frequency is count divided by the number of documents,
+// and types holds the number of values at the path with each BSON
+// type, named as by bsontype.Type's String method, in order of first
+// appearance, so that a path with values of more than one type has
+// more than one entry. Since an array can have many elements, the
+// number of values of the elements of arrays may exceed count.
+//
+// Nil documents are ignored.
+func InferSchema(docs []*Document) *Document {
+	schema := &inferredSchema{paths: map[string]*inferredPath{}}
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		schema.documents++
+		schema.add("", doc, false)
+	}
+
+	paths := DC.Make(len(schema.order))
+	for _, path := range schema.order {
+		p := schema.paths[path]
+
+		types := DC.Make(len(p.order))
+		for _, t := range p.order {
+			types.Append(EC.Int64(t.String(), p.types[t]))
+		}
+
+		paths.Append(EC.SubDocumentFromElements(path,
+			EC.Int64("count", p.count),
+			EC.Double("frequency", float64(p.count)/float64(schema.documents)),
+			EC.SubDocument("types", types),
+		))
+	}
+
+	return DC.Elements(
+		EC.Int64("documents", schema.documents),
+		EC.SubDocument("paths", paths),
+	)
+}
+
+type inferredSchema struct {
+	documents int64
+	paths     map[string]*inferredPath
+	order     []string
+}
+
+type inferredPath struct {
+	count int64
+	// document is the number of the last document that had the
+	// path, so that each document is counted once.
+	document int64
+	types    map[bsontype.Type]int64
+	order    []bsontype.Type
+}
+
+func (s *inferredSchema) add(prefix string, d *Document, isArray bool) {
+	for _, elem := range d.elems {
+		path := prefix + schemaArrayItems
+		if !isArray {
+			path = prefix + elem.Key()
+		}
+
+		t := elem.value.Type()
+		s.record(path, t)
+
+		switch t {
+		case bsontype.EmbeddedDocument:
+			s.add(path+".", elem.value.MutableDocument(), false)
+		case bsontype.Array:
+			s.add(path+".", elem.value.MutableArray().doc, true)
+		}
+	}
+}
+
+func (s *inferredSchema) record(path string, t bsontype.Type) {
+	p, ok := s.paths[path]
+	if !ok {
+		p = &inferredPath{types: map[bsontype.Type]int64{}}
+		s.paths[path] = p
+		s.order = append(s.order, path)
+	}
+
+	if p.document != s.documents {
+		p.document = s.documents
+		p.count++
+	}
+
+	if _, ok := p.types[t]; !ok {
+		p.order = append(p.order, t)
+	}
+	p.types[t]++
+}
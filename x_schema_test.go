@@ -0,0 +1,92 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	docs := []*Document{
+		DC.Elements(
+			EC.String("host", "a"),
+			EC.Int32("ops", 1),
+			EC.SubDocumentFromElements("mem", EC.Int32("resident", 20)),
+			EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5)),
+		),
+		nil,
+		DC.Elements(
+			EC.String("host", "b"),
+			EC.Int64("ops", 2),
+			EC.ArrayFromElements("load", VC.Int32(1), VC.Int32(2), VC.Int32(3)),
+		),
+		DC.Elements(
+			EC.String("host", "c"),
+			EC.String("ops", "unknown"),
+			EC.SubDocumentFromElements("mem", EC.Int32("resident", 21), EC.Boolean("swapped", true)),
+			EC.ArrayFromElements("load", VC.DocumentFromElements(EC.Int32("x", 1))),
+		),
+		DC.Elements(EC.String("host", "d")),
+	}
+
+	schema := InferSchema(docs)
+	assert.Equal(t, int64(4), schema.Lookup("documents").Int64())
+
+	paths := schema.Lookup("paths").MutableDocument()
+	assert.Equal(t, []string{"host", "ops", "mem", "mem.resident", "load", "load.$[]", "mem.swapped", "load.$[].x"}, paths.KeyNames())
+
+	path := func(t *testing.T, key string) (int64, float64, *Document) {
+		p := paths.Lookup(key)
+		require.NotNil(t, p, key)
+		doc := p.MutableDocument()
+		return doc.Lookup("count").Int64(), doc.Lookup("frequency").Double(), doc.Lookup("types").MutableDocument()
+	}
+
+	t.Run("Present", func(t *testing.T) {
+		count, frequency, types := path(t, "host")
+		assert.Equal(t, int64(4), count)
+		assert.Equal(t, 1.0, frequency)
+		assert.True(t, types.EqualExcept(DC.Elements(EC.Int64("string", 4))))
+	})
+	t.Run("Union", func(t *testing.T) {
+		count, frequency, types := path(t, "ops")
+		assert.Equal(t, int64(3), count)
+		assert.Equal(t, 0.75, frequency)
+		assert.True(t, types.EqualExcept(DC.Elements(
+			EC.Int64("32-bit integer", 1),
+			EC.Int64("64-bit integer", 1),
+			EC.Int64("string", 1),
+		)))
+	})
+	t.Run("SubDocuments", func(t *testing.T) {
+		count, _, types := path(t, "mem")
+		assert.Equal(t, int64(2), count)
+		assert.True(t, types.EqualExcept(DC.Elements(EC.Int64("embedded document", 2))))
+
+		count, frequency, _ := path(t, "mem.swapped")
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, 0.25, frequency)
+	})
+	t.Run("Arrays", func(t *testing.T) {
+		count, _, types := path(t, "load.$[]")
+		assert.Equal(t, int64(3), count, "each document counts once")
+		assert.True(t, types.EqualExcept(DC.Elements(
+			EC.Int64("32-bit integer", 4),
+			EC.Int64("double", 1),
+			EC.Int64("embedded document", 1),
+		)))
+
+		count, _, _ = path(t, "load.$[].x")
+		assert.Equal(t, int64(1), count)
+	})
+	t.Run("Serializable", func(t *testing.T) {
+		_, err := schema.MarshalBSON()
+		assert.NoError(t, err)
+	})
+	t.Run("Empty", func(t *testing.T) {
+		schema := InferSchema(nil)
+		assert.Equal(t, int64(0), schema.Lookup("documents").Int64())
+		assert.Equal(t, 0, schema.Lookup("paths").MutableDocument().Len())
+	})
+}
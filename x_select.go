@@ -0,0 +1,78 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// Select returns a new document with the top-level elements of the
+// document for which pred returns true, in order, such as the metrics
+// in a sample that exceed a threshold. The selected values are deep
+// copies, so that changes to the result do not affect the document.
+// Select returns nil if the document is nil.
+func (d *Document) Select(pred func(key string, v *Value) bool) *Document {
+	if d == nil {
+		return nil
+	}
+
+	out := DC.Make(len(d.elems))
+	for _, elem := range d.elems {
+		if pred(elem.Key(), elem.value) {
+			out.Append(EC.Value(elem.Key(), elem.value.Clone()))
+		}
+	}
+
+	return out
+}
+
+// SelectDeep is a recursive form of Select, which calls pred with the
+// dot-separated path to each element, using the position of array
+// elements as their key (e.g. "hosts.0.name"). When pred returns
+// true the element is selected, with all of its contents, and when
+// it returns false for a sub-document or array, SelectDeep selects
+// the elements within it instead. Sub-documents and arrays appear in
+// the result, with the same structure as in the document, only if
+// they contain selected elements; arrays hold their selected
+// elements in order, so their positions may differ from the
+// document's.
+func (d *Document) SelectDeep(pred func(path string, v *Value) bool) *Document {
+	if d == nil {
+		return nil
+	}
+
+	return selectElements(d.elems, "", false, pred)
+}
+
+func selectElements(elems []*Element, prefix string, array bool, pred func(string, *Value) bool) *Document {
+	out := DC.Make(0)
+	for idx, elem := range elems {
+		key := elem.Key()
+		if array {
+			key = strconv.Itoa(idx)
+		}
+		path := prefix + key
+
+		if pred(path, elem.value) {
+			out.Append(EC.Value(elem.Key(), elem.value.Clone()))
+			continue
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			if sub := selectElements(elem.value.MutableDocument().elems, path+".", false, pred); sub.Len() > 0 {
+				out.Append(EC.SubDocument(elem.Key(), sub))
+			}
+		case bsontype.Array:
+			if sub := selectElements(elem.value.MutableArray().doc.elems, path+".", true, pred); sub.Len() > 0 {
+				arr := MakeArray(sub.Len())
+				for _, item := range sub.elems {
+					arr.Append(item.value)
+				}
+				out.Append(EC.Array(elem.Key(), arr))
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,75 @@
+package birch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestSelect(t *testing.T) {
+	large := func(_ string, v *Value) bool {
+		switch v.Type() {
+		case bsontype.Int32, bsontype.Int64, bsontype.Double:
+			return v.Compare(VC.Int32(1000)) > 0
+		default:
+			return false
+		}
+	}
+
+	doc := DC.Elements(
+		EC.Int64("ops", 5000),
+		EC.String("host", "a"),
+		EC.Int32("conns", 10),
+		EC.Double("latency", 1500.5),
+		EC.SubDocumentFromElements("mem",
+			EC.Int64("resident", 2048),
+			EC.Int64("virtual", 512),
+		),
+		EC.ArrayFromElements("queues", VC.Int32(1), VC.Int32(2000), VC.DocumentFromElements(EC.Int64("depth", 3000))),
+		EC.SubDocumentFromElements("empty"),
+	)
+
+	t.Run("Select", func(t *testing.T) {
+		out := doc.Select(large)
+		assert.Equal(t, []string{"ops", "latency"}, out.KeyNames())
+
+		out = doc.Select(func(key string, _ *Value) bool { return strings.HasPrefix(key, "m") || key == "host" })
+		assert.Equal(t, []string{"host", "mem"}, out.KeyNames())
+		assert.Equal(t, int64(2048), out.RecursiveLookup("mem", "resident").Int64())
+
+		assert.Equal(t, 0, doc.Select(func(string, *Value) bool { return false }).Len())
+		assert.Nil(t, (*Document)(nil).Select(large))
+	})
+	t.Run("Independent", func(t *testing.T) {
+		out := doc.Select(func(key string, _ *Value) bool { return key == "mem" })
+		out.Lookup("mem").MutableDocument().Set(EC.Int64("resident", 1))
+
+		assert.Equal(t, int64(1), out.RecursiveLookup("mem", "resident").Int64())
+		assert.Equal(t, int64(2048), doc.RecursiveLookup("mem", "resident").Int64())
+	})
+	t.Run("SelectDeep", func(t *testing.T) {
+		out := doc.SelectDeep(large)
+		expected := DC.Elements(
+			EC.Int64("ops", 5000),
+			EC.Double("latency", 1500.5),
+			EC.SubDocumentFromElements("mem", EC.Int64("resident", 2048)),
+			EC.ArrayFromElements("queues", VC.Int32(2000), VC.DocumentFromElements(EC.Int64("depth", 3000))),
+		)
+		assert.True(t, expected.EqualExcept(out), out.String())
+
+		var paths []string
+		doc.SelectDeep(func(path string, _ *Value) bool {
+			paths = append(paths, path)
+			return path == "mem"
+		})
+		assert.Equal(t, []string{"ops", "host", "conns", "latency", "mem", "queues", "queues.0", "queues.1", "queues.2", "queues.2.depth", "empty"}, paths)
+
+		out.Lookup("mem").MutableDocument().Set(EC.Int64("resident", 1))
+		assert.Equal(t, int64(2048), doc.RecursiveLookup("mem", "resident").Int64())
+
+		require.Nil(t, (*Document)(nil).SelectDeep(large))
+	})
+}
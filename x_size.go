@@ -0,0 +1,49 @@
+package birch
+
+// ByteSize returns the number of bytes that the value occupies when
+// encoded, not counting the type byte and key of its element. Sizes
+// of embedded documents and arrays include all of their elements, and
+// are the same as the sizes that Validate reports for them, so the
+// size of an encoded document is 5 bytes for its length and
+// terminator plus the ByteSize of each of its elements.
+//
+// ByteSize returns 0 for nil or uninitialized values, and for values
+// that are not valid BSON.
+func (v *Value) ByteSize() int {
+	if v == nil || v.offset == 0 || v.data == nil {
+		return 0
+	}
+
+	size, err := v.valueSize()
+	if err != nil {
+		return 0
+	}
+
+	return int(size)
+}
+
+// ByteSize returns the number of bytes that the element occupies when
+// encoded in a document: the type byte, the key and its terminator,
+// and the ByteSize of the value. Elements of arrays are encoded with
+// their index as their key, which may differ from the key of the
+// element.
+//
+// ByteSize returns 0 for nil or uninitialized elements, and for
+// elements that are not valid BSON.
+func (e *Element) ByteSize() int {
+	if e == nil || e.value == nil || e.value.offset == 0 || e.value.data == nil {
+		return 0
+	}
+
+	key, err := e.validateKey()
+	if err != nil {
+		return 0
+	}
+
+	size, err := e.value.valueSize()
+	if err != nil {
+		return 0
+	}
+
+	return 1 + int(key) + int(size)
+}
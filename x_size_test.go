@@ -0,0 +1,79 @@
+package birch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByteSize(t *testing.T) {
+	for name, value := range map[string]struct {
+		value *Value
+		size  int
+	}{
+		"Int32":    {value: VC.Int32(1), size: 4},
+		"Int64":    {value: VC.Int64(1), size: 8},
+		"Double":   {value: VC.Double(1), size: 8},
+		"Time":     {value: VC.Time(time.Now()), size: 8},
+		"Null":     {value: VC.Null(), size: 0},
+		"Boolean":  {value: VC.Boolean(true), size: 1},
+		"String":   {value: VC.String("hello"), size: 4 + 5 + 1},
+		"Empty":    {value: VC.String(""), size: 4 + 1},
+		"Binary":   {value: VC.Binary([]byte("data")), size: 4 + 1 + 4},
+		"Document": {value: VC.DocumentFromElements(EC.Int32("a", 1)), size: 4 + (1 + 2 + 4) + 1},
+		"Array":    {value: VC.ArrayFromValues(VC.Int32(1), VC.String("x")), size: 4 + (1 + 2 + 4) + (1 + 2 + 4 + 2) + 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, value.size, value.value.ByteSize())
+
+			elem := EC.Interface("key", value.value.Interface())
+			if value.value.Type() == elem.Value().Type() {
+				assert.Equal(t, 1+4+value.size, elem.ByteSize())
+			}
+		})
+	}
+	t.Run("MatchesEncoding", func(t *testing.T) {
+		doc := DC.Elements(
+			EC.Int32("a", 1),
+			EC.String("string", "value"),
+			EC.SubDocumentFromElements("doc",
+				EC.ArrayFromElements("array", VC.Int64(1), VC.DocumentFromElements(EC.Boolean("b", true))),
+			),
+			EC.Null("null"),
+		)
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		for _, d := range []*Document{doc, parsed} {
+			total := 5
+			iter := d.Iterator()
+			for iter.Next() {
+				elem := iter.Element()
+				total += elem.ByteSize()
+
+				encoded, err := elem.MarshalBSON()
+				require.NoError(t, err)
+				assert.Equal(t, len(encoded), elem.ByteSize(), elem.Key())
+			}
+			assert.Equal(t, len(data), total)
+
+			sub := d.Lookup("doc")
+			size, err := sub.MutableDocument().Validate()
+			require.NoError(t, err)
+			assert.Equal(t, int(size), sub.ByteSize())
+		}
+	})
+	t.Run("Uninitialized", func(t *testing.T) {
+		var nilValue *Value
+		var nilElem *Element
+		assert.Zero(t, nilValue.ByteSize())
+		assert.Zero(t, (&Value{}).ByteSize())
+		assert.Zero(t, nilElem.ByteSize())
+		assert.Zero(t, (&Element{}).ByteSize())
+		assert.Zero(t, (&Element{&Value{}}).ByteSize())
+	})
+}
@@ -0,0 +1,90 @@
+package birch
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// SplitBySize groups the documents, in order, into batches whose
+// total serialized size is no more than maxBytes. The size of a batch
+// is the size of a BSON array holding its documents, which includes
+// the type and index key of every document as well as the array's own
+// header, so that each batch fits in the documents array of a write
+// command.
+//
+// A document that does not fit in a batch by itself, or that is not
+// valid, is placed in a batch of its own and never grouped with other
+// documents. Use SplitBySizeErr to reject these documents instead.
+func SplitBySize(docs []*Document, maxBytes int) [][]*Document {
+	batches, _ := splitBySize(docs, maxBytes, false)
+	return batches
+}
+
+// SplitBySizeErr is the same as SplitBySize, except that it returns an
+// error identifying the first document that is not valid or does not
+// fit in a batch by itself.
+func SplitBySizeErr(docs []*Document, maxBytes int) ([][]*Document, error) {
+	return splitBySize(docs, maxBytes, true)
+}
+
+// arrayOverhead is the size of a BSON array's length and terminator.
+const arrayOverhead = 4 + 1
+
+func splitBySize(docs []*Document, maxBytes int, strict bool) ([][]*Document, error) {
+	var (
+		batches [][]*Document
+		current []*Document
+		size    int
+	)
+
+	flush := func() {
+		if len(current) > 0 {
+			batches = append(batches, current)
+		}
+		current = nil
+		size = arrayOverhead
+	}
+	flush()
+
+	for idx, doc := range docs {
+		docSize, err := doc.Validate()
+		if err != nil {
+			if strict {
+				return nil, errors.Wrapf(err, "document %d is not valid", idx)
+			}
+
+			flush()
+			batches = append(batches, []*Document{doc})
+			continue
+		}
+
+		// each document is an element of the array: a type
+		// byte, its index as a key, and a null terminator.
+		elemSize := 1 + len(strconv.Itoa(len(current))) + 1 + int(docSize)
+
+		if size+elemSize <= maxBytes {
+			current = append(current, doc)
+			size += elemSize
+			continue
+		}
+
+		flush()
+
+		elemSize = 1 + len("0") + 1 + int(docSize)
+		if size+elemSize > maxBytes {
+			if strict {
+				return nil, errors.Errorf("document %d is %d bytes, which exceeds the limit of %d bytes", idx, docSize, maxBytes)
+			}
+
+			batches = append(batches, []*Document{doc})
+			continue
+		}
+
+		current = append(current, doc)
+		size += elemSize
+	}
+	flush()
+
+	return batches, nil
+}
@@ -0,0 +1,96 @@
+package birch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBySize(t *testing.T) {
+	batchSize := func(t *testing.T, batch []*Document) int {
+		arr := MakeArray(len(batch))
+		for _, doc := range batch {
+			arr.Append(VC.Document(doc))
+		}
+
+		data, err := arr.MarshalBSON()
+		require.NoError(t, err)
+		return len(data)
+	}
+	makeDocs := func(n, size int) []*Document {
+		docs := make([]*Document, n)
+		for i := range docs {
+			docs[i] = DC.Elements(EC.Int("id", i), EC.String("payload", strings.Repeat("x", size)))
+		}
+		return docs
+	}
+	flatten := func(batches [][]*Document) []*Document {
+		var out []*Document
+		for _, batch := range batches {
+			out = append(out, batch...)
+		}
+		return out
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Empty(t, SplitBySize(nil, 1024))
+	})
+	t.Run("Limits", func(t *testing.T) {
+		docs := makeDocs(100, 50)
+		for _, limit := range []int{100, 200, 1000, 4096, 1 << 20} {
+			batches, err := SplitBySizeErr(docs, limit)
+			require.NoError(t, err)
+			assert.Equal(t, docs, flatten(batches))
+
+			for idx, batch := range batches {
+				require.NotEmpty(t, batch)
+				assert.True(t, batchSize(t, batch) <= limit, "batch %d with limit %d", idx, limit)
+
+				// a batch is only closed when the next
+				// document does not fit.
+				if idx < len(batches)-1 {
+					next := append(append([]*Document{}, batch...), batches[idx+1][0])
+					assert.True(t, batchSize(t, next) > limit)
+				}
+			}
+		}
+	})
+	t.Run("ExactFit", func(t *testing.T) {
+		docs := makeDocs(20, 10)
+		limit := batchSize(t, docs[:10])
+
+		batches := SplitBySize(docs, limit)
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 10)
+		assert.Len(t, batches[1], 10)
+	})
+	t.Run("Oversized", func(t *testing.T) {
+		docs := append(makeDocs(3, 10), makeDocs(1, 1000)...)
+		docs = append(docs, makeDocs(2, 10)...)
+
+		batches := SplitBySize(docs, 500)
+		require.Len(t, batches, 3)
+		assert.Len(t, batches[0], 3)
+		assert.Equal(t, []*Document{docs[3]}, batches[1])
+		assert.Len(t, batches[2], 2)
+		assert.Equal(t, docs, flatten(batches))
+
+		_, err := SplitBySizeErr(docs, 500)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "document 3")
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		docs := makeDocs(3, 10)
+		docs[1] = nil
+
+		batches := SplitBySize(docs, 1024)
+		require.Len(t, batches, 3)
+		assert.Equal(t, []*Document{nil}, batches[1])
+
+		_, err := SplitBySizeErr(docs, 1024)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "document 1")
+	})
+}
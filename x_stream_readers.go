@@ -0,0 +1,70 @@
+package birch
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+)
+
+// StreamReaders splits a stream of concatenated BSON documents into
+// Readers, which it sends to out in order, and closes out when it
+// returns. Documents are framed by their length prefix and are not
+// parsed or validated, for services that forward documents without
+// inspecting them; use ValidateStream or ReadDocument to check them.
+//
+// StreamReaders returns nil when the stream ends after a complete
+// document, the context's error if the context is canceled, and
+// otherwise the first error reading the stream. A stream that ends
+// within a document returns an error that wraps ErrUnexpectedEOF,
+// and a length prefix that is less than 5 bytes, or greater than
+// the largest document that MongoDB allows, returns an error that
+// wraps ErrCorruptDocument, since the rest of the stream cannot be
+// framed.
+func StreamReaders(ctx context.Context, r io.Reader, out chan<- Reader) error {
+	defer close(out)
+
+	if r == nil {
+		return bsonerr.NilReader
+	}
+
+	var offset int64
+	for idx := 0; ; idx++ {
+		var length [4]byte
+		_, err := io.ReadFull(r, length[:])
+		switch err {
+		case nil:
+		case io.EOF:
+			return nil
+		case io.ErrUnexpectedEOF:
+			return errors.Wrapf(bsonerr.UnexpectedEOF, "truncated length of document %d at offset %d", idx, offset)
+		default:
+			return errors.Wrapf(err, "problem reading document %d", idx)
+		}
+
+		size := readi32(length[:])
+		if size < 5 || size > maxStreamDocumentSize {
+			return errors.Wrapf(bsonerr.InvalidLength, "document %d at offset %d has length %d", idx, offset, size)
+		}
+
+		doc := make(Reader, size)
+		copy(doc, length[:])
+		_, err = io.ReadFull(r, doc[4:])
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return errors.Wrapf(bsonerr.UnexpectedEOF, "truncated document %d of length %d at offset %d", idx, size, offset)
+		default:
+			return errors.Wrapf(err, "problem reading document %d", idx)
+		}
+
+		select {
+		case out <- doc:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		offset += int64(size)
+	}
+}
@@ -0,0 +1,92 @@
+package birch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamReaders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stream []byte
+	var docs [][]byte
+	for idx := 0; idx < 3; idx++ {
+		data, err := DC.Elements(EC.Int("n", idx), EC.String("s", "value")).MarshalBSON()
+		require.NoError(t, err)
+		docs = append(docs, data)
+		stream = append(stream, data...)
+	}
+
+	collect := func(ctx context.Context, r io.Reader) ([]Reader, error) {
+		out := make(chan Reader)
+		errs := make(chan error, 1)
+		go func() { errs <- StreamReaders(ctx, r, out) }()
+
+		var readers []Reader
+		for reader := range out {
+			readers = append(readers, reader)
+		}
+		return readers, <-errs
+	}
+
+	t.Run("Frames", func(t *testing.T) {
+		readers, err := collect(ctx, bytes.NewReader(stream))
+		require.NoError(t, err)
+		require.Len(t, readers, 3)
+		for idx, reader := range readers {
+			assert.Equal(t, docs[idx], []byte(reader))
+		}
+
+		readers, err = collect(ctx, bytes.NewReader(nil))
+		require.NoError(t, err)
+		assert.Empty(t, readers)
+	})
+	t.Run("Unvalidated", func(t *testing.T) {
+		corrupt := append([]byte{}, docs[0]...)
+		corrupt[4] = 0x42
+		readers, err := collect(ctx, bytes.NewReader(corrupt))
+		require.NoError(t, err)
+		require.Len(t, readers, 1)
+		assert.Equal(t, corrupt, []byte(readers[0]))
+	})
+	t.Run("TruncatedFrame", func(t *testing.T) {
+		readers, err := collect(ctx, bytes.NewReader(stream[:len(stream)-2]))
+		assert.True(t, errors.Is(err, ErrUnexpectedEOF))
+		assert.Contains(t, err.Error(), "document 2")
+		assert.Len(t, readers, 2)
+
+		readers, err = collect(ctx, bytes.NewReader(append(append([]byte{}, docs[0]...), 0x10)))
+		assert.True(t, errors.Is(err, ErrUnexpectedEOF))
+		assert.Len(t, readers, 1)
+	})
+	t.Run("InvalidLength", func(t *testing.T) {
+		readers, err := collect(ctx, bytes.NewReader(append(append([]byte{}, docs[0]...), 0x01, 0x00, 0x00, 0x00, 0x00)))
+		assert.True(t, errors.Is(err, ErrCorruptDocument))
+		assert.Len(t, readers, 1)
+	})
+	t.Run("ReadError", func(t *testing.T) {
+		_, err := collect(ctx, failingReader{})
+		assert.Error(t, err)
+		_, err = collect(ctx, nil)
+		assert.Error(t, err)
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		cctx, ccancel := context.WithCancel(ctx)
+		out := make(chan Reader)
+		errs := make(chan error, 1)
+		go func() { errs <- StreamReaders(cctx, bytes.NewReader(stream), out) }()
+
+		<-out
+		ccancel()
+		assert.Equal(t, context.Canceled, <-errs)
+		_, ok := <-out
+		assert.False(t, ok, "the channel is closed")
+	})
+}
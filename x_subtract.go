@@ -0,0 +1,167 @@
+package birch
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// SubtractOptions controls how Document.SubtractWithOptions handles
+// elements that the two documents do not share.
+type SubtractOptions struct {
+	// Strict returns an error for keys that are missing from
+	// either document and for values that are not numbers or do
+	// not have matching structure, instead of skipping them.
+	Strict bool
+}
+
+// Subtract returns a document with the difference between each
+// numeric value in the document and the value with the same key in
+// other (d - other), such as the change in metrics between two
+// snapshots. Subtract recurses into sub-documents and arrays, which
+// are matched by position, and skips keys that are missing from either
+// document and values that are not numbers. See SubtractWithOptions.
+func (d *Document) Subtract(other *Document) (*Document, error) {
+	return d.SubtractWithOptions(other, SubtractOptions{})
+}
+
+// SubtractWithOptions is the same as Subtract, but allows callers to
+// require that the documents have the same keys and numeric
+// structure.
+//
+// The result has the keys of the document, in order. The difference
+// of two int32 values is an int32, unless it overflows, the
+// difference of integers is otherwise an int64, and the difference
+// of a double and any number is a double. Sub-documents and arrays
+// are always included in the result, even when all of their elements
+// are skipped.
+func (d *Document) SubtractWithOptions(other *Document, opts SubtractOptions) (*Document, error) {
+	if d == nil || other == nil {
+		return nil, errors.New("cannot subtract nil documents")
+	}
+
+	return subtractDocuments(d, other, "", false, opts)
+}
+
+func subtractDocuments(d, other *Document, prefix string, isArray bool, opts SubtractOptions) (*Document, error) {
+	out := DC.Make(len(d.elems))
+
+	key := func(idx int, elem *Element) string {
+		if isArray {
+			return strconv.Itoa(idx)
+		}
+		return elem.Key()
+	}
+
+	var otherKeys map[string]struct{}
+	if opts.Strict && !isArray {
+		otherKeys = make(map[string]struct{}, len(other.elems))
+		for _, elem := range other.elems {
+			otherKeys[elem.Key()] = struct{}{}
+		}
+	}
+
+	for idx, elem := range d.elems {
+		path := prefix + key(idx, elem)
+
+		var match *Value
+		if isArray {
+			if idx < len(other.elems) {
+				match = other.elems[idx].value
+			}
+		} else {
+			match = other.Lookup(elem.Key())
+			delete(otherKeys, elem.Key())
+		}
+
+		if match == nil {
+			if opts.Strict {
+				return nil, errors.Errorf("'%s' is missing from the second document", path)
+			}
+			continue
+		}
+
+		val, err := subtractValues(elem.value, match, path, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if val != nil {
+			out.Append(EC.Value(elem.Key(), val))
+		}
+	}
+
+	if opts.Strict {
+		if isArray && len(other.elems) > len(d.elems) {
+			return nil, errors.Errorf("'%s%d' is missing from the first document", prefix, len(d.elems))
+		}
+
+		for _, elem := range other.elems {
+			if _, ok := otherKeys[elem.Key()]; ok {
+				return nil, errors.Errorf("'%s%s' is missing from the first document", prefix, elem.Key())
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// subtractValues returns the difference of the values, or nil when
+// the values are skipped.
+func subtractValues(v, other *Value, path string, opts SubtractOptions) (*Value, error) {
+	t, ot := v.Type(), other.Type()
+
+	switch {
+	case t == bsontype.EmbeddedDocument && ot == bsontype.EmbeddedDocument:
+		doc, err := subtractDocuments(v.MutableDocument(), other.MutableDocument(), path+".", false, opts)
+		if err != nil {
+			return nil, err
+		}
+		return VC.Document(doc), nil
+	case t == bsontype.Array && ot == bsontype.Array:
+		doc, err := subtractDocuments(v.MutableArray().doc, other.MutableArray().doc, path+".", true, opts)
+		if err != nil {
+			return nil, err
+		}
+		return VC.Array(&Array{doc: doc}), nil
+	case !isSubtractable(t) || !isSubtractable(ot):
+		if opts.Strict {
+			return nil, errors.Errorf("cannot subtract %s from %s at '%s'", ot, t, path)
+		}
+		return nil, nil
+	case t == bsontype.Double || ot == bsontype.Double:
+		return VC.Double(numericFloat(v) - numericFloat(other)), nil
+	case t == bsontype.Int32 && ot == bsontype.Int32:
+		diff := int64(v.Int32()) - int64(other.Int32())
+		if diff >= math.MinInt32 && diff <= math.MaxInt32 {
+			return VC.Int32(int32(diff)), nil
+		}
+		return VC.Int64(diff), nil
+	default:
+		return VC.Int64(numericInt(v) - numericInt(other)), nil
+	}
+}
+
+func isSubtractable(t bsontype.Type) bool {
+	return t == bsontype.Int32 || t == bsontype.Int64 || t == bsontype.Double
+}
+
+func numericFloat(v *Value) float64 {
+	switch v.Type() {
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	default:
+		return v.Double()
+	}
+}
+
+func numericInt(v *Value) int64 {
+	if v.Type() == bsontype.Int32 {
+		return int64(v.Int32())
+	}
+	return v.Int64()
+}
@@ -0,0 +1,116 @@
+package birch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+)
+
+func TestDocumentSubtract(t *testing.T) {
+	current := DC.Elements(
+		EC.String("host", "a"),
+		EC.Int64("ops", 150),
+		EC.Int32("conns", 10),
+		EC.Double("ratio", 0.75),
+		EC.SubDocumentFromElements("mem", EC.Int32("resident", 20), EC.Int64("virtual", 5)),
+		EC.ArrayFromElements("load", VC.Int32(3), VC.Double(1.5), VC.Int32(9)),
+		EC.Int32("onlyCurrent", 1),
+	)
+	previous := DC.Elements(
+		EC.String("host", "a"),
+		EC.Int32("ops", 100),
+		EC.Int32("conns", 12),
+		EC.Int32("ratio", 1),
+		EC.SubDocumentFromElements("mem", EC.Int32("resident", 15), EC.Int64("virtual", 5)),
+		EC.ArrayFromElements("load", VC.Int32(1), VC.Int32(1)),
+		EC.Int32("onlyPrevious", 1),
+	)
+
+	t.Run("Lenient", func(t *testing.T) {
+		diff, err := current.Subtract(previous)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"ops", "conns", "ratio", "mem", "load"}, diff.KeyNames())
+		assert.Equal(t, int64(50), diff.Lookup("ops").Int64())
+		assert.Equal(t, int32(-2), diff.Lookup("conns").Int32())
+		assert.Equal(t, -0.25, diff.Lookup("ratio").Double())
+		assert.Equal(t, int32(5), diff.RecursiveLookup("mem", "resident").Int32())
+		assert.Equal(t, int64(0), diff.RecursiveLookup("mem", "virtual").Int64())
+
+		load := diff.Lookup("load").MutableArray()
+		require.Equal(t, 2, load.Len())
+		assert.Equal(t, int32(2), load.Lookup(0).Int32())
+		assert.Equal(t, 0.5, load.Lookup(1).Double())
+
+		_, err = diff.MarshalBSON()
+		require.NoError(t, err)
+	})
+	t.Run("Strict", func(t *testing.T) {
+		_, err := current.SubtractWithOptions(previous, SubtractOptions{Strict: true})
+		assert.Error(t, err)
+
+		for name, test := range map[string]struct {
+			d     *Document
+			other *Document
+		}{
+			"MissingFromSecond": {
+				d:     DC.Elements(EC.Int32("a", 1), EC.Int32("b", 1)),
+				other: DC.Elements(EC.Int32("a", 1)),
+			},
+			"MissingFromFirst": {
+				d:     DC.Elements(EC.Int32("a", 1)),
+				other: DC.Elements(EC.Int32("a", 1), EC.Int32("b", 1)),
+			},
+			"NestedMissing": {
+				d:     DC.Elements(EC.SubDocumentFromElements("a", EC.Int32("x", 1))),
+				other: DC.Elements(EC.SubDocumentFromElements("a", EC.Int32("y", 1))),
+			},
+			"ArrayLength": {
+				d:     DC.Elements(EC.ArrayFromElements("a", VC.Int32(1))),
+				other: DC.Elements(EC.ArrayFromElements("a", VC.Int32(1), VC.Int32(2))),
+			},
+			"NonNumeric": {
+				d:     DC.Elements(EC.String("a", "x")),
+				other: DC.Elements(EC.String("a", "x")),
+			},
+			"Mismatched": {
+				d:     DC.Elements(EC.SubDocumentFromElements("a", EC.Int32("x", 1))),
+				other: DC.Elements(EC.Int32("a", 1)),
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := test.d.SubtractWithOptions(test.other, SubtractOptions{Strict: true})
+				assert.Error(t, err)
+
+				_, err = test.d.Subtract(test.other)
+				assert.NoError(t, err)
+			})
+		}
+
+		diff, err := current.SubtractWithOptions(current, SubtractOptions{Strict: true})
+		assert.Error(t, err, "strings cannot be subtracted")
+		assert.Nil(t, diff)
+
+		numbers := DC.Elements(EC.Int64("a", 3), EC.SubDocumentFromElements("b", EC.Double("c", 1)))
+		diff, err = numbers.SubtractWithOptions(numbers, SubtractOptions{Strict: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), diff.Lookup("a").Int64())
+		assert.Equal(t, 0.0, diff.RecursiveLookup("b", "c").Double())
+	})
+	t.Run("Overflow", func(t *testing.T) {
+		diff, err := DC.Elements(EC.Int32("a", math.MaxInt32)).Subtract(DC.Elements(EC.Int32("a", -1)))
+		require.NoError(t, err)
+		assert.Equal(t, bsontype.Int64, diff.Lookup("a").Type())
+		assert.Equal(t, int64(math.MaxInt32)+1, diff.Lookup("a").Int64())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var nilDoc *Document
+		_, err := nilDoc.Subtract(DC.New())
+		assert.Error(t, err)
+		_, err = DC.New().Subtract(nil)
+		assert.Error(t, err)
+	})
+}
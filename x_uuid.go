@@ -0,0 +1,86 @@
+package birch
+
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BinaryUUIDLegacy is the binary subtype for UUIDs written by
+	// older drivers, which did not agree on a byte order.
+	BinaryUUIDLegacy byte = 0x03
+	// BinaryUUID is the binary subtype for UUIDs stored in the
+	// standard (RFC 4122) byte order.
+	BinaryUUID byte = 0x04
+)
+
+// UUID returns the canonical string form of a UUID (e.g.
+// "00112233-4455-6677-8899-aabbccddeeff") stored in a binary value with
+// the UUID (4) or legacy UUID (3) subtype, and false if the value is
+// not a 16 byte binary value with one of these subtypes.
+//
+// The bytes are formatted in the order in which they are stored, which
+// is the standard order for subtype 4. For subtype 3 this matches the
+// legacy Python driver, but the legacy Java and C# drivers stored
+// UUIDs in different byte orders, so their subtype 3 values produce a
+// string with the bytes of some groups reversed. The byte order of a
+// legacy UUID cannot be detected from the value.
+func (v *Value) UUID() (string, bool) {
+	subtype, data, ok := v.BinaryOK()
+	if !ok || (subtype != BinaryUUID && subtype != BinaryUUIDLegacy) || len(data) != 16 {
+		return "", false
+	}
+
+	out := make([]byte, 36)
+	hex.Encode(out[0:8], data[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], data[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], data[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], data[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:36], data[10:16])
+
+	return string(out), true
+}
+
+// UUIDString creates a binary element with the UUID (4) subtype from
+// the canonical string form of a UUID, and panics if the string is not
+// a valid UUID.
+func (ElementConstructor) UUIDString(key string, uuid string) *Element {
+	elem, err := EC.UUIDStringErr(key, uuid)
+	if err != nil {
+		panic(err)
+	}
+
+	return elem
+}
+
+// UUIDStringErr is the same as UUIDString, but returns an error rather
+// than panicking if the string is not a valid UUID. Hexadecimal digits
+// may be upper or lower case.
+func (ElementConstructor) UUIDStringErr(key string, uuid string) (*Element, error) {
+	data, err := parseUUID(uuid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return EC.BinaryWithSubtype(key, data, BinaryUUID), nil
+}
+
+func parseUUID(uuid string) ([]byte, error) {
+	if len(uuid) != 36 || uuid[8] != '-' || uuid[13] != '-' || uuid[18] != '-' || uuid[23] != '-' {
+		return nil, errors.Errorf("'%s' is not a canonical uuid string", uuid)
+	}
+
+	digits := uuid[0:8] + uuid[9:13] + uuid[14:18] + uuid[19:23] + uuid[24:36]
+
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, errors.Wrapf(err, "'%s' is not a canonical uuid string", uuid)
+	}
+
+	return data, nil
+}
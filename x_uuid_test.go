@@ -0,0 +1,74 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUID(t *testing.T) {
+	const uuid = "00112233-4455-6677-8899-aabbccddeeff"
+	raw := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	t.Run("Value", func(t *testing.T) {
+		for name, subtype := range map[string]byte{"Standard": BinaryUUID, "Legacy": BinaryUUIDLegacy} {
+			t.Run(name, func(t *testing.T) {
+				out, ok := VC.BinaryWithSubtype(raw, subtype).UUID()
+				assert.True(t, ok)
+				assert.Equal(t, uuid, out)
+			})
+		}
+	})
+	t.Run("NotUUID", func(t *testing.T) {
+		for name, v := range map[string]*Value{
+			"Nil":          nil,
+			"String":       VC.String(uuid),
+			"Generic":      VC.Binary(raw),
+			"Short":        VC.BinaryWithSubtype(raw[:15], BinaryUUID),
+			"Long":         VC.BinaryWithSubtype(append(raw, 0x00), BinaryUUID),
+			"OtherSubtype": VC.BinaryWithSubtype(raw, 0x05),
+		} {
+			t.Run(name, func(t *testing.T) {
+				out, ok := v.UUID()
+				assert.False(t, ok)
+				assert.Equal(t, "", out)
+			})
+		}
+	})
+	t.Run("Constructor", func(t *testing.T) {
+		elem := EC.UUIDString("id", uuid)
+		subtype, data := elem.Value().Binary()
+		assert.Equal(t, BinaryUUID, subtype)
+		assert.Equal(t, raw, data)
+
+		upper, err := EC.UUIDStringErr("id", "00112233-4455-6677-8899-AABBCCDDEEFF")
+		require.NoError(t, err)
+		assert.True(t, elem.Equal(upper))
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		doc := DC.Elements(EC.UUIDString("id", uuid))
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		parsed, err := ReadDocument(data)
+		require.NoError(t, err)
+
+		out, ok := parsed.Lookup("id").UUID()
+		assert.True(t, ok)
+		assert.Equal(t, uuid, out)
+	})
+	t.Run("InvalidString", func(t *testing.T) {
+		for _, in := range []string{
+			"",
+			"00112233445566778899aabbccddeeff",
+			"00112233-4455-6677-8899-aabbccddeef",
+			"00112233-4455-6677-8899_aabbccddeeff",
+			"0011223g-4455-6677-8899-aabbccddeeff",
+			"{00112233-4455-6677-8899-aabbccddeeff}",
+		} {
+			_, err := EC.UUIDStringErr("id", in)
+			assert.Error(t, err, in)
+			assert.Panics(t, func() { EC.UUIDString("id", in) }, in)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package birch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tychoish/birch/bsontype"
+)
+
+// KeyRules configures the keys that Document.ValidateKeys accepts.
+// The zero value applies MongoDB's restrictions on field names.
+type KeyRules struct {
+	// AllowDots accepts keys that contain '.', which MongoDB
+	// interprets as a path to a nested field.
+	AllowDots bool
+	// AllowDollar accepts keys that contain '$', which MongoDB
+	// reserves for operators.
+	AllowDollar bool
+	// AllowEmpty accepts empty keys.
+	AllowEmpty bool
+}
+
+// InvalidKeysError is the error that Document.ValidateKeys returns.
+// It reports every key that breaks the rules.
+type InvalidKeysError struct {
+	// Paths lists the path of each invalid key, in document
+	// order, as the dot-separated keys of the sub-documents that
+	// contain it followed by the key, using the position of array
+	// elements as their key (e.g. "hosts.0.$name").
+	Paths []string
+}
+
+// Error implements the error interface.
+func (e *InvalidKeysError) Error() string {
+	quoted := make([]string, len(e.Paths))
+	for idx, path := range e.Paths {
+		quoted[idx] = "'" + path + "'"
+	}
+
+	return "invalid keys: " + strings.Join(quoted, ", ")
+}
+
+// ValidateKeys checks the keys of the document, and of its
+// sub-documents and the documents in its arrays, against the rules,
+// so that documents destined for MongoDB can be checked before an
+// insert fails. It returns an *InvalidKeysError that lists every
+// invalid key, or nil if all keys are valid. The keys of array
+// elements, which are their positions, are not checked.
+func (d *Document) ValidateKeys(rules KeyRules) error {
+	if d == nil {
+		return nil
+	}
+
+	var paths []string
+	rules.check(&paths, "", d.elems, false)
+
+	if len(paths) > 0 {
+		return &InvalidKeysError{Paths: paths}
+	}
+
+	return nil
+}
+
+func (rules KeyRules) valid(key string) bool {
+	switch {
+	case key == "":
+		return rules.AllowEmpty
+	case !rules.AllowDots && strings.Contains(key, "."):
+		return false
+	case !rules.AllowDollar && strings.Contains(key, "$"):
+		return false
+	default:
+		return true
+	}
+}
+
+func (rules KeyRules) check(paths *[]string, prefix string, elems []*Element, array bool) {
+	for idx, elem := range elems {
+		key := elem.Key()
+		if array {
+			key = strconv.Itoa(idx)
+		} else if !rules.valid(key) {
+			*paths = append(*paths, prefix+key)
+		}
+
+		switch elem.value.Type() {
+		case bsontype.EmbeddedDocument:
+			rules.check(paths, prefix+key+".", elem.value.MutableDocument().elems, false)
+		case bsontype.Array:
+			rules.check(paths, prefix+key+".", elem.value.MutableArray().doc.elems, true)
+		}
+	}
+}
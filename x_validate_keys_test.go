@@ -0,0 +1,52 @@
+package birch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKeys(t *testing.T) {
+	doc := DC.Elements(
+		EC.String("name", "a"),
+		EC.Int32("a.b", 1),
+		EC.SubDocumentFromElements("meta",
+			EC.String("$set", "x"),
+			EC.String("price$", "y"),
+			EC.String("ok", "z"),
+		),
+		EC.ArrayFromElements("hosts",
+			VC.DocumentFromElements(EC.String("host.name", "h")),
+			VC.String("plain"),
+		),
+		EC.Int32("", 0),
+	)
+
+	t.Run("Default", func(t *testing.T) {
+		err := doc.ValidateKeys(KeyRules{})
+		require.Error(t, err)
+
+		var keysErr *InvalidKeysError
+		require.True(t, errors.As(err, &keysErr))
+		assert.Equal(t, []string{"a.b", "meta.$set", "meta.price$", "hosts.0.host.name", ""}, keysErr.Paths)
+		assert.Equal(t, "invalid keys: 'a.b', 'meta.$set', 'meta.price$', 'hosts.0.host.name', ''", err.Error())
+	})
+	t.Run("Rules", func(t *testing.T) {
+		err := doc.ValidateKeys(KeyRules{AllowDots: true, AllowEmpty: true})
+		require.Error(t, err)
+		assert.Equal(t, []string{"meta.$set", "meta.price$"}, err.(*InvalidKeysError).Paths)
+
+		err = doc.ValidateKeys(KeyRules{AllowDollar: true, AllowEmpty: true})
+		require.Error(t, err)
+		assert.Equal(t, []string{"a.b", "hosts.0.host.name"}, err.(*InvalidKeysError).Paths)
+
+		assert.NoError(t, doc.ValidateKeys(KeyRules{AllowDots: true, AllowDollar: true, AllowEmpty: true}))
+	})
+	t.Run("Valid", func(t *testing.T) {
+		assert.NoError(t, DC.Elements(EC.String("name", "a"), EC.SubDocumentFromElements("meta", EC.Int32("n", 1))).ValidateKeys(KeyRules{}))
+		assert.NoError(t, DC.New().ValidateKeys(KeyRules{}))
+		assert.NoError(t, (*Document)(nil).ValidateKeys(KeyRules{}))
+	})
+}
@@ -0,0 +1,168 @@
+package birch
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// maxStreamDocumentSize is the largest document that ValidateStream
+// reads; larger lengths are reported as corrupt rather than
+// allocated.
+const maxStreamDocumentSize = 16*1024*1024 + 16*1024
+
+// ValidationIssue describes a problem that ValidateStream found in a
+// stream of BSON documents.
+type ValidationIssue struct {
+	// Document is the position of the document in the stream,
+	// starting at 0.
+	Document int
+	// Offset is the position, in bytes from the start of the
+	// stream, of the document or element with the problem.
+	Offset int64
+	// Key is the dot-separated path of the element with the
+	// problem, and is empty for problems with the document itself.
+	Key string
+	// Err is the problem. Use errors.Is to classify it: issues wrap
+	// ErrCorruptDocument, ErrUnexpectedEOF, or bsonerr.DuplicateKey.
+	Err error
+}
+
+// Error implements the error interface.
+func (i ValidationIssue) Error() string {
+	if i.Key == "" {
+		return fmt.Sprintf("document %d at offset %d: %s", i.Document, i.Offset, i.Err)
+	}
+
+	return fmt.Sprintf("document %d at offset %d: key '%s': %s", i.Document, i.Offset, i.Key, i.Err)
+}
+
+// Unwrap returns the problem, so that errors.Is and errors.As
+// classify issues by their cause.
+func (i ValidationIssue) Unwrap() error { return i.Err }
+
+// ValidateStream reads a stream of concatenated BSON documents, such
+// as a BSON dump or an FTDC file, and calls report for each problem
+// that it finds, rather than stopping at the first: invalid lengths,
+// unknown types, invalid values, and duplicate keys. The stream is
+// valid if ValidateStream does not call report.
+//
+// Each document's length determines where the next document starts,
+// so ValidateStream continues with the next document after a
+// document that has problems, and with the next element after an
+// element whose size is known. A document that is truncated, or has
+// a length that is less than 5 bytes or greater than the largest
+// document that MongoDB allows, ends the validation, since the rest
+// of the stream cannot be located.
+//
+// ValidateStream only returns an error if reading from the stream
+// fails, or if either argument is nil.
+func ValidateStream(r io.Reader, report func(ValidationIssue)) error {
+	if r == nil {
+		return bsonerr.NilReader
+	}
+	if report == nil {
+		return errors.New("must specify a report function")
+	}
+
+	var offset int64
+	for idx := 0; ; idx++ {
+		issue := func(at int64, key string, err error) {
+			report(ValidationIssue{Document: idx, Offset: at, Key: key, Err: err})
+		}
+
+		var length [4]byte
+		_, err := io.ReadFull(r, length[:])
+		switch err {
+		case nil:
+		case io.EOF:
+			return nil
+		case io.ErrUnexpectedEOF:
+			issue(offset, "", errors.Wrap(bsonerr.UnexpectedEOF, "truncated document length"))
+			return nil
+		default:
+			return errors.Wrapf(err, "problem reading document %d", idx)
+		}
+
+		size := readi32(length[:])
+		if size < 5 || size > maxStreamDocumentSize {
+			issue(offset, "", errors.Wrapf(bsonerr.InvalidLength, "length %d", size))
+			return nil
+		}
+
+		doc := make([]byte, size)
+		copy(doc, length[:])
+		_, err = io.ReadFull(r, doc[4:])
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			issue(offset, "", errors.Wrapf(bsonerr.UnexpectedEOF, "truncated document of length %d", size))
+			return nil
+		default:
+			return errors.Wrapf(err, "problem reading document %d", idx)
+		}
+
+		Reader(doc).validateStream(offset, "", issue)
+		offset += int64(size)
+	}
+}
+
+// validateStream reports the problems in the document, which must
+// have the length of its length prefix. base is the offset of the
+// document in the stream.
+func (r Reader) validateStream(base int64, prefix string, issue func(int64, string, error)) {
+	end := uint32(len(r)) - 1
+	if r[end] != '\x00' {
+		issue(base+int64(end), prefix, errors.Wrap(bsonerr.InvalidReadOnlyDocument, "missing null terminator"))
+	}
+
+	seen := map[string]struct{}{}
+	pos := uint32(4)
+	for pos < end && r[pos] != '\x00' {
+		start := pos
+		at := base + int64(start)
+
+		n, err := r.validateKey(pos+1, end)
+		if err != nil {
+			issue(at, prefix, err)
+			return
+		}
+
+		elem := newElement(start, pos+1+n)
+		elem.value.data = r
+		key := prefix + elem.Key()
+
+		if _, ok := seen[elem.Key()]; ok {
+			issue(at, key, bsonerr.DuplicateKey)
+		}
+		seen[elem.Key()] = struct{}{}
+
+		size, err := elem.value.validate(true)
+		if err == bsonerr.InvalidElement {
+			issue(at, key, errors.Wrapf(err, "unknown type 0x%02x", r[start]))
+			return
+		} else if err != nil {
+			issue(at, key, err)
+			return
+		}
+
+		valStart := elem.value.offset
+		switch bsontype.Type(r[start]) {
+		case bsontype.EmbeddedDocument, bsontype.Array:
+			r[valStart:valStart+size].validateStream(base+int64(valStart), key+".", issue)
+		default:
+			if _, err := elem.value.validate(false); err != nil {
+				issue(at, key, err)
+			}
+		}
+
+		pos = valStart + size
+	}
+
+	if pos != end {
+		issue(base+int64(pos), prefix, errors.Wrap(bsonerr.InvalidLength, "elements do not match the document length"))
+	}
+}
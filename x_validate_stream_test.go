@@ -0,0 +1,109 @@
+package birch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsonerr"
+)
+
+func TestValidateStream(t *testing.T) {
+	marshal := func(t *testing.T, doc *Document) []byte {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		return data
+	}
+	validate := func(t *testing.T, data []byte) []ValidationIssue {
+		var issues []ValidationIssue
+		require.NoError(t, ValidateStream(bytes.NewReader(data), func(issue ValidationIssue) {
+			issues = append(issues, issue)
+		}))
+		return issues
+	}
+
+	first := marshal(t, DC.Elements(EC.Int32("a", 1), EC.SubDocumentFromElements("b", EC.String("c", "d"))))
+	second := marshal(t, DC.Elements(EC.String("x", "y"), EC.ArrayFromElements("z", VC.Int64(1))))
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.Empty(t, validate(t, nil))
+		assert.Empty(t, validate(t, append(append([]byte{}, first...), second...)))
+	})
+	t.Run("ReportsAllIssues", func(t *testing.T) {
+		// an unknown type in the first document, and a duplicate key
+		// and invalid boolean in the nested document of the third.
+		unknown := append([]byte{}, first...)
+		unknown[4] = 0x42
+		third := marshal(t, DC.Elements(
+			EC.SubDocumentFromElements("outer", EC.Boolean("ok", true), EC.Boolean("ok", true)),
+		))
+		boolPos := bytes.LastIndexByte(third, 0x01)
+		third[boolPos] = 0x07
+
+		stream := append(append(unknown, second...), third...)
+		issues := validate(t, stream)
+		require.Len(t, issues, 3)
+
+		assert.Equal(t, 0, issues[0].Document)
+		assert.Equal(t, int64(4), issues[0].Offset)
+		assert.Equal(t, "a", issues[0].Key)
+		assert.True(t, errors.Is(issues[0], ErrCorruptDocument))
+		assert.Contains(t, issues[0].Error(), "unknown type 0x42")
+
+		assert.Equal(t, 2, issues[1].Document)
+		assert.Equal(t, "outer.ok", issues[1].Key)
+		assert.True(t, errors.Is(issues[1], bsonerr.DuplicateKey))
+		assert.Equal(t, int64(len(first)+len(second)+4+1+len("outer")+1+4+1+len("ok")+1+1), issues[1].Offset)
+
+		assert.Equal(t, 2, issues[2].Document)
+		assert.Equal(t, "outer.ok", issues[2].Key)
+		assert.Equal(t, bsonerr.InvalidBooleanType, issues[2].Err)
+	})
+	t.Run("ElementsOverrunDocument", func(t *testing.T) {
+		data := append([]byte{}, first...)
+		data[len(data)-1] = 0x01
+		issues := validate(t, append(data, second...))
+		require.NotEmpty(t, issues)
+		assert.Equal(t, int64(len(first)-1), issues[0].Offset)
+		for _, issue := range issues {
+			assert.Equal(t, 0, issue.Document)
+			assert.True(t, errors.Is(issue, ErrCorruptDocument))
+		}
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		stream := append(append([]byte{}, first...), second[:len(second)-3]...)
+		issues := validate(t, stream)
+		require.Len(t, issues, 1)
+		assert.Equal(t, 1, issues[0].Document)
+		assert.Equal(t, int64(len(first)), issues[0].Offset)
+		assert.True(t, errors.Is(issues[0], ErrUnexpectedEOF))
+
+		issues = validate(t, append(append([]byte{}, first...), 0x10, 0x00))
+		require.Len(t, issues, 1)
+		assert.True(t, errors.Is(issues[0], ErrUnexpectedEOF))
+	})
+	t.Run("InvalidLength", func(t *testing.T) {
+		for name, length := range map[string][]byte{
+			"TooSmall": {0x04, 0x00, 0x00, 0x00},
+			"Negative": {0xff, 0xff, 0xff, 0xff},
+			"TooLarge": {0xff, 0xff, 0xff, 0x7f},
+		} {
+			t.Run(name, func(t *testing.T) {
+				issues := validate(t, append(append([]byte{}, first...), append(length, second...)...))
+				require.Len(t, issues, 1)
+				assert.Equal(t, 1, issues[0].Document)
+				assert.Equal(t, int64(len(first)), issues[0].Offset)
+				assert.True(t, errors.Is(issues[0], bsonerr.InvalidLength))
+			})
+		}
+	})
+	t.Run("StreamErrors", func(t *testing.T) {
+		assert.Error(t, ValidateStream(nil, func(ValidationIssue) {}))
+		assert.Error(t, ValidateStream(bytes.NewReader(first), nil))
+		assert.Error(t, ValidateStream(failingReader{}, func(ValidationIssue) {}))
+		assert.Error(t, ValidateStream(io.MultiReader(bytes.NewReader(first[:10]), failingReader{}), func(ValidationIssue) {}))
+	})
+}
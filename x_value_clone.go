@@ -0,0 +1,43 @@
+package birch
+
+// Clone returns a deep copy of the value that shares no memory with
+// the original, so that changes to either value, or to the documents
+// they contain, do not affect the other. Copy, by contrast, shares
+// the encoded bytes of the value and the elements of embedded
+// documents. By type:
+//
+//   - Double, Int32, Int64, Boolean, DateTime, Timestamp, Decimal128,
+//     ObjectID, Null, Undefined, MinKey and MaxKey: the encoded value
+//     is copied.
+//   - String, Symbol, JavaScript, Regex and DBPointer: the encoded
+//     value is copied; the strings these values return are already
+//     independent of the value.
+//   - Binary: the encoded value is copied, so the slice that Binary
+//     returns for the clone does not alias the slice for the
+//     original.
+//   - EmbeddedDocument and Array: the contents are copied
+//     recursively, including changes made through MutableDocument and
+//     MutableArray that are not yet encoded.
+//   - CodeWithScope: the code and the scope document are copied.
+//
+// Clone returns nil for a nil value and an empty value for an
+// uninitialized value, and panics if the value is not valid BSON.
+func (v *Value) Clone() *Value {
+	if v == nil {
+		return nil
+	}
+
+	if v.offset == 0 || v.data == nil {
+		return &Value{}
+	}
+
+	data, err := (&Element{v}).MarshalBSON()
+	if err != nil {
+		panic(err)
+	}
+
+	elem := newElement(0, v.offset-v.start)
+	elem.value.data = data
+
+	return elem.value
+}
@@ -0,0 +1,108 @@
+package birch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/birch/bsontype"
+	"github.com/tychoish/birch/types"
+)
+
+func TestValueClone(t *testing.T) {
+	t.Run("Scalars", func(t *testing.T) {
+		for name, val := range map[string]*Value{
+			"Double":        VC.Double(4.2),
+			"Int32":         VC.Int32(42),
+			"Int64":         VC.Int64(42),
+			"Boolean":       VC.Boolean(true),
+			"DateTime":      VC.Time(time.Unix(1000, 0)),
+			"Timestamp":     VC.Timestamp(10, 1),
+			"Decimal128":    VC.Decimal128(types.NewDecimal128(33, 43)),
+			"ObjectID":      VC.ObjectID(types.NewObjectID()),
+			"Null":          VC.Null(),
+			"Undefined":     VC.Undefined(),
+			"MinKey":        VC.MinKey(),
+			"MaxKey":        VC.MaxKey(),
+			"String":        VC.String("hello"),
+			"Symbol":        VC.Symbol("sym"),
+			"JavaScript":    VC.JavaScript("x = 1"),
+			"Regex":         VC.Regex("^a", "i"),
+			"DBPointer":     VC.DBPointer("db.coll", types.NewObjectID()),
+			"CodeWithScope": VC.CodeWithScope("x", DC.Elements(EC.Int32("x", 1))),
+		} {
+			t.Run(name, func(t *testing.T) {
+				clone := val.Clone()
+				assert.Equal(t, val.Type(), clone.Type())
+				assert.True(t, val.Equal(clone))
+				assert.Equal(t, val.Interface(), clone.Interface())
+			})
+		}
+	})
+	t.Run("Binary", func(t *testing.T) {
+		val := VC.Binary([]byte{1, 2, 3})
+		clone := val.Clone()
+
+		_, data := val.Binary()
+		data[0] = 9
+
+		_, cloned := clone.Binary()
+		assert.Equal(t, []byte{1, 2, 3}, cloned)
+	})
+	t.Run("SubDocument", func(t *testing.T) {
+		build := func() *Value {
+			return VC.DocumentFromElements(
+				EC.String("host", "a"),
+				EC.SubDocumentFromElements("mem", EC.Int64("resident", 20)),
+			)
+		}
+		parsed := func() *Value {
+			data, err := DC.Elements(EC.Value("doc", build())).MarshalBSON()
+			require.NoError(t, err)
+			doc, err := ReadDocument(data)
+			require.NoError(t, err)
+			return doc.Lookup("doc")
+		}
+
+		for name, val := range map[string]*Value{"Constructed": build(), "Parsed": parsed()} {
+			t.Run(name, func(t *testing.T) {
+				clone := val.Clone()
+				require.Equal(t, bsontype.EmbeddedDocument, clone.Type())
+
+				clone.MutableDocument().Set(EC.String("host", "changed"))
+				clone.MutableDocument().Lookup("mem").MutableDocument().Set(EC.Int64("resident", 0))
+				clone.MutableDocument().Append(EC.Boolean("extra", true))
+				assert.Equal(t, "a", val.MutableDocument().Lookup("host").StringValue())
+				assert.Equal(t, int64(20), val.MutableDocument().RecursiveLookup("mem", "resident").Int64())
+				assert.Equal(t, 2, val.MutableDocument().Len())
+
+				val.MutableDocument().Set(EC.String("host", "source"))
+				assert.Equal(t, "changed", clone.MutableDocument().Lookup("host").StringValue())
+			})
+		}
+	})
+	t.Run("PendingChanges", func(t *testing.T) {
+		val := VC.DocumentFromElements(EC.Int32("a", 1))
+		val.MutableDocument().Append(EC.Int32("b", 2))
+
+		clone := val.Clone()
+		assert.Equal(t, []string{"a", "b"}, clone.MutableDocument().KeyNames())
+	})
+	t.Run("Array", func(t *testing.T) {
+		val := VC.ArrayFromValues(VC.Int32(1), VC.DocumentFromElements(EC.Int32("a", 1)))
+		clone := val.Clone()
+		require.Equal(t, bsontype.Array, clone.Type())
+
+		clone.MutableArray().Lookup(1).MutableDocument().Set(EC.Int32("a", 2))
+		clone.MutableArray().Append(VC.Int32(3))
+		assert.Equal(t, 2, val.MutableArray().Len())
+		assert.Equal(t, int32(1), val.MutableArray().Lookup(1).MutableDocument().Lookup("a").Int32())
+		assert.Equal(t, 3, clone.MutableArray().Len())
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var val *Value
+		assert.Nil(t, val.Clone())
+		assert.Equal(t, &Value{}, (&Value{}).Clone())
+	})
+}
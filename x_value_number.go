@@ -0,0 +1,26 @@
+package birch
+
+import "github.com/tychoish/birch/bsontype"
+
+// DoubleLenientOK returns the value as a float64 when it is a double,
+// a 32-bit integer or a 64-bit integer, for example to read metrics
+// that are recorded as integers in some documents and as doubles in
+// others. It returns false if the value is nil, uninitialized, or of
+// any other type. 64-bit integers with magnitudes above 2^53 are
+// rounded to the nearest float64.
+func (v *Value) DoubleLenientOK() (float64, bool) {
+	if v == nil || v.offset == 0 || v.data == nil {
+		return 0, false
+	}
+
+	switch bsontype.Type(v.data[v.start]) {
+	case bsontype.Double:
+		return v.Double(), true
+	case bsontype.Int32:
+		return float64(v.Int32()), true
+	case bsontype.Int64:
+		return float64(v.Int64()), true
+	default:
+		return 0, false
+	}
+}
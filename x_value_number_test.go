@@ -0,0 +1,41 @@
+package birch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueDoubleLenientOK(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		value    *Value
+		expected float64
+		ok       bool
+		strictOK bool
+	}{
+		{name: "Double", value: VC.Double(2.5), expected: 2.5, ok: true, strictOK: true},
+		{name: "Infinity", value: VC.Double(math.Inf(-1)), expected: math.Inf(-1), ok: true, strictOK: true},
+		{name: "Int32", value: VC.Int32(-7), expected: -7, ok: true},
+		{name: "Int64", value: VC.Int64(1 << 40), expected: 1 << 40, ok: true},
+		{name: "Int64Rounded", value: VC.Int64(1<<53 + 1), expected: 1 << 53, ok: true},
+		{name: "String", value: VC.String("1.5")},
+		{name: "Boolean", value: VC.Boolean(true)},
+		{name: "DateTime", value: VC.DateTime(1000)},
+		{name: "Nil", value: nil},
+		{name: "Uninitialized", value: &Value{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f, ok := test.value.DoubleLenientOK()
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, f)
+
+			f, ok = test.value.DoubleOK()
+			assert.Equal(t, test.strictOK, ok)
+			if !ok {
+				assert.Zero(t, f)
+			}
+		})
+	}
+}
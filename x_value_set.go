@@ -0,0 +1,91 @@
+package birch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tychoish/birch/bsonerr"
+	"github.com/tychoish/birch/bsontype"
+)
+
+// SetDocument replaces the embedded document of the value with d, in
+// place, so that an element obtained from LookupElement keeps its key
+// and its position in the containing document. The value refers to d
+// rather than copying it, as with EC.SubDocument. SetDocument returns
+// an error if d is nil or the value is not an embedded document.
+func (v *Value) SetDocument(d *Document) error {
+	if d == nil {
+		return errors.WithStack(bsonerr.NilDocument)
+	}
+
+	if err := v.checkContainer(bsontype.EmbeddedDocument, "SetDocument"); err != nil {
+		return err
+	}
+
+	v.setContainer(d)
+
+	return nil
+}
+
+// SetArray replaces the array of the value with a, in place, so that
+// an element obtained from LookupElement keeps its key and its
+// position in the containing document. The value refers to a rather
+// than copying it, as with EC.Array. SetArray returns an error if a is
+// nil or the value is not an array.
+func (v *Value) SetArray(a *Array) error {
+	if a == nil || a.doc == nil {
+		return errors.WithStack(bsonerr.NilDocument)
+	}
+
+	if err := v.checkContainer(bsontype.Array, "SetArray"); err != nil {
+		return err
+	}
+
+	v.setContainer(a.doc)
+
+	return nil
+}
+
+// AppendToArray appends the values to the array of the value, in
+// place, so that the document containing the value reflects the new
+// elements without deleting and re-adding the element. AppendToArray
+// returns an error, without modifying the array, if the value is not
+// an array or any of the values is nil.
+func (v *Value) AppendToArray(elems ...*Value) error {
+	if err := v.checkContainer(bsontype.Array, "AppendToArray"); err != nil {
+		return err
+	}
+
+	for _, elem := range elems {
+		if elem == nil {
+			return errors.WithStack(bsonerr.NilElement)
+		}
+	}
+
+	v.MutableArray().Append(elems...)
+
+	return nil
+}
+
+func (v *Value) checkContainer(t bsontype.Type, method string) error {
+	if v == nil || v.offset == 0 || v.data == nil {
+		return errors.WithStack(bsonerr.UninitializedElement)
+	}
+
+	if bsontype.Type(v.data[v.start]) != t {
+		return errors.WithStack(bsonerr.NewElementTypeError("Value."+method, bsontype.Type(v.data[v.start])))
+	}
+
+	return nil
+}
+
+// setContainer points the value at d, and drops the encoded contents
+// of the previous container, keeping only the type and key, as the
+// element constructors do for containers.
+func (v *Value) setContainer(d *Document) {
+	header := make([]byte, v.offset-v.start)
+	copy(header, v.data[v.start:v.offset])
+
+	v.start = 0
+	v.offset = uint32(len(header))
+	v.data = header
+	v.d = d
+}
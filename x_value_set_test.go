@@ -0,0 +1,172 @@
+package birch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueSetContainer(t *testing.T) {
+	build := func(t *testing.T) *Document {
+		doc := DC.Elements(
+			EC.Int32("a", 1),
+			EC.SubDocumentFromElements("b", EC.String("old", "value"), EC.SubDocumentFromElements("inner", EC.Int32("x", 1))),
+			EC.ArrayFromElements("c", VC.Int32(1), VC.Int32(2)),
+			EC.String("d", "last"),
+		)
+
+		// read the document back so that the values refer to
+		// the encoded bytes, as they do in parsed documents.
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		return out
+	}
+	reread := func(t *testing.T, doc *Document) *Document {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		return out
+	}
+
+	keys := func(doc *Document) []string {
+		var out []string
+		iter := doc.Iterator()
+		for iter.Next() {
+			out = append(out, iter.Element().Key())
+		}
+		return out
+	}
+
+	t.Run("SetDocument", func(t *testing.T) {
+		doc := build(t)
+		elem, err := doc.LookupElementErr("b")
+		require.NoError(t, err)
+		require.NoError(t, elem.Value().SetDocument(DC.Elements(EC.Int64("new", 42))))
+
+		out := reread(t, doc)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, keys(out))
+		assert.Equal(t, int64(42), out.Lookup("b").MutableDocument().Lookup("new").Int64())
+		assert.Nil(t, out.Lookup("b").MutableDocument().Lookup("old"))
+		assert.Equal(t, "last", out.Lookup("d").StringValue())
+		assert.Equal(t, int64(42), elem.Value().MutableDocument().Lookup("new").Int64())
+	})
+	t.Run("SetNestedDocument", func(t *testing.T) {
+		doc := build(t)
+		elem, err := doc.Lookup("b").MutableDocument().LookupElementErr("inner")
+		require.NoError(t, err)
+		require.NoError(t, elem.Value().SetDocument(DC.Elements(EC.String("y", "z"))))
+
+		out := reread(t, doc)
+		assert.Equal(t, []string{"old", "inner"}, keys(out.Lookup("b").MutableDocument()))
+		assert.Equal(t, "z", out.Lookup("b").MutableDocument().Lookup("inner").MutableDocument().Lookup("y").StringValue())
+	})
+	t.Run("SetArray", func(t *testing.T) {
+		doc := build(t)
+		elem, err := doc.LookupElementErr("c")
+		require.NoError(t, err)
+		require.NoError(t, elem.Value().SetArray(NewArray(VC.String("one"), VC.String("two"), VC.String("three"))))
+
+		out := reread(t, doc)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, keys(out))
+		arr := out.Lookup("c").MutableArray()
+		require.Equal(t, 3, arr.Len())
+		assert.Equal(t, "three", arr.LookupElement(2).Value().StringValue())
+	})
+	t.Run("Constructed", func(t *testing.T) {
+		doc := DC.Elements(EC.SubDocument("a", DC.New()), EC.Int32("b", 2))
+		require.NoError(t, doc.LookupElement("a").Value().SetDocument(DC.Elements(EC.Int32("x", 1))))
+
+		out := reread(t, doc)
+		assert.Equal(t, []string{"a", "b"}, keys(out))
+		assert.Equal(t, int32(1), out.Lookup("a").MutableDocument().Lookup("x").Int32())
+	})
+	t.Run("Errors", func(t *testing.T) {
+		doc := build(t)
+
+		assert.Error(t, doc.Lookup("a").SetDocument(DC.New()))
+		assert.Error(t, doc.Lookup("a").SetArray(NewArray()))
+		assert.Error(t, doc.Lookup("b").SetArray(NewArray()))
+		assert.Error(t, doc.Lookup("c").SetDocument(DC.New()))
+		assert.Error(t, doc.Lookup("b").SetDocument(nil))
+		assert.Error(t, doc.Lookup("c").SetArray(nil))
+		assert.Error(t, (&Value{}).SetDocument(DC.New()))
+
+		out := reread(t, doc)
+		assert.Equal(t, int32(1), out.Lookup("a").Int32())
+		assert.Equal(t, "value", out.Lookup("b").MutableDocument().Lookup("old").StringValue())
+	})
+}
+
+func TestValueAppendToArray(t *testing.T) {
+	build := func(t *testing.T) *Document {
+		doc := DC.Elements(
+			EC.Int32("a", 1),
+			EC.SubDocumentFromElements("b", EC.ArrayFromElements("nested", VC.String("x"))),
+			EC.ArrayFromElements("c", VC.Int32(1), VC.Int32(2)),
+			EC.String("d", "last"),
+		)
+
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		return out
+	}
+	reread := func(t *testing.T, doc *Document) *Document {
+		data, err := doc.MarshalBSON()
+		require.NoError(t, err)
+		out, err := ReadDocument(data)
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("Parsed", func(t *testing.T) {
+		doc := build(t)
+		require.NoError(t, doc.Lookup("c").AppendToArray(VC.Int32(3), VC.String("four")))
+
+		out := reread(t, doc)
+		arr := out.Lookup("c").MutableArray()
+		require.Equal(t, 4, arr.Len())
+		assert.Equal(t, int32(1), arr.Lookup(0).Int32())
+		assert.Equal(t, int32(3), arr.Lookup(2).Int32())
+		assert.Equal(t, "four", arr.Lookup(3).StringValue())
+		assert.Equal(t, "last", out.Lookup("d").StringValue())
+		assert.Equal(t, 4, doc.Lookup("c").MutableArray().Len())
+	})
+	t.Run("Nested", func(t *testing.T) {
+		doc := build(t)
+		require.NoError(t, doc.RecursiveLookup("b", "nested").AppendToArray(VC.DocumentFromElements(EC.Int32("y", 1))))
+
+		out := reread(t, doc)
+		arr := out.RecursiveLookup("b", "nested").MutableArray()
+		require.Equal(t, 2, arr.Len())
+		assert.Equal(t, int32(1), arr.Lookup(1).MutableDocument().Lookup("y").Int32())
+	})
+	t.Run("Constructed", func(t *testing.T) {
+		doc := DC.Elements(EC.ArrayFromElements("a"))
+		require.NoError(t, doc.Lookup("a").AppendToArray(VC.Boolean(true)))
+		require.NoError(t, doc.Lookup("a").AppendToArray())
+
+		out := reread(t, doc)
+		require.Equal(t, 1, out.Lookup("a").MutableArray().Len())
+		assert.True(t, out.Lookup("a").MutableArray().Lookup(0).Boolean())
+	})
+	t.Run("Errors", func(t *testing.T) {
+		doc := build(t)
+
+		assert.Error(t, doc.Lookup("a").AppendToArray(VC.Int32(1)))
+		assert.Error(t, doc.Lookup("b").AppendToArray(VC.Int32(1)))
+		assert.Error(t, (&Value{}).AppendToArray(VC.Int32(1)))
+		var nilValue *Value
+		assert.Error(t, nilValue.AppendToArray(VC.Int32(1)))
+		assert.Error(t, doc.Lookup("c").AppendToArray(VC.Int32(3), nil))
+
+		out := reread(t, doc)
+		assert.Equal(t, 2, out.Lookup("c").MutableArray().Len())
+		assert.Equal(t, int32(1), out.Lookup("a").Int32())
+	})
+}